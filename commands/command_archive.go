@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"os"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/subprocess"
+	"github.com/spf13/cobra"
+)
+
+var (
+	archiveFormat             string
+	archiveOutput             string
+	archivePrefix             string
+	archiveVerbose            bool
+	archiveWorktreeAttributes bool
+)
+
+// archiveCommand runs "git archive", making sure that the Git LFS smudge filter
+// writes the contents of Git LFS files into the archive, rather than their
+// pointers, whether or not the filter is installed or skips downloads. The
+// objects of the archived tree are fetched first, in one batch.
+func archiveCommand(cmd *cobra.Command, args []string) {
+	requireGitVersion()
+	requireInRepo()
+
+	if len(args) < 1 {
+		Print("Usage: git lfs archive [options] <tree-ish> [<path>...]")
+		os.Exit(1)
+	}
+
+	treeish, paths := args[0], args[1:]
+
+	fetch := []string{"lfs", "fetch"}
+	if len(paths) > 0 {
+		fetch = append(fetch, "--include", strings.Join(paths, ","))
+	}
+	fetch = append(fetch, cfg.Remote(), treeish)
+
+	// Keep the progress of the fetch out of an archive written to stdout.
+	if err := archiveGit(os.Stderr, fetch...); err != nil {
+		Exit("Error fetching Git LFS objects for %q: %s", treeish, err)
+	}
+
+	archive := []string{
+		"-c", "filter.lfs.smudge=git-lfs smudge -- %f",
+		"-c", "filter.lfs.process=git-lfs filter-process",
+		"-c", "filter.lfs.required=true",
+		"archive",
+	}
+	if len(archiveFormat) > 0 {
+		archive = append(archive, "--format", archiveFormat)
+	}
+	if len(archivePrefix) > 0 {
+		archive = append(archive, "--prefix", archivePrefix)
+	}
+	if len(archiveOutput) > 0 {
+		archive = append(archive, "--output", archiveOutput)
+	}
+	if archiveVerbose {
+		archive = append(archive, "--verbose")
+	}
+	if archiveWorktreeAttributes {
+		archive = append(archive, "--worktree-attributes")
+	}
+	archive = append(archive, treeish, "--")
+	archive = append(archive, paths...)
+
+	if err := archiveGit(os.Stdout, archive...); err != nil {
+		Exit("Error creating archive of %q: %s", treeish, err)
+	}
+}
+
+// archiveGit runs Git with the given arguments, writing its output to "stdout",
+// with downloads in the smudge filter enabled.
+func archiveGit(stdout *os.File, args ...string) error {
+	cmd := subprocess.ExecCommand("git", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+
+	env := make([]string, 0, len(cmd.Env))
+	for _, kv := range cmd.Env {
+		if !strings.HasPrefix(kv, "GIT_LFS_SKIP_SMUDGE=") {
+			env = append(env, kv)
+		}
+	}
+	cmd.Env = env
+
+	return cmd.Run()
+}
+
+func init() {
+	RegisterCommand("archive", archiveCommand, func(cmd *cobra.Command) {
+		// Mirror the git archive flags which apply to local archives
+		cmd.Flags().StringVarP(&archiveFormat, "format", "", "", "See 'git archive --help'")
+		cmd.Flags().StringVarP(&archiveOutput, "output", "o", "", "See 'git archive --help'")
+		cmd.Flags().StringVarP(&archivePrefix, "prefix", "", "", "See 'git archive --help'")
+		cmd.Flags().BoolVarP(&archiveVerbose, "verbose", "v", false, "See 'git archive --help'")
+		cmd.Flags().BoolVarP(&archiveWorktreeAttributes, "worktree-attributes", "", false, "See 'git archive --help'")
+	})
+}
@@ -25,6 +25,9 @@ func checkoutCommand(cmd *cobra.Command, args []string) {
 		return
 	}
 
+	includeArg, excludeArg := getIncludeExcludeArgs(cmd)
+	filter := sparseFilepathFilter(buildFilepathFilter(cfg, includeArg, excludeArg))
+
 	var totalBytes int64
 	var pointers []*lfs.WrappedPointer
 	logger := tasklog.NewLogger(os.Stdout)
@@ -36,6 +39,12 @@ func checkoutCommand(cmd *cobra.Command, args []string) {
 			return
 		}
 
+		// Leave the pointers of files excluded from fetching in place,
+		// as their content is not expected to be local.
+		if !filter.Allows(p.Name) {
+			return
+		}
+
 		totalBytes += p.Size
 		meter.Add(p.Size)
 		meter.StartTransfer(p.Name)
@@ -61,6 +70,10 @@ func checkoutCommand(cmd *cobra.Command, args []string) {
 
 	meter.Finish()
 	singleCheckout.Close()
+	reportSkippedDownloads(singleCheckout.NotLocal())
+
+	recurseSubmodules("checkout")
+	exitWithErrorClass()
 }
 
 // Parameters are filters
@@ -80,5 +93,12 @@ func rootedPaths(args []string) []string {
 }
 
 func init() {
-	RegisterCommand("checkout", checkoutCommand, nil)
+	RegisterCommand("checkout", checkoutCommand, func(cmd *cobra.Command) {
+		cmd.Flags().StringVarP(&includeArg, "include", "I", "", "Include a list of paths")
+		cmd.Flags().StringVarP(&excludeArg, "exclude", "X", "", "Exclude a list of paths")
+		addRecurseSubmodulesFlag(cmd)
+		addNoSparseFlag(cmd)
+		addSkipDownloadErrorsFlag(cmd)
+		addJSONErrorsFlag(cmd)
+	})
 }
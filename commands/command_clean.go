@@ -84,6 +84,8 @@ func clean(gf *lfs.GitFilter, to io.Writer, from io.Reader, fileName string, fil
 		Debug("Writing %s", mediafile)
 	}
 
+	recordObjectMetadata(cleaned.Oid, fileName, mediafile)
+
 	_, err = lfs.EncodePointer(to, cleaned.Pointer)
 	return cleaned.Pointer, err
 }
@@ -79,7 +79,7 @@ func cloneCommand(cmd *cobra.Command, args []string) {
 
 	if ref, err := git.CurrentRef(); err == nil {
 		includeArg, excludeArg := getIncludeExcludeArgs(cmd)
-		filter := buildFilepathFilter(cfg, includeArg, excludeArg)
+		filter := sparseFilepathFilter(buildFilepathFilter(cfg, includeArg, excludeArg))
 		if cloneFlags.NoCheckout || cloneFlags.Bare {
 			// If --no-checkout or --bare then we shouldn't check out, just fetch instead
 			fetchRef(ref.Name, filter)
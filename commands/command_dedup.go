@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tools/humanize"
+	"github.com/spf13/cobra"
+)
+
+// dedupCommand replaces the working tree copies of Git LFS files in HEAD with
+// copy-on-write clones of the objects in the LFS storage directory, so that
+// both share the same blocks on disk.
+func dedupCommand(cmd *cobra.Command, args []string) {
+	requireInRepo()
+
+	if supported, err := tools.CheckCloneFileSupported(cfg.TempDir()); err != nil {
+		ExitWithError(err)
+	} else if !supported {
+		Exit("This system does not support deduplication.")
+	}
+
+	if len(cfg.Extensions()) > 0 {
+		Exit("This repository uses Git LFS extensions, which deduplication does not support.")
+	}
+
+	if dirty, err := git.IsWorkingCopyDirty(); err != nil {
+		ExitWithError(err)
+	} else if dirty {
+		Exit("Working tree is dirty. Please commit or reset your changes first.")
+	}
+
+	ref, err := git.CurrentRef()
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	pathConverter, err := lfs.NewRepoToCurrentPathConverter(cfg)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	var pointers []*lfs.WrappedPointer
+	var failed bool
+
+	gitscanner := lfs.NewGitScanner(func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			LoggedError(err, "Scanner error: %s", err)
+			failed = true
+			return
+		}
+		pointers = append(pointers, p)
+	})
+
+	if err := gitscanner.ScanTree(ref.Sha); err != nil {
+		ExitWithError(err)
+	}
+	gitscanner.Close()
+
+	indexer := &gitIndexer{}
+	var count int
+	var dedupSize int64
+
+	for _, p := range pointers {
+		cwdfilepath := pathConverter.Convert(p.Name)
+		ok, err := dedup(cwdfilepath, p)
+		if err != nil {
+			LoggedError(err, "Could not deduplicate %q: %s", p.Name, err)
+			failed = true
+			continue
+		} else if !ok {
+			continue
+		}
+
+		if err := indexer.Add(cwdfilepath); err != nil {
+			Panic(err, "Could not update the index")
+		}
+		count++
+		dedupSize += p.Size
+	}
+
+	if err := indexer.Close(); err != nil {
+		LoggedError(err, "Error updating the git index:\n%s", indexer.Output())
+	}
+
+	Print("Deduplicated %d file(s), %s", count, humanize.FormatBytes(uint64(dedupSize)))
+	if failed {
+		os.Exit(2)
+	}
+}
+
+// dedup replaces the file at the given path with a clone of the object it
+// holds, returning whether it did. Files whose object is not present locally,
+// such as those which are still pointers, are left alone.
+func dedup(cwdfilepath string, p *lfs.WrappedPointer) (bool, error) {
+	if !cfg.LFSObjectExists(p.Oid, p.Size) {
+		return false, nil
+	}
+
+	stat, err := os.Lstat(cwdfilepath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if !stat.Mode().IsRegular() || stat.Size() != p.Size {
+		return false, nil
+	}
+
+	return tools.CloneFileByPath(cwdfilepath, cfg.Filesystem().ObjectPathname(p.Oid))
+}
+
+func init() {
+	RegisterCommand("dedup", dedupCommand, nil)
+}
@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/git-lfs/git-lfs/encryption"
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/subprocess"
+	"github.com/spf13/cobra"
+)
+
+// encryptCleanCommand encrypts its standard input to its standard output. It
+// is meant to be run as the clean command of a Git LFS extension, as
+// configured by `git lfs encrypt install`.
+func encryptCleanCommand(cmd *cobra.Command, args []string) {
+	key := encryptionKey()
+
+	// The contents are read twice, so buffer them in a file.
+	tmp, err := ioutil.TempFile(cfg.TempDir(), "")
+	if err != nil {
+		ExitWithError(err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, os.Stdin); err != nil {
+		ExitWithError(errors.Wrap(err, "Error reading contents to encrypt"))
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		ExitWithError(err)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	if err := key.Encrypt(w, tmp); err != nil {
+		ExitWithError(errors.Wrap(err, "Error encrypting contents"))
+	}
+	if err := w.Flush(); err != nil {
+		ExitWithError(err)
+	}
+}
+
+// encryptSmudgeCommand decrypts its standard input to its standard output. It
+// is meant to be run as the smudge command of a Git LFS extension.
+func encryptSmudgeCommand(cmd *cobra.Command, args []string) {
+	key := encryptionKey()
+
+	w := bufio.NewWriter(os.Stdout)
+	if err := key.Decrypt(w, bufio.NewReader(os.Stdin)); err != nil {
+		Exit("Error decrypting contents: %s", err)
+	}
+	if err := w.Flush(); err != nil {
+		ExitWithError(err)
+	}
+}
+
+// encryptInstallCommand configures the "encrypt" extension in the local
+// repository.
+func encryptInstallCommand(cmd *cobra.Command, args []string) {
+	requireInRepo()
+
+	gitConfig := cfg.GitConfig()
+	for _, kv := range [][2]string{
+		{"lfs.extension.encrypt.clean", "git-lfs encrypt clean %f"},
+		{"lfs.extension.encrypt.smudge", "git-lfs encrypt smudge %f"},
+		{"lfs.extension.encrypt.priority", "0"},
+	} {
+		if _, err := gitConfig.SetLocal(kv[0], kv[1]); err != nil {
+			ExitWithError(err)
+		}
+	}
+
+	Print("Git LFS encryption configured.")
+	if _, ok := cfg.Git.Get("lfs.encryption.keyfile"); !ok {
+		if _, ok := cfg.Git.Get("lfs.encryption.keycommand"); !ok {
+			Print("Set lfs.encryption.keyfile or lfs.encryption.keycommand to give the key.")
+		}
+	}
+}
+
+// encryptionKey returns the key given by lfs.encryption.keycommand or
+// lfs.encryption.keyfile, exiting if there is none.
+func encryptionKey() *encryption.Key {
+	var secret []byte
+
+	if command, ok := cfg.Git.Get("lfs.encryption.keycommand"); ok {
+		out, err := subprocess.ExecCommand("sh", "-c", command).Output()
+		if err != nil {
+			Exit("Error running lfs.encryption.keycommand %q: %s", command, err)
+		}
+		secret = out
+	} else if keyfile, ok := cfg.Git.Get("lfs.encryption.keyfile"); ok {
+		if !filepath.IsAbs(keyfile) {
+			keyfile = filepath.Join(cfg.LocalWorkingDir(), keyfile)
+		}
+
+		contents, err := ioutil.ReadFile(keyfile)
+		if err != nil {
+			Exit("Error reading lfs.encryption.keyfile: %s", err)
+		}
+		secret = contents
+	} else {
+		Exit("No encryption key is configured: set lfs.encryption.keyfile or lfs.encryption.keycommand.")
+	}
+
+	key, err := encryption.NewKey(bytes.TrimSpace(secret))
+	if err != nil {
+		Exit("Invalid encryption key: %s", err)
+	}
+	return key
+}
+
+func init() {
+	RegisterCommand("encrypt", nil, func(cmd *cobra.Command) {
+		cmd.AddCommand(
+			NewCommand("clean", encryptCleanCommand),
+			NewCommand("smudge", encryptSmudgeCommand),
+			NewCommand("install", encryptInstallCommand),
+		)
+	})
+}
@@ -1,12 +1,40 @@
 package commands
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
 	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/git-lfs/git-lfs/tools"
 	"github.com/spf13/cobra"
 )
 
+var (
+	envJson bool
+)
+
+// envEndpoint describes the endpoint of a remote, or of the default remote if
+// Remote is empty. URLs are stripped of their credentials.
+type envEndpoint struct {
+	Remote string `json:"remote,omitempty"`
+	URL    string `json:"url"`
+	Auth   string `json:"auth"`
+	SSH    string `json:"ssh,omitempty"`
+	Proxy  string `json:"proxy,omitempty"`
+}
+
+type envJSON struct {
+	Version    string            `json:"version"`
+	GitVersion string            `json:"git_version"`
+	Endpoints  []*envEndpoint    `json:"endpoints"`
+	Env        map[string]string `json:"env"`
+	GitConfig  map[string]string `json:"git_config"`
+}
+
 func envCommand(cmd *cobra.Command, args []string) {
 	config.ShowConfigWarnings = true
 
@@ -15,40 +43,109 @@ func envCommand(cmd *cobra.Command, args []string) {
 		gitV = "Error getting git version: " + err.Error()
 	}
 
+	endpoints := envEndpoints()
+	environ := lfs.Environ(cfg, getTransferManifest())
+	filterKeys := []string{"filter.lfs.process", "filter.lfs.smudge", "filter.lfs.clean"}
+
+	if envJson {
+		out := &envJSON{
+			Version:    config.VersionDesc,
+			GitVersion: gitV,
+			Endpoints:  endpoints,
+			Env:        make(map[string]string, len(environ)),
+			GitConfig:  make(map[string]string, len(filterKeys)),
+		}
+		for _, env := range environ {
+			parts := strings.SplitN(env, "=", 2)
+			if len(parts) == 2 {
+				out.Env[parts[0]] = parts[1]
+			}
+		}
+		for _, key := range filterKeys {
+			out.GitConfig[key], _ = cfg.Git.Get(key)
+		}
+
+		ret, err := json.Marshal(out)
+		if err != nil {
+			ExitWithError(err)
+		}
+		Print(string(ret))
+		return
+	}
+
 	Print(config.VersionDesc)
 	Print(gitV)
 	Print("")
 
+	for _, e := range endpoints {
+		if len(e.Remote) > 0 {
+			Print("Endpoint (%s)=%s (auth=%s)", e.Remote, e.URL, e.Auth)
+		} else {
+			Print("Endpoint=%s (auth=%s)", e.URL, e.Auth)
+		}
+		if len(e.SSH) > 0 {
+			Print("  SSH=%s", e.SSH)
+		}
+		if len(e.Proxy) > 0 {
+			Print("  Proxy=%s", e.Proxy)
+		}
+	}
+
+	for _, env := range environ {
+		Print(env)
+	}
+
+	for _, key := range filterKeys {
+		value, _ := cfg.Git.Get(key)
+		Print("git config %s = %q", key, value)
+	}
+}
+
+// envEndpoints returns the download endpoints of the default remote, if it is
+// not one of the configured remotes, and of each configured remote.
+func envEndpoints() []*envEndpoint {
+	apiClient := getAPIClient()
+	var endpoints []*envEndpoint
+
 	if cfg.IsDefaultRemote() {
-		endpoint := getAPIClient().Endpoints.Endpoint("download", cfg.Remote())
+		endpoint := apiClient.Endpoints.Endpoint("download", cfg.Remote())
 		if len(endpoint.Url) > 0 {
-			access := getAPIClient().Endpoints.AccessFor(endpoint.Url)
-			Print("Endpoint=%s (auth=%s)", endpoint.Url, access)
-			if len(endpoint.SshUserAndHost) > 0 {
-				Print("  SSH=%s:%s", endpoint.SshUserAndHost, endpoint.SshPath)
-			}
+			endpoints = append(endpoints, newEnvEndpoint(apiClient, "", endpoint))
 		}
 	}
 
 	for _, remote := range cfg.Remotes() {
-		remoteEndpoint := getAPIClient().Endpoints.RemoteEndpoint("download", remote)
-		remoteAccess := getAPIClient().Endpoints.AccessFor(remoteEndpoint.Url)
-		Print("Endpoint (%s)=%s (auth=%s)", remote, remoteEndpoint.Url, remoteAccess)
-		if len(remoteEndpoint.SshUserAndHost) > 0 {
-			Print("  SSH=%s:%s", remoteEndpoint.SshUserAndHost, remoteEndpoint.SshPath)
-		}
+		endpoint := apiClient.Endpoints.RemoteEndpoint("download", remote)
+		endpoints = append(endpoints, newEnvEndpoint(apiClient, remote, endpoint))
 	}
 
-	for _, env := range lfs.Environ(cfg, getTransferManifest()) {
-		Print(env)
+	return endpoints
+}
+
+func newEnvEndpoint(apiClient *lfsapi.Client, remote string, endpoint lfsapi.Endpoint) *envEndpoint {
+	e := &envEndpoint{
+		Remote: remote,
+		URL:    tools.RedactURLCredentials(endpoint.Url),
+		Auth:   string(apiClient.Endpoints.AccessFor(endpoint.Url)),
 	}
 
-	for _, key := range []string{"filter.lfs.process", "filter.lfs.smudge", "filter.lfs.clean"} {
-		value, _ := cfg.Git.Get(key)
-		Print("git config %s = %q", key, value)
+	if len(endpoint.SshUserAndHost) > 0 {
+		e.SSH = fmt.Sprintf("%s:%s", endpoint.SshUserAndHost, endpoint.SshPath)
 	}
+
+	if len(endpoint.Url) > 0 {
+		if proxyURL, err := apiClient.ProxyFor(endpoint.Url); err != nil {
+			e.Proxy = tools.RedactURLCredentials(fmt.Sprintf("Error: %s", err))
+		} else if proxyURL != nil {
+			e.Proxy = tools.RedactURLCredentials(proxyURL.String())
+		}
+	}
+
+	return e
 }
 
 func init() {
-	RegisterCommand("env", envCommand, nil)
+	RegisterCommand("env", envCommand, func(cmd *cobra.Command) {
+		cmd.Flags().BoolVarP(&envJson, "json", "j", false, "Give the output in a stable json format for scripts.")
+	})
 }
@@ -0,0 +1,176 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tq"
+	"github.com/spf13/cobra"
+)
+
+var (
+	existsRemote bool
+	existsJSON   bool
+)
+
+// existsBatchSize is the number of objects checked in each batch request,
+// unless lfs.transfer.batchsize is set, as for transfers.
+const existsBatchSize = 100
+
+// existsEntry describes the presence of a single object, both in the local
+// object store and (optionally) on the remote.
+type existsEntry struct {
+	Oid    string `json:"oid"`
+	Size   int64  `json:"size"`
+	Local  bool   `json:"local"`
+	Remote *bool  `json:"remote,omitempty"`
+	// Error is why the object could not be checked on the remote, in
+	// which case Remote is nil.
+	Error string `json:"error,omitempty"`
+}
+
+// existsCommand reports whether each of the given OIDs is present locally
+// and, with --remote, on the Git LFS server of the current remote. It exits
+// with status 1 if any object is unavailable, or else with status 2 if any
+// object which is not present locally could not be checked on the remote.
+func existsCommand(cmd *cobra.Command, args []string) {
+	requireInRepo()
+
+	if len(args) == 0 {
+		Exit("Usage: git lfs exists [--remote] [--json] <oid> [<oid> ...]")
+	}
+
+	entries := make([]*existsEntry, 0, len(args))
+	for _, oid := range args {
+		if !tools.IsValidOid(oid) {
+			Exit("Invalid object ID: %q", oid)
+		}
+
+		entry := &existsEntry{Oid: oid}
+		if stat, err := os.Stat(cfg.Filesystem().ObjectPathname(oid)); err == nil {
+			entry.Local = true
+			entry.Size = stat.Size()
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if existsRemote {
+		existsCheckRemote(entries)
+	}
+
+	if existsJSON {
+		if err := json.NewEncoder(os.Stdout).Encode(struct {
+			Objects []*existsEntry `json:"objects"`
+		}{entries}); err != nil {
+			ExitWithError(err)
+		}
+	} else {
+		for _, entry := range entries {
+			if len(entry.Error) > 0 {
+				Error("Could not check %s on %q: %s", entry.Oid, cfg.Remote(), entry.Error)
+			}
+			Print("%s %s %s", entry.Oid, existsState(&entry.Local), existsRemoteState(entry))
+		}
+	}
+
+	var unchecked bool
+	for _, entry := range entries {
+		if entry.Local {
+			continue
+		}
+		if entry.Remote == nil && len(entry.Error) > 0 {
+			unchecked = true
+		} else if entry.Remote == nil || !*entry.Remote {
+			os.Exit(1)
+		}
+	}
+	if unchecked {
+		os.Exit(2)
+	}
+}
+
+// existsCheckRemote fills in the Remote field of each entry by making batch
+// download requests against the current remote without transferring any
+// content. Objects the server does not have, as answered with a 404 or 410
+// error, are missing, while those it answers for with any other error, or which
+// could not be asked about at all, have their Error field set instead.
+func existsCheckRemote(entries []*existsEntry) {
+	manifest := getTransferManifestOperationRemote("download", cfg.Remote())
+	batchSize := manifest.BatchSize()
+	if batchSize < 1 {
+		batchSize = existsBatchSize
+	}
+
+	for i := 0; i < len(entries); i += batchSize {
+		end := i + batchSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+		batch := entries[i:end]
+
+		transfers := make([]*tq.Transfer, 0, len(batch))
+		for _, entry := range batch {
+			transfers = append(transfers, &tq.Transfer{Oid: entry.Oid, Size: entry.Size})
+		}
+
+		bRes, err := tq.Batch(manifest, tq.Download, cfg.Remote(), transfers)
+		if err != nil {
+			for _, entry := range batch {
+				entry.Error = err.Error()
+			}
+			continue
+		}
+
+		found := make(map[string]*tq.Transfer, len(bRes.Objects))
+		for _, t := range bRes.Objects {
+			found[t.Oid] = t
+		}
+
+		for _, entry := range batch {
+			existsCheckObject(entry, found[entry.Oid])
+		}
+	}
+}
+
+// existsCheckObject fills in the Remote or Error field of the given entry from
+// "t", the object of the batch response for it, if any.
+func existsCheckObject(entry *existsEntry, t *tq.Transfer) {
+	present := false
+	switch {
+	case t == nil, t.Error != nil && (t.Error.Code == 404 || t.Error.Code == 410):
+		entry.Remote = &present
+	case t.Error != nil:
+		entry.Error = t.Error.Error()
+	default:
+		present = true
+		entry.Remote = &present
+	}
+}
+
+// existsRemoteState returns the state of the object of the given entry on the
+// remote, which is "unknown" if it could not be checked.
+func existsRemoteState(entry *existsEntry) string {
+	if len(entry.Error) > 0 {
+		return "unknown"
+	}
+	return existsState(entry.Remote)
+}
+
+func existsState(present *bool) string {
+	if present == nil {
+		return "-"
+	}
+	if *present {
+		return "present"
+	}
+	return "missing"
+}
+
+func init() {
+	RegisterCommand("exists", existsCommand, func(cmd *cobra.Command) {
+		cmd.Flags().BoolVarP(&existsRemote, "remote", "r", false, "Also check for the objects on the Git LFS server.")
+		cmd.Flags().BoolVarP(&existsJSON, "json", "j", false, "Give the output in a stable json format for scripts.")
+	})
+}
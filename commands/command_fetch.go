@@ -3,6 +3,8 @@ package commands
 import (
 	"fmt"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/git-lfs/git-lfs/filepathfilter"
@@ -10,7 +12,8 @@ import (
 	"github.com/git-lfs/git-lfs/lfs"
 	"github.com/git-lfs/git-lfs/progress"
 	"github.com/git-lfs/git-lfs/tasklog"
-	"github.com/git-lfs/git-lfs/tq"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tools/humanize"
 	"github.com/rubyist/tracerx"
 	"github.com/spf13/cobra"
 )
@@ -19,6 +22,11 @@ var (
 	fetchRecentArg bool
 	fetchAllArg    bool
 	fetchPruneArg  bool
+
+	// fetchCheckpoint records the progress of fetch and pull, so that
+	// an interrupted one can be resumed. It is nil for the other commands
+	// which fetch objects.
+	fetchCheckpoint *lfs.FetchCheckpoint
 )
 
 func getIncludeExcludeArgs(cmd *cobra.Command) (include, exclude *string) {
@@ -36,6 +44,7 @@ func getIncludeExcludeArgs(cmd *cobra.Command) (include, exclude *string) {
 
 func fetchCommand(cmd *cobra.Command, args []string) {
 	requireInRepo()
+	startTransferTimeout()
 
 	var refs []*git.Ref
 
@@ -60,6 +69,8 @@ func fetchCommand(cmd *cobra.Command, args []string) {
 		refs = []*git.Ref{ref}
 	}
 
+	openFetchCheckpoint(cfg.Remote())
+
 	success := true
 	gitscanner := lfs.NewGitScanner(nil)
 	defer gitscanner.Close()
@@ -80,7 +91,7 @@ func fetchCommand(cmd *cobra.Command, args []string) {
 		success = fetchAll()
 
 	} else { // !all
-		filter := buildFilepathFilter(cfg, include, exclude)
+		filter := sparseFilepathFilter(buildFilepathFilter(cfg, include, exclude))
 
 		// Fetch refs sequentially per arg order; duplicates in later refs will be ignored
 		for _, ref := range refs {
@@ -95,6 +106,14 @@ func fetchCommand(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	closeFetchCheckpoint(success)
+
+	if success {
+		// Don't go on to prune if we ran out of time. Errors take
+		// precedence, and are reported below.
+		exitIfTimedOut()
+	}
+
 	if fetchPruneArg {
 		verify := fetchPruneCfg.PruneVerifyRemoteAlways
 		// no dry-run or verbose options in fetch, assume false
@@ -106,6 +125,63 @@ func fetchCommand(cmd *cobra.Command, args []string) {
 		e := c.Endpoints.Endpoint("download", cfg.Remote())
 		Exit("error: failed to fetch some objects from '%s'", e.Url)
 	}
+
+	// The remote, refs and paths given are those of the superproject, so
+	// only the flags which apply anywhere are passed on to submodules.
+	submoduleArgs := []string{"fetch"}
+	if fetchAllArg {
+		submoduleArgs = append(submoduleArgs, "--all")
+	}
+	if fetchRecentArg {
+		submoduleArgs = append(submoduleArgs, "--recent")
+	}
+	if fetchPruneArg {
+		submoduleArgs = append(submoduleArgs, "--prune")
+	}
+	recurseSubmodules(submoduleArgs...)
+}
+
+// openFetchCheckpoint opens the checkpoint of fetching objects from the given
+// remote, telling the user if it resumes an interrupted fetch.
+func openFetchCheckpoint(remote string) {
+	fetchCheckpoint = lfs.OpenFetchCheckpoint(cfg.Filesystem(), remote)
+
+	if p := fetchCheckpoint.Resumed(); p != nil && p.DoneObjects > 0 {
+		Print("Resuming interrupted fetch: %d of %d objects (%s of %s) already downloaded",
+			p.DoneObjects, p.Objects,
+			humanize.FormatBytes(uint64(p.DoneBytes)),
+			humanize.FormatBytes(uint64(p.Bytes)))
+	}
+}
+
+// closeFetchCheckpoint closes the fetch checkpoint, keeping it for the next
+// fetch unless every object was fetched.
+func closeFetchCheckpoint(success bool) {
+	if fetchCheckpoint == nil {
+		return
+	}
+	fetchCheckpoint.Close(success && atomic.LoadInt32(&transfersTimedOut) == 0)
+}
+
+// watchFetchCheckpoint records each object the given queue downloads in the
+// fetch checkpoint, if any, and returns a function which waits until all of
+// them have been, once the queue is done.
+func watchFetchCheckpoint(q *fallbackDownloadQueue) func() {
+	if fetchCheckpoint == nil {
+		return func() {}
+	}
+
+	dlwatch := q.Watch()
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		for t := range dlwatch {
+			fetchCheckpoint.Complete(t.Oid)
+		}
+		wg.Done()
+	}()
+	return wg.Wait
 }
 
 func pointersToFetchForRef(ref string, filter *filepathfilter.Filter) ([]*lfs.WrappedPointer, error) {
@@ -270,10 +346,8 @@ func scanAll() []*lfs.WrappedPointer {
 // Returns true if all completed with no errors, false if errors were written to stderr/log
 func fetchAndReportToChan(allpointers []*lfs.WrappedPointer, filter *filepathfilter.Filter, out chan<- *lfs.WrappedPointer) bool {
 	ready, pointers, meter := readyAndMissingPointers(allpointers, filter)
-	q := newDownloadQueue(
-		getTransferManifestOperationRemote("download", cfg.Remote()),
-		cfg.Remote(), tq.WithProgress(meter),
-	)
+	q := newFallbackDownloadQueue(cfg.Remote(), meter)
+	waitForCheckpoint := watchFetchCheckpoint(q)
 
 	if out != nil {
 		// If we already have it, or it won't be fetched
@@ -314,7 +388,9 @@ func fetchAndReportToChan(allpointers []*lfs.WrappedPointer, filter *filepathfil
 
 	processQueue := time.Now()
 	q.Wait()
-	tracerx.PerformanceSince("process queue", processQueue)
+	waitForCheckpoint()
+	tools.PerformanceSince("process queue", processQueue)
+	recordTimedOut(q)
 
 	ok := true
 	for _, err := range q.Errors() {
@@ -342,12 +418,17 @@ func readyAndMissingPointers(allpointers []*lfs.WrappedPointer, filter *filepath
 		seen[p.Oid] = true
 
 		// no need to download objects that exist locally already
-		lfs.LinkOrCopyFromReference(cfg, p.Oid, p.Size)
+		if !fetchedByCheckpoint(p) {
+			lfs.LinkOrCopyFromReference(cfg, p.Oid, p.Size)
+		}
 		if cfg.LFSObjectExists(p.Oid, p.Size) {
 			ready = append(ready, p)
 			continue
 		}
 
+		if fetchCheckpoint != nil {
+			fetchCheckpoint.Pending(p.Oid, p.Size)
+		}
 		missing = append(missing, p)
 		meter.Add(p.Size)
 	}
@@ -355,6 +436,13 @@ func readyAndMissingPointers(allpointers []*lfs.WrappedPointer, filter *filepath
 	return ready, missing, meter
 }
 
+// fetchedByCheckpoint returns whether the given object was downloaded by an
+// interrupted fetch which this one resumes, in which case there is no need to
+// look for it in the reference repository.
+func fetchedByCheckpoint(p *lfs.WrappedPointer) bool {
+	return fetchCheckpoint != nil && fetchCheckpoint.Done(p.Oid)
+}
+
 func init() {
 	RegisterCommand("fetch", fetchCommand, func(cmd *cobra.Command) {
 		cmd.Flags().StringVarP(&includeArg, "include", "I", "", "Include a list of paths")
@@ -362,5 +450,9 @@ func init() {
 		cmd.Flags().BoolVarP(&fetchRecentArg, "recent", "r", false, "Fetch recent refs & commits")
 		cmd.Flags().BoolVarP(&fetchAllArg, "all", "a", false, "Fetch all LFS files ever referenced")
 		cmd.Flags().BoolVarP(&fetchPruneArg, "prune", "p", false, "After fetching, prune old data")
+		addTimeoutFlag(cmd)
+		addRecurseSubmodulesFlag(cmd)
+		addNoSparseFlag(cmd)
+		addJSONErrorsFlag(cmd)
 	})
 }
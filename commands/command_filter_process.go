@@ -77,6 +77,7 @@ func filterCommand(cmd *cobra.Command, args []string) {
 
 	var malformed []string
 	var malformedOnWindows []string
+	var skippedDownloads []string
 	gitfilter := lfs.NewGitFilter(cfg)
 	for s.Scan() {
 		var n int64
@@ -115,7 +116,7 @@ func filterCommand(cmd *cobra.Command, args []string) {
 				}
 
 				n, err = smudge(gitfilter, w, from, req.Header["pathname"], skip, filter)
-				if err == nil {
+				if err == nil || isSkippedDownloadError(err) {
 					delete(ptrs, req.Header["pathname"])
 				}
 			}
@@ -166,6 +167,11 @@ func filterCommand(cmd *cobra.Command, args []string) {
 		if errors.IsNotAPointerError(err) {
 			malformed = append(malformed, req.Header["pathname"])
 			err = nil
+		} else if isSkippedDownloadError(err) {
+			// The pointer was written in place of the object's
+			// contents, so that the checkout can go on.
+			skippedDownloads = append(skippedDownloads, req.Header["pathname"])
+			err = nil
 		} else if possiblyMalformedObjectSize(n) {
 			malformedOnWindows = append(malformedOnWindows, req.Header["pathname"])
 		}
@@ -210,6 +216,8 @@ func filterCommand(cmd *cobra.Command, args []string) {
 		fmt.Fprintf(os.Stderr, "\nSee: `git lfs help smudge` for more details.\n")
 	}
 
+	reportSkippedDownloads(skippedDownloads)
+
 	if err := s.Err(); err != nil && err != io.EOF {
 		ExitWithError(err)
 	}
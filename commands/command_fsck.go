@@ -1,19 +1,26 @@
 package commands
 
 import (
-	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 
+	"github.com/git-lfs/git-lfs/fs"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tools/humanize"
 	"github.com/spf13/cobra"
 )
 
 var (
-	fsckDryRun bool
+	fsckDryRun   bool
+	fsckObjects  bool
+	fsckPointers bool
+	fsckRemote   bool
+	fsckRepair   bool
 )
 
 // TODO(zeroshirts): 'git fsck' reports status (percentage, current#/total) as
@@ -26,18 +33,114 @@ func fsckCommand(cmd *cobra.Command, args []string) {
 	installHooks(false)
 	requireInRepo()
 
-	ref, err := git.CurrentRef()
-	if err != nil {
-		ExitWithError(err)
+	if fsckDryRun && fsckRepair {
+		Exit("Cannot combine --dry-run with --repair.")
+	}
+
+	// Without either of --objects and --pointers, check both.
+	if !fsckObjects && !fsckPointers {
+		fsckObjects, fsckPointers = true, true
+	}
+	// The objects checked on the remote are those of the pointers.
+	if fsckRemote {
+		fsckPointers = true
+	}
+
+	var corrupt []*lfs.WrappedPointer
+	var anomalies []*lfs.SizeAnomaly
+	var invalid []string
+	var absent []*lfs.WrappedPointer
+	var missing []*verifyRemoteProblem
+
+	if fsckObjects {
+		corrupt, anomalies = fsckCheckObjects()
+	}
+	if fsckPointers {
+		invalid, absent = fsckCheckPointers()
+	}
+	if fsckRemote {
+		missing = fsckCheckRemote(absent)
+	}
+
+	for _, anomaly := range anomalies {
+		Print("%s", anomaly.Error())
+		Print("  %s", anomaly.Fix(isPointerFile(anomaly.Pointer.Name)))
+	}
+
+	for _, name := range invalid {
+		Print("File %s is tracked by Git LFS, but is not a valid pointer", name)
+		if isPointerFile(name) {
+			Print("  %s, then run `git rm --cached -- %s && git add -- %s` and commit to store it in Git LFS.", lfs.RestoreContentsFix(name), name, name)
+		} else {
+			Print("  If %s holds the right contents, run `git rm --cached -- %s && git add -- %s` and commit to store it in Git LFS.", name, name, name)
+		}
+	}
+
+	for _, problem := range missing {
+		p := problem.Pointer
+		if problem.Missing {
+			Print("Object %s (%s) is missing locally and could not be found on the remote", p.Name, p.Oid)
+		} else {
+			Print("Object %s (%s) is missing locally, and %s", p.Name, p.Oid, problem.Reason)
+		}
+	}
+
+	if len(corrupt) == 0 && len(anomalies) == 0 && len(invalid) == 0 && len(missing) == 0 {
+		Print("Git LFS fsck OK")
+		return
 	}
 
-	var corruptOids []string
+	if len(corrupt) > 0 && !fsckDryRun {
+		fsckQuarantine(corrupt)
+
+		if fsckRepair && fsckRedownload(corrupt) {
+			corrupt = nil
+		}
+	}
+
+	if len(corrupt) > 0 || len(anomalies) > 0 || len(invalid) > 0 || len(missing) > 0 {
+		os.Exit(1)
+	}
+}
+
+// fsckCheckObjects checks the objects of the pointers in the history of HEAD,
+// the index and the rest of the local storage directory, returning those which
+// are corrupt, along with pointers whose size is anomalous. Objects which are
+// not present locally are not checked.
+//
+// In a bare repository, such as a mirror, the pointers in the history of all
+// refs are checked instead, as there is no index, nor any one branch which is
+// more current than the others.
+func fsckCheckObjects() ([]*lfs.WrappedPointer, []*lfs.SizeAnomaly) {
+	var corrupt []*lfs.WrappedPointer
+	var anomalies []*lfs.SizeAnomaly
+	checked := tools.NewStringSet()
+
+	checker := lfs.NewSizeAnomalyChecker(maxPlausibleSize())
 	gitscanner := lfs.NewGitScanner(func(p *lfs.WrappedPointer, err error) {
 		if err == nil {
+			anomaly := checker.Check(p)
+			if anomaly != nil {
+				anomalies = append(anomalies, anomaly)
+			}
+			if !checker.IsPlausibleSize(p.Size) {
+				// There is no object worth checking.
+				return
+			}
+
+			if !checked.Add(p.Oid) || !tools.FileExists(cfg.Filesystem().ObjectPathname(p.Oid)) {
+				return
+			}
+
 			var pointerOk bool
-			pointerOk, err = fsckPointer(p.Name, p.Oid)
+			var size int64
+			pointerOk, size, err = fsckPointer(p.Name, p.Oid)
 			if !pointerOk {
-				corruptOids = append(corruptOids, p.Oid)
+				corrupt = append(corrupt, p)
+			} else if anomaly == nil {
+				if anomaly = lfs.CheckObjectSize(p, size); anomaly != nil {
+					anomalies = append(anomalies, anomaly)
+				}
 			}
 		}
 
@@ -46,25 +149,163 @@ func fsckCommand(cmd *cobra.Command, args []string) {
 		}
 	})
 
-	if err := gitscanner.ScanRef(ref.Sha, nil); err != nil {
+	if cfg.LocalWorkingDir() == "" {
+		if err := gitscanner.ScanAll(nil); err != nil {
+			ExitWithError(err)
+		}
+	} else {
+		ref, err := git.CurrentRef()
+		if err != nil {
+			ExitWithError(err)
+		}
+
+		if err := gitscanner.ScanRef(ref.Sha, nil); err != nil {
+			ExitWithError(err)
+		}
+
+		if err := gitscanner.ScanIndex("HEAD", nil); err != nil {
+			ExitWithError(err)
+		}
+	}
+
+	gitscanner.Close()
+
+	// Check the objects which no pointer above refers to, such as those
+	// of other branches.
+	err := cfg.EachLFSObject(func(obj fs.Object) error {
+		if checked.Contains(obj.Oid) {
+			return nil
+		}
+
+		ok, _, err := fsckPointer(obj.Oid, obj.Oid)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			corrupt = append(corrupt, &lfs.WrappedPointer{
+				Name:    obj.Oid,
+				Pointer: lfs.NewPointer(obj.Oid, obj.Size, nil),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	return corrupt, anomalies
+}
+
+// fsckCheckPointers checks that the files in the index which are tracked by
+// Git LFS are pointers, returning the names of those which are not. With
+// --remote, it also returns the pointers whose object is not present locally,
+// to be checked on the remote.
+//
+// A bare repository has no index, so the pointers in the history of all refs
+// are checked for absent objects instead.
+func fsckCheckPointers() ([]string, []*lfs.WrappedPointer) {
+	if cfg.LocalWorkingDir() == "" {
+		if !fsckRemote {
+			return nil, nil
+		}
+		return nil, fsckAbsentFromHistory()
+	}
+
+	entries, err := git.IndexFilesWithAttribute("filter", "lfs")
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	scanner, err := lfs.NewPointerScanner()
+	if err != nil {
 		ExitWithError(err)
 	}
 
-	if err := gitscanner.ScanIndex("HEAD", nil); err != nil {
+	var invalid []string
+	var absent []*lfs.WrappedPointer
+	for _, e := range entries {
+		scanner.Scan(e.Sha1)
+		if err := scanner.Err(); err != nil {
+			ExitWithError(err)
+		}
+
+		p := scanner.Pointer()
+		if p == nil {
+			invalid = append(invalid, e.Path)
+			continue
+		}
+
+		p.Name = e.Path
+		if fsckRemote && !tools.FileExists(cfg.Filesystem().ObjectPathname(p.Oid)) {
+			absent = append(absent, p)
+		}
+	}
+
+	if err := scanner.Close(); err != nil {
 		ExitWithError(err)
 	}
 
+	return invalid, absent
+}
+
+// fsckAbsentFromHistory returns the pointers in the history of all refs whose
+// object is not present locally, once for each object.
+func fsckAbsentFromHistory() []*lfs.WrappedPointer {
+	var absent []*lfs.WrappedPointer
+	seen := tools.NewStringSet()
+
+	gitscanner := lfs.NewGitScanner(func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			Panic(err, "Error checking Git LFS files")
+		}
+
+		if !seen.Add(p.Oid) || tools.FileExists(cfg.Filesystem().ObjectPathname(p.Oid)) {
+			return
+		}
+		absent = append(absent, p)
+	})
+
+	if err := gitscanner.ScanAll(nil); err != nil {
+		ExitWithError(err)
+	}
 	gitscanner.Close()
 
-	if len(corruptOids) == 0 {
-		Print("Git LFS fsck OK")
-		return
+	return absent
+}
+
+// fsckCheckRemote returns the problems with the objects of the given pointers
+// on the remote: all of them are missing if there is no remote. If the remote
+// could not be asked, all of them are reported as unverified.
+func fsckCheckRemote(pointers []*lfs.WrappedPointer) []*verifyRemoteProblem {
+	if len(pointers) == 0 {
+		return nil
 	}
 
-	if fsckDryRun {
-		return
+	endpoint := getAPIClient().Endpoints.Endpoint("download", cfg.Remote())
+	if len(endpoint.Url) == 0 {
+		problems := make([]*verifyRemoteProblem, 0, len(pointers))
+		for _, p := range pointers {
+			problems = append(problems, &verifyRemoteProblem{Pointer: p, Missing: true})
+		}
+		return problems
 	}
 
+	problems, err := verifyRemoteCheck(pointers)
+	if err != nil {
+		problems = make([]*verifyRemoteProblem, 0, len(pointers))
+		for _, p := range pointers {
+			problems = append(problems, &verifyRemoteProblem{
+				Pointer: p,
+				Reason:  fmt.Sprintf("could not be checked on %q: %s", cfg.Remote(), err),
+			})
+		}
+	}
+	return problems
+}
+
+// fsckQuarantine moves the objects of the given pointers out of the local
+// storage directory, so that they are downloaded again when needed.
+func fsckQuarantine(corrupt []*lfs.WrappedPointer) {
 	badDir := filepath.Join(cfg.LFSStorageDir(), "bad")
 	Print("Moving corrupt objects to %s", badDir)
 
@@ -72,15 +313,24 @@ func fsckCommand(cmd *cobra.Command, args []string) {
 		ExitWithError(err)
 	}
 
-	for _, oid := range corruptOids {
-		badFile := filepath.Join(badDir, oid)
-		if err := os.Rename(cfg.Filesystem().ObjectPathname(oid), badFile); err != nil {
+	for _, p := range corrupt {
+		badFile := filepath.Join(badDir, p.Oid)
+		if err := os.Rename(cfg.Filesystem().ObjectPathname(p.Oid), badFile); err != nil {
 			ExitWithError(err)
 		}
 	}
 }
 
-func fsckPointer(name, oid string) (bool, error) {
+// fsckRedownload downloads the objects of the given pointers again from the
+// remote, returning whether all of them were.
+func fsckRedownload(pointers []*lfs.WrappedPointer) bool {
+	Print("Downloading corrupt objects again from %s", cfg.Remote())
+	return fetchAndReportToChan(pointers, nil, nil)
+}
+
+// fsckPointer returns whether the local object with the given OID is intact,
+// and its size.
+func fsckPointer(name, oid string) (bool, int64, error) {
 	path := cfg.Filesystem().ObjectPathname(oid)
 
 	Debug("Examining %v (%v)", name, path)
@@ -88,31 +338,46 @@ func fsckPointer(name, oid string) (bool, error) {
 	f, err := os.Open(path)
 	if pErr, pOk := err.(*os.PathError); pOk {
 		Print("Object %s (%s) could not be checked: %s", name, oid, pErr.Err)
-		return false, nil
+		return false, 0, nil
 	}
 
 	if err != nil {
-		return false, err
+		return false, 0, err
 	}
 
-	oidHash := sha256.New()
-	_, err = io.Copy(oidHash, f)
+	oidHash := tools.NewLfsContentHashForOid(oid)
+	size, err := io.Copy(oidHash, f)
 	f.Close()
 	if err != nil {
-		return false, err
+		return false, 0, err
 	}
 
 	recalculatedOid := hex.EncodeToString(oidHash.Sum(nil))
 	if recalculatedOid == oid {
-		return true, nil
+		return true, size, nil
 	}
 
 	Print("Object %s (%s) is corrupt", name, oid)
-	return false, nil
+	return false, size, nil
+}
+
+// maxPlausibleSize returns the size above which a pointer's declared size is
+// considered implausible, according to lfs.maxplausiblesize.
+func maxPlausibleSize() int64 {
+	if v, ok := cfg.Git.Get("lfs.maxplausiblesize"); ok {
+		if size, err := humanize.ParseBytes(v); err == nil && size > 0 {
+			return int64(size)
+		}
+	}
+	return lfs.DefaultMaxPlausibleSize
 }
 
 func init() {
 	RegisterCommand("fsck", fsckCommand, func(cmd *cobra.Command) {
 		cmd.Flags().BoolVarP(&fsckDryRun, "dry-run", "d", false, "List corrupt objects without deleting them.")
+		cmd.Flags().BoolVarP(&fsckObjects, "objects", "", false, "Check that each local object matches its OID.")
+		cmd.Flags().BoolVarP(&fsckPointers, "pointers", "", false, "Check that each file tracked by Git LFS is a valid pointer.")
+		cmd.Flags().BoolVarP(&fsckRemote, "remote", "", false, "Check that the remote has each object not present locally.")
+		cmd.Flags().BoolVarP(&fsckRepair, "repair", "", false, "Download corrupt objects again from the remote.")
 	})
 }
@@ -10,7 +10,9 @@ import (
 var (
 	forceInstall      = false
 	localInstall      = false
+	worktreeInstall   = false
 	manualInstall     = false
+	chainInstall      = false
 	systemInstall     = false
 	skipSmudgeInstall = false
 	skipRepoInstall   = false
@@ -23,7 +25,7 @@ func installCommand(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	if !skipRepoInstall && (localInstall || cfg.InRepo()) {
+	if !skipRepoInstall && (localInstall || worktreeInstall || cfg.InRepo()) {
 		installHooksCommand(cmd, args)
 	}
 
@@ -33,12 +35,21 @@ func installCommand(cmd *cobra.Command, args []string) {
 func cmdInstallOptions() *lfs.FilterOptions {
 	requireGitVersion()
 
-	if localInstall {
+	if localInstall || worktreeInstall {
 		requireInRepo()
 	}
 
-	if localInstall && systemInstall {
+	switch {
+	case localInstall && worktreeInstall:
+		Exit("Only one of --local and --worktree options can be specified.")
+	case localInstall && systemInstall:
 		Exit("Only one of --local and --system options can be specified.")
+	case worktreeInstall && systemInstall:
+		Exit("Only one of --worktree and --system options can be specified.")
+	}
+
+	if worktreeInstall && !cfg.Git.Bool("extensions.worktreeconfig", false) {
+		Exit("The --worktree option requires extensions.worktreeConfig to be enabled.\nRun `git config extensions.worktreeConfig true` to enable it.")
 	}
 
 	if systemInstall && os.Geteuid() != 0 {
@@ -49,6 +60,7 @@ func cmdInstallOptions() *lfs.FilterOptions {
 		GitConfig:  cfg.GitConfig(),
 		Force:      forceInstall,
 		Local:      localInstall,
+		Worktree:   worktreeInstall,
 		System:     systemInstall,
 		SkipSmudge: skipSmudgeInstall,
 	}
@@ -65,6 +77,7 @@ func installHooksCommand(cmd *cobra.Command, args []string) {
 	// At a later date, extract `git-lfs-update(1)`-related logic into its
 	// own function, and translate this flag as a boolean argument to it.
 	updateManual = manualInstall
+	updateChain = chainInstall
 
 	updateCommand(cmd, args)
 }
@@ -73,10 +86,12 @@ func init() {
 	RegisterCommand("install", installCommand, func(cmd *cobra.Command) {
 		cmd.Flags().BoolVarP(&forceInstall, "force", "f", false, "Set the Git LFS global config, overwriting previous values.")
 		cmd.Flags().BoolVarP(&localInstall, "local", "l", false, "Set the Git LFS config for the local Git repository only.")
+		cmd.Flags().BoolVarP(&worktreeInstall, "worktree", "", false, "Set the Git LFS config for the current Git worktree only.")
 		cmd.Flags().BoolVarP(&systemInstall, "system", "", false, "Set the Git LFS config in system-wide scope.")
 		cmd.Flags().BoolVarP(&skipSmudgeInstall, "skip-smudge", "s", false, "Skip automatic downloading of objects on clone or pull.")
 		cmd.Flags().BoolVarP(&skipRepoInstall, "skip-repo", "", false, "Skip repo setup, just install global filters.")
 		cmd.Flags().BoolVarP(&manualInstall, "manual", "m", false, "Print instructions for manual install.")
+		cmd.Flags().BoolVarP(&chainInstall, "chain", "", false, "Run Git LFS from the start of existing hooks instead of overwriting them.")
 		cmd.AddCommand(NewCommand("hooks", installHooksCommand))
 	})
 }
@@ -1,45 +1,117 @@
 package commands
 
 import (
+	"encoding/json"
 	"os"
+	"strings"
 
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tools/humanize"
 	"github.com/spf13/cobra"
 )
 
 var (
-	longOIDs = false
-	debug    = false
+	longOIDs       = false
+	lsFilesSize    = false
+	lsFilesJSON    = false
+	lsFilesAll     = false
+	lsFilesDeleted = false
+	debug          = false
 )
 
+// lsFilesEntry describes a single Git LFS file in the output of
+// `git lfs ls-files --json`.
+type lsFilesEntry struct {
+	Name       string `json:"name"`
+	Size       int64  `json:"size"`
+	Checkout   bool   `json:"checkout"`
+	Downloaded bool   `json:"downloaded"`
+	OidType    string `json:"oid_type"`
+	Oid        string `json:"oid"`
+	Version    string `json:"version"`
+}
+
 func lsFilesCommand(cmd *cobra.Command, args []string) {
 	requireInRepo()
 
-	var ref string
+	var left, right string
+
+	if lsFilesAll {
+		if lsFilesDeleted {
+			Exit("Cannot use --all with --deleted.")
+		}
+		if len(args) > 0 {
+			Exit("Cannot use --all with explicit reference.")
+		}
+	}
+
+	switch len(args) {
+	case 0:
+		if lsFilesAll {
+			break
+		}
 
-	if len(args) == 1 {
-		ref = args[0]
-	} else {
 		fullref, err := git.CurrentRef()
 		if err != nil {
 			Exit(err.Error())
 		}
-		ref = fullref.Sha
+		right = fullref.Sha
+	case 1:
+		if strings.Contains(args[0], "...") {
+			Exit("Invalid ref range %q, only <ref>..<ref> is supported", args[0])
+		}
+		if i := strings.Index(args[0], ".."); i >= 0 {
+			left, right = args[0][:i], args[0][i+2:]
+			if len(left) == 0 || len(right) == 0 {
+				Exit("Invalid ref range %q, both refs must be given", args[0])
+			}
+		} else {
+			right = args[0]
+		}
+	case 2:
+		left, right = args[0], args[1]
+	default:
+		Exit("Usage: git lfs ls-files [<ref> | <ref>..<ref> | <ref> <ref>]")
+	}
+
+	if lsFilesDeleted && len(left) > 0 {
+		Exit("Cannot use --deleted with a range of references.")
 	}
 
-	showOidLen := 10
-	if longOIDs {
-		showOidLen = 64
+	// With --deleted, the objects still in the tree are left out.
+	var current tools.StringSet
+	if lsFilesDeleted {
+		var err error
+		if current, err = lsFilesTreeOids(right); err != nil {
+			Exit("Could not scan for Git LFS tree: %s", err)
+		}
 	}
 
+	entries := make([]*lsFilesEntry, 0)
+
 	gitscanner := lfs.NewGitScanner(func(p *lfs.WrappedPointer, err error) {
 		if err != nil {
 			Exit("Could not scan for Git LFS tree: %s", err)
 			return
 		}
 
-		if debug {
+		if current != nil && current.Contains(p.Oid) {
+			return
+		}
+
+		if lsFilesJSON {
+			entries = append(entries, &lsFilesEntry{
+				Name:       p.Name,
+				Size:       p.Size,
+				Checkout:   fileExistsOfSize(p),
+				Downloaded: cfg.LFSObjectExists(p.Oid, p.Size),
+				OidType:    p.OidType,
+				Oid:        p.Oid,
+				Version:    p.Version,
+			})
+		} else if debug {
 			Print(
 				"filepath: %s\n"+
 					"    size: %d\n"+
@@ -54,23 +126,83 @@ func lsFilesCommand(cmd *cobra.Command, args []string) {
 				p.OidType,
 				p.Oid,
 				p.Version)
+		} else if lsFilesSize {
+			Print("%s %s %s (%s)", lsFilesOid(p), lsFilesMarker(p), p.Name, humanize.FormatBytes(uint64(p.Size)))
 		} else {
-			Print("%s %s %s", p.Oid[0:showOidLen], lsFilesMarker(p), p.Name)
+			Print("%s %s %s", lsFilesOid(p), lsFilesMarker(p), p.Name)
+		}
+	})
+
+	var err error
+	switch {
+	case lsFilesAll:
+		err = gitscanner.ScanAll(nil)
+	case lsFilesDeleted:
+		err = gitscanner.ScanRefWithDeleted(right, nil)
+	case len(left) > 0:
+		// List the objects introduced by the commits in the range,
+		// rather than the contents of a single tree.
+		err = gitscanner.ScanRefs([]string{right}, []string{left}, nil)
+	default:
+		err = gitscanner.ScanTree(right)
+	}
+	gitscanner.Close()
+
+	if err != nil {
+		Exit("Could not scan for Git LFS tree: %s", err)
+	}
+
+	if lsFilesJSON {
+		if err := json.NewEncoder(os.Stdout).Encode(struct {
+			Files []*lsFilesEntry `json:"files"`
+		}{entries}); err != nil {
+			ExitWithError(err)
+		}
+	}
+}
+
+// lsFilesTreeOids returns the OIDs of the Git LFS files in the tree at the
+// given ref.
+func lsFilesTreeOids(ref string) (tools.StringSet, error) {
+	oids := tools.NewStringSet()
+	var scanErr error
+	gitscanner := lfs.NewGitScanner(func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			scanErr = err
+			return
 		}
+		oids.Add(p.Oid)
 	})
 	defer gitscanner.Close()
 
 	if err := gitscanner.ScanTree(ref); err != nil {
-		Exit("Could not scan for Git LFS tree: %s", err)
+		return nil, err
 	}
+	return oids, scanErr
 }
 
 // Returns true if a pointer appears to be properly smudge on checkout
 func fileExistsOfSize(p *lfs.WrappedPointer) bool {
+	// A symbolic link in place of the file, or of a directory leading to
+	// it, is not a checkout of it, whatever it points to.
+	if len(tools.SymlinkInPath(cfg.LocalWorkingDir(), p.Name)) > 0 {
+		return false
+	}
+
 	info, err := os.Stat(p.Name)
 	return err == nil && info.Size() == p.Size
 }
 
+// lsFilesOid returns the OID of the given pointer as shown by ls-files: in full
+// with --long, however long the digests of its hash algorithm are, or
+// abbreviated otherwise.
+func lsFilesOid(p *lfs.WrappedPointer) string {
+	if longOIDs || len(p.Oid) <= 10 {
+		return p.Oid
+	}
+	return p.Oid[0:10]
+}
+
 func lsFilesMarker(p *lfs.WrappedPointer) string {
 	if fileExistsOfSize(p) {
 		return "*"
@@ -81,6 +213,10 @@ func lsFilesMarker(p *lfs.WrappedPointer) string {
 func init() {
 	RegisterCommand("ls-files", lsFilesCommand, func(cmd *cobra.Command) {
 		cmd.Flags().BoolVarP(&longOIDs, "long", "l", false, "")
+		cmd.Flags().BoolVarP(&lsFilesSize, "size", "s", false, "")
 		cmd.Flags().BoolVarP(&debug, "debug", "d", false, "")
+		cmd.Flags().BoolVar(&lsFilesJSON, "json", false, "")
+		cmd.Flags().BoolVarP(&lsFilesAll, "all", "a", false, "")
+		cmd.Flags().BoolVar(&lsFilesDeleted, "deleted", false, "")
 	})
 }
@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/fs"
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/spf13/cobra"
+)
+
+var (
+	metadataJSON bool
+)
+
+// metadataCommand shows the metadata stored for the object given by OID or by
+// the path of a Git LFS file, or, given any "<key>=<value>" arguments, changes
+// it. An empty value removes the key.
+func metadataCommand(cmd *cobra.Command, args []string) {
+	requireInRepo()
+
+	if len(args) == 0 {
+		Exit("Usage: git lfs metadata [--json] <oid|path> [<key>=<value> ...]")
+	}
+
+	oid := metadataOid(args[0])
+	md, err := cfg.Filesystem().ReadObjectMetadata(oid)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	if len(args) > 1 {
+		for _, kv := range args[1:] {
+			parts := strings.SplitN(kv, "=", 2)
+			if len(parts) != 2 || len(parts[0]) == 0 {
+				Exit("Invalid metadata %q, expected <key>=<value>", kv)
+			}
+
+			if len(parts[1]) == 0 {
+				delete(md, parts[0])
+			} else {
+				md[parts[0]] = parts[1]
+			}
+		}
+
+		if err := cfg.Filesystem().WriteObjectMetadata(oid, md); err != nil {
+			ExitWithError(err)
+		}
+		return
+	}
+
+	if metadataJSON {
+		if err := json.NewEncoder(os.Stdout).Encode(struct {
+			Oid      string            `json:"oid"`
+			Metadata fs.ObjectMetadata `json:"metadata"`
+		}{oid, md}); err != nil {
+			ExitWithError(err)
+		}
+		return
+	}
+
+	keys := make([]string, 0, len(md))
+	for key := range md {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		Print("%s=%s", key, md[key])
+	}
+}
+
+// metadataOid returns the OID of the object given on the command line, either
+// directly, or as the path of a Git LFS file in the working tree.
+func metadataOid(arg string) string {
+	if tools.IsValidOid(arg) {
+		return arg
+	}
+
+	if ptr, err := lfs.DecodePointerFromFile(arg); err == nil {
+		return ptr.Oid
+	}
+
+	f, err := os.Open(arg)
+	if err != nil {
+		Exit("Not an object ID or a file: %q", arg)
+	}
+	defer f.Close()
+
+	h := newContentHash()
+	if _, err := io.Copy(h, f); err != nil {
+		ExitWithError(err)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordObjectMetadata records the path that the given object was cleaned
+// from, and the content type of its contents, stored at "mediafile", if
+// lfs.metadata is enabled. Failing to do so is not fatal to the clean.
+func recordObjectMetadata(oid, fileName, mediafile string) {
+	if len(fileName) == 0 || !cfg.Git.Bool("lfs.metadata", false) {
+		return
+	}
+
+	md, err := cfg.Filesystem().ReadObjectMetadata(oid)
+	if err != nil {
+		Error("Unable to read metadata for %s: %s", fileName, err)
+		return
+	}
+
+	md[fs.MetadataPath] = filepath.ToSlash(fileName)
+	if _, ok := md[fs.MetadataContentType]; !ok {
+		if ct, err := tools.FileContentType(mediafile, fileName); err == nil && ct != tools.DefaultContentType {
+			md[fs.MetadataContentType] = ct
+		}
+	}
+
+	if err := cfg.Filesystem().WriteObjectMetadata(oid, md); err != nil {
+		Error("Unable to record metadata for %s: %s", fileName, err)
+	}
+}
+
+func init() {
+	RegisterCommand("metadata", metadataCommand, func(cmd *cobra.Command) {
+		cmd.Flags().BoolVarP(&metadataJSON, "json", "j", false, "Give the output in a stable json format for scripts.")
+	})
+}
@@ -0,0 +1,198 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/filepathfilter"
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/git/githistory"
+	"github.com/git-lfs/git-lfs/git/odb"
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tasklog"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// migrateExportRemote is the remote to download missing objects from
+	// when exporting them out of Git LFS.
+	migrateExportRemote string
+)
+
+func migrateExportCommand(cmd *cobra.Command, args []string) {
+	requireInRepo()
+
+	l := tasklog.NewLogger(os.Stderr)
+	defer l.Close()
+
+	db, err := getObjectDatabase()
+	if err != nil {
+		ExitWithError(err)
+	}
+	defer db.Close()
+
+	rewriter := getHistoryRewriter(cmd, db, l)
+	filter := rewriter.Filter()
+	if len(filter.Include()) == 0 {
+		ExitWithError(errors.New("fatal: one or more files must be specified with --include"))
+	}
+
+	if len(migrateExportRemote) > 0 {
+		if err := git.ValidateRemote(migrateExportRemote); err != nil {
+			ExitWithError(errors.Errorf("fatal: invalid remote %q provided", migrateExportRemote))
+		}
+		cfg.SetRemote(migrateExportRemote)
+	}
+
+	gitfilter := lfs.NewGitFilter(cfg)
+	manifest := getTransferManifestOperationRemote("download", cfg.Remote())
+	untracked := untrackedFromFilter(filter)
+
+	opts, err := rewriteOptions(args, &githistory.RewriteOptions{
+		Verbose: migrateVerbose,
+		BlobFn: func(path string, b *odb.Blob) (*odb.Blob, error) {
+			if filepath.Base(path) == ".gitattributes" {
+				return b, nil
+			}
+
+			ptr, pbuf, err := lfs.DecodeFrom(b.Contents)
+			if err != nil {
+				// Not a pointer, so leave the blob as it
+				// is.
+				return &odb.Blob{Contents: pbuf, Size: b.Size}, nil
+			}
+
+			var buf bytes.Buffer
+
+			if _, err := gitfilter.Smudge(&buf, ptr, path, true, manifest, nil); err != nil {
+				return nil, err
+			}
+
+			return &odb.Blob{
+				Contents: &buf, Size: int64(buf.Len()),
+			}, nil
+		},
+
+		TreeCallbackFn: func(path string, t *odb.Tree) (*odb.Tree, error) {
+			if path != string(os.PathSeparator) {
+				// Ignore non-root trees.
+				return t, nil
+			}
+
+			theirs, err := trackedFromAttrs(db, t)
+			if err != nil {
+				return nil, err
+			}
+
+			// Stop tracking the exported patterns, and make
+			// sure that no broader pattern still tracks them.
+			attrs := tools.NewOrderedSet()
+			for line := range theirs.Iter() {
+				if !tracksExported(line, filter) {
+					attrs.Add(line)
+				}
+			}
+
+			blob, err := trackedToBlob(db, attrs.Union(untracked))
+			if err != nil {
+				return nil, err
+			}
+
+			return t.Merge(&odb.TreeEntry{
+				Name:     ".gitattributes",
+				Filemode: 0100644,
+				Oid:      blob,
+			}), nil
+		},
+
+		UpdateRefs: true,
+	}, l)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	// Download the objects being exported in as few batches as possible
+	// before rewriting, instead of one by one as they are smudged.
+	if !fetchObjectsToExport(opts.Include, opts.Exclude, filter) {
+		Exit("migrate: could not download all objects to export")
+	}
+
+	if _, err := rewriter.Rewrite(opts); err != nil {
+		ExitWithError(err)
+	}
+
+	// Only perform `git-checkout(1) -f` if the repository is
+	// non-bare.
+	if bare, _ := git.IsBare(); !bare {
+		t := l.Waiter("migrate: checkout")
+		err := git.Checkout("", nil, true)
+		t.Complete()
+
+		if err != nil {
+			ExitWithError(err)
+		}
+	}
+}
+
+// fetchObjectsToExport downloads any missing objects for pointers matching the
+// given filter in the commits reachable from "include" and not from "exclude".
+// It returns whether all of them were downloaded successfully.
+func fetchObjectsToExport(include, exclude []string, filter *filepathfilter.Filter) bool {
+	var pointers []*lfs.WrappedPointer
+	var multiErr error
+
+	gitscanner := lfs.NewGitScanner(func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			if multiErr != nil {
+				multiErr = fmt.Errorf("%v\n%v", multiErr, err)
+			} else {
+				multiErr = err
+			}
+			return
+		}
+
+		if filter.Allows(p.Name) {
+			pointers = append(pointers, p)
+		}
+	})
+
+	if err := gitscanner.ScanRefs(include, exclude, nil); err != nil {
+		ExitWithError(err)
+	}
+	gitscanner.Close()
+
+	if multiErr != nil {
+		Panic(multiErr, "Could not scan for Git LFS files")
+	}
+
+	return fetchAndReportToChan(pointers, filter, nil)
+}
+
+// untrackedFromFilter returns an ordered set of .gitattributes lines that
+// unset the filter/diff/merge attributes for patterns included in the given
+// filter, so that files matching them are stored in Git as they are.
+func untrackedFromFilter(filter *filepathfilter.Filter) *tools.OrderedSet {
+	untracked := tools.NewOrderedSet()
+
+	for _, include := range filter.Include() {
+		untracked.Add(fmt.Sprintf("%s !text !filter !merge !diff", include))
+	}
+
+	return untracked
+}
+
+// tracksExported returns whether the given .gitattributes line tracks one of
+// the patterns included in the given filter with Git LFS, as written by
+// git-lfs-track(1) or git-lfs-migrate(1) import.
+func tracksExported(line string, filter *filepathfilter.Filter) bool {
+	for _, include := range filter.Include() {
+		if line == fmt.Sprintf("%s filter=lfs diff=lfs merge=lfs -text", include) {
+			return true
+		}
+	}
+	return false
+}
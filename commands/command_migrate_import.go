@@ -3,12 +3,17 @@ package commands
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha1"
 	"encoding/hex"
 	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/filepathfilter"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/git/githistory"
@@ -19,6 +24,13 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	// migrateImportLegacy indicates the presence of the --legacy-pointers
+	// flag, and instructs 'git lfs migrate import' to convert git-media and
+	// git-fat stubs into Git LFS pointers.
+	migrateImportLegacy bool
+)
+
 func migrateImportCommand(cmd *cobra.Command, args []string) {
 	l := tasklog.NewLogger(os.Stderr)
 	defer l.Close()
@@ -34,6 +46,7 @@ func migrateImportCommand(cmd *cobra.Command, args []string) {
 	tracked := trackedFromFilter(rewriter.Filter())
 	exts := tools.NewOrderedSet()
 	gitfilter := lfs.NewGitFilter(cfg)
+	missing := tools.NewOrderedSet()
 
 	migrate(args, rewriter, l, &githistory.RewriteOptions{
 		Verbose: migrateVerbose,
@@ -44,10 +57,44 @@ func migrateImportCommand(cmd *cobra.Command, args []string) {
 
 			var buf bytes.Buffer
 
-			if _, err := clean(gitfilter, &buf, b.Contents, path, b.Size); err != nil {
+			contents, size := b.Contents, b.Size
+			if migrateImportLegacy && size <= lfs.LegacyPointerMaxSize {
+				stub, err := ioutil.ReadAll(contents)
+				if err != nil {
+					return nil, err
+				}
+				contents = bytes.NewReader(stub)
+
+				if legacy, err := lfs.DecodeLegacyPointer(stub); err == nil {
+					cached, err := openLegacyContents(legacy)
+					if err != nil {
+						return nil, err
+					}
+					if cached == nil {
+						// Keep the stub, which can be
+						// migrated again once its
+						// contents are available.
+						missing.Add(fmt.Sprintf("%s (%s %s)", path, legacy.Format, legacy.Sha1))
+						return &odb.Blob{
+							Contents: contents, Size: size,
+						}, nil
+					}
+					defer cached.Close()
+
+					contents, size = cached, cached.size
+				}
+			}
+
+			if _, err := clean(gitfilter, &buf, contents, path, size); err != nil {
 				return nil, err
 			}
 
+			if cached, ok := contents.(*legacyContents); ok {
+				if err := cached.Verify(); err != nil {
+					return nil, err
+				}
+			}
+
 			if ext := filepath.Ext(path); len(ext) > 0 {
 				exts.Add(fmt.Sprintf("*%s filter=lfs diff=lfs merge=lfs -text", ext))
 			}
@@ -103,6 +150,13 @@ func migrateImportCommand(cmd *cobra.Command, args []string) {
 		UpdateRefs: true,
 	})
 
+	if missing.Cardinality() > 0 {
+		Error("migrate: kept %d stub(s) whose contents were not found in the git-media or git-fat cache:", missing.Cardinality())
+		for entry := range missing.Iter() {
+			Error("  %s", entry)
+		}
+	}
+
 	// Only perform `git-checkout(1) -f` if the repository is
 	// non-bare.
 	if bare, _ := git.IsBare(); !bare {
@@ -116,6 +170,59 @@ func migrateImportCommand(cmd *cobra.Command, args []string) {
 	}
 }
 
+// legacyContents reads the contents of a git-media or git-fat stub from the
+// cache of the tool which wrote it, hashing them as they are read.
+type legacyContents struct {
+	io.Reader
+
+	legacy *lfs.LegacyPointer
+	size   int64
+	file   *os.File
+	hash   hash.Hash
+}
+
+// openLegacyContents opens the cached contents of the given stub, returning nil
+// if they are not present.
+func openLegacyContents(legacy *lfs.LegacyPointer) (*legacyContents, error) {
+	file, err := os.Open(legacy.CachePath(cfg.LocalGitDir()))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	h := sha1.New()
+	return &legacyContents{
+		Reader: io.TeeReader(file, h),
+		legacy: legacy,
+		size:   stat.Size(),
+		file:   file,
+		hash:   h,
+	}, nil
+}
+
+// Verify returns an error if the contents read do not match the stub.
+func (c *legacyContents) Verify() error {
+	if sha := hex.EncodeToString(c.hash.Sum(nil)); sha != c.legacy.Sha1 {
+		return errors.Errorf("migrate: %s object %s is corrupt, its contents have SHA-1 %s", c.legacy.Format, c.legacy.Sha1, sha)
+	}
+	if c.legacy.Size >= 0 && c.legacy.Size != c.size {
+		return errors.Errorf("migrate: %s object %s is corrupt, expected %d bytes, found %d", c.legacy.Format, c.legacy.Sha1, c.legacy.Size, c.size)
+	}
+	return nil
+}
+
+func (c *legacyContents) Close() error {
+	return c.file.Close()
+}
+
 // trackedFromFilter returns an ordered set of strings where each entry is a
 // line in the .gitattributes file. It adds/removes the fiter/diff/merge=lfs
 // attributes based on patterns included/excldued in the given filter.
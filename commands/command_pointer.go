@@ -2,7 +2,6 @@ package commands
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -18,6 +17,7 @@ var (
 	pointerFile    string
 	pointerCompare string
 	pointerStdin   bool
+	pointerCheck   bool
 )
 
 func pointerCommand(cmd *cobra.Command, args []string) {
@@ -26,6 +26,11 @@ func pointerCommand(cmd *cobra.Command, args []string) {
 	buildOid := ""
 	compareOid := ""
 
+	if pointerCheck {
+		pointerCheckCommand()
+		return
+	}
+
 	if len(pointerCompare) > 0 || pointerStdin {
 		comparing = true
 	}
@@ -38,7 +43,7 @@ func pointerCommand(cmd *cobra.Command, args []string) {
 			os.Exit(1)
 		}
 
-		oidHash := sha256.New()
+		oidHash := newContentHash()
 		size, err := io.Copy(oidHash, buildFile)
 		buildFile.Close()
 
@@ -48,6 +53,7 @@ func pointerCommand(cmd *cobra.Command, args []string) {
 		}
 
 		ptr := lfs.NewPointer(hex.EncodeToString(oidHash.Sum(nil)), size, nil)
+		ptr.OidType = cfg.HashAlgorithm()
 		fmt.Fprintf(os.Stderr, "Git LFS pointer for %s\n\n", pointerFile)
 		buf := &bytes.Buffer{}
 		lfs.EncodePointer(io.MultiWriter(os.Stdout, buf), ptr)
@@ -110,6 +116,61 @@ func pointerCommand(cmd *cobra.Command, args []string) {
 	}
 }
 
+// pointerCheckCommand exits with status 0 if the file given by --file, or the
+// data read through --stdin, is a valid pointer, 1 if it is not, and 2 if it
+// could not be opened.
+func pointerCheckCommand() {
+	if len(pointerCompare) > 0 {
+		Exit("Cannot combine --check with --pointer.")
+	}
+
+	var r io.ReadCloser
+	if len(pointerFile) > 0 {
+		if pointerStdin {
+			Exit("With --check, --file cannot be combined with --stdin.")
+		}
+
+		f, err := os.Open(pointerFile)
+		if err != nil {
+			Exit(err.Error())
+		}
+		r = f
+	} else if pointerStdin {
+		requireStdin("The --stdin flag expects a pointer file from STDIN.")
+		r = os.Stdin
+	} else {
+		Exit("Must specify either --file or --stdin with --check.")
+	}
+
+	rec := &readErrorRecorder{Reader: r}
+	_, err := lfs.DecodeEntirePointer(rec)
+	r.Close()
+
+	if rec.err != nil {
+		Exit("Could not read the pointer: %s", rec.err)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// readErrorRecorder records the first error other than io.EOF returned by its
+// Reader, so that failing to read the data can be told from the data not being
+// a pointer.
+type readErrorRecorder struct {
+	io.Reader
+	err error
+}
+
+func (r *readErrorRecorder) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
+
 func pointerReader() (io.ReadCloser, error) {
 	if len(pointerCompare) > 0 {
 		if pointerStdin {
@@ -129,5 +190,6 @@ func init() {
 		cmd.Flags().StringVarP(&pointerFile, "file", "f", "", "Path to a local file to generate the pointer from.")
 		cmd.Flags().StringVarP(&pointerCompare, "pointer", "p", "", "Path to a local file containing a pointer built by another Git LFS implementation.")
 		cmd.Flags().BoolVarP(&pointerStdin, "stdin", "", false, "Read a pointer built by another Git LFS implementation through STDIN.")
+		cmd.Flags().BoolVarP(&pointerCheck, "check", "", false, "Check whether the given file is a valid Git LFS pointer.")
 	})
 }
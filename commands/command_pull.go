@@ -11,7 +11,7 @@ import (
 	"github.com/git-lfs/git-lfs/lfs"
 	"github.com/git-lfs/git-lfs/progress"
 	"github.com/git-lfs/git-lfs/tasklog"
-	"github.com/git-lfs/git-lfs/tq"
+	"github.com/git-lfs/git-lfs/tools"
 	"github.com/rubyist/tracerx"
 	"github.com/spf13/cobra"
 )
@@ -19,6 +19,7 @@ import (
 func pullCommand(cmd *cobra.Command, args []string) {
 	requireGitVersion()
 	requireInRepo()
+	startTransferTimeout()
 
 	if len(args) > 0 {
 		// Remote is first arg
@@ -28,8 +29,9 @@ func pullCommand(cmd *cobra.Command, args []string) {
 	}
 
 	includeArg, excludeArg := getIncludeExcludeArgs(cmd)
-	filter := buildFilepathFilter(cfg, includeArg, excludeArg)
+	filter := sparseFilepathFilter(buildFilepathFilter(cfg, includeArg, excludeArg))
 	pull(filter)
+	recurseSubmodules("pull")
 }
 
 func pull(filter *filepathfilter.Filter) {
@@ -43,8 +45,9 @@ func pull(filter *filepathfilter.Filter) {
 	meter := progress.NewMeter(progress.WithOSEnv(cfg.Os))
 	logger.Enqueue(meter)
 	remote := cfg.Remote()
+	openFetchCheckpoint(remote)
 	singleCheckout := newSingleCheckout(cfg.Git, remote)
-	q := newDownloadQueue(singleCheckout.Manifest(), remote, tq.WithProgress(meter))
+	q := newFallbackDownloadQueue(remote, meter)
 	gitscanner := lfs.NewGitScanner(func(p *lfs.WrappedPointer, err error) {
 		if err != nil {
 			LoggedError(err, "Scanner error: %s", err)
@@ -56,12 +59,15 @@ func pull(filter *filepathfilter.Filter) {
 		}
 
 		// no need to download objects that exist locally already
-		lfs.LinkOrCopyFromReference(cfg, p.Oid, p.Size)
+		if !fetchedByCheckpoint(p) {
+			lfs.LinkOrCopyFromReference(cfg, p.Oid, p.Size)
+		}
 		if cfg.LFSObjectExists(p.Oid, p.Size) {
 			singleCheckout.Run(p)
 			return
 		}
 
+		fetchCheckpoint.Pending(p.Oid, p.Size)
 		meter.Add(p.Size)
 		tracerx.Printf("fetch %v [%v]", p.Name, p.Oid)
 		pointers.Add(p)
@@ -76,6 +82,7 @@ func pull(filter *filepathfilter.Filter) {
 
 	go func() {
 		for t := range dlwatch {
+			fetchCheckpoint.Complete(t.Oid)
 			for _, p := range pointers.All(t.Oid) {
 				singleCheckout.Run(p)
 			}
@@ -93,7 +100,8 @@ func pull(filter *filepathfilter.Filter) {
 	gitscanner.Close()
 	q.Wait()
 	wg.Wait()
-	tracerx.PerformanceSince("process queue", processQueue)
+	tools.PerformanceSince("process queue", processQueue)
+	recordTimedOut(q)
 
 	singleCheckout.Close()
 
@@ -102,8 +110,11 @@ func pull(filter *filepathfilter.Filter) {
 		success = false
 		FullError(err)
 	}
+	closeFetchCheckpoint(success)
 
-	if !success {
+	if !success && skipDownloadErrors() {
+		reportSkippedDownloads(append(pointers.Names(), singleCheckout.NotLocal()...))
+	} else if !success {
 		c := getAPIClient()
 		e := c.Endpoints.Endpoint("download", remote)
 		Exit("error: failed to fetch some objects from '%s'", e.Url)
@@ -112,6 +123,13 @@ func pull(filter *filepathfilter.Filter) {
 	if singleCheckout.Skip() {
 		fmt.Println("Skipping object checkout, Git LFS is not installed.")
 	}
+
+	if success {
+		// Errors checking out files, such as a full disk, are only
+		// reported above.
+		exitWithErrorClass()
+	}
+	exitIfTimedOut()
 }
 
 // tracks LFS objects being downloaded, according to their unique OIDs.
@@ -140,6 +158,21 @@ func (m *pointerMap) Add(p *lfs.WrappedPointer) {
 	m.pointers[p.Oid] = append(m.pointers[p.Oid], p)
 }
 
+// Names returns the names of the files whose objects have not been
+// downloaded.
+func (m *pointerMap) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var names []string
+	for _, pointers := range m.pointers {
+		for _, p := range pointers {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
 func (m *pointerMap) All(oid string) []*lfs.WrappedPointer {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -152,5 +185,10 @@ func init() {
 	RegisterCommand("pull", pullCommand, func(cmd *cobra.Command) {
 		cmd.Flags().StringVarP(&includeArg, "include", "I", "", "Include a list of paths")
 		cmd.Flags().StringVarP(&excludeArg, "exclude", "X", "", "Exclude a list of paths")
+		addTimeoutFlag(cmd)
+		addRecurseSubmodulesFlag(cmd)
+		addNoSparseFlag(cmd)
+		addSkipDownloadErrorsFlag(cmd)
+		addJSONErrorsFlag(cmd)
 	})
 }
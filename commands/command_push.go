@@ -35,13 +35,25 @@ func pushCommand(cmd *cobra.Command, args []string) {
 	}
 
 	requireGitVersion()
+	startTransferTimeout()
 
 	// Remote is first arg
 	if err := cfg.SetValidRemote(args[0]); err != nil {
 		Exit("Invalid remote name %q: %s", args[0], err)
 	}
 
+	if pushJSON {
+		if !pushDryRun {
+			Exit("--json can only be used with --dry-run.")
+		}
+
+		// Leave standard output to the report, so that it can be
+		// parsed.
+		OutputWriter = ErrorWriter
+	}
+
 	ctx := newUploadContext(pushDryRun)
+	ctx.reportDryRun = pushDryRun
 	if pushObjectIDs {
 		if len(args) < 2 {
 			Print("Usage: git lfs push --object-id <remote> <lfs-object-id> [lfs-object-id] ...")
@@ -57,6 +69,8 @@ func pushCommand(cmd *cobra.Command, args []string) {
 
 		uploadsBetweenRefAndRemote(ctx, args[1:])
 	}
+
+	exitIfTimedOut()
 }
 
 func uploadsBetweenRefAndRemote(ctx *uploadContext, refnames []string) {
@@ -71,6 +85,14 @@ func uploadsBetweenRefAndRemote(ctx *uploadContext, refnames []string) {
 	if err := uploadForRefUpdates(ctx, updates, pushAll); err != nil {
 		ExitWithError(err)
 	}
+
+	if ctx.DryRun {
+		commits, err := pushDryRunCommits(ctx, updates, pushAll)
+		if err != nil {
+			ExitWithError(err)
+		}
+		reportPushDryRun(ctx, commits)
+	}
 }
 
 func uploadsWithObjectIDs(ctx *uploadContext, oids []string) {
@@ -97,6 +119,10 @@ func uploadsWithObjectIDs(ctx *uploadContext, oids []string) {
 
 	uploadPointers(ctx, pointers...)
 	ctx.Await()
+
+	if ctx.DryRun {
+		reportPushDryRun(ctx, nil)
+	}
 }
 
 // lfsPushRefs returns valid ref updates from the given ref and --all arguments.
@@ -139,5 +165,9 @@ func init() {
 		cmd.Flags().BoolVarP(&pushDryRun, "dry-run", "d", false, "Do everything except actually send the updates")
 		cmd.Flags().BoolVarP(&pushObjectIDs, "object-id", "o", false, "Push LFS object ID(s)")
 		cmd.Flags().BoolVarP(&pushAll, "all", "a", false, "Push all objects for the current ref to the remote.")
+		addTimeoutFlag(cmd)
+		addPushReviewFlag(cmd)
+		addPushDryRunFlags(cmd)
+		addJSONErrorsFlag(cmd)
 	})
 }
@@ -94,6 +94,10 @@ func delayedSmudge(gf *lfs.GitFilter, s *git.FilterProcessScanner, to io.Writer,
 // will not be downloaded, and the object will remain a pointer on disk, as if
 // the smudge filter had not been applied at all.
 //
+// If the object could not be downloaded, its pointer is written instead, and
+// the process exits, unless download errors are skipped, in which case a
+// *skippedDownloadError is returned.
+//
 // Any errors encountered along the way will be returned immediately if they
 // were non-fatal, otherwise execution will halt and the process will be
 // terminated by using the `commands.Panic()` func.
@@ -139,9 +143,10 @@ func smudge(gf *lfs.GitFilter, to io.Writer, from io.Reader, filename string, sk
 			}
 
 			LoggedError(err, "Error downloading object: %s (%s): %s", filename, oid, err)
-			if !cfg.SkipDownloadErrors() {
+			if !skipDownloadErrors() {
 				os.Exit(2)
 			}
+			return n, &skippedDownloadError{err}
 		}
 	}
 
@@ -161,6 +166,8 @@ func smudgeCommand(cmd *cobra.Command, args []string) {
 	if n, err := smudge(gitfilter, os.Stdout, os.Stdin, smudgeFilename(args), smudgeSkip, filter); err != nil {
 		if errors.IsNotAPointerError(err) {
 			fmt.Fprintln(os.Stderr, err.Error())
+		} else if isSkippedDownloadError(err) {
+			// Already reported by smudge.
 		} else {
 			Error(err.Error())
 		}
@@ -1,7 +1,6 @@
 package commands
 
 import (
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +8,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/lfs"
 	"github.com/spf13/cobra"
@@ -45,7 +45,8 @@ func statusCommand(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	statusScanRefRange(ref)
+	checker := lfs.NewSizeAnomalyChecker(maxPlausibleSize())
+	anomalies := statusScanRefRange(ref, checker)
 
 	staged, unstaged, err := scanIndex(scanIndexAt)
 	if err != nil {
@@ -69,11 +70,50 @@ func statusCommand(cmd *cobra.Command, args []string) {
 
 	Print("")
 
+	anomalies = append(anomalies, indexSizeAnomalies(scanner, checker, append(staged, unstaged...))...)
+	if len(anomalies) > 0 {
+		Print("Git LFS pointers with invalid sizes:\n")
+		for _, anomaly := range anomalies {
+			Print("\t%s", anomaly.Error())
+			Print("\t  %s", anomaly.Fix(isPointerFile(anomaly.Pointer.Name)))
+		}
+		Print("")
+	}
+
 	if err = scanner.Close(); err != nil {
 		ExitWithError(err)
 	}
 }
 
+// indexSizeAnomalies returns the anomalies with the sizes of the pointers in
+// the given index entries, as stored in Git.
+func indexSizeAnomalies(s *lfs.PointerScanner, checker *lfs.SizeAnomalyChecker, entries []*lfs.DiffIndexEntry) []*lfs.SizeAnomaly {
+	var anomalies []*lfs.SizeAnomaly
+	for _, entry := range entries {
+		name := entry.DstName
+		if len(name) == 0 {
+			name = entry.SrcName
+		}
+
+		for _, sha := range []string{entry.SrcSha, entry.DstSha} {
+			if z40.MatchString(sha) {
+				continue
+			}
+
+			s.Scan(sha)
+			if s.Err() != nil || s.Pointer() == nil {
+				continue
+			}
+
+			p := &lfs.WrappedPointer{Sha1: sha, Name: name, Pointer: s.Pointer().Pointer}
+			if anomaly := checker.Check(p); anomaly != nil {
+				anomalies = append(anomalies, anomaly)
+			}
+		}
+	}
+	return anomalies
+}
+
 var z40 = regexp.MustCompile(`\^?0{40}`)
 
 func formatBlobInfo(s *lfs.PointerScanner, entry *lfs.DiffIndexEntry) string {
@@ -134,13 +174,21 @@ func blobInfo(s *lfs.PointerScanner, blobSha, name string) (sha, from string, er
 		return s.ContentsSha()[:7], from, nil
 	}
 
+	// Git stores a symbolic link as the path it points to, rather than
+	// the contents there, which may not even exist.
+	if target, ok := readSymlink(name); ok {
+		shasum := newContentHash()
+		shasum.Write([]byte(target))
+		return fmt.Sprintf("%x", shasum.Sum(nil))[:7], "Symlink", nil
+	}
+
 	f, err := os.Open(name)
 	if err != nil {
 		return "", "", err
 	}
 	defer f.Close()
 
-	shasum := sha256.New()
+	shasum := newContentHash()
 	if _, err = io.Copy(shasum, f); err != nil {
 		return "", "", err
 	}
@@ -148,6 +196,21 @@ func blobInfo(s *lfs.PointerScanner, blobSha, name string) (sha, from string, er
 	return fmt.Sprintf("%x", shasum.Sum(nil))[:7], "File", nil
 }
 
+// readSymlink returns the path which the working tree file with the given name
+// points to, and whether it is a symbolic link at all.
+func readSymlink(name string) (string, bool) {
+	fi, err := os.Lstat(name)
+	if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		return "", false
+	}
+
+	target, err := os.Readlink(name)
+	if err != nil {
+		return "", false
+	}
+	return target, true
+}
+
 func scanIndex(ref string) (staged, unstaged []*lfs.DiffIndexEntry, err error) {
 	uncached, err := lfs.NewDiffIndexScanner(ref, false)
 	if err != nil {
@@ -203,18 +266,21 @@ func keyFromEntry(e *lfs.DiffIndexEntry) string {
 	return strings.Join([]string{e.SrcSha, e.DstSha, name}, ":")
 }
 
-func statusScanRefRange(ref *git.Ref) {
+// statusScanRefRange lists the objects to be pushed from the given ref, and
+// returns the anomalies with the sizes of their pointers.
+func statusScanRefRange(ref *git.Ref, checker *lfs.SizeAnomalyChecker) []*lfs.SizeAnomaly {
 	if ref == nil {
-		return
+		return nil
 	}
 
 	Print("On branch %s", ref.Name)
 
 	remoteRef, err := cfg.GitConfig().CurrentRemoteRef()
 	if err != nil {
-		return
+		return nil
 	}
 
+	var anomalies []*lfs.SizeAnomaly
 	gitscanner := lfs.NewGitScanner(func(p *lfs.WrappedPointer, err error) {
 		if err != nil {
 			Panic(err, "Could not scan for Git LFS objects")
@@ -222,6 +288,9 @@ func statusScanRefRange(ref *git.Ref) {
 		}
 
 		Print("\t%s (%s)", p.Name, p.Oid)
+		if anomaly := checker.Check(p); anomaly != nil {
+			anomalies = append(anomalies, anomaly)
+		}
 	})
 	defer gitscanner.Close()
 
@@ -230,15 +299,30 @@ func statusScanRefRange(ref *git.Ref) {
 		Panic(err, "Could not scan for Git LFS objects")
 	}
 
+	return anomalies
 }
 
 type JSONStatusEntry struct {
 	Status string `json:"status"`
 	From   string `json:"from,omitempty"`
+	// Staged is whether the change is staged in the index, rather than
+	// only made in the working tree.
+	Staged bool `json:"staged"`
+	// Oid and Size describe the Git LFS object which the file has in the
+	// index if the change is staged, or in the working tree otherwise.
+	// They are omitted for deleted files, but not for empty ones.
+	Oid  string `json:"oid,omitempty"`
+	Size *int64 `json:"size,omitempty"`
 }
 
 type JSONStatus struct {
+	// Files holds each changed file once, with its staged change if it
+	// has both a staged and an unstaged one, as older versions did.
 	Files map[string]JSONStatusEntry `json:"files"`
+	// Staged and Unstaged hold the changes to each file which are staged
+	// in the index, and those only made in the working tree.
+	Staged   map[string]JSONStatusEntry `json:"staged"`
+	Unstaged map[string]JSONStatusEntry `json:"unstaged"`
 }
 
 func jsonStagedPointers(scanner *lfs.PointerScanner, ref string) {
@@ -247,9 +331,20 @@ func jsonStagedPointers(scanner *lfs.PointerScanner, ref string) {
 		ExitWithError(err)
 	}
 
-	status := JSONStatus{Files: make(map[string]JSONStatusEntry)}
+	// Names in the index are relative to the root of the repository,
+	// rather than to the current directory, where the files are read.
+	pathConverter, err := lfs.NewRepoToCurrentPathConverter(cfg)
+	if err != nil {
+		ExitWithError(err)
+	}
 
-	for _, entry := range append(unstaged, staged...) {
+	status := JSONStatus{
+		Files:    make(map[string]JSONStatusEntry),
+		Staged:   make(map[string]JSONStatusEntry),
+		Unstaged: make(map[string]JSONStatusEntry),
+	}
+
+	for i, entry := range append(unstaged, staged...) {
 		_, fromSrc, err := blobInfoFrom(scanner, entry)
 		if err != nil {
 			ExitWithError(err)
@@ -259,15 +354,33 @@ func jsonStagedPointers(scanner *lfs.PointerScanner, ref string) {
 			continue
 		}
 
+		isStaged := i >= len(unstaged)
+		oid, size, err := objectInfoTo(scanner, pathConverter, entry, isStaged)
+		if err != nil {
+			ExitWithError(err)
+		}
+
+		name := entry.SrcName
+		jsonEntry := JSONStatusEntry{
+			Status: string(entry.Status),
+			Staged: isStaged, Oid: oid,
+		}
+		if len(oid) > 0 {
+			jsonEntry.Size = &size
+		}
 		switch entry.Status {
 		case lfs.StatusRename, lfs.StatusCopy:
-			status.Files[entry.DstName] = JSONStatusEntry{
-				Status: string(entry.Status), From: entry.SrcName,
-			}
-		default:
-			status.Files[entry.SrcName] = JSONStatusEntry{
-				Status: string(entry.Status),
-			}
+			name = entry.DstName
+			jsonEntry.From = entry.SrcName
+		}
+
+		// Staged entries come last, and so replace any unstaged ones in
+		// status.Files.
+		status.Files[name] = jsonEntry
+		if isStaged {
+			status.Staged[name] = jsonEntry
+		} else {
+			status.Unstaged[name] = jsonEntry
 		}
 	}
 
@@ -278,6 +391,67 @@ func jsonStagedPointers(scanner *lfs.PointerScanner, ref string) {
 	Print(string(ret))
 }
 
+// objectInfoTo returns the OID and size of the Git LFS object which the file
+// changed by the given entry has after the change: in the index if the change
+// is staged, or in the working tree otherwise, where it is found by converting
+// its name with pathConverter. It returns an empty OID if the file was deleted.
+func objectInfoTo(s *lfs.PointerScanner, pathConverter lfs.PathConverter, entry *lfs.DiffIndexEntry, staged bool) (string, int64, error) {
+	name := entry.DstName
+	if len(name) == 0 {
+		name = entry.SrcName
+	}
+
+	if !z40.MatchString(entry.DstSha) {
+		s.Scan(entry.DstSha)
+		if err := s.Err(); err != nil {
+			if git.IsMissingObject(err) {
+				return "", 0, nil
+			}
+			return "", 0, err
+		}
+		if p := s.Pointer(); p != nil {
+			return p.Oid, p.Size, nil
+		}
+		return "", 0, nil
+	}
+
+	if staged {
+		return "", 0, nil
+	}
+
+	path := pathConverter.Convert(name)
+
+	// A symbolic link is stored by Git as it is, rather than as a Git LFS
+	// object, however well what it points to would do as one.
+	if _, ok := readSymlink(path); ok {
+		return "", 0, nil
+	}
+
+	// The working tree file is either still a pointer, or the contents
+	// which the clean filter would store.
+	p, err := lfs.DecodePointerFromFile(path)
+	if err == nil {
+		return p.Oid, p.Size, nil
+	} else if os.IsNotExist(err) {
+		return "", 0, nil
+	} else if !errors.IsNotAPointerError(err) {
+		return "", 0, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	shasum := newContentHash()
+	size, err := io.Copy(shasum, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return fmt.Sprintf("%x", shasum.Sum(nil)), size, nil
+}
+
 func porcelainStagedPointers(ref string) {
 	staged, unstaged, err := scanIndex(ref)
 	if err != nil {
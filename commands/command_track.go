@@ -3,6 +3,7 @@ package commands
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -25,8 +26,22 @@ var (
 	trackVerboseLoggingFlag bool
 	trackDryRunFlag         bool
 	trackNoModifyAttrsFlag  bool
+	trackFilenameFlag       bool
+	trackNoTouchFlag        bool
+	trackJSONFlag           bool
 )
 
+// trackedPattern describes a pattern tracked by Git LFS in the output of
+// "git lfs track --json".
+type trackedPattern struct {
+	Pattern  string `json:"pattern"`
+	Source   string `json:"source"`
+	Lockable bool   `json:"lockable"`
+	// Status is "added", "updated" or "unchanged" for the patterns given
+	// on the command line, and empty when listing patterns.
+	Status string `json:"status,omitempty"`
+}
+
 func trackCommand(cmd *cobra.Command, args []string) {
 	requireGitVersion()
 
@@ -49,7 +64,7 @@ func trackCommand(cmd *cobra.Command, args []string) {
 		return
 	}
 
-	knownPatterns := git.GetAttributePaths(cfg.LocalWorkingDir(), cfg.LocalGitDir())
+	knownPatterns := git.GetAttributePaths(cfg.LocalWorkingDir(), cfg.LocalGitStorageDir())
 	lineEnd := getAttributeLineEnding(knownPatterns)
 	if len(lineEnd) == 0 {
 		lineEnd = gitLineEnding(cfg.Git)
@@ -63,31 +78,54 @@ func trackCommand(cmd *cobra.Command, args []string) {
 	}
 
 	changedAttribLines := make(map[string]string)
+	// newPatterns holds the escaped patterns of changedAttribLines in the
+	// order they were given, with the unescaped pattern of each.
+	var newPatterns []string
+	unescapedPatterns := make(map[string]string)
 	var readOnlyPatterns []string
 	var writeablePatterns []string
+	var tracked []*trackedPattern
 ArgsLoop:
 	for _, unsanitizedPattern := range args {
 		pattern := cleanRootPath(unsanitizedPattern)
-		if !trackNoModifyAttrsFlag {
-			for _, known := range knownPatterns {
-				if known.Path == filepath.Join(relpath, pattern) &&
-					((trackLockableFlag && known.Lockable) || // enabling lockable & already lockable (no change)
-						(trackNotLockableFlag && !known.Lockable) || // disabling lockable & not lockable (no change)
-						(!trackLockableFlag && !trackNotLockableFlag)) { // leave lockable as-is in all cases
-					Print("%q already supported", pattern)
-					continue ArgsLoop
-				}
+		encodedArg := escapeTrackPattern(pattern)
+		if trackFilenameFlag {
+			encodedArg = escapeTrackFilename(pattern)
+		}
+
+		status := "added"
+		for _, known := range knownPatterns {
+			if known.Path != filepath.Join(relpath, encodedArg) {
+				continue
 			}
+
+			if !trackNoModifyAttrsFlag &&
+				((trackLockableFlag && known.Lockable) || // enabling lockable & already lockable (no change)
+					(trackNotLockableFlag && !known.Lockable) || // disabling lockable & not lockable (no change)
+					(!trackLockableFlag && !trackNotLockableFlag)) { // leave lockable as-is in all cases
+				trackPrint("%q already supported", pattern)
+				tracked = append(tracked, &trackedPattern{
+					Pattern:  known.Path,
+					Source:   known.Source.Path,
+					Lockable: known.Lockable,
+					Status:   "unchanged",
+				})
+				continue ArgsLoop
+			}
+			status = "updated"
 		}
 
 		// Generate the new / changed attrib line for merging
-		encodedArg := escapeTrackPattern(pattern)
 		lockableArg := ""
 		if trackLockableFlag { // no need to test trackNotLockableFlag, if we got here we're disabling
 			lockableArg = " " + git.LockableAttrib
 		}
 
-		changedAttribLines[pattern] = fmt.Sprintf("%s filter=lfs diff=lfs merge=lfs -text%v%s", encodedArg, lockableArg, lineEnd)
+		if _, ok := changedAttribLines[encodedArg]; !ok {
+			newPatterns = append(newPatterns, encodedArg)
+		}
+		unescapedPatterns[encodedArg] = pattern
+		changedAttribLines[encodedArg] = fmt.Sprintf("%s filter=lfs diff=lfs merge=lfs -text%v%s", encodedArg, lockableArg, lineEnd)
 
 		if trackLockableFlag {
 			readOnlyPatterns = append(readOnlyPatterns, pattern)
@@ -95,50 +133,53 @@ ArgsLoop:
 			writeablePatterns = append(writeablePatterns, pattern)
 		}
 
-		Print("Tracking %q", unescapeTrackPattern(encodedArg))
+		if trackFilenameFlag {
+			trackPrint("Tracking %q", pattern)
+		} else {
+			trackPrint("Tracking %q", unescapeTrackPattern(encodedArg))
+		}
+		tracked = append(tracked, &trackedPattern{
+			Pattern:  filepath.Join(relpath, encodedArg),
+			Source:   filepath.Join(relpath, ".gitattributes"),
+			Lockable: trackLockableFlag,
+			Status:   status,
+		})
 	}
 
 	// Now read the whole local attributes file and iterate over the contents,
 	// replacing any lines where the values have changed, and appending new lines
 	// change this:
 
-	var (
-		attribContents []byte
-		attributesFile *os.File
-	)
+	var attributes bytes.Buffer
 	if !trackNoModifyAttrsFlag {
-		attribContents, err = ioutil.ReadFile(".gitattributes")
+		attribContents, err := ioutil.ReadFile(".gitattributes")
 		// it's fine for file to not exist
 		if err != nil && !os.IsNotExist(err) {
 			Print("Error reading .gitattributes file")
 			return
 		}
-		// Re-generate the file with merge of old contents and new (to deal with changes)
-		attributesFile, err = os.OpenFile(".gitattributes", os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0660)
-		if err != nil {
-			Print("Error opening .gitattributes file")
-			return
-		}
-		defer attributesFile.Close()
 
+		// Re-generate the file with merge of old contents and new (to deal with changes)
 		if len(attribContents) > 0 {
 			scanner := bufio.NewScanner(bytes.NewReader(attribContents))
 			for scanner.Scan() {
 				line := scanner.Text()
 				fields := strings.Fields(line)
 				if len(fields) < 1 {
+					// Keep blank lines, which may group patterns.
+					attributes.WriteString(lineEnd)
 					continue
 				}
 
 				pattern := fields[0]
 				if newline, ok := changedAttribLines[pattern]; ok {
 					// Replace this line (newline already embedded)
-					attributesFile.WriteString(newline)
+					attributes.WriteString(newline)
 					// Remove from map so we know we don't have to add it to the end
 					delete(changedAttribLines, pattern)
 				} else {
 					// Write line unchanged (replace newline)
-					attributesFile.WriteString(line + lineEnd)
+					attributes.WriteString(line + lineEnd)
 				}
 			}
 
@@ -146,13 +187,28 @@ ArgsLoop:
 		}
 	}
 
-	// Any items left in the map, write new lines at the end of the file
+	// Any items left in the map, write new lines at the end of the file, in
+	// the order they were given.
 	// Note this is only new patterns, not ones which changed locking flags
-	for pattern, newline := range changedAttribLines {
-		if !trackNoModifyAttrsFlag {
+	for _, encoded := range newPatterns {
+		if newline, ok := changedAttribLines[encoded]; ok {
 			// Newline already embedded
-			attributesFile.WriteString(newline)
+			attributes.WriteString(newline)
 		}
+	}
+
+	if !trackNoModifyAttrsFlag && !trackDryRunFlag {
+		if err := ioutil.WriteFile(".gitattributes", attributes.Bytes(), 0660); err != nil {
+			Print("Error writing .gitattributes file")
+			return
+		}
+	}
+
+	for _, encoded := range newPatterns {
+		if _, ok := changedAttribLines[encoded]; !ok || trackNoTouchFlag {
+			continue
+		}
+		pattern := unescapedPatterns[encoded]
 
 		// Also, for any new patterns we've added, make sure any existing git
 		// tracked files have their timestamp updated so they will now show as
@@ -165,7 +221,7 @@ ArgsLoop:
 		// the repository, the leading slash is simply removed for its
 		// implicit counterpart.
 		if trackVerboseLoggingFlag {
-			Print("Searching for files matching pattern: %s", pattern)
+			trackPrint("Searching for files matching pattern: %s", pattern)
 		}
 
 		gittracked, err := git.GetTrackedFiles(pattern)
@@ -174,13 +230,13 @@ ArgsLoop:
 		}
 
 		if trackVerboseLoggingFlag {
-			Print("Found %d files previously added to Git matching pattern: %s", len(gittracked), pattern)
+			trackPrint("Found %d files previously added to Git matching pattern: %s", len(gittracked), pattern)
 		}
 
 		var matchedBlocklist bool
 		for _, f := range gittracked {
 			if forbidden := blocklistItem(f); forbidden != "" {
-				Print("Pattern %s matches forbidden file %s. If you would like to track %s, modify .gitattributes manually.", pattern, f, f)
+				trackPrint("Pattern %s matches forbidden file %s. If you would like to track %s, modify .gitattributes manually.", pattern, f, f)
 				matchedBlocklist = true
 			}
 		}
@@ -190,7 +246,7 @@ ArgsLoop:
 
 		for _, f := range gittracked {
 			if trackVerboseLoggingFlag || trackDryRunFlag {
-				Print("Git LFS: touching %q", f)
+				trackPrint("Git LFS: touching %q", f)
 			}
 
 			if !trackDryRunFlag {
@@ -205,15 +261,58 @@ ArgsLoop:
 	}
 
 	// now flip read-only mode based on lockable / not lockable changes
-	lockClient := newLockClient()
-	err = lockClient.FixFileWriteFlagsInDir(relpath, readOnlyPatterns, writeablePatterns)
+	if !trackDryRunFlag {
+		lockClient := newLockClient()
+		err = lockClient.FixFileWriteFlagsInDir(relpath, readOnlyPatterns, writeablePatterns)
+		if err != nil {
+			LoggedError(err, "Error changing lockable file permissions: %s", err)
+		}
+	}
+
+	if trackJSONFlag {
+		printTrackedPatterns(tracked)
+	}
+}
+
+// trackPrint prints a message about what "git lfs track" does, to STDERR
+// instead of STDOUT if the output is JSON, so that it stays parseable.
+func trackPrint(format string, args ...interface{}) {
+	if trackJSONFlag {
+		Error(format, args...)
+	} else {
+		Print(format, args...)
+	}
+}
+
+func printTrackedPatterns(patterns []*trackedPattern) {
+	if patterns == nil {
+		patterns = []*trackedPattern{}
+	}
+
+	ret, err := json.Marshal(struct {
+		Patterns []*trackedPattern `json:"patterns"`
+	}{patterns})
 	if err != nil {
-		LoggedError(err, "Error changing lockable file permissions: %s", err)
+		ExitWithError(err)
 	}
+	Print(string(ret))
 }
 
 func listPatterns() {
-	knownPatterns := git.GetAttributePaths(cfg.LocalWorkingDir(), cfg.LocalGitDir())
+	knownPatterns := git.GetAttributePaths(cfg.LocalWorkingDir(), cfg.LocalGitStorageDir())
+	if trackJSONFlag {
+		patterns := make([]*trackedPattern, 0, len(knownPatterns))
+		for _, t := range knownPatterns {
+			patterns = append(patterns, &trackedPattern{
+				Pattern:  t.Path,
+				Source:   t.Source.Path,
+				Lockable: t.Lockable,
+			})
+		}
+		printTrackedPatterns(patterns)
+		return
+	}
+
 	if len(knownPatterns) < 1 {
 		return
 	}
@@ -268,6 +367,35 @@ func escapeTrackPattern(unescaped string) string {
 	return escaped
 }
 
+// escapeTrackFilename escapes the given filename like escapeTrackPattern, and
+// also escapes the characters which gitattributes patterns would otherwise
+// treat as wildcards, so that the pattern matches only that file. Backslashes
+// are only path separators on Windows; elsewhere they can be part of a
+// filename, and are escaped too.
+func escapeTrackFilename(filename string) string {
+	filename = filepath.ToSlash(filename)
+
+	var buf bytes.Buffer
+	for i, r := range filename {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			buf.WriteRune('\\')
+		case '!':
+			if i == 0 {
+				buf.WriteRune('\\')
+			}
+		}
+		buf.WriteRune(r)
+	}
+
+	escaped := buf.String()
+	for from, to := range trackEscapePatterns {
+		escaped = strings.Replace(escaped, from, to, -1)
+	}
+
+	return escaped
+}
+
 func unescapeTrackPattern(escaped string) string {
 	var unescaped string = escaped
 
@@ -285,5 +413,8 @@ func init() {
 		cmd.Flags().BoolVarP(&trackVerboseLoggingFlag, "verbose", "v", false, "log which files are being tracked and modified")
 		cmd.Flags().BoolVarP(&trackDryRunFlag, "dry-run", "d", false, "preview results of running `git lfs track`")
 		cmd.Flags().BoolVarP(&trackNoModifyAttrsFlag, "no-modify-attrs", "", false, "skip modifying .gitattributes file")
+		cmd.Flags().BoolVarP(&trackFilenameFlag, "filename", "", false, "treat the arguments as literal filenames, not patterns")
+		cmd.Flags().BoolVarP(&trackNoTouchFlag, "no-touch", "", false, "skip touching files matching the patterns")
+		cmd.Flags().BoolVarP(&trackJSONFlag, "json", "j", false, "give the output in a stable json format for scripts")
 	})
 }
@@ -9,6 +9,7 @@ import (
 var (
 	updateForce  = false
 	updateManual = false
+	updateChain  = false
 )
 
 // updateCommand is used for updating parts of Git LFS that reside under
@@ -41,12 +42,21 @@ func updateCommand(cmd *cobra.Command, args []string) {
 		Exit("You cannot use --force and --manual options together")
 	}
 
+	if updateChain && (updateForce || updateManual) {
+		Exit("You cannot use --chain with the --force or --manual options")
+	}
+
 	if updateManual {
 		Print(getHookInstallSteps())
+	} else if updateChain {
+		if err := chainHooks(); err != nil {
+			ExitWithError(err)
+		}
+		Print("Updated git hooks.")
 	} else {
 		if err := installHooks(updateForce); err != nil {
 			Error(err.Error())
-			Exit("To resolve this, either:\n  1: run `git lfs update --manual` for instructions on how to merge hooks.\n  2: run `git lfs update --force` to overwrite your hook.")
+			Exit("To resolve this, either:\n  1: run `git lfs update --manual` for instructions on how to merge hooks.\n  2: run `git lfs update --force` to overwrite your hook.\n  3: run `git lfs update --chain` to run Git LFS from the start of your hook.")
 		} else {
 			Print("Updated git hooks.")
 		}
@@ -58,5 +68,6 @@ func init() {
 	RegisterCommand("update", updateCommand, func(cmd *cobra.Command) {
 		cmd.Flags().BoolVarP(&updateForce, "force", "f", false, "Overwrite existing hooks.")
 		cmd.Flags().BoolVarP(&updateManual, "manual", "m", false, "Print instructions for manual install.")
+		cmd.Flags().BoolVarP(&updateChain, "chain", "", false, "Run Git LFS from the start of existing hooks instead of overwriting them.")
 	})
 }
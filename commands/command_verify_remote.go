@@ -0,0 +1,252 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tools/humanize"
+	"github.com/git-lfs/git-lfs/tq"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyRemoteAllArg bool
+)
+
+// verifyRemoteBatchSize is the number of objects checked in each batch
+// request, unless lfs.transfer.batchsize is set, as for transfers.
+const verifyRemoteBatchSize = 100
+
+// verifyRemoteProblem is an object referenced by the refs being verified which
+// the remote does not have, or has with another size.
+type verifyRemoteProblem struct {
+	Pointer *lfs.WrappedPointer
+	// Reason describes what is wrong with the object on the remote.
+	Reason string
+	// Missing is whether the remote does not have the object at all, as
+	// opposed to having it with another size, or not answering for it.
+	Missing bool
+}
+
+// verifyRemoteCommand checks that the remote has every object referenced in
+// the history of the given refs, or of all local refs with --all, with the
+// right size, without downloading any of them. It reports those which it
+// does not, with the commits and paths needing them, and exits non-zero if
+// there are any.
+func verifyRemoteCommand(cmd *cobra.Command, args []string) {
+	requireInRepo()
+
+	if len(args) > 0 {
+		// Remote is first arg
+		if err := cfg.SetValidRemote(args[0]); err != nil {
+			Exit("Invalid remote name %q: %s", args[0], err)
+		}
+	}
+
+	if verifyRemoteAllArg && len(args) > 1 {
+		Exit("Cannot combine --all with ref arguments")
+	}
+
+	refs, err := verifyRemoteRefs(args)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	endpoint := getAPIClient().Endpoints.Endpoint("download", cfg.Remote())
+	if len(endpoint.Url) == 0 {
+		Exit("No Git LFS server for remote %q", cfg.Remote())
+	}
+
+	pointers, err := verifyRemoteScan(refs)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	problems, err := verifyRemoteCheck(pointers)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	if len(problems) == 0 {
+		Print("All %d object(s) are present on %q", len(pointers), cfg.Remote())
+		return
+	}
+
+	needed, err := verifyRemoteNeededBy(refs)
+	if err != nil {
+		ExitWithError(err)
+	}
+
+	for _, problem := range problems {
+		p := problem.Pointer
+		Print("Object %s (%s) %s", p.Oid, humanize.FormatBytes(uint64(p.Size)), problem.Reason)
+
+		var found bool
+		for _, c := range needed[p.Oid] {
+			// Only pointers with the same size as the one checked
+			// have the same problem.
+			if c.Size == p.Size {
+				Print("  needed by %s in %s", c.Name, c.Commit)
+				found = true
+			}
+		}
+		if !found {
+			Print("  needed by %s", p.Name)
+		}
+	}
+
+	Print("%d of %d object(s) are missing or invalid on %q", len(problems), len(pointers), cfg.Remote())
+	os.Exit(1)
+}
+
+// verifyRemoteRefs returns the commitishes of the refs given after the remote,
+// of all local refs with --all, or of the current ref otherwise.
+func verifyRemoteRefs(args []string) ([]string, error) {
+	var refs []*git.Ref
+	if verifyRemoteAllArg {
+		localrefs, err := git.LocalRefs()
+		if err != nil {
+			return nil, err
+		}
+		refs = localrefs
+	} else if len(args) > 1 {
+		resolved, err := git.ResolveRefs(args[1:])
+		if err != nil {
+			return nil, err
+		}
+		refs = resolved
+	} else {
+		ref, err := git.CurrentRef()
+		if err != nil {
+			return nil, err
+		}
+		refs = []*git.Ref{ref}
+	}
+
+	commitishes := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		commitishes = append(commitishes, ref.Sha)
+	}
+	return commitishes, nil
+}
+
+// verifyRemoteScan returns a pointer to each object in the history of the
+// given refs, including those of files which were since modified or deleted.
+func verifyRemoteScan(refs []string) ([]*lfs.WrappedPointer, error) {
+	var pointers []*lfs.WrappedPointer
+	var multiErr error
+	gitscanner := lfs.NewGitScanner(func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			if multiErr != nil {
+				multiErr = fmt.Errorf("%v\n%v", multiErr, err)
+			} else {
+				multiErr = err
+			}
+			return
+		}
+
+		pointers = append(pointers, p)
+	})
+	defer gitscanner.Close()
+
+	if err := gitscanner.ScanRefs(refs, nil, nil); err != nil {
+		return nil, err
+	}
+	return pointers, multiErr
+}
+
+// verifyRemoteCheck makes batch download requests for the objects of the given
+// pointers, at most lfs.transfer.batchsize at a time, and returns those which
+// the remote does not have, or has with another size.
+func verifyRemoteCheck(pointers []*lfs.WrappedPointer) ([]*verifyRemoteProblem, error) {
+	manifest := getTransferManifestOperationRemote("download", cfg.Remote())
+	batchSize := manifest.BatchSize()
+	if batchSize < 1 {
+		batchSize = verifyRemoteBatchSize
+	}
+
+	var problems []*verifyRemoteProblem
+	for i := 0; i < len(pointers); i += batchSize {
+		end := i + batchSize
+		if end > len(pointers) {
+			end = len(pointers)
+		}
+		batch := pointers[i:end]
+
+		transfers := make([]*tq.Transfer, 0, len(batch))
+		for _, p := range batch {
+			transfers = append(transfers, &tq.Transfer{Oid: p.Oid, Size: p.Size})
+		}
+
+		bRes, err := tq.Batch(manifest, tq.Download, cfg.Remote(), transfers)
+		if err != nil {
+			return nil, err
+		}
+
+		found := make(map[string]*tq.Transfer, len(bRes.Objects))
+		for _, t := range bRes.Objects {
+			found[t.Oid] = t
+		}
+
+		for _, p := range batch {
+			if problem := verifyRemoteObject(p, found[p.Oid]); problem != nil {
+				problems = append(problems, problem)
+			}
+		}
+	}
+	return problems, nil
+}
+
+// verifyRemoteObject returns what is wrong with the object of the given
+// pointer on the remote, as given by the object "t" of the batch response, or
+// nil if nothing is.
+func verifyRemoteObject(p *lfs.WrappedPointer, t *tq.Transfer) *verifyRemoteProblem {
+	switch {
+	case t == nil, t.Error != nil && t.Error.Code == 404:
+		return &verifyRemoteProblem{
+			Pointer: p,
+			Reason:  fmt.Sprintf("is missing on %q", cfg.Remote()),
+			Missing: true,
+		}
+	case t.Error != nil:
+		return &verifyRemoteProblem{
+			Pointer: p,
+			Reason:  fmt.Sprintf("could not be verified on %q: %s", cfg.Remote(), t.Error.Message),
+		}
+	case t.Size != p.Size:
+		return &verifyRemoteProblem{
+			Pointer: p,
+			Reason:  fmt.Sprintf("has a size of %d byte(s) on %q, instead of %d", t.Size, cfg.Remote(), p.Size),
+		}
+	}
+	return nil
+}
+
+// verifyRemoteNeededBy returns the pointers added in the history of the given
+// refs, by OID, each with the commit adding it, oldest first.
+func verifyRemoteNeededBy(refs []string) (map[string][]*lfs.WrappedPointer, error) {
+	needed := make(map[string][]*lfs.WrappedPointer)
+	var scanErr error
+	gitscanner := lfs.NewGitScanner(func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			scanErr = err
+			return
+		}
+
+		needed[p.Oid] = append(needed[p.Oid], p)
+	})
+	defer gitscanner.Close()
+
+	if err := gitscanner.ScanLogRefs(refs, nil); err != nil {
+		return nil, err
+	}
+	return needed, scanErr
+}
+
+func init() {
+	RegisterCommand("verify-remote", verifyRemoteCommand, func(cmd *cobra.Command) {
+		cmd.Flags().BoolVarP(&verifyRemoteAllArg, "all", "a", false, "Verify the objects referenced by all local refs.")
+	})
+}
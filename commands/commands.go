@@ -3,6 +3,7 @@ package commands
 import (
 	"bytes"
 	"fmt"
+	"hash"
 	"io"
 	"log"
 	"net"
@@ -103,7 +104,7 @@ func newLockClient() *locking.Client {
 
 	// Configure dirs
 	lockClient.LocalWorkingDir = cfg.LocalWorkingDir()
-	lockClient.LocalGitDir = cfg.LocalGitDir()
+	lockClient.LocalGitDir = cfg.LocalGitStorageDir()
 	lockClient.SetLockableFilesReadOnly = cfg.SetLockableFilesReadOnly()
 
 	return lockClient
@@ -119,12 +120,12 @@ func newDownloadCheckQueue(manifest *tq.Manifest, remote string, options ...tq.O
 
 // newDownloadQueue builds a DownloadQueue, allowing concurrent downloads.
 func newDownloadQueue(manifest *tq.Manifest, remote string, options ...tq.Option) *tq.TransferQueue {
-	return tq.NewTransferQueue(tq.Download, manifest, remote, options...)
+	return tq.NewTransferQueue(tq.Download, manifest, remote, transferDeadlineOptions(options)...)
 }
 
 // newUploadQueue builds an UploadQueue, allowing `workers` concurrent uploads.
 func newUploadQueue(manifest *tq.Manifest, remote string, options ...tq.Option) *tq.TransferQueue {
-	return tq.NewTransferQueue(tq.Upload, manifest, remote, options...)
+	return tq.NewTransferQueue(tq.Upload, manifest, remote, transferDeadlineOptions(options)...)
 }
 
 func buildFilepathFilter(config *config.Configuration, includeArg, excludeArg *string) *filepathfilter.Filter {
@@ -132,6 +133,17 @@ func buildFilepathFilter(config *config.Configuration, includeArg, excludeArg *s
 	return filepathfilter.New(inc, exc)
 }
 
+// newContentHash returns a new Hash instance of the algorithm with which new
+// objects are named, as the clean filter would use, exiting if it is not
+// supported.
+func newContentHash() hash.Hash {
+	h, err := tools.NewContentHash(cfg.HashAlgorithm())
+	if err != nil {
+		ExitWithError(err)
+	}
+	return h
+}
+
 func downloadTransfer(p *lfs.WrappedPointer) (name, path, oid string, size int64) {
 	path, _ = cfg.Filesystem().ObjectPath(p.Oid)
 	return p.Name, path, p.Oid, p.Size
@@ -161,6 +173,26 @@ func installHooks(force bool) error {
 	return nil
 }
 
+// chainHooks installs all hooks, adding them to the start of any existing hooks
+// which Git LFS did not write instead of failing. None are written if any of
+// those isn't a shell script.
+func chainHooks() error {
+	hooks := lfs.LoadHooks(cfg.HookDir())
+	for _, h := range hooks {
+		if err := h.CanChain(); err != nil {
+			return err
+		}
+	}
+
+	for _, h := range hooks {
+		if err := h.Chain(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // uninstallHooks removes all hooks in range of the `hooks` var.
 func uninstallHooks() error {
 	if !cfg.InRepo() {
@@ -197,10 +229,11 @@ func Print(format string, args ...interface{}) {
 	fmt.Fprintf(OutputWriter, format+"\n", args...)
 }
 
-// Exit prints a formatted message and exits.
+// Exit prints a formatted message and exits, with the status of the class of
+// the errors reported before, if they had one.
 func Exit(format string, args ...interface{}) {
 	Error(format, args...)
-	os.Exit(2)
+	os.Exit(classifiedExitCode(2))
 }
 
 // ExitWithError either panics with a full stack trace for fatal errors, or
@@ -221,6 +254,7 @@ func errorWith(err error, fatalErrFn func(error, string, ...interface{}), errFn
 		return
 	}
 
+	recordErrorClass(err)
 	errFn("%s", err)
 }
 
@@ -240,6 +274,7 @@ func Debug(format string, args ...interface{}) {
 //
 // It also writes a stack trace for the error to a log file without exiting.
 func LoggedError(err error, format string, args ...interface{}) {
+	recordErrorClass(err)
 	if len(format) > 0 {
 		Error(format, args...)
 	}
@@ -254,7 +289,7 @@ func LoggedError(err error, format string, args ...interface{}) {
 // a log file before exiting.
 func Panic(err error, format string, args ...interface{}) {
 	LoggedError(err, format, args...)
-	os.Exit(2)
+	os.Exit(classifiedExitCode(2))
 }
 
 func Cleanup() {
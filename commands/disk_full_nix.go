@@ -0,0 +1,11 @@
+// +build !windows
+
+package commands
+
+import "syscall"
+
+// isDiskFullErrno returns whether the given error number means that there is
+// no space left on the disk.
+func isDiskFullErrno(errno syscall.Errno) bool {
+	return errno == syscall.ENOSPC
+}
@@ -0,0 +1,17 @@
+// +build windows
+
+package commands
+
+import "syscall"
+
+const (
+	errorHandleDiskFull = syscall.Errno(39)  // ERROR_HANDLE_DISK_FULL
+	errorDiskFull       = syscall.Errno(112) // ERROR_DISK_FULL
+)
+
+// isDiskFullErrno returns whether the given error number means that there is
+// no space left on the disk.
+func isDiskFullErrno(errno syscall.Errno) bool {
+	return errno == errorDiskFull || errno == errorHandleDiskFull ||
+		errno == syscall.ENOSPC
+}
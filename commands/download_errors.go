@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// skipDownloadErrorsArg is the value of the --skip-download-errors
+	// flag, which leaves the files whose objects could not be downloaded
+	// as pointers instead of failing.
+	skipDownloadErrorsArg bool
+)
+
+// addSkipDownloadErrorsFlag registers the --skip-download-errors flag on the
+// given command.
+func addSkipDownloadErrorsFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&skipDownloadErrorsArg, "skip-download-errors", "", false, "Leave files which could not be downloaded as pointers")
+}
+
+// skipDownloadErrors returns whether files whose objects could not be
+// downloaded are to be left as pointers and reported at the end, rather than
+// failing, either because --skip-download-errors was given, or
+// lfs.skipdownloaderrors is set.
+func skipDownloadErrors() bool {
+	return skipDownloadErrorsArg || cfg.SkipDownloadErrors()
+}
+
+// skippedDownloadError is returned by smudge when the object of a file could
+// not be downloaded, and its pointer was written instead, as download errors
+// are skipped.
+type skippedDownloadError struct {
+	error
+}
+
+// isSkippedDownloadError returns whether the given error is a
+// *skippedDownloadError.
+func isSkippedDownloadError(err error) bool {
+	_, ok := err.(*skippedDownloadError)
+	return ok
+}
+
+// reportSkippedDownloads prints a summary of the given files, which were left
+// as pointers since their objects could not be downloaded, if there are any.
+func reportSkippedDownloads(names []string) {
+	if len(names) == 0 {
+		return
+	}
+
+	sort.Strings(names)
+
+	Error("Encountered %d file(s) that could not be downloaded, and were left as pointers:", len(names))
+	for _, name := range names {
+		Error("\t%s", name)
+	}
+	Error("\nRun `git lfs pull` to try downloading them again.")
+}
@@ -0,0 +1,219 @@
+package commands
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/progress"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tq"
+	"github.com/rubyist/tracerx"
+)
+
+// downloadRemotes returns the remotes to download the objects fetched from the
+// given remote from, in the order in which to try them: those listed in
+// lfs.remotepriority, such as a nearby mirror, followed by the given remote, if
+// it is not among them.
+func downloadRemotes(remote string) []string {
+	v, _ := cfg.Git.Get("lfs.remotepriority")
+
+	known := tools.NewStringSet()
+	for _, r := range cfg.Remotes() {
+		known.Add(r)
+	}
+
+	seen := tools.NewStringSet()
+	remotes := make([]string, 0, 1)
+	for _, r := range strings.FieldsFunc(v, func(c rune) bool {
+		return c == ',' || c == ' '
+	}) {
+		if seen.Contains(r) {
+			continue
+		}
+		if !known.Contains(r) && r != remote {
+			Error("Ignoring unknown remote %q in lfs.remotepriority", r)
+			continue
+		}
+
+		seen.Add(r)
+		remotes = append(remotes, r)
+	}
+
+	if !seen.Contains(remote) {
+		remotes = append(remotes, remote)
+	}
+	return remotes
+}
+
+// fallbackDownloadQueue downloads objects from each of a list of remotes in
+// turn, falling back to the next remote for the objects which could not be
+// downloaded from the one before, so that objects can be fetched from a nearby
+// mirror, and only those it lacks from the remote they were pushed to.
+//
+// Like a *tq.TransferQueue, objects are added with Add, reported to channels
+// returned by Watch as they are downloaded, and Wait waits for them all. Only
+// the errors of the last remote tried are reported by Errors.
+type fallbackDownloadQueue struct {
+	remotes []string
+	meter   progress.Meter
+	options []tq.Option
+
+	// q is the queue downloading from remotes[0], which objects are
+	// added to as they are found.
+	q *tq.TransferQueue
+
+	mu        sync.Mutex
+	transfers map[string][]*downloadArgs
+	done      tools.StringSet
+	watchers  []chan *tq.Transfer
+
+	errors   []error
+	timedOut int
+}
+
+// downloadArgs are the arguments given to fallbackDownloadQueue.Add, to add
+// objects to the queue of the next remote with.
+type downloadArgs struct {
+	name, path, oid string
+	size            int64
+}
+
+// newFallbackDownloadQueue returns a queue downloading the objects fetched from
+// the given remote from the remotes given by downloadRemotes, reporting
+// progress to the given meter.
+func newFallbackDownloadQueue(remote string, meter progress.Meter, options ...tq.Option) *fallbackDownloadQueue {
+	q := &fallbackDownloadQueue{
+		remotes:   downloadRemotes(remote),
+		meter:     meter,
+		options:   options,
+		transfers: make(map[string][]*downloadArgs),
+		done:      tools.NewStringSet(),
+	}
+	q.q = q.newQueue(0)
+	return q
+}
+
+// newQueue returns the queue downloading from the i-th remote. Only that of the
+// last remote finishes the progress meter, and fails objects in it.
+func (q *fallbackDownloadQueue) newQueue(i int) *tq.TransferQueue {
+	var meter progress.Meter = q.meter
+	if i < len(q.remotes)-1 {
+		meter = &fallbackMeter{Meter: q.meter}
+	}
+
+	options := make([]tq.Option, 0, len(q.options)+1)
+	options = append(options, q.options...)
+	options = append(options, tq.WithProgress(meter))
+
+	remote := q.remotes[i]
+	return newDownloadQueue(
+		getTransferManifestOperationRemote("download", remote), remote,
+		options...,
+	)
+}
+
+// Add adds an object to be downloaded, as with *tq.TransferQueue.
+func (q *fallbackDownloadQueue) Add(name, path, oid string, size int64) {
+	q.mu.Lock()
+	q.transfers[oid] = append(q.transfers[oid], &downloadArgs{name, path, oid, size})
+	q.mu.Unlock()
+
+	q.q.Add(name, path, oid, size)
+}
+
+// Watch returns a channel to which each object is written once it has been
+// downloaded from any of the remotes, as with *tq.TransferQueue.
+func (q *fallbackDownloadQueue) Watch() chan *tq.Transfer {
+	c := make(chan *tq.Transfer, 100)
+	q.watchers = append(q.watchers, c)
+	return c
+}
+
+// Wait waits for the objects added to be downloaded, falling back to the next
+// remote for any which could not be, then closes the channels returned by
+// Watch.
+func (q *fallbackDownloadQueue) Wait() {
+	defer func() {
+		for _, c := range q.watchers {
+			close(c)
+		}
+	}()
+
+	current := q.q
+	for i := range q.remotes {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		dlwatch := current.Watch()
+		go func() {
+			for t := range dlwatch {
+				q.mu.Lock()
+				q.done.Add(t.Oid)
+				q.mu.Unlock()
+
+				for _, c := range q.watchers {
+					c <- t
+				}
+			}
+			wg.Done()
+		}()
+
+		current.Wait()
+		wg.Wait()
+
+		q.errors = current.Errors()
+		q.timedOut = current.TimedOut()
+
+		missing := q.missing()
+		// Don't start over on another remote once out of time.
+		if i == len(q.remotes)-1 || len(missing) == 0 || q.timedOut > 0 {
+			return
+		}
+
+		for _, err := range q.errors {
+			tracerx.Printf("fetch: %s: %s", q.remotes[i], err)
+		}
+		tracerx.Printf("fetch: %d object(s) not downloaded from %q, falling back to %q",
+			len(missing), q.remotes[i], q.remotes[i+1])
+
+		current = q.newQueue(i + 1)
+		for _, a := range missing {
+			current.Add(a.name, a.path, a.oid, a.size)
+		}
+	}
+}
+
+// missing returns the arguments of the objects which have not been downloaded
+// from any remote yet.
+func (q *fallbackDownloadQueue) missing() []*downloadArgs {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var missing []*downloadArgs
+	for oid, transfers := range q.transfers {
+		if !q.done.Contains(oid) {
+			missing = append(missing, transfers...)
+		}
+	}
+	return missing
+}
+
+// Errors returns the errors downloading from the last remote tried.
+func (q *fallbackDownloadQueue) Errors() []error {
+	return q.errors
+}
+
+// TimedOut returns the number of objects which were not downloaded from the
+// last remote tried because --timeout expired.
+func (q *fallbackDownloadQueue) TimedOut() int {
+	return q.timedOut
+}
+
+// fallbackMeter is the progress meter of a queue downloading from a remote
+// which is not the last to be tried: objects which fail to be downloaded from
+// it may still be from the next, and the meter goes on to show that.
+type fallbackMeter struct {
+	progress.Meter
+}
+
+func (m *fallbackMeter) Fail(size int64) {}
+func (m *fallbackMeter) Finish()         {}
@@ -0,0 +1,195 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"syscall"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/git-lfs/git-lfs/tq"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// jsonErrorsArg is the value of the --json-errors flag, which writes
+	// each error reported as a line of JSON to standard error, as well.
+	jsonErrorsArg bool
+
+	// reportedClasses holds the classes of the errors reported so far, by
+	// name.
+	reportedClasses = make(map[string]bool)
+	reportedMu      sync.Mutex
+)
+
+// errorClass is a kind of failure that commands exit with a distinct status
+// for, so that scripts can tell them apart.
+type errorClass struct {
+	Name     string
+	ExitCode int
+	Matches  func(err error) bool
+}
+
+// errorClasses are the classes of errors, in order of precedence when errors
+// of several classes were reported. Their exit codes are those of
+// sysexits(3).
+var errorClasses = []*errorClass{
+	{Name: "auth", ExitCode: 77, Matches: isAuthFailure},
+	{Name: "disk-full", ExitCode: 74, Matches: isDiskFull},
+	{Name: "network-timeout", ExitCode: 75, Matches: isNetworkTimeout},
+	{Name: "hash-mismatch", ExitCode: 65, Matches: isHashMismatch},
+	{Name: "object-missing", ExitCode: 66, Matches: isObjectMissing},
+}
+
+// addJSONErrorsFlag registers the --json-errors flag on the given command.
+func addJSONErrorsFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&jsonErrorsArg, "json-errors", "", false, "Also write each error as a line of JSON to standard error.")
+}
+
+// classifyError returns the class of the given error, or nil if it is of none
+// of them.
+func classifyError(err error) *errorClass {
+	for _, class := range errorClasses {
+		if class.Matches(err) {
+			return class
+		}
+	}
+	return nil
+}
+
+// recordErrorClass remembers the class of the given error, if it has one, for
+// the exit status, and writes it as JSON with --json-errors.
+func recordErrorClass(err error) {
+	if err == nil {
+		return
+	}
+
+	class := classifyError(err)
+	if class != nil {
+		reportedMu.Lock()
+		reportedClasses[class.Name] = true
+		reportedMu.Unlock()
+	}
+
+	if jsonErrorsArg {
+		writeJSONError(err, class)
+	}
+}
+
+// jsonError is an error as written by --json-errors.
+type jsonError struct {
+	Class    string `json:"class"`
+	ExitCode int    `json:"exit_code"`
+	Oid      string `json:"oid,omitempty"`
+	Message  string `json:"message"`
+}
+
+// writeJSONError writes the given error, of the given class, if any, as a line
+// of JSON to standard error.
+func writeJSONError(err error, class *errorClass) {
+	e := &jsonError{Class: "other", ExitCode: 2, Oid: errorOid(err), Message: err.Error()}
+	if class != nil {
+		e.Class, e.ExitCode = class.Name, class.ExitCode
+	}
+
+	json.NewEncoder(ErrorWriter).Encode(e)
+}
+
+// classifiedExitCode returns the exit code of the class of errors reported so
+// far which takes precedence, or the given one if none of them had a class.
+func classifiedExitCode(code int) int {
+	reportedMu.Lock()
+	defer reportedMu.Unlock()
+
+	for _, class := range errorClasses {
+		if reportedClasses[class.Name] {
+			return class.ExitCode
+		}
+	}
+	return code
+}
+
+// exitWithErrorClass exits with the exit code of the class of errors reported
+// so far which takes precedence, if any of them had a class.
+func exitWithErrorClass() {
+	if code := classifiedExitCode(0); code != 0 {
+		os.Exit(code)
+	}
+}
+
+// errorOid returns the OID of the object the given error is about, if it is
+// known.
+func errorOid(err error) string {
+	if oid, ok := errors.GetContext(err, "oid").(string); ok && len(oid) > 0 {
+		return oid
+	}
+
+	switch cause := errors.Cause(err).(type) {
+	case *tq.HashMismatchError:
+		return cause.Oid
+	case *tq.InvalidObjectError:
+		return cause.Oid
+	case *tq.MalformedObjectError:
+		return cause.Oid
+	}
+	return ""
+}
+
+// isAuthFailure returns whether the given error is from the server refusing
+// the credentials given, or access to the repository or an object.
+func isAuthFailure(err error) bool {
+	if errors.IsAuthError(err) {
+		return true
+	}
+
+	cause := errors.Cause(err)
+	if objErr, ok := cause.(*tq.ObjectError); ok {
+		return objErr.Code == 401 || objErr.Code == 403
+	}
+	if res, ok := lfsapi.IsHTTP(cause); ok && res != nil {
+		return res.StatusCode == 401 || res.StatusCode == 403
+	}
+	return false
+}
+
+// isObjectMissing returns whether the given error is from the server not
+// having an object.
+func isObjectMissing(err error) bool {
+	objErr, ok := errors.Cause(err).(*tq.ObjectError)
+	return ok && (objErr.Code == 404 || objErr.Code == 410)
+}
+
+// isNetworkTimeout returns whether the given error is from a connection or
+// request to the server timing out.
+func isNetworkTimeout(err error) bool {
+	t, ok := errors.Cause(err).(interface {
+		Timeout() bool
+	})
+	return ok && t.Timeout()
+}
+
+// isHashMismatch returns whether the given error is from the content of an
+// object not matching its OID.
+func isHashMismatch(err error) bool {
+	_, ok := errors.Cause(err).(*tq.HashMismatchError)
+	return ok
+}
+
+// isDiskFull returns whether the given error is from a write failing for lack
+// of space on the disk.
+func isDiskFull(err error) bool {
+	switch cause := errors.Cause(err).(type) {
+	case *os.PathError:
+		err = cause.Err
+	case *os.LinkError:
+		err = cause.Err
+	case *os.SyscallError:
+		err = cause.Err
+	default:
+		err = cause
+	}
+
+	errno, ok := err.(syscall.Errno)
+	return ok && isDiskFullErrno(errno)
+}
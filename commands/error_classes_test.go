@@ -0,0 +1,55 @@
+package commands
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/tq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	for class, err := range map[string]error{
+		"auth":            errors.NewAuthError(errors.New("unauthorized")),
+		"disk-full":       errors.Wrap(&os.PathError{Op: "write", Path: "a.dat", Err: syscall.ENOSPC}, "cannot write data"),
+		"network-timeout": errors.NewRetriableError(&url.Error{Op: "Get", URL: "https://example.com", Err: context.DeadlineExceeded}),
+		"hash-mismatch":   errors.NewRetriableError(&tq.HashMismatchError{Oid: "a", Actual: "b"}),
+		"object-missing":  errors.Wrapf(&tq.ObjectError{Code: 404, Message: "not found"}, "[a] not found"),
+	} {
+		if c := classifyError(err); assert.NotNil(t, c, class) {
+			assert.Equal(t, class, c.Name)
+		}
+	}
+
+	assert.Equal(t, "auth", classifyError(errors.Wrap(&tq.ObjectError{Code: 403}, "forbidden")).Name)
+	assert.Nil(t, classifyError(errors.New("some other error")))
+	assert.Nil(t, classifyError(errors.Wrap(&tq.ObjectError{Code: 422}, "invalid")))
+}
+
+func TestErrorOid(t *testing.T) {
+	err := errors.Wrapf(&tq.ObjectError{Code: 404}, "[a] not found")
+	errors.SetContext(err, "oid", "a")
+
+	assert.Equal(t, "a", errorOid(err))
+	assert.Equal(t, "b", errorOid(errors.NewRetriableError(&tq.HashMismatchError{Oid: "b"})))
+	assert.Equal(t, "", errorOid(errors.New("some other error")))
+}
+
+func TestClassifiedExitCode(t *testing.T) {
+	defer func() { reportedClasses = make(map[string]bool) }()
+
+	assert.Equal(t, 2, classifiedExitCode(2))
+
+	recordErrorClass(errors.New("some other error"))
+	assert.Equal(t, 2, classifiedExitCode(2))
+
+	recordErrorClass(errors.Wrap(&tq.ObjectError{Code: 404}, "not found"))
+	assert.Equal(t, 66, classifiedExitCode(2))
+
+	recordErrorClass(errors.NewAuthError(errors.New("unauthorized")))
+	assert.Equal(t, 77, classifiedExitCode(2))
+}
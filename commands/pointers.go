@@ -1,6 +1,10 @@
 package commands
 
-import "github.com/git-lfs/git-lfs/lfs"
+import (
+	"path/filepath"
+
+	"github.com/git-lfs/git-lfs/lfs"
+)
 
 func collectPointers(pointerCh *lfs.PointerChannelWrapper) ([]*lfs.WrappedPointer, error) {
 	var pointers []*lfs.WrappedPointer
@@ -9,3 +13,15 @@ func collectPointers(pointerCh *lfs.PointerChannelWrapper) ([]*lfs.WrappedPointe
 	}
 	return pointers, pointerCh.Wait()
 }
+
+// isPointerFile returns whether the working tree file with the given name,
+// relative to the root of the repository, holds pointer text rather than its
+// contents, as it does when its object was not downloaded.
+func isPointerFile(name string) bool {
+	if len(cfg.LocalWorkingDir()) == 0 {
+		return false
+	}
+
+	_, err := lfs.DecodePointerFromFile(filepath.Join(cfg.LocalWorkingDir(), name))
+	return err == nil
+}
@@ -12,7 +12,9 @@ import (
 	"github.com/git-lfs/git-lfs/git"
 	"github.com/git-lfs/git-lfs/lfs"
 	"github.com/git-lfs/git-lfs/subprocess"
+	"github.com/git-lfs/git-lfs/tools"
 	"github.com/git-lfs/git-lfs/tq"
+	"github.com/rubyist/tracerx"
 )
 
 // Handles the process of checking out a single file, and updating the git
@@ -36,6 +38,7 @@ func newSingleCheckout(gitEnv config.Environment, remote string) abstractCheckou
 		gitIndexer:    &gitIndexer{},
 		pathConverter: pathConverter,
 		manifest:      manifest,
+		dedup:         gitEnv.Bool("lfs.dedup", false),
 	}
 }
 
@@ -43,6 +46,7 @@ type abstractCheckout interface {
 	Manifest() *tq.Manifest
 	Skip() bool
 	Run(*lfs.WrappedPointer)
+	NotLocal() []string
 	Close()
 }
 
@@ -50,6 +54,14 @@ type singleCheckout struct {
 	gitIndexer    *gitIndexer
 	pathConverter lfs.PathConverter
 	manifest      *tq.Manifest
+	// dedup is whether to check out objects as copy-on-write clones of
+	// their copies in the LFS storage directory, where supported.
+	dedup bool
+
+	// notLocal are the files left as pointers since their objects are not
+	// present locally, when download errors are skipped.
+	notLocal []string
+	mu       sync.Mutex
 }
 
 func (c *singleCheckout) Manifest() *tq.Manifest {
@@ -63,6 +75,13 @@ func (c *singleCheckout) Skip() bool {
 func (c *singleCheckout) Run(p *lfs.WrappedPointer) {
 	cwdfilepath := c.pathConverter.Convert(p.Name)
 
+	// Don't follow symbolic links in the working tree, which would read
+	// and overwrite whatever they point to rather than the file itself.
+	if link := tools.SymlinkInPath(cfg.LocalWorkingDir(), p.Name); len(link) > 0 {
+		Error("Skipped checkout for %q, %q is a symbolic link", p.Name, link)
+		return
+	}
+
 	// Check the content - either missing or still this pointer (not exist is ok)
 	filepointer, err := lfs.DecodePointerFromFile(cwdfilepath)
 	if err != nil && !os.IsNotExist(err) {
@@ -81,10 +100,22 @@ func (c *singleCheckout) Run(p *lfs.WrappedPointer) {
 		return
 	}
 
+	if c.dedup && filepointer != nil && c.clone(cwdfilepath, p) {
+		if err := c.gitIndexer.Add(cwdfilepath); err != nil {
+			Panic(err, "Could not update the index")
+		}
+		return
+	}
+
 	gitfilter := lfs.NewGitFilter(cfg)
 	err = gitfilter.SmudgeToFile(cwdfilepath, p.Pointer, false, c.manifest, nil)
 	if err != nil {
-		if errors.IsDownloadDeclinedError(err) {
+		if errors.IsDownloadDeclinedError(err) && skipDownloadErrors() {
+			// Reported with the other files left as pointers.
+			c.mu.Lock()
+			c.notLocal = append(c.notLocal, p.Name)
+			c.mu.Unlock()
+		} else if errors.IsDownloadDeclinedError(err) {
 			// acceptable error, data not local (fetch not run or include/exclude)
 			LoggedError(err, "Skipped checkout for %q, content not local. Use fetch to download.", p.Name)
 		} else {
@@ -99,6 +130,30 @@ func (c *singleCheckout) Run(p *lfs.WrappedPointer) {
 	}
 }
 
+// clone replaces the pointer file at the given path with a clone of the
+// object it points to, returning whether it did. Objects which are not
+// present locally, or which pass through smudge extensions, are not cloned.
+func (c *singleCheckout) clone(cwdfilepath string, p *lfs.WrappedPointer) bool {
+	if len(p.Extensions) > 0 || !cfg.LFSObjectExists(p.Oid, p.Size) {
+		return false
+	}
+
+	ok, err := tools.CloneFileByPath(cwdfilepath, cfg.Filesystem().ObjectPathname(p.Oid))
+	if err != nil {
+		tracerx.Printf("checkout: could not clone %s: %v", p.Name, err)
+	}
+	return ok && err == nil
+}
+
+// NotLocal returns the files which were left as pointers since their objects
+// are not present locally, if download errors are skipped. Otherwise, each is
+// reported as it is checked out.
+func (c *singleCheckout) NotLocal() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.notLocal
+}
+
 func (c *singleCheckout) Close() {
 	if err := c.gitIndexer.Close(); err != nil {
 		LoggedError(err, "Error updating the git index:\n%s", c.gitIndexer.Output())
@@ -118,6 +173,7 @@ func (c *noOpCheckout) Skip() bool {
 }
 
 func (c *noOpCheckout) Run(p *lfs.WrappedPointer) {}
+func (c *noOpCheckout) NotLocal() []string        { return nil }
 func (c *noOpCheckout) Close()                    {}
 
 // Don't fire up the update-index command until we have at least one file to
@@ -0,0 +1,164 @@
+package commands
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/rubyist/tracerx"
+)
+
+// pushCacheSize is the number of commits a pushCache remembers for each
+// endpoint.
+const pushCacheSize = 50
+
+var commitShaRE = regexp.MustCompile(`\A[0-9a-f]{40}\z`)
+
+// pushCache remembers the commits whose Git LFS objects have all been pushed to
+// an endpoint, so that later pushes there can skip scanning their history, even
+// if there are no remote-tracking refs to tell where the remote is at. It is
+// enabled with the "lfs.pushcache" configuration option, and kept in
+// ".git/lfs/cache/pushed", in a file per endpoint.
+type pushCache struct {
+	path string
+}
+
+// newPushCache returns the push cache for the given remote, or nil if it is
+// disabled.
+func newPushCache(remote string) *pushCache {
+	if !cfg.Git.Bool("lfs.pushcache", false) {
+		return nil
+	}
+
+	ep := getAPIClient().Endpoints.Endpoint("upload", remote)
+	if len(ep.Url) == 0 {
+		return nil
+	}
+
+	key := ep.Url
+	if u, err := url.Parse(key); err == nil {
+		u.User = nil
+		key = u.String()
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return &pushCache{
+		path: filepath.Join(cfg.LFSStorageDir(), "cache", "pushed", hex.EncodeToString(sum[:])),
+	}
+}
+
+// Excludes returns the cached commits that are in the local repository, which
+// need not be scanned again.
+func (c *pushCache) Excludes() []string {
+	if c == nil {
+		return nil
+	}
+
+	shas := c.load()
+	if len(shas) == 0 {
+		return nil
+	}
+
+	present, err := presentCommits(shas)
+	if err != nil {
+		tracerx.Printf("push cache: unable to look up cached commits: %s", err)
+		return nil
+	}
+
+	tracerx.Printf("push cache: skipping history of %d commit(s)", len(present))
+	return present
+}
+
+// Record remembers that the Git LFS objects of the given commits, and of
+// their history, have been pushed. Only the most recent pushCacheSize commits
+// are kept.
+func (c *pushCache) Record(commitishes []string) {
+	if c == nil {
+		return
+	}
+
+	shas := make([]string, 0, len(commitishes))
+	for _, commitish := range commitishes {
+		if !commitShaRE.MatchString(commitish) {
+			ref, err := git.ResolveRef(commitish)
+			if err != nil {
+				continue
+			}
+			commitish = ref.Sha
+		}
+		shas = append(shas, commitish)
+	}
+
+	seen := make(map[string]bool)
+	all := make([]string, 0, len(shas)+pushCacheSize)
+	for _, sha := range append(shas, c.load()...) {
+		if seen[sha] || len(all) >= pushCacheSize {
+			continue
+		}
+		seen[sha] = true
+		all = append(all, sha)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		tracerx.Printf("push cache: unable to record pushed commits: %s", err)
+		return
+	}
+	if err := ioutil.WriteFile(c.path, []byte(strings.Join(all, "\n")+"\n"), 0644); err != nil {
+		tracerx.Printf("push cache: unable to record pushed commits: %s", err)
+	}
+}
+
+func (c *pushCache) load() []string {
+	f, err := os.Open(c.path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var shas []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if sha := strings.TrimSpace(scanner.Text()); commitShaRE.MatchString(sha) {
+			shas = append(shas, sha)
+		}
+	}
+	return shas
+}
+
+// presentCommits returns those of the given commits that are in the local
+// repository. Git refuses to exclude commits it doesn't have from a scan, and
+// commits that were pushed may since have been garbage collected.
+func presentCommits(shas []string) ([]string, error) {
+	cmd, err := git.CatFile()
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for _, sha := range shas {
+			cmd.Stdin.Write([]byte(sha + "\n"))
+		}
+		cmd.Stdin.Close()
+	}()
+
+	present := make([]string, 0, len(shas))
+	scanner := bufio.NewScanner(cmd.Stdout)
+	for scanner.Scan() {
+		// <sha1> <type> <size>, or <sha1> missing
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 3 && fields[1] == "commit" {
+			present = append(present, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return present, cmd.Wait()
+}
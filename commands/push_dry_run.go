@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tools/humanize"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// pushJSON is the value of the --json flag, which gives the report of
+	// a push --dry-run as JSON.
+	pushJSON bool
+)
+
+// addPushDryRunFlags registers the --json flag on the given command.
+func addPushDryRunFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&pushJSON, "json", "j", false, "Give the output of --dry-run in a stable json format for scripts.")
+}
+
+// pushDryRunEntry is an object which would be uploaded by a push, as reported
+// by --dry-run.
+type pushDryRunEntry struct {
+	Oid    string `json:"oid"`
+	Size   int64  `json:"size"`
+	Name   string `json:"name"`
+	Commit string `json:"commit,omitempty"`
+}
+
+// pushDryRunCommits returns the commit which added each of the objects to be
+// pushed for the given ref updates, by OID, as uploadForRefUpdates would find
+// them. Objects which were only added in merges have none.
+func pushDryRunCommits(ctx *uploadContext, updates []*refUpdate, pushAll bool) (map[string]string, error) {
+	commits := make(map[string]string)
+	var scanErr error
+	gitscanner := lfs.NewGitScanner(func(p *lfs.WrappedPointer, err error) {
+		if err != nil {
+			scanErr = err
+			return
+		}
+
+		// Pointers are found oldest first.
+		if _, ok := commits[p.Oid]; !ok {
+			commits[p.Oid] = p.Commit
+		}
+	})
+	defer gitscanner.Close()
+
+	if err := gitscanner.RemoteForPush(ctx.Remote); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if pushAll {
+		err = gitscanner.ScanLogRefs(leftCommitishes(updates), nil)
+	} else {
+		err = gitscanner.ScanLogLeftToRemote(leftCommitishes(updates), ctx.pushCache.Excludes(), nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return commits, scanErr
+}
+
+// reportPushDryRun prints the objects which the push would have uploaded, with
+// the commit which added each, if any is given, their total size, and the
+// duplicates it would have skipped, or gives them as JSON with --json.
+func reportPushDryRun(ctx *uploadContext, commits map[string]string) {
+	entries := make([]*pushDryRunEntry, 0, len(ctx.dryRunPointers))
+	var total int64
+	for _, p := range ctx.dryRunPointers {
+		entries = append(entries, &pushDryRunEntry{
+			Oid:    p.Oid,
+			Size:   p.Size,
+			Name:   p.Name,
+			Commit: commits[p.Oid],
+		})
+		total += p.Size
+	}
+
+	if pushJSON {
+		if err := json.NewEncoder(os.Stdout).Encode(struct {
+			Remote    string             `json:"remote"`
+			Objects   []*pushDryRunEntry `json:"objects"`
+			TotalSize int64              `json:"total_size"`
+		}{ctx.Remote, entries, total}); err != nil {
+			ExitWithError(err)
+		}
+		return
+	}
+
+	for _, e := range entries {
+		size := humanize.FormatBytes(uint64(e.Size))
+		if len(e.Commit) > 0 {
+			Print("push %s => %s (%s, commit %s)", e.Oid, e.Name, size, e.Commit)
+		} else {
+			Print("push %s => %s (%s)", e.Oid, e.Name, size)
+		}
+	}
+	Print("Total: %d objects, %s", len(entries), humanize.FormatBytes(uint64(total)))
+	ctx.reportDuplicates()
+}
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/git"
+	"github.com/git-lfs/git-lfs/lfs"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tools/humanize"
+	"github.com/spf13/cobra"
+)
+
+// pushReviewLargest is the number of largest files listed when asking to
+// confirm a push.
+const pushReviewLargest = 5
+
+var (
+	// pushAssumeYes is the value of the --yes flag, which confirms pushes
+	// that would otherwise be reviewed.
+	pushAssumeYes bool
+)
+
+// addPushReviewFlag registers the --yes flag on the given command.
+func addPushReviewFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&pushAssumeYes, "yes", "y", false, "Don't ask to confirm pushes larger than lfs.pushreviewthreshold")
+}
+
+// pushReviewThreshold returns the number of bytes above which a push has to be
+// confirmed, according to lfs.pushreviewthreshold, or 0 if pushes are never
+// reviewed.
+func pushReviewThreshold() uint64 {
+	v, ok := cfg.Git.Get("lfs.pushreviewthreshold")
+	if !ok || len(v) == 0 {
+		return 0
+	}
+
+	threshold, err := humanize.ParseBytes(v)
+	if err != nil {
+		Exit("Invalid lfs.pushreviewthreshold %q: %s", v, err)
+	}
+	return threshold
+}
+
+// pushReviewAllowsNonInteractive returns whether a push which would be
+// reviewed goes ahead when there is no terminal to ask on, according to
+// lfs.pushreviewnoninteractive.
+func pushReviewAllowsNonInteractive() bool {
+	switch v, _ := cfg.Git.Get("lfs.pushreviewnoninteractive"); v {
+	case "", "refuse":
+		return false
+	case "allow":
+		return true
+	default:
+		Exit("Invalid lfs.pushreviewnoninteractive %q: expected \"allow\" or \"refuse\"", v)
+	}
+	return false
+}
+
+// shouldReviewPush returns whether the objects to be pushed for the given ref
+// updates have to be reviewed before they are uploaded: that is, when
+// lfs.pushreviewthreshold is set, and at least one of the updates creates its
+// ref on the remote.
+func shouldReviewPush(ctx *uploadContext, updates []*refUpdate) bool {
+	if ctx.DryRun || pushAssumeYes || pushReviewThreshold() == 0 {
+		return false
+	}
+
+	for _, update := range updates {
+		if isFirstPush(update) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFirstPush returns whether the given update creates its ref on the remote.
+// The pre-push hook is given the remote's commit, which is all zeros for a new
+// ref. Otherwise, the remote-tracking ref is looked for instead.
+func isFirstPush(update *refUpdate) bool {
+	if update.rightKnown {
+		return update.Right().Sha == prePushDeleteBranch
+	}
+
+	_, err := git.ResolveRef(fmt.Sprintf("refs/remotes/%s/%s", update.remote, update.Right().Name))
+	return err != nil
+}
+
+// reviewPush asks the user to confirm uploading the objects of the given
+// pointers, held back while scanning a push, when together they are larger
+// than lfs.pushreviewthreshold, and exits if the push is not confirmed.
+func reviewPush(ctx *uploadContext, pointers []*lfs.WrappedPointer) {
+	threshold := pushReviewThreshold()
+
+	seen := make(map[string]struct{}, len(pointers))
+	uniq := make([]*lfs.WrappedPointer, 0, len(pointers))
+	var total uint64
+	for _, p := range pointers {
+		if _, ok := seen[p.Oid]; ok {
+			continue
+		}
+		seen[p.Oid] = struct{}{}
+
+		uniq = append(uniq, p)
+		total += uint64(p.Size)
+	}
+
+	if total <= threshold {
+		return
+	}
+
+	sort.SliceStable(uniq, func(i, j int) bool { return uniq[i].Size > uniq[j].Size })
+
+	Print("Git LFS is about to upload %s in %d files to %s, more than lfs.pushreviewthreshold (%s).",
+		humanize.FormatBytes(total), len(uniq), ctx.Remote, humanize.FormatBytes(threshold))
+	Print("Largest files:")
+	for i, p := range uniq {
+		if i == pushReviewLargest {
+			break
+		}
+		Print("  %9s  %s", humanize.FormatBytes(uint64(p.Size)), p.Name)
+	}
+
+	// The question is only asked if it can be seen.
+	var tty *os.File
+	var err error
+	if stderrIsTerminal() {
+		tty, err = tools.OpenTTY()
+	}
+	if tty == nil || err != nil {
+		if pushReviewAllowsNonInteractive() {
+			Print("Pushing anyway, as lfs.pushreviewnoninteractive is \"allow\".")
+			return
+		}
+		Exit("Push not confirmed. Run `git lfs push --yes %s`, or set lfs.pushreviewnoninteractive to \"allow\", to push anyway.", ctx.Remote)
+	}
+	defer tty.Close()
+
+	fmt.Fprint(os.Stderr, "Continue? [y/N] ")
+	answer, _ := bufio.NewReader(tty).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return
+	}
+
+	Exit("Push cancelled.")
+}
+
+// stderrIsTerminal returns whether standard error, where the question
+// confirming a push is asked, is a terminal.
+func stderrIsTerminal() bool {
+	stat, err := os.Stderr.Stat()
+	if err != nil {
+		return false
+	}
+	return (stat.Mode() & os.ModeCharDevice) != 0
+}
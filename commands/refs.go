@@ -13,14 +13,20 @@ type refUpdate struct {
 	remote string
 	left   *git.Ref
 	right  *git.Ref
+
+	// rightKnown is whether the remote ref was given, with the commit the
+	// remote has for it, as it is to the pre-push hook, rather than
+	// guessed from the config by Right().
+	rightKnown bool
 }
 
 func newRefUpdate(g config.Environment, remote string, l, r *git.Ref) *refUpdate {
 	return &refUpdate{
-		git:    g,
-		remote: remote,
-		left:   l,
-		right:  r,
+		git:        g,
+		remote:     remote,
+		left:       l,
+		right:      r,
+		rightKnown: r != nil,
 	}
 }
 
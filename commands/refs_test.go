@@ -73,3 +73,11 @@ func TestRefUpdateExplicitLeftAndRight(t *testing.T) {
 	assert.Equal(t, "", u.Right().Sha)
 	assert.Equal(t, "right", u.RightCommitish())
 }
+
+func TestIsFirstPushFromPrePush(t *testing.T) {
+	u := newRefUpdate(nil, "origin", git.ParseRef("refs/heads/left", "abc123"), git.ParseRef("refs/heads/left", prePushDeleteBranch))
+	assert.True(t, isFirstPush(u))
+
+	u = newRefUpdate(nil, "origin", git.ParseRef("refs/heads/left", "abc123"), git.ParseRef("refs/heads/left", "def456"))
+	assert.False(t, isFirstPush(u))
+}
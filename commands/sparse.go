@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"github.com/git-lfs/git-lfs/filepathfilter"
+	"github.com/spf13/cobra"
+)
+
+// noSparseArg is the value of the --no-sparse flag.
+var noSparseArg bool
+
+// addNoSparseFlag registers the --no-sparse flag on the given command.
+func addNoSparseFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&noSparseArg, "no-sparse", "", false, "Do not limit the files to those of a sparse checkout")
+}
+
+// sparseFilepathFilter limits the given filter to the files which are checked
+// out in a sparse checkout, unless --no-sparse was given, since the objects of
+// the others would not be used.
+func sparseFilepathFilter(filter *filepathfilter.Filter) *filepathfilter.Filter {
+	if noSparseArg {
+		return filter
+	}
+
+	patterns := cfg.SparseCheckoutPatterns()
+	if patterns == nil {
+		return filter
+	}
+	return filter.WithSparseCheckout(patterns)
+}
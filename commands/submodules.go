@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"os"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/subprocess"
+	"github.com/spf13/cobra"
+)
+
+// recurseSubmodulesArg is the value of the --recurse-submodules flag.
+var recurseSubmodulesArg bool
+
+// addRecurseSubmodulesFlag registers the --recurse-submodules flag on the given
+// command.
+func addRecurseSubmodulesFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVarP(&recurseSubmodulesArg, "recurse-submodules", "", false, "Also run in each initialized submodule")
+}
+
+// recurseSubmodules runs "git lfs" with the given arguments in each initialized
+// submodule, and in theirs, if --recurse-submodules was given or
+// lfs.recursesubmodules is set. It exits if the command fails in any of them.
+//
+// The submodules are visited by "git submodule foreach --recursive", so the
+// command is run with lfs.recursesubmodules unset, in order not to visit
+// nested submodules twice. The --no-sparse, --skip-download-errors and
+// --json-errors flags are passed on, as they apply to any repository.
+func recurseSubmodules(args ...string) {
+	if !recurseSubmodulesArg && !cfg.Git.Bool("lfs.recursesubmodules", false) {
+		return
+	}
+
+	if noSparseArg {
+		args = append(args, "--no-sparse")
+	}
+	if skipDownloadErrorsArg {
+		args = append(args, "--skip-download-errors")
+	}
+	if jsonErrorsArg {
+		args = append(args, "--json-errors")
+	}
+
+	command := "git -c lfs.recursesubmodules=false lfs " + strings.Join(args, " ")
+
+	cmd := subprocess.ExecCommand("git", "submodule", "foreach", "--recursive", command)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		Exit("Error running 'git lfs %s' in submodules: %v", args[0], err)
+	}
+}
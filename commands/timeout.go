@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/git-lfs/git-lfs/tq"
+	"github.com/spf13/cobra"
+)
+
+// timeoutExitCode is the status that fetch, pull and push exit with when they
+// stopped early because their --timeout expired, matching timeout(1).
+const timeoutExitCode = 124
+
+var (
+	// transferTimeout is the value of the --timeout flag.
+	transferTimeout time.Duration
+	// transferDeadline is the time after which no new transfers are
+	// started, and those in progress are cancelled, or the zero time if
+	// --timeout was not given.
+	transferDeadline time.Time
+	// transfersTimedOut counts the objects that were not transferred
+	// because transferDeadline had passed.
+	transfersTimedOut int32
+)
+
+// addTimeoutFlag registers the --timeout flag on the given command.
+func addTimeoutFlag(cmd *cobra.Command) {
+	cmd.Flags().DurationVar(&transferTimeout, "timeout", 0, "Stop transferring objects after the given duration (e.g. 10m)")
+}
+
+// startTransferTimeout starts the clock for --timeout, if it was given.
+func startTransferTimeout() {
+	if transferTimeout > 0 {
+		transferDeadline = time.Now().Add(transferTimeout)
+	}
+}
+
+// transferDeadlineOptions returns the tq.Options needed to make a transfer
+// queue respect --timeout.
+func transferDeadlineOptions(options []tq.Option) []tq.Option {
+	if transferDeadline.IsZero() {
+		return options
+	}
+	return append(options, tq.WithDeadline(transferDeadline))
+}
+
+// recordTimedOut records the objects that the given (finished) queue, such as a
+// *tq.TransferQueue, did not transfer because of --timeout.
+func recordTimedOut(q interface{ TimedOut() int }) {
+	atomic.AddInt32(&transfersTimedOut, int32(q.TimedOut()))
+}
+
+// exitIfTimedOut reports how many objects were left untransferred because
+// --timeout expired, and exits with timeoutExitCode if there were any.
+func exitIfTimedOut() {
+	n := atomic.LoadInt32(&transfersTimedOut)
+	if n == 0 {
+		return
+	}
+
+	Error("Stopped after --timeout of %s: %d object(s) were not transferred.", transferTimeout, n)
+	Error("Run the command again to continue.")
+	os.Exit(timeoutExitCode)
+}
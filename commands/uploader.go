@@ -15,6 +15,7 @@ import (
 	"github.com/git-lfs/git-lfs/progress"
 	"github.com/git-lfs/git-lfs/tasklog"
 	"github.com/git-lfs/git-lfs/tools"
+	"github.com/git-lfs/git-lfs/tools/humanize"
 	"github.com/git-lfs/git-lfs/tq"
 	"github.com/rubyist/tracerx"
 )
@@ -27,29 +28,67 @@ func uploadForRefUpdates(ctx *uploadContext, updates []*refUpdate, pushAll bool)
 	defer gitscanner.Close()
 
 	verifyLocksForUpdates(ctx.lockVerifier, updates)
-	for _, update := range updates {
-		if err := uploadLeftOrAll(gitscanner, ctx, update, pushAll); err != nil {
-			return errors.Wrap(err, fmt.Sprintf("ref %s:", update.Left().Name))
+
+	// The objects of a push to be reviewed are held back while scanning,
+	// and only uploaded once it is confirmed.
+	ctx.reviewing = shouldReviewPush(ctx, updates)
+	if pushAll {
+		for _, update := range updates {
+			if err := uploadAll(gitscanner, ctx, update); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("ref %s:", update.Left().Name))
+			}
+		}
+	} else if len(updates) > 0 {
+		if err := uploadLeftsToRemote(gitscanner, ctx, updates); err != nil {
+			return err
 		}
 	}
 
-	ctx.Await()
+	if ctx.reviewing {
+		ctx.reviewing = false
+		reviewPush(ctx, ctx.reviewPointers)
+		uploadPointers(ctx, ctx.reviewPointers...)
+		ctx.reviewPointers = nil
+	}
+
+	if ctx.Await() && !pushAll && !ctx.DryRun {
+		ctx.pushCache.Record(leftCommitishes(updates))
+	}
 	return nil
 }
 
-func uploadLeftOrAll(g *lfs.GitScanner, ctx *uploadContext, update *refUpdate, pushAll bool) error {
-	if pushAll {
-		if err := g.ScanRefWithDeleted(update.LeftCommitish(), nil); err != nil {
-			return err
-		}
-	} else {
-		if err := g.ScanLeftToRemote(update.LeftCommitish(), nil); err != nil {
-			return err
-		}
+func uploadAll(g *lfs.GitScanner, ctx *uploadContext, update *refUpdate) error {
+	if err := g.ScanRefWithDeleted(update.LeftCommitish(), nil); err != nil {
+		return err
 	}
 	return ctx.scannerError()
 }
 
+// uploadLeftsToRemote uploads the objects of all of the given updates that the
+// remote doesn't have, scanning the history they share only once.
+func uploadLeftsToRemote(g *lfs.GitScanner, ctx *uploadContext, updates []*refUpdate) error {
+	err := g.ScanMultiLeftToRemote(leftCommitishes(updates), ctx.pushCache.Excludes(), nil)
+	if err == nil {
+		err = ctx.scannerError()
+	}
+	if err != nil {
+		names := make([]string, 0, len(updates))
+		for _, update := range updates {
+			names = append(names, update.Left().Name)
+		}
+		return errors.Wrap(err, fmt.Sprintf("ref %s:", strings.Join(names, ", ")))
+	}
+	return nil
+}
+
+func leftCommitishes(updates []*refUpdate) []string {
+	commitishes := make([]string, 0, len(updates))
+	for _, update := range updates {
+		commitishes = append(commitishes, update.LeftCommitish())
+	}
+	return commitishes
+}
+
 type uploadContext struct {
 	Remote       string
 	DryRun       bool
@@ -65,11 +104,31 @@ type uploadContext struct {
 	committerEmail string
 
 	lockVerifier *lockVerifier
+	pushCache    *pushCache
 
 	// allowMissing specifies whether pushes containing missing/corrupt
 	// pointers should allow pushing Git blobs
 	allowMissing bool
 
+	// reportDryRun specifies whether the objects a dry run would upload
+	// are collected in dryRunPointers to be reported at the end, rather
+	// than printed as they are found.
+	reportDryRun   bool
+	dryRunPointers []*lfs.WrappedPointer
+
+	// duplicates and duplicateSize count the pointers found again at
+	// another path, or in another commit or ref, after their object was
+	// already queued for upload, and their total size, which were not
+	// uploaded again.
+	duplicates    int
+	duplicateSize int64
+
+	// reviewing specifies whether the pointers found while scanning are
+	// held back in reviewPointers, for the push to be reviewed before
+	// their objects are uploaded.
+	reviewing      bool
+	reviewPointers []*lfs.WrappedPointer
+
 	// tracks errors from gitscanner callbacks
 	scannerErr error
 	errMu      sync.Mutex
@@ -86,6 +145,7 @@ func newUploadContext(dryRun bool) *uploadContext {
 		gitfilter:    lfs.NewGitFilter(cfg),
 		lockVerifier: newLockVerifier(manifest),
 		allowMissing: cfg.Git.Bool("lfs.allowincompletepush", true),
+		pushCache:    newPushCache(remote),
 	}
 
 	var sink io.Writer = os.Stdout
@@ -124,6 +184,8 @@ func (c *uploadContext) buildGitScanner() (*lfs.GitScanner, error) {
 	gitscanner := lfs.NewGitScanner(func(p *lfs.WrappedPointer, err error) {
 		if err != nil {
 			c.addScannerError(err)
+		} else if c.reviewing {
+			c.reviewPointers = append(c.reviewPointers, p)
 		} else {
 			uploadPointers(c, p)
 		}
@@ -146,12 +208,32 @@ func (c *uploadContext) HasUploaded(oid string) bool {
 	return c.uploadedOids.Contains(oid)
 }
 
+// skipDuplicate records that the object of the given pointer is not uploaded
+// again, as it was already found at another path, or in another commit.
+func (c *uploadContext) skipDuplicate(p *lfs.WrappedPointer) {
+	tracerx.Printf("already uploading %s for %q, skipping duplicate", p.Oid, p.Name)
+
+	c.duplicates++
+	c.duplicateSize += p.Size
+}
+
+// reportDuplicates prints how many objects were not uploaded again, and how
+// much was saved by doing so, if any were found more than once.
+func (c *uploadContext) reportDuplicates() {
+	if c.duplicates == 0 {
+		return
+	}
+
+	Print("Skipped %d duplicate object(s), saving %s of uploads",
+		c.duplicates, humanize.FormatBytes(uint64(c.duplicateSize)))
+}
+
 func (c *uploadContext) prepareUpload(unfiltered ...*lfs.WrappedPointer) (*tq.TransferQueue, []*lfs.WrappedPointer) {
 	numUnfiltered := len(unfiltered)
 	uploadables := make([]*lfs.WrappedPointer, 0, numUnfiltered)
 
-	// XXX(taylor): temporary measure to fix duplicate (broken) results from
-	// scanner
+	// OIDs of the objects queued below, as the same object can be found
+	// at several paths at once.
 	uniqOids := tools.NewStringSet()
 
 	// separate out objects that _should_ be uploaded, but don't exist in
@@ -160,6 +242,7 @@ func (c *uploadContext) prepareUpload(unfiltered ...*lfs.WrappedPointer) (*tq.Tr
 		// object already uploaded in this process, or we've already
 		// seen this OID (see above), skip!
 		if uniqOids.Contains(p.Oid) || c.HasUploaded(p.Oid) {
+			c.skipDuplicate(p)
 			continue
 		}
 		uniqOids.Add(p.Oid)
@@ -201,10 +284,15 @@ func uploadPointers(c *uploadContext, unfiltered ...*lfs.WrappedPointer) {
 	if c.DryRun {
 		for _, p := range unfiltered {
 			if c.HasUploaded(p.Oid) {
+				c.skipDuplicate(p)
 				continue
 			}
 
-			Print("push %s => %s", p.Oid, p.Name)
+			if c.reportDryRun {
+				c.dryRunPointers = append(c.dryRunPointers, p)
+			} else {
+				Print("push %s => %s", p.Oid, p.Name)
+			}
 			c.SetUploaded(p.Oid)
 		}
 
@@ -223,8 +311,15 @@ func uploadPointers(c *uploadContext, unfiltered ...*lfs.WrappedPointer) {
 	}
 }
 
-func (c *uploadContext) Await() {
+// Await waits for the uploads to finish, reporting any that failed, and
+// returns whether every object was uploaded.
+func (c *uploadContext) Await() bool {
 	c.tq.Wait()
+	recordTimedOut(c.tq)
+
+	if !c.DryRun {
+		c.reportDuplicates()
+	}
 
 	var missing = make(map[string]string)
 	var corrupt = make(map[string]string)
@@ -263,12 +358,12 @@ func (c *uploadContext) Await() {
 		}
 
 		if !c.allowMissing {
-			os.Exit(2)
+			os.Exit(classifiedExitCode(2))
 		}
 	}
 
 	if len(others) > 0 {
-		os.Exit(2)
+		os.Exit(classifiedExitCode(2))
 	}
 
 	if c.lockVerifier.HasUnownedLocks() {
@@ -288,6 +383,8 @@ func (c *uploadContext) Await() {
 			Print("* %s", owned.Path())
 		}
 	}
+
+	return len(missing) == 0 && len(corrupt) == 0 && c.tq.TimedOut() == 0
 }
 
 var (
@@ -4,6 +4,7 @@ package config
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -152,6 +153,30 @@ func (c *Configuration) FetchExcludePaths() []string {
 	return tools.CleanPaths(patterns, ",")
 }
 
+// HashAlgorithm returns the algorithm with which new Git LFS objects are named,
+// as set by lfs.hashalgo.
+func (c *Configuration) HashAlgorithm() string {
+	if algo, ok := c.Git.Get("lfs.hashalgo"); ok && len(algo) > 0 {
+		return strings.ToLower(algo)
+	}
+	return tools.HashAlgoSHA256
+}
+
+// SparseCheckoutPatterns returns the lines of the sparse-checkout file of the
+// current working tree, or nil if core.sparseCheckout is not enabled or the
+// file cannot be read.
+func (c *Configuration) SparseCheckoutPatterns() []string {
+	if !c.Git.Bool("core.sparsecheckout", false) || len(c.LocalWorkingDir()) == 0 {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(c.LocalGitDir(), "info", "sparse-checkout"))
+	if err != nil {
+		return nil
+	}
+	return strings.Split(string(data), "\n")
+}
+
 func (c *Configuration) CurrentRef() *git.Ref {
 	c.loading.Lock()
 	defer c.loading.Unlock()
@@ -266,7 +291,8 @@ func (c *Configuration) HookDir() string {
 			return hp
 		}
 	}
-	return filepath.Join(c.LocalGitDir(), "hooks")
+	// Linked worktrees share the hooks of the main repository.
+	return filepath.Join(c.LocalGitStorageDir(), "hooks")
 }
 
 func (c *Configuration) InRepo() bool {
@@ -295,7 +321,7 @@ func (c *Configuration) loadGitDirs() {
 	if err != nil {
 		errMsg := err.Error()
 		tracerx.Printf("Error running 'git rev-parse': %s", errMsg)
-		if !strings.Contains(errMsg, "Not a git repository") {
+		if !strings.Contains(strings.ToLower(errMsg), "not a git repository") {
 			fmt.Fprintf(os.Stderr, "Error: %s\n", errMsg)
 		}
 		c.gitDir = &gitdir
@@ -310,8 +336,8 @@ func (c *Configuration) LocalGitStorageDir() string {
 	return c.Filesystem().GitStorageDir
 }
 
-func (c *Configuration) LocalReferenceDir() string {
-	return c.Filesystem().ReferenceDir
+func (c *Configuration) LocalReferenceDirs() []string {
+	return c.Filesystem().ReferenceDirs
 }
 
 func (c *Configuration) LFSStorageDir() string {
@@ -345,7 +371,7 @@ func (c *Configuration) Filesystem() *fs.Filesystem {
 
 	if c.fs == nil {
 		lfsdir, _ := c.Git.Get("lfs.storage")
-		c.fs = fs.New(c.LocalGitDir(), c.LocalWorkingDir(), lfsdir)
+		c.fs = fs.New(c.LocalGitDir(), c.LocalWorkingDir(), lfsdir, c.Git.GetAll("lfs.alternate"))
 	}
 
 	return c.fs
@@ -158,9 +158,12 @@ func keyIsUnsafe(key string) bool {
 }
 
 var safeKeys = []string{
+	"lfs.compressiblefiles",
+	"lfs.concurrenttransfers",
 	"lfs.fetchexclude",
 	"lfs.fetchinclude",
 	"lfs.gitprotocol",
+	"lfs.incompressiblefiles",
 	"lfs.pushurl",
 	"lfs.url",
 }
@@ -0,0 +1,189 @@
+// Package encryption implements the client-side encryption of object contents
+// used by `git lfs encrypt`.
+//
+// Contents are encrypted with AES-256-GCM in chunks, so that objects of any
+// size can be encrypted and decrypted as streams. Encryption is
+// deterministic: the per-object key is derived from a MAC of the plaintext, so
+// that cleaning the same file twice gives the same object, as Git expects of
+// a clean filter. This reveals which objects have the same contents, but
+// nothing else about them.
+//
+// An encrypted object is laid out as:
+//
+//	magic (8 bytes) | salt (32 bytes) | chunk... | last chunk
+//
+// where each chunk holds up to ChunkSize bytes of plaintext followed by a
+// 16-byte GCM tag. The nonce of each chunk holds its index and whether it is
+// the last one, so that chunks can't be reordered or the object truncated
+// without decryption failing.
+package encryption
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+
+	"github.com/git-lfs/git-lfs/errors"
+)
+
+const (
+	// ChunkSize is the number of bytes of plaintext in each chunk.
+	ChunkSize = 64 * 1024
+
+	// MinSecretSize is the minimum size of the secret a key is derived
+	// from.
+	MinSecretSize = 16
+
+	magic    = "GLFSENC1"
+	saltSize = sha256.Size
+	tagSize  = 16
+)
+
+// Key encrypts and decrypts object contents.
+type Key struct {
+	encKey []byte
+	macKey []byte
+}
+
+// NewKey derives a Key from the given secret, which must be at least
+// MinSecretSize bytes long.
+func NewKey(secret []byte) (*Key, error) {
+	if len(secret) < MinSecretSize {
+		return nil, errors.Errorf("encryption key must be at least %d bytes long, got %d", MinSecretSize, len(secret))
+	}
+
+	return &Key{
+		encKey: mac(secret, []byte("git-lfs encryption key")),
+		macKey: mac(secret, []byte("git-lfs salt key")),
+	}, nil
+}
+
+// Encrypt writes the encrypted contents of src to dst. It reads src twice:
+// once to derive the salt, and again to encrypt it.
+func (k *Key) Encrypt(dst io.Writer, src io.ReadSeeker) error {
+	h := hmac.New(sha256.New, k.macKey)
+	if _, err := io.Copy(h, src); err != nil {
+		return err
+	}
+	salt := h.Sum(nil)
+
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	aead, err := k.aead(salt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(dst, magic); err != nil {
+		return err
+	}
+	if _, err := dst.Write(salt); err != nil {
+		return err
+	}
+
+	r := bufio.NewReaderSize(src, ChunkSize)
+	plaintext := make([]byte, ChunkSize)
+	ciphertext := make([]byte, 0, ChunkSize+tagSize)
+
+	for index := uint32(0); ; index++ {
+		n, last, err := readChunk(r, plaintext)
+		if err != nil {
+			return err
+		}
+
+		ciphertext = aead.Seal(ciphertext[:0], nonce(index, last), plaintext[:n], nil)
+		if _, err := dst.Write(ciphertext); err != nil {
+			return err
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+// Decrypt writes the decrypted contents of src to dst. It returns an error if
+// src wasn't encrypted with this Key, or has been modified since.
+func (k *Key) Decrypt(dst io.Writer, src io.Reader) error {
+	header := make([]byte, len(magic)+saltSize)
+	if _, err := io.ReadFull(src, header); err != nil || string(header[:len(magic)]) != magic {
+		return errors.New("contents are not encrypted by Git LFS")
+	}
+
+	aead, err := k.aead(header[len(magic):])
+	if err != nil {
+		return err
+	}
+
+	r := bufio.NewReaderSize(src, ChunkSize+tagSize)
+	ciphertext := make([]byte, ChunkSize+tagSize)
+	plaintext := make([]byte, 0, ChunkSize)
+
+	for index := uint32(0); ; index++ {
+		n, last, err := readChunk(r, ciphertext)
+		if err != nil {
+			return err
+		}
+
+		plaintext, err = aead.Open(plaintext[:0], nonce(index, last), ciphertext[:n], nil)
+		if err != nil {
+			return errors.New("contents could not be decrypted: wrong key, or corrupt contents")
+		}
+		if _, err := dst.Write(plaintext); err != nil {
+			return err
+		}
+
+		if last {
+			return nil
+		}
+	}
+}
+
+// aead returns the cipher for the object with the given salt.
+func (k *Key) aead(salt []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(mac(k.encKey, salt))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// readChunk fills buf from r as far as possible, returning the number of bytes
+// read and whether r has no more data.
+func readChunk(r *bufio.Reader, buf []byte) (int, bool, error) {
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return n, true, nil
+	} else if err != nil {
+		return n, false, err
+	}
+
+	if _, err := r.Peek(1); err == io.EOF {
+		return n, true, nil
+	} else if err != nil {
+		return n, false, err
+	}
+	return n, false, nil
+}
+
+// nonce returns the nonce of the chunk with the given index.
+func nonce(index uint32, last bool) []byte {
+	n := make([]byte, 12)
+	binary.BigEndian.PutUint32(n[7:11], index)
+	if last {
+		n[11] = 1
+	}
+	return n
+}
+
+func mac(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
@@ -0,0 +1,73 @@
+package encryption
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKey(t *testing.T, secret string) *Key {
+	key, err := NewKey([]byte(secret))
+	require.Nil(t, err)
+	return key
+}
+
+func encrypt(t *testing.T, key *Key, plaintext []byte) []byte {
+	var buf bytes.Buffer
+	require.Nil(t, key.Encrypt(&buf, bytes.NewReader(plaintext)))
+	return buf.Bytes()
+}
+
+func TestEncryptionRoundTrip(t *testing.T) {
+	key := newTestKey(t, "0123456789abcdef")
+
+	for _, size := range []int{0, 1, ChunkSize - 1, ChunkSize, ChunkSize + 1, 3 * ChunkSize} {
+		plaintext := bytes.Repeat([]byte{'a'}, size)
+		ciphertext := encrypt(t, key, plaintext)
+		assert.NotContains(t, string(ciphertext), "aaaa")
+
+		var decrypted bytes.Buffer
+		require.Nil(t, key.Decrypt(&decrypted, bytes.NewReader(ciphertext)), "size %d", size)
+		assert.Equal(t, size, decrypted.Len(), "size %d", size)
+		assert.True(t, bytes.Equal(plaintext, decrypted.Bytes()), "size %d", size)
+	}
+}
+
+func TestEncryptionIsDeterministic(t *testing.T) {
+	key := newTestKey(t, "0123456789abcdef")
+
+	a := encrypt(t, key, []byte("contents"))
+	assert.Equal(t, a, encrypt(t, key, []byte("contents")))
+	assert.NotEqual(t, a, encrypt(t, key, []byte("other contents")))
+	assert.NotEqual(t, a, encrypt(t, newTestKey(t, "fedcba9876543210"), []byte("contents")))
+}
+
+func TestDecryptWithWrongKey(t *testing.T) {
+	ciphertext := encrypt(t, newTestKey(t, "0123456789abcdef"), []byte("contents"))
+
+	var buf bytes.Buffer
+	err := newTestKey(t, "fedcba9876543210").Decrypt(&buf, bytes.NewReader(ciphertext))
+	assert.EqualError(t, err, "contents could not be decrypted: wrong key, or corrupt contents")
+}
+
+func TestDecryptTruncated(t *testing.T) {
+	key := newTestKey(t, "0123456789abcdef")
+	ciphertext := encrypt(t, key, bytes.Repeat([]byte{'a'}, 2*ChunkSize))
+
+	var buf bytes.Buffer
+	err := key.Decrypt(&buf, bytes.NewReader(ciphertext[:len(ciphertext)-ChunkSize-tagSize]))
+	assert.EqualError(t, err, "contents could not be decrypted: wrong key, or corrupt contents")
+}
+
+func TestDecryptUnencrypted(t *testing.T) {
+	var buf bytes.Buffer
+	err := newTestKey(t, "0123456789abcdef").Decrypt(&buf, bytes.NewReader([]byte("plain contents")))
+	assert.EqualError(t, err, "contents are not encrypted by Git LFS")
+}
+
+func TestNewKeyRequiresLongSecret(t *testing.T) {
+	_, err := NewKey([]byte("short"))
+	assert.EqualError(t, err, "encryption key must be at least 16 bytes long, got 5")
+}
@@ -27,6 +27,7 @@ type Pattern interface {
 type Filter struct {
 	include []Pattern
 	exclude []Pattern
+	sparse  []*sparsePattern
 }
 
 func NewFromPatterns(include, exclude []Pattern) *Filter {
@@ -105,6 +106,10 @@ func (f *Filter) AllowsPattern(filename string) (pattern string, allowed bool) {
 		return "", true
 	}
 
+	if !f.allowsSparse(filename) {
+		return "", false
+	}
+
 	if len(f.include)+len(f.exclude) == 0 {
 		return "", true
 	}
@@ -0,0 +1,157 @@
+package filepathfilter
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// sparsePattern is a single line of a sparse-checkout file, which follows the
+// syntax of a .gitignore file.
+type sparsePattern struct {
+	negated  bool
+	dirOnly  bool
+	anchored bool
+	re       *regexp.Regexp
+}
+
+// newSparsePattern parses the given line of a sparse-checkout file, returning
+// nil if the line is blank or a comment.
+func newSparsePattern(line string) *sparsePattern {
+	line = strings.TrimRight(line, " \t\r")
+	if len(line) == 0 || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	p := &sparsePattern{}
+	if strings.HasPrefix(line, "!") {
+		p.negated = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\") {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	// A pattern containing a slash anywhere but at the end is relative to
+	// the root of the repository, otherwise it matches at any level.
+	if strings.Contains(line, "/") {
+		p.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if len(line) == 0 {
+		return nil
+	}
+
+	p.re = regexp.MustCompile("^" + sparseGlobToRegexp(line) + "$")
+	return p
+}
+
+// Match returns whether the pattern matches the given slash-separated path,
+// relative to the root of the repository.
+func (p *sparsePattern) Match(name string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	if !p.anchored {
+		name = name[strings.LastIndex(name, "/")+1:]
+	}
+	return p.re.MatchString(name)
+}
+
+// sparseGlobToRegexp converts the given wildmatch pattern into a regular
+// expression, where "*" and "?" do not match a slash, and "**" matches any
+// number of directories.
+func sparseGlobToRegexp(glob string) string {
+	var re bytes.Buffer
+
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			re.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		case c == '\\' && i+1 < len(glob):
+			re.WriteString(regexp.QuoteMeta(glob[i+1 : i+2]))
+			i++
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				re.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + strings.Replace(class, `\`, `\\`, -1) + "]")
+			i += end + 1
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	return re.String()
+}
+
+// WithSparseCheckout returns a copy of the filter which, in addition to its
+// include and exclude patterns, only allows the files which Git would check out
+// given the patterns of a sparse-checkout file. Both patterns written in cone
+// mode and in full mode are supported, since the former are a restricted form
+// of the latter.
+func (f *Filter) WithSparseCheckout(patterns []string) *Filter {
+	sparse := make([]*sparsePattern, 0, len(patterns))
+	for _, line := range patterns {
+		if p := newSparsePattern(line); p != nil {
+			sparse = append(sparse, p)
+		}
+	}
+
+	dup := &Filter{sparse: sparse}
+	if f != nil {
+		dup.include = f.include
+		dup.exclude = f.exclude
+	}
+	return dup
+}
+
+// allowsSparse returns whether the given file is included by the filter's
+// sparse-checkout patterns, if it has any.
+//
+// As in Git, the last pattern matching the file itself decides, and if none of
+// them do, the last pattern matching its closest parent directory does. Files
+// matched by no pattern are excluded.
+func (f *Filter) allowsSparse(filename string) bool {
+	if f.sparse == nil {
+		return true
+	}
+
+	name := strings.Trim(filepath.ToSlash(filename), "/")
+	isDir := false
+	for len(name) > 0 && name != "." {
+		for i := len(f.sparse) - 1; i >= 0; i-- {
+			if p := f.sparse[i]; p.Match(name, isDir) {
+				return !p.negated
+			}
+		}
+
+		slash := strings.LastIndex(name, "/")
+		if slash < 0 {
+			break
+		}
+		name = name[:slash]
+		isDir = true
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package filepathfilter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSparseCheckoutConeMode(t *testing.T) {
+	f := New(nil, nil).WithSparseCheckout([]string{
+		"/*",
+		"!/*/",
+		"/a/",
+		"!/a/*/",
+		"/a/b/",
+		"/c/",
+		"",
+	})
+
+	for _, name := range []string{"top.dat", "a/file.dat", "a/b/file.dat", "a/b/c/file.dat", "c/d/e/file.dat"} {
+		assert.True(t, f.Allows(name), "expected %q to be allowed", name)
+	}
+	for _, name := range []string{"d/file.dat", "a/c/file.dat", "b/file.dat", "d/a/b/file.dat"} {
+		assert.False(t, f.Allows(name), "expected %q not to be allowed", name)
+	}
+}
+
+func TestSparseCheckoutFullMode(t *testing.T) {
+	f := New(nil, nil).WithSparseCheckout([]string{
+		"# comment",
+		"*.dat",
+		"!unused/*.dat",
+		"docs/**/*.png",
+		"keep?.bin",
+	})
+
+	for _, name := range []string{"a.dat", "x/y/a.dat", "docs/a.png", "docs/x/y/a.png", "sub/keep1.bin", "x/unused/a.dat"} {
+		assert.True(t, f.Allows(name), "expected %q to be allowed", name)
+	}
+	for _, name := range []string{"unused/a.dat", "a.png", "x/docs/a.png", "keep10.bin", "a.bin"} {
+		assert.False(t, f.Allows(name), "expected %q not to be allowed", name)
+	}
+}
+
+func TestSparseCheckoutKeepsIncludeAndExclude(t *testing.T) {
+	f := New([]string{"a"}, []string{"a/b"}).WithSparseCheckout([]string{"/a/", "/c/"})
+
+	assert.True(t, f.Allows("a/file.dat"))
+	assert.False(t, f.Allows("a/b/file.dat"))
+	assert.False(t, f.Allows("c/file.dat"))
+	assert.Equal(t, []string{"a"}, f.Include())
+	assert.Equal(t, []string{"a/b"}, f.Exclude())
+}
+
+func TestSparseCheckoutOnNilFilter(t *testing.T) {
+	var f *Filter
+	f = f.WithSparseCheckout([]string{"/a/"})
+
+	assert.True(t, f.Allows("a/file.dat"))
+	assert.False(t, f.Allows("b/file.dat"))
+}
@@ -27,19 +27,18 @@ func (f *Filesystem) cleanupTmp() error {
 		path := filepath.Join(parentDir, info.Name())
 		parts := strings.SplitN(info.Name(), "-", 2)
 		oid := parts[0]
-		if len(parts) < 2 || len(oid) != 64 {
-			tracerx.Printf("Removing invalid tmp object file: %s", path)
-			os.RemoveAll(path)
-			return
-		}
-
-		fi, err := os.Stat(f.ObjectPathname(oid))
-		if err == nil && !fi.IsDir() {
-			tracerx.Printf("Removing existing tmp object file: %s", path)
-			os.RemoveAll(path)
-			return
+		if len(parts) == 2 && len(oid) == 64 {
+			fi, err := os.Stat(f.ObjectPathname(oid))
+			if err == nil && !fi.IsDir() {
+				tracerx.Printf("Removing existing tmp object file: %s", path)
+				os.RemoveAll(path)
+				return
+			}
 		}
 
+		// Other files may be in use by another process, such as one
+		// of another repository sharing the same lfs.storage directory,
+		// so only remove them once they are old.
 		if time.Since(info.ModTime()) > time.Hour {
 			tracerx.Printf("Removing old tmp object file: %s", path)
 			os.RemoveAll(path)
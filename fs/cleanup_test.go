@@ -0,0 +1,43 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanupTmpKeepsRecentFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-cleanup")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &Filesystem{LFSStorageDir: dir}
+
+	recent := filepath.Join(f.TempDir(), "123456")
+	old := filepath.Join(f.TempDir(), "654321")
+	stored := filepath.Join(f.TempDir(), metadataTestOid+"-123456")
+	for _, path := range []string{recent, old, stored} {
+		require.Nil(t, ioutil.WriteFile(path, []byte("x"), 0644))
+	}
+
+	then := time.Now().Add(-2 * time.Hour)
+	require.Nil(t, os.Chtimes(old, then, then))
+
+	objectPath, err := f.ObjectPath(metadataTestOid)
+	require.Nil(t, err)
+	require.Nil(t, ioutil.WriteFile(objectPath, []byte("x"), 0644))
+
+	require.Nil(t, f.Cleanup())
+
+	_, err = os.Stat(recent)
+	assert.Nil(t, err)
+	_, err = os.Stat(old)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(stored)
+	assert.True(t, os.IsNotExist(err))
+}
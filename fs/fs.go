@@ -23,7 +23,7 @@ type Object struct {
 type Filesystem struct {
 	GitStorageDir string // parent of objects/lfs (may be same as GitDir but may not)
 	LFSStorageDir string // parent of lfs objects and tmp dirs. Default: ".git/lfs"
-	ReferenceDir  string // alternative local media dir (relative to clone reference repo)
+	ReferenceDirs []string // alternative local media dirs (of clone reference repos and lfs.alternate)
 	lfsobjdir     string
 	tmpdir        string
 	logdir        string
@@ -53,26 +53,35 @@ func (f *Filesystem) ObjectExists(oid string, size int64) bool {
 
 func (f *Filesystem) ObjectPath(oid string) (string, error) {
 	dir := f.localObjectDir(oid)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := os.MkdirAll(tools.LongPath(dir), 0755); err != nil {
 		return "", fmt.Errorf("Error trying to create local storage directory in %q: %s", dir, err)
 	}
-	return filepath.Join(dir, oid), nil
+	return tools.LongPath(filepath.Join(dir, oid)), nil
 }
 
 func (f *Filesystem) ObjectPathname(oid string) string {
-	return filepath.Join(f.localObjectDir(oid), oid)
+	return tools.LongPath(filepath.Join(f.localObjectDir(oid), oid))
 }
 
 func (f *Filesystem) localObjectDir(oid string) string {
 	return filepath.Join(f.LFSObjectDir(), oid[0:2], oid[2:4])
 }
 
-func (f *Filesystem) ObjectReferencePath(oid string) string {
-	if len(f.ReferenceDir) == 0 {
-		return f.ReferenceDir
+// ObjectReferencePaths returns the paths that the object with the given OID
+// would have in each of the alternative local media dirs.
+func (f *Filesystem) ObjectReferencePaths(oid string) []string {
+	paths := make([]string, 0, len(f.ReferenceDirs))
+	for _, dir := range f.ReferenceDirs {
+		paths = append(paths, filepath.Join(dir, oid[0:2], oid[2:4], oid))
 	}
+	return paths
+}
 
-	return filepath.Join(f.ReferenceDir, oid[0:2], oid[2:4], oid)
+// RepoLFSDir returns the "lfs" directory of the repository's own Git storage
+// directory. Unlike LFSStorageDir, which lfs.storage can point at a directory
+// shared by several repositories, it holds state of this repository alone.
+func (f *Filesystem) RepoLFSDir() string {
+	return filepath.Join(f.GitStorageDir, "lfs")
 }
 
 func (f *Filesystem) LFSObjectDir() string {
@@ -81,7 +90,7 @@ func (f *Filesystem) LFSObjectDir() string {
 
 	if len(f.lfsobjdir) == 0 {
 		f.lfsobjdir = filepath.Join(f.LFSStorageDir, "objects")
-		os.MkdirAll(f.lfsobjdir, 0755)
+		os.MkdirAll(tools.LongPath(f.lfsobjdir), 0755)
 	}
 
 	return f.lfsobjdir
@@ -105,7 +114,7 @@ func (f *Filesystem) TempDir() string {
 
 	if len(f.tmpdir) == 0 {
 		f.tmpdir = filepath.Join(f.LFSStorageDir, "tmp")
-		os.MkdirAll(f.tmpdir, 0755)
+		os.MkdirAll(tools.LongPath(f.tmpdir), 0755)
 	}
 
 	return f.tmpdir
@@ -120,13 +129,15 @@ func (f *Filesystem) Cleanup() error {
 
 // New initializes a new *Filesystem with the given directories. gitdir is the
 // path to the bare repo, workdir is the path to the repository working
-// directory, and lfsdir is the optional path to the `.git/lfs` directory.
-func New(gitdir, workdir, lfsdir string) *Filesystem {
+// directory, lfsdir is the optional path to the `.git/lfs` directory, and
+// alternates are the optional repositories or object directories to borrow
+// objects from.
+func New(gitdir, workdir, lfsdir string, alternates []string) *Filesystem {
 	fs := &Filesystem{
 		GitStorageDir: resolveGitStorageDir(gitdir),
 	}
 
-	fs.ReferenceDir = resolveReferenceDir(fs.GitStorageDir)
+	fs.ReferenceDirs = resolveReferenceDirs(fs.GitStorageDir, workdir, alternates)
 
 	if len(lfsdir) == 0 {
 		lfsdir = "lfs"
@@ -141,19 +152,56 @@ func New(gitdir, workdir, lfsdir string) *Filesystem {
 	return fs
 }
 
-func resolveReferenceDir(gitStorageDir string) string {
-	cloneReferencePath := filepath.Join(gitStorageDir, "objects", "info", "alternates")
-	if tools.FileExists(cloneReferencePath) {
-		buffer, err := ioutil.ReadFile(cloneReferencePath)
-		if err == nil {
-			path := strings.TrimSpace(string(buffer[:]))
+// resolveReferenceDirs returns the media dirs of the repositories whose Git
+// objects are borrowed through "objects/info/alternates", such as with `git
+// clone --reference`, followed by those of the given alternates. Relative
+// alternates are relative to workdir.
+func resolveReferenceDirs(gitStorageDir, workdir string, alternates []string) []string {
+	var dirs []string
+
+	objectsDir := filepath.Join(gitStorageDir, "objects")
+	buffer, err := ioutil.ReadFile(filepath.Join(objectsDir, "info", "alternates"))
+	if err == nil {
+		for _, line := range strings.Split(string(buffer), "\n") {
+			path := strings.TrimSpace(line)
+			if len(path) == 0 || strings.HasPrefix(path, "#") {
+				continue
+			}
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(objectsDir, path)
+			}
+
 			referenceLfsStoragePath := filepath.Join(filepath.Dir(path), "lfs", "objects")
 			if tools.DirExists(referenceLfsStoragePath) {
-				return referenceLfsStoragePath
+				dirs = append(dirs, referenceLfsStoragePath)
 			}
 		}
 	}
-	return ""
+
+	for _, alternate := range alternates {
+		if !filepath.IsAbs(alternate) {
+			alternate = filepath.Join(workdir, alternate)
+		}
+
+		if dir := alternateMediaDir(alternate); tools.DirExists(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return dirs
+}
+
+// alternateMediaDir returns the media dir of the given repository, or the
+// directory itself if it isn't a repository, in which case it is assumed to be
+// laid out like one.
+func alternateMediaDir(dir string) string {
+	if tools.DirExists(filepath.Join(dir, ".git")) {
+		return filepath.Join(dir, ".git", "lfs", "objects")
+	}
+	if tools.FileExists(filepath.Join(dir, "HEAD")) && tools.DirExists(filepath.Join(dir, "objects")) {
+		return filepath.Join(dir, "lfs", "objects")
+	}
+	return dir
 }
 
 // From a git dir, get the location that objects are to be stored (we will store lfs alongside)
@@ -0,0 +1,53 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveReferenceDirs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-reference")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	mkdir := func(parts ...string) string {
+		path := filepath.Join(append([]string{dir}, parts...)...)
+		require.Nil(t, os.MkdirAll(path, 0755))
+		return path
+	}
+
+	gitdir := mkdir("repo", ".git")
+	mkdir("repo", ".git", "objects", "info")
+	reference := mkdir("reference", ".git", "lfs", "objects")
+	bare := mkdir("bare.git", "lfs", "objects")
+	mkdir("bare.git", "objects")
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "bare.git", "HEAD"), []byte("ref: refs/heads/master\n"), 0644))
+	cache := mkdir("cache")
+
+	alternates := "# comment\n" +
+		filepath.Join(dir, "reference", ".git", "objects") + "\n" +
+		"../../../missing/.git/objects\n"
+	require.Nil(t, ioutil.WriteFile(filepath.Join(gitdir, "objects", "info", "alternates"), []byte(alternates), 0644))
+
+	dirs := resolveReferenceDirs(gitdir, filepath.Join(dir, "repo"), []string{
+		filepath.Join(dir, "bare.git"),
+		"../cache",
+		"../nonexistent",
+	})
+
+	assert.Equal(t, []string{reference, bare, cache}, dirs)
+}
+
+func TestObjectReferencePaths(t *testing.T) {
+	f := &Filesystem{ReferenceDirs: []string{"a", "b"}}
+
+	assert.Equal(t, []string{
+		filepath.Join("a", "4d", "7a", metadataTestOid),
+		filepath.Join("b", "4d", "7a", metadataTestOid),
+	}, f.ObjectReferencePaths(metadataTestOid))
+}
@@ -0,0 +1,97 @@
+package fs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// MetadataPath is the ObjectMetadata key for the path, relative to the
+	// root of the repository, that an object was cleaned from.
+	MetadataPath = "path"
+	// MetadataContentType is the ObjectMetadata key for the MIME type of an
+	// object's contents.
+	MetadataContentType = "content-type"
+)
+
+// ObjectMetadata is small, free-form information about an object, such as the
+// path it was cleaned from, which is kept in a sidecar file alongside the
+// local object store.
+type ObjectMetadata map[string]string
+
+// ObjectMetadataPathname returns the path to the sidecar file holding the
+// metadata for the given object.
+func (f *Filesystem) ObjectMetadataPathname(oid string) string {
+	return filepath.Join(f.LFSStorageDir, "metadata", oid[0:2], oid[2:4], oid+".json")
+}
+
+// ReadObjectMetadata returns the metadata stored for the given object, or an
+// empty ObjectMetadata if there is none.
+func (f *Filesystem) ReadObjectMetadata(oid string) (ObjectMetadata, error) {
+	md, err := readSidecar(f.ObjectMetadataPathname(oid))
+	return ObjectMetadata(md), err
+}
+
+// WriteObjectMetadata replaces the metadata stored for the given object with
+// "md", removing the sidecar file altogether if "md" is empty.
+func (f *Filesystem) WriteObjectMetadata(oid string, md ObjectMetadata) error {
+	return f.writeSidecar(f.ObjectMetadataPathname(oid), oid+"-metadata", md)
+}
+
+// readSidecar returns the string map held in the JSON sidecar file at "path",
+// or an empty map if there is none.
+func readSidecar(path string) (map[string]string, error) {
+	m := make(map[string]string)
+
+	by, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(by, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// writeSidecar replaces the JSON sidecar file at "path" with "m", removing it
+// altogether if "m" is empty.
+func (f *Filesystem) writeSidecar(path, tmpPrefix string, m map[string]string) error {
+	if len(m) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	by, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so that concurrent readers never
+	// see a partially written sidecar.
+	tmp, err := ioutil.TempFile(f.TempDir(), tmpPrefix)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(by)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
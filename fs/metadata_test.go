@@ -0,0 +1,47 @@
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const metadataTestOid = "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+
+func TestObjectMetadataRoundTrip(t *testing.T) {
+	f := newMetadataTestFilesystem(t)
+	defer os.RemoveAll(f.LFSStorageDir)
+
+	md, err := f.ReadObjectMetadata(metadataTestOid)
+	require.Nil(t, err)
+	assert.Empty(t, md)
+
+	md[MetadataPath] = "a.dat"
+	md["owner"] = "art"
+	require.Nil(t, f.WriteObjectMetadata(metadataTestOid, md))
+
+	read, err := f.ReadObjectMetadata(metadataTestOid)
+	require.Nil(t, err)
+	assert.Equal(t, ObjectMetadata{"path": "a.dat", "owner": "art"}, read)
+}
+
+func TestObjectMetadataRemovedWhenEmpty(t *testing.T) {
+	f := newMetadataTestFilesystem(t)
+	defer os.RemoveAll(f.LFSStorageDir)
+
+	require.Nil(t, f.WriteObjectMetadata(metadataTestOid, ObjectMetadata{"owner": "art"}))
+	require.Nil(t, f.WriteObjectMetadata(metadataTestOid, ObjectMetadata{}))
+
+	_, err := os.Stat(f.ObjectMetadataPathname(metadataTestOid))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func newMetadataTestFilesystem(t *testing.T) *Filesystem {
+	dir, err := ioutil.TempDir("", "lfs-metadata")
+	require.Nil(t, err)
+
+	return &Filesystem{LFSStorageDir: dir}
+}
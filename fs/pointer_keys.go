@@ -0,0 +1,23 @@
+package fs
+
+import "path/filepath"
+
+// ObjectPointerKeysPathname returns the path to the sidecar file holding the
+// pointer keys remembered for the given object.
+func (f *Filesystem) ObjectPointerKeysPathname(oid string) string {
+	return filepath.Join(f.LFSStorageDir, "pointer-keys", oid[0:2], oid[2:4], oid+".json")
+}
+
+// ReadObjectPointerKeys returns the keys, along with their values, which a
+// pointer to the given object carried without Git LFS knowing them, or an empty
+// map if none were remembered.
+func (f *Filesystem) ReadObjectPointerKeys(oid string) (map[string]string, error) {
+	return readSidecar(f.ObjectPointerKeysPathname(oid))
+}
+
+// WriteObjectPointerKeys remembers the given keys of a pointer to the object,
+// so that they can be written into a pointer to it again, as when the file it
+// was checked out into is added back. An empty map forgets them.
+func (f *Filesystem) WriteObjectPointerKeys(oid string, keys map[string]string) error {
+	return f.writeSidecar(f.ObjectPointerKeysPathname(oid), oid+"-pointer-keys", keys)
+}
@@ -0,0 +1,33 @@
+package fs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObjectPointerKeysRoundTrip(t *testing.T) {
+	f := newMetadataTestFilesystem(t)
+	defer os.RemoveAll(f.LFSStorageDir)
+
+	keys, err := f.ReadObjectPointerKeys(metadataTestOid)
+	require.Nil(t, err)
+	assert.Empty(t, keys)
+
+	require.Nil(t, f.WriteObjectPointerKeys(metadataTestOid, map[string]string{"review": "approved"}))
+
+	keys, err = f.ReadObjectPointerKeys(metadataTestOid)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]string{"review": "approved"}, keys)
+
+	// Kept apart from the object's metadata.
+	md, err := f.ReadObjectMetadata(metadataTestOid)
+	require.Nil(t, err)
+	assert.Empty(t, md)
+
+	require.Nil(t, f.WriteObjectPointerKeys(metadataTestOid, nil))
+	_, err = os.Stat(f.ObjectPointerKeysPathname(metadataTestOid))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -61,6 +61,13 @@ func (c *Configuration) FindLocal(key string) string {
 	return output
 }
 
+// FindWorktree returns the git config value for the key in the config of the
+// current worktree
+func (c *Configuration) FindWorktree(key string) string {
+	output, _ := c.gitConfig("--worktree", key)
+	return output
+}
+
 // SetGlobal sets the git config value for the key in the global config
 func (c *Configuration) SetGlobal(key, val string) (string, error) {
 	return c.gitConfig("--global", "--replace-all", key, val)
@@ -91,6 +98,18 @@ func (c *Configuration) SetLocal(key, val string) (string, error) {
 	return c.gitConfig("--replace-all", key, val)
 }
 
+// SetWorktree sets the git config value for the key in the config of the
+// current worktree
+func (c *Configuration) SetWorktree(key, val string) (string, error) {
+	return c.gitConfig("--worktree", "--replace-all", key, val)
+}
+
+// UnsetWorktreeSection removes the entire named section from the config of
+// the current worktree
+func (c *Configuration) UnsetWorktreeSection(key string) (string, error) {
+	return c.gitConfig("--worktree", "--remove-section", key)
+}
+
 // UnsetLocalKey removes the git config value for the key from the specified config file
 func (c *Configuration) UnsetLocalKey(key string) (string, error) {
 	return c.gitConfig("--unset", key)
@@ -12,10 +12,12 @@ import (
 	"io/ioutil"
 	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	lfserrors "github.com/git-lfs/git-lfs/errors"
@@ -373,7 +375,23 @@ func LocalRefs() ([]*Ref, error) {
 		refs = append(refs, &Ref{name, rtype, parts[0]})
 	}
 
-	return refs, cmd.Wait()
+	if err := cmd.Wait(); err != nil && !showRefFoundNothing(err) {
+		return refs, err
+	}
+	return refs, nil
+}
+
+// showRefFoundNothing returns whether the error "err" from "git show-ref" only
+// means that there were no refs to show, as in a repository which has fetched
+// a single commit without creating any branches, e.g. in a CI job.
+func showRefFoundNothing(err error) bool {
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return false
+	}
+
+	status, ok := exitErr.Sys().(syscall.WaitStatus)
+	return ok && status.ExitStatus() == 1
 }
 
 // UpdateRef moves the given ref to a new sha with a given reason (and creates a
@@ -597,7 +615,14 @@ func GitAndRootDirs() (string, string, error) {
 	out, err := cmd.Output()
 	output := string(out)
 	if err != nil {
-		return "", "", fmt.Errorf("Failed to call git rev-parse --git-dir --show-toplevel: %q", buf.String())
+		// Newer versions of Git refuse to show the top-level directory
+		// of a repository without a work tree, such as a bare one,
+		// instead of showing nothing.
+		gitDir, gerr := gitNoLFSSimple("rev-parse", "--git-dir")
+		if gerr != nil {
+			return "", "", fmt.Errorf("Failed to call git rev-parse --git-dir --show-toplevel: %q", buf.String())
+		}
+		output = gitDir
 	}
 
 	paths := strings.Split(output, "\n")
@@ -732,6 +757,36 @@ func IsBare() (bool, error) {
 	return strconv.ParseBool(s)
 }
 
+// IsPartialClone returns whether or not the current repository is a partial
+// clone, i.e., whether it has a promisor remote from which Git fetches missing
+// objects on demand. Any error running git-config(1) is treated as false.
+//
+// It is only used to skip blobs missing from such a clone when scanning; its
+// filter spec is neither read nor changed, and missing pointers are not
+// hydrated by Git LFS, see "PARTIAL CLONES" in git-lfs-clone(1).
+func IsPartialClone() bool {
+	out, err := gitNoLFSSimple("config", "--get-regexp",
+		`^(extensions\.partialclone|remote\..*\.promisor)$`)
+	if err != nil {
+		return false
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		if fields[0] == "extensions.partialclone" {
+			return true
+		}
+		if promisor, _ := strconv.ParseBool(fields[1]); promisor {
+			return true
+		}
+	}
+	return false
+}
+
 // For compatibility with git clone we must mirror all flags in CloneWithoutFilters
 type CloneFlags struct {
 	// --template <template_directory>
@@ -965,7 +1020,11 @@ func CachedRemoteRefs(remoteName string) ([]*Ref, error) {
 			ret = append(ret, &Ref{name, RefTypeRemoteBranch, sha})
 		}
 	}
-	return ret, cmd.Wait()
+
+	if err := cmd.Wait(); err != nil && !showRefFoundNothing(err) {
+		return ret, err
+	}
+	return ret, nil
 }
 
 // Fetch performs a fetch with no arguments against the given remotes.
@@ -1185,3 +1244,75 @@ func IsFileModified(filepath string) (bool, error) {
 
 	return matched, nil
 }
+
+// IsWorkingCopyDirty returns whether any tracked file has uncommitted changes
+// in the working copy or the index, according to `git status`. Untracked files
+// are ignored.
+func IsWorkingCopyDirty() (bool, error) {
+	out, err := gitSimple("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return false, lfserrors.Wrap(err, "Git status failed")
+	}
+	return len(strings.TrimSpace(out)) > 0, nil
+}
+
+// IndexEntry is a file in the index, along with the SHA-1 of its blob.
+type IndexEntry struct {
+	Sha1 string
+	Path string
+}
+
+// IndexFilesWithAttribute returns the files in the index whose gitattribute
+// "attr" is set to "value", with paths relative to the root of the repository.
+func IndexFilesWithAttribute(attr, value string) ([]*IndexEntry, error) {
+	root, err := RootDir()
+	if err != nil {
+		return nil, err
+	}
+
+	lsFiles := gitNoLFS("ls-files", "--cached", "--stage", "-z")
+	lsFiles.Dir = root
+	out, err := lsFiles.Output()
+	if err != nil {
+		return nil, lfserrors.Wrap(err, "Git ls-files failed")
+	}
+
+	// ls-files --stage -z prints "<mode> <sha1> <stage> TAB <path> NUL" for
+	// each file, and each stage of a file with conflicts.
+	entries := make(map[string]*IndexEntry)
+	var paths bytes.Buffer
+	for _, line := range strings.Split(string(out), "\x00") {
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) < 2 {
+			continue
+		}
+		info := strings.Fields(parts[0])
+		if len(info) < 3 || !strings.HasPrefix(info[0], "100") {
+			continue
+		}
+		if _, ok := entries[parts[1]]; !ok {
+			paths.WriteString(parts[1])
+			paths.WriteByte(0)
+		}
+		entries[parts[1]] = &IndexEntry{Sha1: info[1], Path: parts[1]}
+	}
+
+	checkAttr := gitNoLFS("check-attr", "-z", "--stdin", attr)
+	checkAttr.Dir = root
+	checkAttr.Stdin = &paths
+	attrs, err := checkAttr.Output()
+	if err != nil {
+		return nil, lfserrors.Wrap(err, "Git check-attr failed")
+	}
+
+	// check-attr -z prints "<path> NUL <attribute> NUL <value> NUL" for
+	// each path.
+	fields := strings.Split(string(attrs), "\x00")
+	var matches []*IndexEntry
+	for i := 0; i+2 < len(fields); i += 3 {
+		if e, ok := entries[fields[i]]; ok && fields[i+2] == value {
+			matches = append(matches, e)
+		}
+	}
+	return matches, nil
+}
@@ -528,6 +528,30 @@ func TestLocalRefs(t *testing.T) {
 	}
 }
 
+func TestLocalRefsWithoutBranchesOrTags(t *testing.T) {
+	repo := test.NewRepo(t)
+	repo.Pushd()
+	defer func() {
+		repo.Popd()
+		repo.Cleanup()
+	}()
+
+	repo.AddCommits([]*test.CommitInput{
+		{
+			Files: []*test.FileInput{
+				{Filename: "file1.txt", Size: 20},
+			},
+		},
+	})
+
+	test.RunGitCommand(t, true, "checkout", "--detach")
+	test.RunGitCommand(t, true, "branch", "-D", "master")
+
+	refs, err := LocalRefs()
+	assert.Nil(t, err)
+	assert.Empty(t, refs)
+}
+
 func TestGetFilesChanges(t *testing.T) {
 	repo := test.NewRepo(t)
 	repo.Pushd()
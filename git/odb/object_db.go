@@ -230,20 +230,23 @@ func (o *ObjectDatabase) open(sha []byte) (*ObjectReader, error) {
 			return nil, err
 		}
 
-		unpacked, err := packed.Unpack()
+		contents, size, err := packed.Reader()
 		if err != nil {
 			return nil, err
 		}
 
-		return NewUncompressedObjectReader(io.MultiReader(
-			// Git object header:
-			strings.NewReader(fmt.Sprintf("%s %d\x00",
-				packed.Type(), len(unpacked),
-			)),
-
-			// Git object (uncompressed) contents:
-			bytes.NewReader(unpacked),
-		))
+		return NewUncompressedObjectReadCloser(&packedObjectReader{
+			Reader: io.MultiReader(
+				// Git object header:
+				strings.NewReader(fmt.Sprintf("%s %d\x00",
+					packed.Type(), size,
+				)),
+
+				// Git object (uncompressed) contents:
+				contents,
+			),
+			contents: contents,
+		})
 	}
 
 	return NewObjectReadCloser(f)
@@ -279,3 +282,15 @@ func (o *ObjectDatabase) decode(sha []byte, into Object) error {
 	}
 	return r.Close()
 }
+
+// packedObjectReader is an io.ReadCloser yielding a packed object's header
+// and contents, which closes the contents once it is closed.
+type packedObjectReader struct {
+	io.Reader
+	contents io.Closer
+}
+
+// Close implements io.Closer by closing the packed object's contents.
+func (r *packedObjectReader) Close() error {
+	return r.contents.Close()
+}
@@ -43,6 +43,36 @@ func (b *ChainBase) Unpack() ([]byte, error) {
 	return buf, nil
 }
 
+// Reader returns a reader yielding the uncompressed data encoded in the base
+// element, inflating it as it is read, rather than all at once as Unpack does.
+// It must be closed once it has been read.
+func (b *ChainBase) Reader() (io.ReadCloser, error) {
+	zr, err := zlib.NewReader(&OffsetReaderAt{
+		r: b.r,
+		o: b.offset,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &chainBaseReader{
+		Reader: io.LimitReader(zr, b.size),
+		zr:     zr,
+	}, nil
+}
+
+// chainBaseReader is the io.ReadCloser returned by *ChainBase.Reader.
+type chainBaseReader struct {
+	io.Reader
+	zr io.ReadCloser
+}
+
+// Close implements io.Closer by closing the underlying zlib reader.
+func (r *chainBaseReader) Close() error {
+	return r.zr.Close()
+}
+
 // ChainBase returns the type of the object it encodes.
 func (b *ChainBase) Type() PackedObjectType {
 	return b.typ
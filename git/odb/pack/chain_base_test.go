@@ -3,6 +3,7 @@ package pack
 import (
 	"bytes"
 	"compress/zlib"
+	"io/ioutil"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -37,6 +38,28 @@ func TestChainBaseDecompressesData(t *testing.T) {
 	assert.Equal(t, contents, string(unpacked))
 }
 
+func TestChainBaseReaderDecompressesData(t *testing.T) {
+	const contents = "Hello, world!\n"
+
+	compressed, err := compress(contents)
+	assert.NoError(t, err)
+
+	base := &ChainBase{
+		offset: 0,
+		size:   int64(len(contents)),
+
+		r: bytes.NewReader(compressed),
+	}
+
+	r, err := base.Reader()
+	assert.NoError(t, err)
+
+	unpacked, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, contents, string(unpacked))
+	assert.NoError(t, r.Close())
+}
+
 func TestChainBaseTypeReturnsType(t *testing.T) {
 	b := &ChainBase{
 		typ: TypeCommit,
@@ -1,5 +1,11 @@
 package pack
 
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+)
+
 // Object is an encapsulation of an object found in a packfile, or a packed
 // object.
 type Object struct {
@@ -20,6 +26,26 @@ func (o *Object) Unpack() ([]byte, error) {
 	return o.data.Unpack()
 }
 
+// Reader returns a reader yielding the same data as Unpack, along with its
+// size. Objects which are not stored as deltas are inflated as they are read,
+// so that large ones, which Git does not usually delta, need not fit in memory.
+// The reader must be closed once it has been read.
+func (o *Object) Reader() (io.ReadCloser, int64, error) {
+	if base, ok := o.data.(*ChainBase); ok {
+		r, err := base.Reader()
+		if err != nil {
+			return nil, 0, err
+		}
+		return r, base.size, nil
+	}
+
+	unpacked, err := o.Unpack()
+	if err != nil {
+		return nil, 0, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(unpacked)), int64(len(unpacked)), nil
+}
+
 // Type returns the underlying object's type. Rather than the type of the
 // front-most delta-base component, it is the type of the object itself.
 func (o *Object) Type() PackedObjectType {
@@ -1,6 +1,7 @@
 package pack
 
 import (
+	"io/ioutil"
 	"testing"
 
 	"github.com/git-lfs/git-lfs/errors"
@@ -45,3 +46,36 @@ func TestObjectUnpackPropogatesErrors(t *testing.T) {
 	assert.Nil(t, data)
 	assert.Equal(t, expected, err)
 }
+
+func TestObjectReaderReadsUnpackedData(t *testing.T) {
+	expected := []byte{0x1, 0x2, 0x3, 0x4}
+
+	o := &Object{
+		data: &ChainSimple{
+			X: expected,
+		},
+	}
+
+	r, size, err := o.Reader()
+	assert.NoError(t, err)
+	assert.EqualValues(t, len(expected), size)
+
+	data, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, data)
+}
+
+func TestObjectReaderPropogatesErrors(t *testing.T) {
+	expected := errors.New("git/odb/pack: testing")
+
+	o := &Object{
+		data: &ChainSimple{
+			Err: expected,
+		},
+	}
+
+	r, _, err := o.Reader()
+
+	assert.Nil(t, r)
+	assert.Equal(t, expected, err)
+}
@@ -98,6 +98,11 @@ type ScanRefsOptions struct {
 	// order.
 	Reverse bool
 
+	// AllowPromisorMissing specifies whether or not objects missing from
+	// a partial clone, but available from one of its promisor remotes,
+	// should be silently omitted rather than fetched on demand.
+	AllowPromisorMissing bool
+
 	// SkippedRefs provides a list of refs to ignore.
 	SkippedRefs []string
 	// Mutex guards names.
@@ -238,6 +243,10 @@ func revListArgs(include, exclude []string, opt *ScanRefsOptions) (io.Reader, []
 		args = append(args, orderFlag)
 	}
 
+	if opt.AllowPromisorMissing {
+		args = append(args, "--missing=allow-promisor")
+	}
+
 	switch opt.Mode {
 	case ScanRefsMode:
 		if opt.SkipDeletedBlobs {
@@ -153,6 +153,14 @@ func TestRevListArgs(t *testing.T) {
 			ExpectedStdin: fmt.Sprintf("%s\n^%s", s1, s2),
 			ExpectedArgs:  []string{"rev-list", "--stdin", "--objects", "--reverse", "--do-walk", "--"},
 		},
+		"scan allowing promisor missing": {
+			Include: []string{s1}, Exclude: []string{s2}, Opt: &ScanRefsOptions{
+				Mode:                 ScanRefsMode,
+				AllowPromisorMissing: true,
+			},
+			ExpectedStdin: fmt.Sprintf("%s\n^%s", s1, s2),
+			ExpectedArgs:  []string{"rev-list", "--stdin", "--objects", "--missing=allow-promisor", "--do-walk", "--"},
+		},
 	} {
 		t.Run(desc, c.Assert)
 	}
@@ -24,6 +24,10 @@ type Attribute struct {
 	Properties map[string]string
 	// Previous values of these attributes that can be automatically upgraded
 	Upgradeables map[string][]string
+	// The values which turn this Attribute off, written in place of its
+	// Properties when it is uninstalled from a worktree, so that those set
+	// for the repository, user or system no longer apply to it.
+	Disabled map[string]string
 }
 
 // FilterOptions serves as an argument to Install().
@@ -31,6 +35,7 @@ type FilterOptions struct {
 	GitConfig  *git.Configuration
 	Force      bool
 	Local      bool
+	Worktree   bool
 	System     bool
 	SkipSmudge bool
 }
@@ -57,6 +62,7 @@ func filterAttribute() *Attribute {
 			"required": "true",
 		},
 		Upgradeables: upgradeables(),
+		Disabled:     disabledFilter(),
 	}
 }
 
@@ -70,6 +76,7 @@ func skipSmudgeFilterAttribute() *Attribute {
 			"required": "true",
 		},
 		Upgradeables: upgradeables(),
+		Disabled:     disabledFilter(),
 	}
 }
 
@@ -91,6 +98,15 @@ func upgradeables() map[string][]string {
 	}
 }
 
+func disabledFilter() map[string]string {
+	return map[string]string{
+		"clean":    "",
+		"smudge":   "",
+		"process":  "",
+		"required": "false",
+	}
+}
+
 // Install instructs Git to set all keys and values relative to the root
 // location of this Attribute. For any particular key/value pair, if a matching
 // key is already set, it will be overridden if it is either a) empty, or b) the
@@ -104,6 +120,11 @@ func (a *Attribute) Install(opt *FilterOptions) error {
 			// use pre-normalised key since caller will have set up the same
 			upgradeables = a.Upgradeables[k]
 		}
+		if disabled, ok := a.Disabled[k]; ok && opt.Worktree {
+			// Installing again in a worktree it was uninstalled
+			// from replaces the values which turn it off.
+			upgradeables = append([]string{disabled}, upgradeables...)
+		}
 		key := a.normalizeKey(k)
 		if err := a.set(opt.GitConfig, key, v, upgradeables, opt); err != nil {
 			return err
@@ -127,6 +148,8 @@ func (a *Attribute) set(gitConfig *git.Configuration, key, value string, upgrade
 	var currentValue string
 	if opt.Local {
 		currentValue = gitConfig.FindLocal(key)
+	} else if opt.Worktree {
+		currentValue = gitConfig.FindWorktree(key)
 	} else if opt.System {
 		currentValue = gitConfig.FindSystem(key)
 	} else {
@@ -137,6 +160,8 @@ func (a *Attribute) set(gitConfig *git.Configuration, key, value string, upgrade
 		var err error
 		if opt.Local {
 			_, err = gitConfig.SetLocal(key, value)
+		} else if opt.Worktree {
+			_, err = gitConfig.SetWorktree(key, value)
 		} else if opt.System {
 			_, err = gitConfig.SetSystem(key, value)
 		} else {
@@ -151,10 +176,17 @@ func (a *Attribute) set(gitConfig *git.Configuration, key, value string, upgrade
 	return nil
 }
 
-// Uninstall removes all properties in the path of this property.
+// Uninstall removes all properties in the path of this property. In a worktree,
+// where the properties set for the repository, user or system would still
+// apply, they are instead replaced with its Disabled values.
 func (a *Attribute) Uninstall(opt *FilterOptions) {
 	if opt.Local {
 		opt.GitConfig.UnsetLocalSection(a.Section)
+	} else if opt.Worktree {
+		opt.GitConfig.UnsetWorktreeSection(a.Section)
+		for k, v := range a.Disabled {
+			opt.GitConfig.SetWorktree(a.normalizeKey(k), v)
+		}
 	} else if opt.System {
 		opt.GitConfig.UnsetSystemSection(a.Section)
 	} else {
@@ -0,0 +1,226 @@
+package lfs
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/fs"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/rubyist/tracerx"
+)
+
+// FetchCheckpoint records the progress of fetching objects from a remote in
+// the "fetch-checkpoint" file of the repository's own .git/lfs directory: the
+// objects still to be downloaded, and those which have been. A fetch which is
+// interrupted, e.g. by a laptop going to sleep half way through a large clone,
+// leaves it behind, so that the next fetch from the same remote can tell how
+// far the last one got, and skip the objects it completed.
+//
+// It is not kept in the LFS storage directory, since lfs.storage can point
+// several repositories at the same one, and each has its own fetches.
+//
+// The file is a list of lines, i.e.:
+//
+//   remote origin
+//   pending <oid> <size>
+//   done <oid>
+//
+// which is appended to as the fetch goes on, and removed once one finishes
+// without errors.
+//
+// Recording progress is best effort: failing to do so never fails the fetch.
+type FetchCheckpoint struct {
+	path   string
+	remote string
+
+	mu      sync.Mutex
+	f       *os.File
+	pending map[string]int64
+	done    tools.StringSet
+	resumed bool
+}
+
+// OpenFetchCheckpoint returns the checkpoint of fetching objects from the given
+// remote, resuming from the one left behind by an interrupted fetch from the
+// same remote, if any.
+func OpenFetchCheckpoint(f *fs.Filesystem, remote string) *FetchCheckpoint {
+	c := &FetchCheckpoint{
+		path:    filepath.Join(f.RepoLFSDir(), "fetch-checkpoint"),
+		remote:  remote,
+		pending: make(map[string]int64),
+		done:    tools.NewStringSet(),
+	}
+
+	if err := c.load(); err != nil {
+		tracerx.Printf("fetch checkpoint: unable to read %q: %s", c.path, err)
+	}
+
+	flags := os.O_APPEND | os.O_CREATE | os.O_WRONLY
+	if !c.resumed {
+		// Start over, rather than appending to the checkpoint of a
+		// fetch from another remote.
+		flags |= os.O_TRUNC
+		c.pending = make(map[string]int64)
+		c.done = tools.NewStringSet()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		tracerx.Printf("fetch checkpoint: %s", err)
+		return c
+	}
+
+	file, err := os.OpenFile(c.path, flags, 0644)
+	if err != nil {
+		tracerx.Printf("fetch checkpoint: unable to open %q: %s", c.path, err)
+		return c
+	}
+	c.f = file
+
+	if !c.resumed {
+		c.write(fmt.Sprintf("remote %s", remote))
+	}
+	return c
+}
+
+// load reads the checkpoint left behind by an earlier fetch, if it was from the
+// same remote.
+func (c *FetchCheckpoint) load() error {
+	f, err := os.Open(c.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		switch fields[0] {
+		case "remote":
+			if fields[1] != c.remote {
+				return nil
+			}
+			c.resumed = true
+		case "pending":
+			if len(fields) < 3 {
+				continue
+			}
+			size, err := strconv.ParseInt(fields[2], 10, 64)
+			if err != nil {
+				continue
+			}
+			c.pending[fields[1]] = size
+			c.done.Remove(fields[1])
+		case "done":
+			c.done.Add(fields[1])
+		}
+	}
+	return scanner.Err()
+}
+
+// FetchProgress is how far a fetch got, in objects and bytes.
+type FetchProgress struct {
+	Objects     int
+	DoneObjects int
+	Bytes       int64
+	DoneBytes   int64
+}
+
+// Resumed returns how far an earlier, interrupted fetch from the same remote
+// got with the objects it was fetching, or nil if there was none.
+func (c *FetchCheckpoint) Resumed() *FetchProgress {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.resumed {
+		return nil
+	}
+
+	p := &FetchProgress{}
+	for oid, size := range c.pending {
+		p.Objects++
+		p.Bytes += size
+		if c.done.Contains(oid) {
+			p.DoneObjects++
+			p.DoneBytes += size
+		}
+	}
+	return p
+}
+
+// Done returns whether the object with the given OID has been downloaded by
+// this fetch, or by an interrupted one which it resumes.
+func (c *FetchCheckpoint) Done(oid string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.done.Contains(oid)
+}
+
+// Pending records that the object with the given OID and size is to be
+// downloaded.
+func (c *FetchCheckpoint) Pending(oid string, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.pending[oid]; ok && !c.done.Contains(oid) {
+		// Already pending, e.g. since the interrupted fetch.
+		return
+	}
+
+	c.pending[oid] = size
+	c.done.Remove(oid)
+	c.write(fmt.Sprintf("pending %s %d", oid, size))
+}
+
+// Complete records that the object with the given OID has been downloaded.
+func (c *FetchCheckpoint) Complete(oid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.done.Contains(oid) {
+		return
+	}
+
+	c.done.Add(oid)
+	c.write(fmt.Sprintf("done %s", oid))
+}
+
+// Close closes the checkpoint, removing it if the fetch finished without
+// errors, so that the next one starts afresh.
+func (c *FetchCheckpoint) Close(success bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.f != nil {
+		c.f.Close()
+		c.f = nil
+	}
+
+	if success {
+		if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+			tracerx.Printf("fetch checkpoint: unable to remove %q: %s", c.path, err)
+		}
+	}
+}
+
+func (c *FetchCheckpoint) write(line string) {
+	if c.f == nil {
+		return
+	}
+
+	if _, err := c.f.WriteString(line + "\n"); err != nil {
+		tracerx.Printf("fetch checkpoint: unable to write to %q: %s", c.path, err)
+	}
+}
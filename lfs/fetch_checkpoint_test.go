@@ -0,0 +1,123 @@
+package lfs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/fs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchCheckpointResumesInterruptedFetch(t *testing.T) {
+	f := newFetchCheckpointFilesystem(t)
+	defer os.RemoveAll(f.GitStorageDir)
+
+	c := OpenFetchCheckpoint(f, "origin")
+	assert.Nil(t, c.Resumed())
+	c.Pending("oid1", 10)
+	c.Pending("oid2", 20)
+	c.Complete("oid1")
+	c.Close(false)
+
+	c = OpenFetchCheckpoint(f, "origin")
+	defer c.Close(true)
+
+	p := c.Resumed()
+	require.NotNil(t, p)
+	assert.Equal(t, &FetchProgress{
+		Objects:     2,
+		DoneObjects: 1,
+		Bytes:       30,
+		DoneBytes:   10,
+	}, p)
+	assert.True(t, c.Done("oid1"))
+	assert.False(t, c.Done("oid2"))
+}
+
+func TestFetchCheckpointFromOtherRemoteStartsOver(t *testing.T) {
+	f := newFetchCheckpointFilesystem(t)
+	defer os.RemoveAll(f.GitStorageDir)
+
+	c := OpenFetchCheckpoint(f, "origin")
+	c.Pending("oid1", 10)
+	c.Complete("oid1")
+	c.Close(false)
+
+	c = OpenFetchCheckpoint(f, "upstream")
+	assert.Nil(t, c.Resumed())
+	assert.False(t, c.Done("oid1"))
+	c.Close(false)
+
+	c = OpenFetchCheckpoint(f, "origin")
+	defer c.Close(true)
+	assert.Nil(t, c.Resumed())
+}
+
+func TestFetchCheckpointPendingAgainAfterDone(t *testing.T) {
+	f := newFetchCheckpointFilesystem(t)
+	defer os.RemoveAll(f.GitStorageDir)
+
+	c := OpenFetchCheckpoint(f, "origin")
+	c.Pending("oid1", 10)
+	c.Complete("oid1")
+	c.Pending("oid1", 10)
+	c.Close(false)
+
+	c = OpenFetchCheckpoint(f, "origin")
+	defer c.Close(true)
+	assert.False(t, c.Done("oid1"))
+}
+
+func TestFetchCheckpointRemovedOnSuccess(t *testing.T) {
+	f := newFetchCheckpointFilesystem(t)
+	defer os.RemoveAll(f.GitStorageDir)
+
+	c := OpenFetchCheckpoint(f, "origin")
+	c.Pending("oid1", 10)
+	c.Complete("oid1")
+	c.Close(true)
+
+	_, err := os.Stat(filepath.Join(f.RepoLFSDir(), "fetch-checkpoint"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFetchCheckpointIsNotSharedWithStorage(t *testing.T) {
+	storage, err := ioutil.TempDir("", "lfs-fetch-checkpoint-storage")
+	require.Nil(t, err)
+	defer os.RemoveAll(storage)
+
+	a := newFetchCheckpointFilesystem(t)
+	defer os.RemoveAll(a.GitStorageDir)
+	a = fs.New(a.GitStorageDir, a.GitStorageDir, storage, nil)
+
+	b := newFetchCheckpointFilesystem(t)
+	defer os.RemoveAll(b.GitStorageDir)
+	b = fs.New(b.GitStorageDir, b.GitStorageDir, storage, nil)
+
+	c := OpenFetchCheckpoint(a, "origin")
+	c.Pending("oid1", 10)
+	c.Close(false)
+
+	// Another repository using the same storage starts over, and leaves
+	// the checkpoint of the first one alone.
+	c = OpenFetchCheckpoint(b, "origin")
+	assert.Nil(t, c.Resumed())
+	c.Close(true)
+
+	c = OpenFetchCheckpoint(a, "origin")
+	defer c.Close(true)
+	assert.NotNil(t, c.Resumed())
+
+	_, err = os.Stat(filepath.Join(storage, "fetch-checkpoint"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func newFetchCheckpointFilesystem(t *testing.T) *fs.Filesystem {
+	dir, err := ioutil.TempDir("", "lfs-fetch-checkpoint")
+	require.Nil(t, err)
+
+	return fs.New(dir, dir, "", nil)
+}
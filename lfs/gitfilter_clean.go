@@ -2,7 +2,6 @@ package lfs
 
 import (
 	"bytes"
-	"crypto/sha256"
 	"encoding/hex"
 	"io"
 	"io/ioutil"
@@ -10,8 +9,13 @@ import (
 
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/tools"
+	"github.com/rubyist/tracerx"
 )
 
+// cleanBufferSize is the size of the chunks in which the clean filter reads
+// files, hashing each chunk while writing it to the object store.
+const cleanBufferSize = 1024 * 1024
+
 type cleanedAsset struct {
 	Filename string
 	*Pointer
@@ -23,6 +27,7 @@ func (f *GitFilter) Clean(reader io.Reader, fileName string, fileSize int64, cb
 		return nil, err
 	}
 
+	var algo string
 	var oid string
 	var size int64
 	var tmp *os.File
@@ -50,17 +55,34 @@ func (f *GitFilter) Clean(reader io.Reader, fileName string, fileSize int64, cb
 			}
 		}
 	} else {
-		oid, size, tmp, err = f.copyToTemp(reader, fileSize, cb)
+		algo = f.cfg.HashAlgorithm()
+		oid, size, tmp, err = f.copyToTemp(reader, algo, fileSize, cb)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	pointer := NewPointer(oid, size, exts)
+	if len(algo) > 0 {
+		pointer.OidType = algo
+	}
+
+	// Restore the keys of the pointer the file was checked out from, if
+	// they were remembered when it was smudged.
+	if keys, err := f.fs.ReadObjectPointerKeys(oid); err != nil {
+		tracerx.Printf("Unable to read the pointer keys of %s: %v", oid, err)
+	} else if len(keys) > 0 {
+		pointer.Extra = keys
+	}
 	return &cleanedAsset{tmp.Name(), pointer}, err
 }
 
-func (f *GitFilter) copyToTemp(reader io.Reader, fileSize int64, cb tools.CopyCallback) (oid string, size int64, tmp *os.File, err error) {
+func (f *GitFilter) copyToTemp(reader io.Reader, algo string, fileSize int64, cb tools.CopyCallback) (oid string, size int64, tmp *os.File, err error) {
+	oidHash, err := tools.NewContentHash(algo)
+	if err != nil {
+		return
+	}
+
 	tmp, err = ioutil.TempFile(f.cfg.TempDir(), "")
 	if err != nil {
 		return
@@ -68,9 +90,6 @@ func (f *GitFilter) copyToTemp(reader io.Reader, fileSize int64, cb tools.CopyCa
 
 	defer tmp.Close()
 
-	oidHash := sha256.New()
-	writer := io.MultiWriter(oidHash, tmp)
-
 	if fileSize == 0 {
 		cb = nil
 	}
@@ -93,7 +112,11 @@ func (f *GitFilter) copyToTemp(reader io.Reader, fileSize int64, cb tools.CopyCa
 		from = io.MultiReader(from, reader)
 	}
 
-	size, err = tools.CopyWithCallback(writer, from, fileSize, cb)
+	if cb != nil {
+		from = &tools.CallbackReader{C: cb, TotalSize: fileSize, Reader: from}
+	}
+
+	size, err = tools.ConcurrentCopy(from, cleanBufferSize, oidHash, tmp)
 
 	if err != nil {
 		return
@@ -15,8 +15,11 @@ import (
 )
 
 func (f *GitFilter) SmudgeToFile(filename string, ptr *Pointer, download bool, manifest *tq.Manifest, cb tools.CopyCallback) error {
-	os.MkdirAll(filepath.Dir(filename), 0755)
-	file, err := os.Create(filename)
+	// Working tree paths are relative, and so are not extended by Go
+	// itself when they are too long for Windows.
+	path := tools.LongPath(filename)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	file, err := os.Create(path)
 	if err != nil {
 		return fmt.Errorf("Could not create working directory file: %v", err)
 	}
@@ -68,6 +71,14 @@ func (f *GitFilter) Smudge(writer io.Writer, ptr *Pointer, workingfile string, d
 		return 0, errors.NewSmudgeError(err, ptr.Oid, mediafile)
 	}
 
+	if len(ptr.Extra) > 0 {
+		// Remember the keys that Git LFS doesn't know, so that they
+		// are written back into the pointer when the file is cleaned.
+		if err := f.fs.WriteObjectPointerKeys(ptr.Oid, ptr.Extra); err != nil {
+			tracerx.Printf("Unable to remember the pointer keys of %s: %v", ptr.Oid, err)
+		}
+	}
+
 	return n, nil
 }
 
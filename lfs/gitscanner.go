@@ -3,11 +3,12 @@ package lfs
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/git-lfs/git-lfs/filepathfilter"
-	"github.com/rubyist/tracerx"
+	"github.com/git-lfs/git-lfs/tools"
 )
 
 var missingCallbackErr = errors.New("No callback given")
@@ -56,7 +57,7 @@ func (s *GitScanner) Close() {
 	}
 
 	s.closed = true
-	tracerx.PerformanceSince("scan", s.started)
+	tools.PerformanceSince("scan", s.started)
 }
 
 // RemoteForPush sets up this *GitScanner to scan for objects to push to the
@@ -77,6 +78,14 @@ func (s *GitScanner) RemoteForPush(r string) error {
 // ScanLeftToRemote scans through all commits starting at the given ref that the
 // given remote does not have. See RemoteForPush().
 func (s *GitScanner) ScanLeftToRemote(left string, cb GitScannerFoundPointer) error {
+	return s.ScanMultiLeftToRemote([]string{left}, nil, cb)
+}
+
+// ScanMultiLeftToRemote is like ScanLeftToRemote, but scans through all commits
+// starting at any of the given refs in a single pass, so that history they
+// share is only scanned once. Commits reachable from any of the "exclude" refs
+// are skipped, as well.
+func (s *GitScanner) ScanMultiLeftToRemote(lefts, exclude []string, cb GitScannerFoundPointer) error {
 	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
 	if err != nil {
 		return err
@@ -85,11 +94,11 @@ func (s *GitScanner) ScanLeftToRemote(left string, cb GitScannerFoundPointer) er
 	s.mu.Lock()
 	if len(s.remote) == 0 {
 		s.mu.Unlock()
-		return fmt.Errorf("Unable to scan starting at %q: no remote set.", left)
+		return fmt.Errorf("Unable to scan starting at %q: no remote set.", strings.Join(lefts, ", "))
 	}
 	s.mu.Unlock()
 
-	return scanRefsToChan(s, callback, left, "", s.opts(ScanLeftToRemoteMode))
+	return scanMultiRefsToChan(s, callback, lefts, exclude, s.opts(ScanLeftToRemoteMode))
 }
 
 // ScanRefRange scans through all commits from the given left and right refs,
@@ -105,6 +114,20 @@ func (s *GitScanner) ScanRefRange(left, right string, cb GitScannerFoundPointer)
 	return scanRefsToChan(s, callback, left, right, opts)
 }
 
+// ScanRefs scans through all commits reachable from any of the "include" refs
+// and from none of the "exclude" refs, including git objects that have been
+// modified or deleted.
+func (s *GitScanner) ScanRefs(include, exclude []string, cb GitScannerFoundPointer) error {
+	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
+	if err != nil {
+		return err
+	}
+
+	opts := s.opts(ScanRefsMode)
+	opts.SkipDeletedBlobs = false
+	return scanMultiRefsToChan(s, callback, include, exclude, opts)
+}
+
 // ScanRefWithDeleted scans through all objects in the given ref, including
 // git objects that have been modified or deleted.
 func (s *GitScanner) ScanRefWithDeleted(ref string, cb GitScannerFoundPointer) error {
@@ -157,6 +180,37 @@ func (s *GitScanner) ScanUnpushed(remote string, cb GitScannerFoundPointer) erro
 	return scanUnpushed(callback, remote)
 }
 
+// ScanLogLeftToRemote scans through all commits starting at the given refs
+// that the remote does not have, like ScanMultiLeftToRemote, but with git log,
+// so that each pointer is reported with the commit that added it. Pointers are
+// reported oldest first, once for each commit adding them. See RemoteForPush().
+func (s *GitScanner) ScanLogLeftToRemote(lefts, exclude []string, cb GitScannerFoundPointer) error {
+	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	remote, skippedRefs := s.remote, s.skippedRefs
+	s.mu.Unlock()
+
+	if len(remote) == 0 {
+		return fmt.Errorf("Unable to scan starting at %q: no remote set.", strings.Join(lefts, ", "))
+	}
+	return logLeftToRemote(callback, lefts, exclude, remote, skippedRefs)
+}
+
+// ScanLogRefs scans through all commits reachable from any of the given refs
+// with git log, reporting each pointer with the commit that added it, oldest
+// first.
+func (s *GitScanner) ScanLogRefs(refs []string, cb GitScannerFoundPointer) error {
+	callback, err := firstGitScannerCallback(cb, s.FoundPointer)
+	if err != nil {
+		return err
+	}
+	return logLeftToRemote(callback, refs, nil, "", nil)
+}
+
 // ScanPreviousVersions scans changes reachable from ref (commit) back to since.
 // Returns channel of pointers for *previous* versions that overlap that time.
 // Does not include pointers which were still in use at ref (use ScanRefsToChan
@@ -213,26 +267,8 @@ type ScanRefsOptions struct {
 	RemoteName       string
 	SkipDeletedBlobs bool
 	skippedRefs      []string
-	nameMap          map[string]string
-	mutex            *sync.Mutex
-}
-
-func (o *ScanRefsOptions) GetName(sha string) (string, bool) {
-	o.mutex.Lock()
-	name, ok := o.nameMap[sha]
-	o.mutex.Unlock()
-	return name, ok
-}
-
-func (o *ScanRefsOptions) SetName(sha, name string) {
-	o.mutex.Lock()
-	o.nameMap[sha] = name
-	o.mutex.Unlock()
 }
 
 func newScanRefsOptions() *ScanRefsOptions {
-	return &ScanRefsOptions{
-		nameMap: make(map[string]string, 0),
-		mutex:   &sync.Mutex{},
-	}
+	return &ScanRefsOptions{}
 }
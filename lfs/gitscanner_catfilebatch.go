@@ -11,7 +11,8 @@ import (
 
 // runCatFileBatch uses 'git cat-file --batch' to get the object contents of a
 // git object, given its sha1. The contents will be decoded into a Git LFS
-// pointer. Git Blob SHA1s are read from the sha1Ch channel and fed to STDIN.
+// pointer. Git Blob SHA1s, optionally followed by the blob's name, are read
+// from the revs channel and fed to STDIN.
 // Results are parsed from STDOUT, and any eligible LFS pointers are sent to
 // pointerCh. If a Git Blob is not an LFS pointer, check the lockableSet to see
 // if that blob is for a locked file. Any errors are sent to errCh. An error is
@@ -24,14 +25,18 @@ func runCatFileBatch(pointerCh chan *WrappedPointer, lockableCh chan string, loc
 
 	go func() {
 		for r := range revs.Results {
-			canScan := scanner.Scan(r)
+			sha, name := splitRev(r)
+			canScan := scanner.Scan(sha)
 
 			if err := scanner.Err(); err != nil {
 				errCh <- err
 			} else if p := scanner.Pointer(); p != nil {
+				if len(name) > 0 {
+					p.Name = name
+				}
 				pointerCh <- p
 			} else if b := scanner.BlobSHA(); len(b) == 40 {
-				if name, ok := lockableSet.Check(b); ok {
+				if name, ok := lockableSet.Check(name); ok {
 					lockableCh <- name
 				}
 			}
@@ -12,8 +12,9 @@ import (
 // runCatFileBatchCheck uses 'git cat-file --batch-check' to get the type and
 // size of a git object. Any object that isn't of type blob and under the
 // blobSizeCutoff will be ignored, unless it's a locked file. revs is a channel
-// over which strings containing git sha1s will be sent. It returns a channel
-// from which sha1 strings can be read.
+// over which strings containing git sha1s, optionally followed by the object's
+// name, will be sent. The small blobs are sent on to smallRevCh as they were
+// received, names included.
 func runCatFileBatchCheck(smallRevCh chan string, lockableCh chan string, lockableSet *lockableNameSet, revs *StringChannelWrapper, errCh chan error) error {
 	cmd, err := git.CatFile()
 	if err != nil {
@@ -23,14 +24,15 @@ func runCatFileBatchCheck(smallRevCh chan string, lockableCh chan string, lockab
 	go func() {
 		scanner := &catFileBatchCheckScanner{s: bufio.NewScanner(cmd.Stdout), limit: blobSizeCutoff}
 		for r := range revs.Results {
-			cmd.Stdin.Write([]byte(r + "\n"))
+			sha, name := splitRev(r)
+			cmd.Stdin.Write([]byte(sha + "\n"))
 			hasNext := scanner.Scan()
 			if err := scanner.Err(); err != nil {
 				errCh <- err
 			} else if b := scanner.LFSBlobOID(); len(b) > 0 {
-				smallRevCh <- b
+				smallRevCh <- revWithName(b, name)
 			} else if b := scanner.GitBlobOID(); len(b) > 0 {
-				if name, ok := lockableSet.Check(b); ok {
+				if name, ok := lockableSet.Check(name); ok {
 					lockableCh <- name
 				}
 			}
@@ -28,7 +28,7 @@ var (
 	// Arguments to append to a git log call which will limit the output to
 	// lfs changes and format the output suitable for parseLogOutput.. method(s)
 	logLfsSearchArgs = []string{
-		"-G", "oid sha256:", // only diffs which include an lfs file SHA change
+		"-G", "oid sha[0-9]*:", // only diffs which include an lfs file SHA change
 		"-p",   // include diff so we can read the SHA
 		"-U12", // Make sure diff context is always big enough to support 10 extension lines to get whole pointer
 		`--format=lfs-commit-sha: %H %P`, // just a predictable commit header we can detect
@@ -87,6 +87,41 @@ func parseScannerLogOutput(cb GitScannerFoundPointer, direction LogDiffDirection
 	}
 }
 
+// logLeftToRemote scans the commits reachable from any of the "include" refs
+// and from none of the "exclude" refs, nor the given remote's, oldest first, so
+// that each pointer is first found in the commit which added it. If remote is
+// empty, no remote refs are excluded. skippedRefs, as given by
+// calcSkippedRefs, are excluded instead of the remote tracking refs, if any.
+func logLeftToRemote(cb GitScannerFoundPointer, include, exclude []string, remote string, skippedRefs []string) error {
+	logArgs := []string{"--reverse"}
+	logArgs = append(logArgs, logLfsSearchArgs...)
+	for _, ref := range include {
+		if len(ref) > 0 && !z40.MatchString(ref) {
+			logArgs = append(logArgs, ref)
+		}
+	}
+	for _, ref := range exclude {
+		if len(ref) > 0 && !z40.MatchString(ref) {
+			logArgs = append(logArgs, "^"+ref)
+		}
+	}
+
+	if len(skippedRefs) > 0 {
+		logArgs = append(logArgs, skippedRefs...)
+	} else if len(remote) > 0 {
+		logArgs = append(logArgs, "--not", fmt.Sprintf("--remotes=%v", remote))
+	}
+	logArgs = append(logArgs, "--")
+
+	cmd, err := git.Log(logArgs...)
+	if err != nil {
+		return err
+	}
+
+	parseScannerLogOutput(cb, LogDiffAdditions, cmd)
+	return nil
+}
+
 // logPreviousVersions scans history for all previous versions of LFS pointers
 // from 'since' up to (but not including) the final state at ref
 func logPreviousSHAs(cb GitScannerFoundPointer, ref string, since time.Time) error {
@@ -132,6 +167,7 @@ type logScanner struct {
 	pointer *WrappedPointer
 
 	pointerData         *bytes.Buffer
+	currentCommit       string
 	currentFilename     string
 	currentFileIncluded bool
 
@@ -155,7 +191,7 @@ func newLogScanner(dir LogDiffDirection, r io.Reader) *logScanner {
 		commitHeaderRegex:    regexp.MustCompile(`^lfs-commit-sha: ([A-Fa-f0-9]{40})(?: ([A-Fa-f0-9]{40}))*`),
 		fileHeaderRegex:      regexp.MustCompile(`diff --git a\/(.+?)\s+b\/(.+)`),
 		fileMergeHeaderRegex: regexp.MustCompile(`diff --cc (.+)`),
-		pointerDataRegex:     regexp.MustCompile(`^([\+\- ])(version https://git-lfs|oid sha256|size|ext-).*$`),
+		pointerDataRegex:     regexp.MustCompile(`^([\+\- ])(version https://git-lfs|oid sha[0-9]+|hash-algo|size|ext-).*$`),
 	}
 }
 
@@ -184,7 +220,7 @@ func (s *logScanner) finishLastPointer() *WrappedPointer {
 	s.pointerData.Reset()
 
 	if err == nil {
-		return &WrappedPointer{Name: s.currentFilename, Pointer: p}
+		return &WrappedPointer{Name: s.currentFilename, Commit: s.currentCommit, Pointer: p}
 	} else {
 		tracerx.Printf("Unable to parse pointer from log: %v", err)
 		return nil
@@ -212,9 +248,12 @@ func (s *logScanner) scan() (*WrappedPointer, bool) {
 		line := s.s.Text()
 
 		if match := s.commitHeaderRegex.FindStringSubmatch(line); match != nil {
-			// Currently we're not pulling out commit groupings, but could if we wanted
-			// This just acts as a delimiter for finishing a multiline pointer
-			if p := s.finishLastPointer(); p != nil {
+			// This acts as a delimiter for finishing a multiline pointer,
+			// which belongs to the commit before
+			p := s.finishLastPointer()
+			s.currentCommit = match[1]
+
+			if p != nil {
 				return p, true
 			}
 		} else if match := s.fileHeaderRegex.FindStringSubmatch(line); match != nil {
@@ -249,7 +288,10 @@ func (s *logScanner) scan() (*WrappedPointer, bool) {
 				changeType := match[1][0]
 
 				// Always include unchanged context lines (normally just the version line)
-				if LogDiffDirection(changeType) == s.dir || changeType == ' ' {
+				// A pointer is never larger than blobSizeCutoff, so
+				// don't hold on to more of a file that only happens to
+				// have lines which look like a pointer's.
+				if (LogDiffDirection(changeType) == s.dir || changeType == ' ') && s.pointerData.Len() <= blobSizeCutoff {
 					// Must skip diff +/- marker
 					s.pointerData.WriteString(line[1:])
 					s.pointerData.WriteString("\n") // newline was stripped off by scanner
@@ -3,6 +3,7 @@ package lfs
 import (
 	"encoding/hex"
 	"regexp"
+	"strings"
 
 	"github.com/git-lfs/git-lfs/git"
 )
@@ -10,43 +11,63 @@ import (
 var z40 = regexp.MustCompile(`\^?0{40}`)
 
 type lockableNameSet struct {
-	opt *ScanRefsOptions
 	set GitScannerSet
 }
 
-// Determines if the given blob sha matches a locked file.
-func (s *lockableNameSet) Check(blobSha string) (string, bool) {
-	if s == nil || s.opt == nil || s.set == nil {
+// Determines if the given blob name matches a locked file.
+func (s *lockableNameSet) Check(name string) (string, bool) {
+	if s == nil || s.set == nil || len(name) == 0 {
 		return "", false
 	}
 
-	name, ok := s.opt.GetName(blobSha)
-	if !ok {
-		return name, ok
-	}
-
 	if s.set.Contains(name) {
 		return name, true
 	}
 	return name, false
 }
 
+// revWithName returns the entry sent over a channel of revisions for the
+// object with the given sha1 and name, which may be empty. Names are passed
+// along with the objects, rather than kept aside, so that scanning doesn't
+// have to remember the name of every object in the history.
+func revWithName(sha, name string) string {
+	if len(name) == 0 {
+		return sha
+	}
+	return sha + " " + name
+}
+
+// splitRev splits an entry sent over a channel of revisions into the object's
+// sha1 and name.
+func splitRev(rev string) (sha, name string) {
+	if i := strings.IndexByte(rev, ' '); i >= 0 {
+		return rev[:i], rev[i+1:]
+	}
+	return rev, ""
+}
+
 func noopFoundLockable(name string) {}
 
 // scanRefsToChan takes a ref and returns a channel of WrappedPointer objects
 // for all Git LFS pointers it finds for that ref.
 // Reports unique oids once only, not multiple times if >1 file uses the same content
 func scanRefsToChan(scanner *GitScanner, pointerCb GitScannerFoundPointer, refLeft, refRight string, opt *ScanRefsOptions) error {
+	return scanMultiRefsToChan(scanner, pointerCb, []string{refLeft, refRight}, nil, opt)
+}
+
+// scanMultiRefsToChan is like scanRefsToChan, but scans the objects reachable
+// from any of the "include" refs and from none of the "exclude" refs.
+func scanMultiRefsToChan(scanner *GitScanner, pointerCb GitScannerFoundPointer, include, exclude []string, opt *ScanRefsOptions) error {
 	if opt == nil {
 		panic("no scan ref options")
 	}
 
-	revs, err := revListShas([]string{refLeft, refRight}, nil, opt)
+	revs, err := revListShas(include, exclude, opt)
 	if err != nil {
 		return err
 	}
 
-	lockableSet := &lockableNameSet{opt: opt, set: scanner.PotentialLockables}
+	lockableSet := &lockableNameSet{set: scanner.PotentialLockables}
 	smallShas, batchLockableCh, err := catFileBatchCheck(revs, lockableSet)
 	if err != nil {
 		return err
@@ -69,9 +90,6 @@ func scanRefsToChan(scanner *GitScanner, pointerCb GitScannerFoundPointer, refLe
 	}
 
 	for p := range pointers.Results {
-		if name, ok := opt.GetName(p.Sha1); ok {
-			p.Name = name
-		}
 		pointerCb(p, nil)
 	}
 
@@ -88,15 +106,16 @@ func scanRefsToChan(scanner *GitScanner, pointerCb GitScannerFoundPointer, refLe
 
 // revListShas uses git rev-list to return the list of object sha1s
 // for the given ref. If all is true, ref is ignored. It returns a
-// channel from which sha1 strings can be read.
+// channel from which sha1 strings, followed by the object's name if it has
+// one, can be read.
 func revListShas(include, exclude []string, opt *ScanRefsOptions) (*StringChannelWrapper, error) {
 	scanner, err := git.NewRevListScanner(include, exclude, &git.ScanRefsOptions{
 		Mode:             git.ScanningMode(opt.ScanMode),
 		Remote:           opt.RemoteName,
 		SkipDeletedBlobs: opt.SkipDeletedBlobs,
 		SkippedRefs:      opt.skippedRefs,
-		Mutex:            opt.mutex,
-		Names:            opt.nameMap,
+
+		AllowPromisorMissing: git.IsPartialClone(),
 	})
 
 	if err != nil {
@@ -108,11 +127,7 @@ func revListShas(include, exclude []string, opt *ScanRefsOptions) (*StringChanne
 
 	go func() {
 		for scanner.Scan() {
-			sha := hex.EncodeToString(scanner.OID())
-			if name := scanner.Name(); len(name) > 0 {
-				opt.SetName(sha, name)
-			}
-			revs <- sha
+			revs <- revWithName(hex.EncodeToString(scanner.OID()), scanner.Name())
 		}
 
 		if err = scanner.Err(); err != nil {
@@ -2,9 +2,9 @@ package lfs
 
 import (
 	"fmt"
-	"io"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -12,7 +12,22 @@ import (
 	"github.com/rubyist/tracerx"
 )
 
+const (
+	// chainBegin and chainEnd surround the lines which run a Git LFS hook
+	// from the start of another one.
+	chainBegin = "# BEGIN git-lfs hook"
+	chainEnd   = "# END git-lfs hook"
+
+	// chainStdinHook is the type of hook whose standard input is read by
+	// Git LFS, and so is saved for the hook it is chained to.
+	chainStdinHook = "pre-push"
+)
+
 var (
+	// chainShells are the interpreters of the hooks which Git LFS can be
+	// chained to, since they run the shell lines which it adds.
+	chainShells = []string{"sh", "bash", "dash", "ash", "ksh", "mksh", "zsh"}
+
 	// The basic hook which just calls 'git lfs TYPE'
 	hookBaseContent = "#!/bin/sh\ncommand -v git-lfs >/dev/null 2>&1 || { echo >&2 \"\\nThis repository is configured for Git LFS but 'git-lfs' was not found on your path. If you no longer wish to use Git LFS, remove this hook by deleting .git/hooks/{{Command}}.\\n\"; exit 2; }\ngit lfs {{Command}} \"$@\""
 )
@@ -91,11 +106,188 @@ func (h *Hook) write() error {
 	return ioutil.WriteFile(h.Path(), []byte(h.Contents+"\n"), 0755)
 }
 
+// Chain installs this Git hook like Install, except that a hook which does
+// not match any version of this hook is kept, and the lines which run this hook
+// are added at its start, or replaced if they were added before.
+func (h *Hook) Chain() error {
+	tracerx.Printf("Chain hook: %s, path=%s", h.Type, h.Path())
+
+	if err := os.MkdirAll(h.Dir, 0755); err != nil {
+		return err
+	}
+
+	if !h.Exists() {
+		return h.write()
+	}
+
+	contents, err := h.read()
+	if err != nil {
+		return err
+	}
+
+	if h.matches(contents) {
+		return h.write()
+	}
+
+	if err := h.checkChainable(contents); err != nil {
+		return err
+	}
+	return h.writeChained(contents)
+}
+
+// CanChain returns an error if this hook exists, and is not one which Chain
+// can either overwrite or add the lines which run this hook to, i.e. a shell
+// script. It returns nil otherwise.
+func (h *Hook) CanChain() error {
+	if !h.Exists() {
+		return nil
+	}
+
+	contents, err := h.read()
+	if err != nil {
+		return err
+	}
+
+	if h.matches(contents) {
+		return nil
+	}
+	return h.checkChainable(contents)
+}
+
+// checkChainable returns an error if the given contents of another hook are
+// not a script run by one of chainShells, as given by its "#!" line. A hook
+// without one isn't chained to either, since whether Git runs it with a shell
+// depends on the platform.
+func (h *Hook) checkChainable(contents string) error {
+	shebang := contents
+	if i := strings.Index(shebang, "\n"); i >= 0 {
+		shebang = shebang[:i]
+	}
+
+	if !strings.HasPrefix(shebang, "#!") {
+		return fmt.Errorf("Hook %s has no \"#!\" line, so Git LFS cannot tell whether it can run from its start.\n\n%s", h.Type, chainResolution)
+	}
+
+	if interpreter := shebangInterpreter(shebang); !isChainShell(interpreter) {
+		return fmt.Errorf("Hook %s is not a shell script (%s), so Git LFS cannot run from its start.\n\n%s", h.Type, strings.TrimSpace(shebang), chainResolution)
+	}
+	return nil
+}
+
+// chainResolution is the advice given when a hook cannot be chained to.
+const chainResolution = "To resolve this, either:\n  1: run `git lfs update --manual` for instructions on how to merge hooks.\n  2: run `git lfs update --force` to overwrite your hook."
+
+// shebangInterpreter returns the base name of the interpreter given by the
+// "#!" line of a script, without any ".exe", and looking through "/usr/bin/env".
+func shebangInterpreter(shebang string) string {
+	fields := strings.Fields(strings.TrimPrefix(shebang, "#!"))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interpreter := strings.TrimSuffix(path.Base(fields[0]), ".exe")
+	if interpreter != "env" {
+		return interpreter
+	}
+
+	for _, arg := range fields[1:] {
+		if !strings.HasPrefix(arg, "-") && !strings.Contains(arg, "=") {
+			return strings.TrimSuffix(path.Base(arg), ".exe")
+		}
+	}
+	return ""
+}
+
+func isChainShell(interpreter string) bool {
+	for _, shell := range chainShells {
+		if interpreter == shell {
+			return true
+		}
+	}
+	return false
+}
+
+// writeChained writes the given contents of another hook, a shell script (see
+// checkChainable), to disk, with the lines which run this hook right after its
+// "#!" line, in place of any previous ones. They come first so that the other
+// hook cannot exit before they run.
+func (h *Hook) writeChained(contents string) error {
+	rest, _ := unchain(contents)
+
+	shebang, body := rest, ""
+	if i := strings.Index(rest, "\n"); i >= 0 {
+		shebang, body = rest[:i], rest[i+1:]
+	}
+
+	chained := shebang + "\n" + h.chainedContents() + "\n"
+	if body = strings.Trim(body, "\n"); len(body) > 0 {
+		chained += "\n" + body + "\n"
+	}
+
+	if err := ioutil.WriteFile(h.Path(), []byte(chained), 0755); err != nil {
+		return err
+	}
+	return os.Chmod(h.Path(), 0755)
+}
+
+// chainedContents returns the lines which run this hook from the start of
+// another one. They exit with the status of Git LFS if it fails. The standard
+// input of the pre-push hook is saved to a temporary file, and given to the
+// other hook once Git LFS has read it.
+func (h *Hook) chainedContents() string {
+	body := h.Contents
+	if i := strings.Index(body, "\n"); i >= 0 && strings.HasPrefix(body, "#!") {
+		body = body[i+1:]
+	}
+
+	if h.Type != chainStdinHook {
+		return chainBegin + "\n" + body + " || exit $?\n" + chainEnd
+	}
+
+	lines := strings.Split(body, "\n")
+	run := lines[len(lines)-1]
+	lines = append(lines[:len(lines)-1],
+		`lfs_stdin="$(mktemp)" || exit 2`,
+		`cat > "$lfs_stdin"`,
+		run+` < "$lfs_stdin" || { lfs_status=$?; rm -f "$lfs_stdin"; exit $lfs_status; }`,
+		`exec < "$lfs_stdin"`,
+		`rm -f "$lfs_stdin"`,
+	)
+	return chainBegin + "\n" + strings.Join(lines, "\n") + "\n" + chainEnd
+}
+
+// unchain returns the given hook contents without the lines which run a Git
+// LFS hook from it, and whether there were any.
+func unchain(contents string) (string, bool) {
+	begin := strings.Index(contents, chainBegin)
+	if begin < 0 {
+		return contents, false
+	}
+
+	end := strings.Index(contents[begin:], chainEnd)
+	if end < 0 {
+		return contents, false
+	}
+	end += begin + len(chainEnd)
+
+	return strings.TrimRight(contents[:begin], "\n") + "\n" +
+		strings.TrimLeft(contents[end:], "\n"), true
+}
+
 // Upgrade upgrades the (assumed to be) existing git hook to the current
 // contents. A hook is considered "upgrade-able" if its contents are matched in
 // the member variable `Upgradeables`. It halts and returns any errors as they
 // arise.
 func (h *Hook) Upgrade() error {
+	contents, err := h.read()
+	if err != nil {
+		return err
+	}
+
+	if _, chained := unchain(contents); chained {
+		return h.writeChained(contents)
+	}
+
 	match, err := h.matchesCurrent()
 	if err != nil {
 		return err
@@ -109,10 +301,23 @@ func (h *Hook) Upgrade() error {
 }
 
 // Uninstall removes the hook on disk so long as it matches the current version,
-// or any of the past versions of this hook.
+// or any of the past versions of this hook. If the hook was chained to another
+// one, only the lines which run it are removed.
 func (h *Hook) Uninstall() error {
 	msg := fmt.Sprintf("Uninstall hook: %s, path=%s", h.Type, h.Path())
 
+	if h.Exists() {
+		contents, err := h.read()
+		if err != nil {
+			return err
+		}
+
+		if rest, chained := unchain(contents); chained {
+			tracerx.Printf(msg + ", unchaining...")
+			return ioutil.WriteFile(h.Path(), []byte(rest), 0755)
+		}
+	}
+
 	match, err := h.matchesCurrent()
 	if err != nil {
 		return err
@@ -132,27 +337,44 @@ func (h *Hook) Uninstall() error {
 // its contents match the current contents, or any past "upgrade-able" contents
 // of this hook.
 func (h *Hook) matchesCurrent() (bool, error) {
-	file, err := os.Open(h.Path())
+	contents, err := h.read()
 	if err != nil {
 		return false, err
 	}
 
-	by, err := ioutil.ReadAll(io.LimitReader(file, 1024))
-	file.Close()
-	if err != nil {
-		return false, err
+	if h.matches(contents) {
+		return true, nil
 	}
 
-	contents := strings.TrimSpace(tools.Undent(string(by)))
+	return false, fmt.Errorf("Hook already exists: %s\n\n%s\n", string(h.Type), tools.Indent(h.normalize(contents)))
+}
+
+// matches returns whether the given hook contents are empty, or those of the
+// current or any past "upgrade-able" version of this hook.
+func (h *Hook) matches(contents string) bool {
+	contents = h.normalize(contents)
 	if contents == h.Contents || len(contents) == 0 {
-		return true, nil
+		return true
 	}
 
 	for _, u := range h.upgradeables {
 		if u == contents {
-			return true, nil
+			return true
 		}
 	}
 
-	return false, fmt.Errorf("Hook already exists: %s\n\n%s\n", string(h.Type), tools.Indent(contents))
+	return false
+}
+
+func (h *Hook) normalize(contents string) string {
+	return strings.TrimSpace(tools.Undent(contents))
+}
+
+// read returns the contents of the existing hook on disk.
+func (h *Hook) read() (string, error) {
+	by, err := ioutil.ReadFile(h.Path())
+	if err != nil {
+		return "", err
+	}
+	return string(by), nil
 }
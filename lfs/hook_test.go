@@ -0,0 +1,120 @@
+package lfs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHookChainPrependsToExistingHook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-hooks")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	hook := NewStandardHook("pre-push", dir, nil)
+	userHook := "#!/bin/sh\necho user\n"
+	require.Nil(t, ioutil.WriteFile(hook.Path(), []byte(userHook), 0755))
+
+	require.Nil(t, hook.Chain())
+	require.Nil(t, hook.Chain())
+
+	by, err := ioutil.ReadFile(hook.Path())
+	require.Nil(t, err)
+	assert.Equal(t, "#!/bin/sh\n"+hook.chainedContents()+"\n\necho user\n", string(by))
+
+	require.Nil(t, hook.Uninstall())
+
+	by, err = ioutil.ReadFile(hook.Path())
+	require.Nil(t, err)
+	assert.Equal(t, userHook, string(by))
+}
+
+func TestHookChainRefusesNonShellHooks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-hooks")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	hook := NewStandardHook("pre-push", dir, nil)
+	for _, userHook := range []string{
+		"#!/usr/bin/env python\nprint('user')\n",
+		"#!/usr/bin/perl -w\nprint \"user\\n\";\n",
+		"echo user\n",
+	} {
+		require.Nil(t, ioutil.WriteFile(hook.Path(), []byte(userHook), 0755))
+
+		assert.NotNil(t, hook.CanChain(), userHook)
+		err := hook.Chain()
+		require.NotNil(t, err, userHook)
+		assert.Contains(t, err.Error(), "git lfs update --force")
+
+		by, err := ioutil.ReadFile(hook.Path())
+		require.Nil(t, err)
+		assert.Equal(t, userHook, string(by))
+	}
+}
+
+func TestHookChainAcceptsShellHooks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-hooks")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	hook := NewStandardHook("post-merge", dir, nil)
+	for _, shebang := range []string{
+		"#!/bin/sh",
+		"#!/bin/bash -e",
+		"#!/usr/bin/env bash",
+		"#!/usr/bin/env -S zsh -f",
+		"#! /bin/dash\r",
+	} {
+		userHook := shebang + "\necho user\n"
+		require.Nil(t, ioutil.WriteFile(hook.Path(), []byte(userHook), 0755))
+
+		assert.Nil(t, hook.CanChain(), shebang)
+		require.Nil(t, hook.Chain(), shebang)
+
+		by, err := ioutil.ReadFile(hook.Path())
+		require.Nil(t, err)
+		assert.Equal(t, shebang+"\n"+hook.chainedContents()+"\n\necho user\n", string(by))
+	}
+}
+
+func TestHookChainWritesMissingHook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-hooks")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	hook := NewStandardHook("post-merge", dir, nil)
+	require.Nil(t, hook.Chain())
+
+	by, err := ioutil.ReadFile(filepath.Join(dir, "post-merge"))
+	require.Nil(t, err)
+	assert.Equal(t, hook.Contents+"\n", string(by))
+}
+
+func TestHookChainSavesPrePushInput(t *testing.T) {
+	contents := NewStandardHook("pre-push", "", nil).chainedContents()
+	assert.Contains(t, contents, "git lfs pre-push \"$@\" < \"$lfs_stdin\" || {")
+	assert.Contains(t, contents, "exec < \"$lfs_stdin\"\n")
+
+	contents = NewStandardHook("post-merge", "", nil).chainedContents()
+	assert.Contains(t, contents, "git lfs post-merge \"$@\" || exit $?\n"+chainEnd)
+	assert.NotContains(t, contents, "lfs_stdin")
+}
+
+func TestHookUnchain(t *testing.T) {
+	rest, chained := unchain("#!/bin/sh\necho user\n\n" + chainBegin + "\ngit lfs pre-push\n" + chainEnd + "\necho after\n")
+	assert.True(t, chained)
+	assert.Equal(t, "#!/bin/sh\necho user\necho after\n", rest)
+
+	rest, chained = unchain("#!/bin/sh\n" + chainBegin + "\ngit lfs pre-push\n" + chainEnd + "\n\necho user\n")
+	assert.True(t, chained)
+	assert.Equal(t, "#!/bin/sh\necho user\n", rest)
+
+	rest, chained = unchain("#!/bin/sh\necho user\n")
+	assert.False(t, chained)
+	assert.Equal(t, "#!/bin/sh\necho user\n", rest)
+}
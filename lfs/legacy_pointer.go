@@ -0,0 +1,69 @@
+package lfs
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/git-lfs/git-lfs/errors"
+)
+
+const (
+	// LegacyGitMedia is the format of the stubs written by git-media.
+	LegacyGitMedia = "git-media"
+	// LegacyGitFat is the format of the stubs written by git-fat.
+	LegacyGitFat = "git-fat"
+
+	// LegacyPointerMaxSize is the size of the largest stub that either
+	// format writes.
+	LegacyPointerMaxSize = 128
+)
+
+var (
+	gitMediaStubRE = regexp.MustCompile(`\A([0-9a-f]{40})\n?\z`)
+	gitFatStubRE   = regexp.MustCompile(`\A#\$# git-fat ([0-9a-f]{40}) +([0-9]+)\n?\z`)
+)
+
+// LegacyPointer is the stub which git-media or git-fat, the predecessors of Git
+// LFS, store in Git in place of the contents of a file. Both identify the
+// contents by their SHA-1.
+type LegacyPointer struct {
+	Format string
+	Sha1   string
+	// Size is the size of the contents, or -1 if the stub does not record
+	// it, as is the case for git-media.
+	Size int64
+}
+
+// DecodeLegacyPointer parses the given git-media or git-fat stub, returning a
+// NotAPointerError if it is neither.
+func DecodeLegacyPointer(data []byte) (*LegacyPointer, error) {
+	if len(data) > LegacyPointerMaxSize {
+		return nil, errors.NewNotAPointerError(errors.New("data size exceeds legacy pointer size"))
+	}
+
+	if m := gitFatStubRE.FindSubmatch(data); m != nil {
+		size, err := strconv.ParseInt(string(m[2]), 10, 64)
+		if err != nil {
+			return nil, errors.NewNotAPointerError(errors.Wrap(err, "invalid git-fat size"))
+		}
+		return &LegacyPointer{Format: LegacyGitFat, Sha1: string(m[1]), Size: size}, nil
+	}
+
+	if m := gitMediaStubRE.FindSubmatch(data); m != nil {
+		return &LegacyPointer{Format: LegacyGitMedia, Sha1: string(m[1]), Size: -1}, nil
+	}
+
+	return nil, errors.NewNotAPointerError(errors.New("not a git-media or git-fat stub"))
+}
+
+// CachePath returns the path at which the tool which wrote the stub keeps its
+// contents, in the repository whose Git directory is "gitDir".
+func (p *LegacyPointer) CachePath(gitDir string) string {
+	switch p.Format {
+	case LegacyGitFat:
+		return filepath.Join(gitDir, "fat", "objects", p.Sha1)
+	default:
+		return filepath.Join(gitDir, "media", "objects", p.Sha1)
+	}
+}
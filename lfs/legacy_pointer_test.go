@@ -0,0 +1,50 @@
+package lfs
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const legacySha1 = "0beec7b5ea3f0fdbc95d0dd47f3c5bc275da8a33"
+
+func TestDecodeLegacyPointerGitMedia(t *testing.T) {
+	for _, stub := range []string{legacySha1 + "\n", legacySha1} {
+		p, err := DecodeLegacyPointer([]byte(stub))
+		assert.Nil(t, err)
+		assert.Equal(t, LegacyGitMedia, p.Format)
+		assert.Equal(t, legacySha1, p.Sha1)
+		assert.EqualValues(t, -1, p.Size)
+		assert.Equal(t, filepath.Join(".git", "media", "objects", legacySha1), p.CachePath(".git"))
+	}
+}
+
+func TestDecodeLegacyPointerGitFat(t *testing.T) {
+	// git-fat pads the size to 20 characters.
+	p, err := DecodeLegacyPointer([]byte("#$# git-fat " + legacySha1 + "                 123\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, LegacyGitFat, p.Format)
+	assert.Equal(t, legacySha1, p.Sha1)
+	assert.EqualValues(t, 123, p.Size)
+	assert.Equal(t, filepath.Join(".git", "fat", "objects", legacySha1), p.CachePath(".git"))
+}
+
+func TestDecodeLegacyPointerInvalid(t *testing.T) {
+	for _, stub := range []string{
+		"",
+		legacySha1 + "\nmore\n",
+		strings.ToUpper(legacySha1) + "\n",
+		legacySha1[1:] + "\n",
+		"#$# git-fat " + legacySha1 + "\n",
+		"#$# git-fat " + legacySha1 + " -1\n",
+		"version https://git-lfs.github.com/spec/v1\noid sha256:" + legacySha1 + "\nsize 3\n",
+		strings.Repeat(legacySha1, 4),
+	} {
+		p, err := DecodeLegacyPointer([]byte(stub))
+		assert.Nil(t, p, "expected %q not to be a stub", stub)
+		assert.True(t, errors.IsNotAPointerError(err), "expected %q not to be a stub", stub)
+	}
+}
@@ -40,7 +40,7 @@ func Environ(cfg *config.Configuration, manifest *tq.Manifest) []string {
 		fmt.Sprintf("LocalGitDir=%s", cfg.LocalGitDir()),
 		fmt.Sprintf("LocalGitStorageDir=%s", cfg.LocalGitStorageDir()),
 		fmt.Sprintf("LocalMediaDir=%s", cfg.LFSObjectDir()),
-		fmt.Sprintf("LocalReferenceDir=%s", cfg.LocalReferenceDir()),
+		fmt.Sprintf("LocalReferenceDir=%s", strings.Join(cfg.LocalReferenceDirs(), string(os.PathListSeparator))),
 		fmt.Sprintf("TempDir=%s", cfg.TempDir()),
 		fmt.Sprintf("ConcurrentTransfers=%d", api.ConcurrentTransfers),
 		fmt.Sprintf("TusTransfers=%v", cfg.TusTransfersAllowed()),
@@ -73,7 +73,7 @@ func Environ(cfg *config.Configuration, manifest *tq.Manifest) []string {
 		if !strings.Contains(strings.SplitN(e, "=", 2)[0], "GIT_") {
 			continue
 		}
-		env = append(env, e)
+		env = append(env, tools.RedactURLCredentials(e))
 	}
 
 	return env
@@ -98,13 +98,14 @@ func LinkOrCopyFromReference(cfg *config.Configuration, oid string, size int64)
 	if cfg.LFSObjectExists(oid, size) {
 		return nil
 	}
-	altMediafile := cfg.Filesystem().ObjectReferencePath(oid)
 	mediafile, err := cfg.Filesystem().ObjectPath(oid)
 	if err != nil {
 		return err
 	}
-	if altMediafile != "" && tools.FileExistsOfSize(altMediafile, size) {
-		return LinkOrCopy(cfg, altMediafile, mediafile)
+	for _, altMediafile := range cfg.Filesystem().ObjectReferencePaths(oid) {
+		if tools.FileExistsOfSize(altMediafile, size) {
+			return LinkOrCopy(cfg, altMediafile, mediafile)
+		}
 	}
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"regexp"
 	"sort"
@@ -12,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/tools"
 )
 
 var (
@@ -23,8 +25,10 @@ var (
 	latest      = "https://git-lfs.github.com/spec/v1"
 	oidType     = "sha256"
 	oidRE       = regexp.MustCompile(`\A[[:alnum:]]{64}`)
+	hexRE       = regexp.MustCompile(`\A[0-9a-f]+\z`)
 	matcherRE   = regexp.MustCompile("git-media|hawser|git-lfs")
 	extRE       = regexp.MustCompile(`\Aext-\d{1}-\w+`)
+	keyRE       = regexp.MustCompile(`\A[a-z0-9.-]+\z`)
 	pointerKeys = []string{"version", "oid", "size"}
 )
 
@@ -34,6 +38,10 @@ type Pointer struct {
 	Size       int64
 	OidType    string
 	Extensions []*PointerExtension
+	// Extra holds the keys of the pointer which Git LFS does not know,
+	// such as those added by other tools, along with their values. They
+	// are written back out as they were read.
+	Extra map[string]string
 }
 
 // A PointerExtension is parsed from the Git LFS Pointer file.
@@ -51,7 +59,7 @@ func (p ByPriority) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 func (p ByPriority) Less(i, j int) bool { return p[i].Priority < p[j].Priority }
 
 func NewPointer(oid string, size int64, exts []*PointerExtension) *Pointer {
-	return &Pointer{latest, oid, size, oidType, exts}
+	return &Pointer{latest, oid, size, oidType, exts, nil}
 }
 
 func NewPointerExtension(name string, priority int, oid string) *PointerExtension {
@@ -67,13 +75,31 @@ func (p *Pointer) Encoded() string {
 		return ""
 	}
 
+	var lines []string
+	for _, ext := range p.Extensions {
+		lines = append(lines, fmt.Sprintf("ext-%d-%s %s:%s\n", ext.Priority, ext.Name, ext.OidType, ext.Oid))
+	}
+	if p.OidType != oidType {
+		lines = append(lines, fmt.Sprintf("hash-algo %s\n", p.OidType))
+	}
+	lines = append(lines, fmt.Sprintf("oid %s:%s\n", p.OidType, p.Oid))
+	lines = append(lines, fmt.Sprintf("size %d\n", p.Size))
+	for key, value := range p.Extra {
+		lines = append(lines, fmt.Sprintf("%s %s\n", key, value))
+	}
+
+	// Every key but "version" is sorted, which the keys Git LFS writes
+	// itself already are. Since a space sorts before any character allowed
+	// in a key, sorting the lines sorts their keys.
+	if len(p.Extra) > 0 {
+		sort.Strings(lines)
+	}
+
 	var buffer bytes.Buffer
 	buffer.WriteString(fmt.Sprintf("version %s\n", latest))
-	for _, ext := range p.Extensions {
-		buffer.WriteString(fmt.Sprintf("ext-%d-%s %s:%s\n", ext.Priority, ext.Name, ext.OidType, ext.Oid))
+	for _, line := range lines {
+		buffer.WriteString(line)
 	}
-	buffer.WriteString(fmt.Sprintf("oid %s:%s\n", p.OidType, p.Oid))
-	buffer.WriteString(fmt.Sprintf("size %d\n", p.Size))
 	return buffer.String()
 }
 
@@ -82,6 +108,8 @@ func EncodePointer(writer io.Writer, pointer *Pointer) (int, error) {
 }
 
 func DecodePointerFromFile(file string) (*Pointer, error) {
+	file = tools.LongPath(file)
+
 	// Check size before reading
 	stat, err := os.Stat(file)
 	if err != nil {
@@ -102,6 +130,21 @@ func DecodePointer(reader io.Reader) (*Pointer, error) {
 	return p, err
 }
 
+// DecodeEntirePointer decodes an *lfs.Pointer from all of the data in the
+// given io.Reader. Unlike DecodePointer, it fails with a NotAPointerError if the
+// reader holds anything after the pointer, such as a larger file which merely
+// starts like one.
+func DecodeEntirePointer(reader io.Reader) (*Pointer, error) {
+	buf, err := ioutil.ReadAll(io.LimitReader(reader, blobSizeCutoff+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) > blobSizeCutoff {
+		return nil, errors.NewNotAPointerError(errors.New("data size exceeds lfs pointer size cutoff"))
+	}
+	return decodeKV(bytes.TrimSpace(buf))
+}
+
 // DecodeFrom decodes an *lfs.Pointer from the given io.Reader, "reader".
 // If the pointer encoded in the reader could successfully be read and decoded,
 // it will be returned with a nil error.
@@ -109,8 +152,21 @@ func DecodePointer(reader io.Reader) (*Pointer, error) {
 // If the pointer could not be decoded, an io.Reader containing the entire
 // blob's data will be returned, along with a parse error.
 func DecodeFrom(reader io.Reader) (*Pointer, io.Reader, error) {
+	// Read no more than a pointer could hold, however large the rest of
+	// the contents are, but don't mistake a short read for the end of them.
+	//
+	// Unlike io.ReadFull, this keeps an io.EOF returned along with the
+	// last bytes which fill the buffer, since some readers, such as that
+	// of a single file in "git lfs filter-process", must not be read past
+	// their end.
 	buf := make([]byte, blobSizeCutoff)
-	n, err := reader.Read(buf)
+	var n int
+	var err error
+	for n < len(buf) && err == nil {
+		var nn int
+		nn, err = reader.Read(buf[n:])
+		n += nn
+	}
 	buf = buf[:n]
 
 	var contents io.Reader = bytes.NewReader(buf)
@@ -141,7 +197,7 @@ func verifyVersion(version string) error {
 }
 
 func decodeKV(data []byte) (*Pointer, error) {
-	kvps, exts, err := decodeKVData(data)
+	kvps, exts, extra, err := decodeKVData(data)
 	if err != nil {
 		if errors.IsBadPointerKeyError(err) {
 			return nil, errors.StandardizeBadPointerError(err)
@@ -158,11 +214,15 @@ func decodeKV(data []byte) (*Pointer, error) {
 		return nil, errors.New("Invalid Oid")
 	}
 
-	oid, err := parseOid(value)
+	algo, oid, err := parseOid(value)
 	if err != nil {
 		return nil, err
 	}
 
+	if value, ok := kvps["hash-algo"]; ok && value != algo {
+		return nil, fmt.Errorf("Hash algorithm %q does not match Oid type %q", value, algo)
+	}
+
 	value, ok = kvps["size"]
 	size, err := strconv.ParseInt(value, 10, 0)
 	if err != nil || size < 0 {
@@ -184,22 +244,36 @@ func decodeKV(data []byte) (*Pointer, error) {
 		sort.Sort(ByPriority(extensions))
 	}
 
-	return NewPointer(oid, size, extensions), nil
+	p := NewPointer(oid, size, extensions)
+	p.OidType = algo
+	p.Extra = extra
+	return p, nil
 }
 
-func parseOid(value string) (string, error) {
+// parseOid parses the value of an "oid" key, or of an extension, returning the
+// hash algorithm which named the object along with its OID.
+func parseOid(value string) (string, string, error) {
 	parts := strings.SplitN(value, ":", 2)
 	if len(parts) != 2 {
-		return "", errors.New("Invalid Oid value: " + value)
+		return "", "", errors.New("Invalid Oid value: " + value)
+	}
+
+	algo, oid := parts[0], parts[1]
+	if algo == oidType {
+		if !oidRE.Match([]byte(oid)) {
+			return "", "", errors.New("Invalid Oid: " + oid)
+		}
+		return algo, oid, nil
 	}
-	if parts[0] != oidType {
-		return "", errors.New("Invalid Oid type: " + parts[0])
+
+	h, err := tools.NewContentHash(algo)
+	if err != nil {
+		return "", "", errors.New("Invalid Oid type: " + algo)
 	}
-	oid := parts[1]
-	if !oidRE.Match([]byte(oid)) {
-		return "", errors.New("Invalid Oid: " + oid)
+	if len(oid) != 2*h.Size() || !hexRE.MatchString(oid) {
+		return "", "", errors.New("Invalid Oid: " + oid)
 	}
-	return oid, nil
+	return algo, oid, nil
 }
 
 func parsePointerExtension(key string, value string) (*PointerExtension, error) {
@@ -215,12 +289,14 @@ func parsePointerExtension(key string, value string) (*PointerExtension, error)
 
 	name := keyParts[2]
 
-	oid, err := parseOid(value)
+	algo, oid, err := parseOid(value)
 	if err != nil {
 		return nil, err
 	}
 
-	return NewPointerExtension(name, p, oid), nil
+	ext := NewPointerExtension(name, p, oid)
+	ext.OidType = algo
+	return ext, nil
 }
 
 func validatePointerExtensions(exts []*PointerExtension) error {
@@ -234,7 +310,7 @@ func validatePointerExtensions(exts []*PointerExtension) error {
 	return nil
 }
 
-func decodeKVData(data []byte) (kvps map[string]string, exts map[string]string, err error) {
+func decodeKVData(data []byte) (kvps map[string]string, exts map[string]string, extra map[string]string, err error) {
 	kvps = make(map[string]string)
 
 	if !matcherRE.Match(data) {
@@ -245,6 +321,9 @@ func decodeKVData(data []byte) (kvps map[string]string, exts map[string]string,
 	scanner := bufio.NewScanner(bytes.NewBuffer(data))
 	line := 0
 	numKeys := len(pointerKeys)
+	// prev is the last key read after "version", other than an extension,
+	// which the next one has to sort after.
+	var prev string
 	for scanner.Scan() {
 		text := scanner.Text()
 		if len(text) == 0 {
@@ -261,22 +340,55 @@ func decodeKVData(data []byte) (kvps map[string]string, exts map[string]string,
 		value := parts[1]
 
 		if numKeys <= line {
-			err = fmt.Errorf("Extra line: %s", text)
-			return
+			if key <= prev || !keyRE.MatchString(key) {
+				err = fmt.Errorf("Extra line: %s", text)
+				return
+			}
+			if extra == nil {
+				extra = make(map[string]string)
+			}
+			extra[key] = value
+			prev = key
+			continue
 		}
 
 		if expected := pointerKeys[line]; key != expected {
-			if !extRE.Match([]byte(key)) {
+			if key == "hash-algo" && expected == "oid" && key > prev {
+				// Sorted along with the extensions, before
+				// the "oid" key it describes.
+				kvps[key] = value
+				prev = key
+				continue
+			}
+			if strings.HasPrefix(key, "ext-") {
+				if !extRE.Match([]byte(key)) {
+					err = errors.NewBadPointerKeyError(expected, key)
+					return
+				}
+				if exts == nil {
+					exts = make(map[string]string)
+				}
+				exts[key] = value
+				continue
+			}
+			if line == 0 || key <= prev || key >= expected || !keyRE.MatchString(key) {
 				err = errors.NewBadPointerKeyError(expected, key)
 				return
 			}
-			if exts == nil {
-				exts = make(map[string]string)
+
+			// A key which Git LFS does not know, sorted between
+			// those it does.
+			if extra == nil {
+				extra = make(map[string]string)
 			}
-			exts[key] = value
+			extra[key] = value
+			prev = key
 			continue
 		}
 
+		if line > 0 {
+			prev = key
+		}
 		line += 1
 		kvps[key] = value
 	}
@@ -0,0 +1,156 @@
+package lfs
+
+import (
+	"fmt"
+	"sync"
+)
+
+const (
+	// DefaultMaxPlausibleSize is the default size, in bytes, above which a
+	// pointer's declared size is considered implausible (1 TiB), see
+	// lfs.maxplausiblesize.
+	DefaultMaxPlausibleSize = 1 << 40
+)
+
+// SizeAnomalyKind is the kind of problem found with a pointer's declared size.
+type SizeAnomalyKind int
+
+const (
+	// SizeAnomalyZero is a pointer declaring a size of 0 bytes. Git LFS
+	// never writes these, since empty files are kept in Git as they are.
+	SizeAnomalyZero SizeAnomalyKind = iota
+	// SizeAnomalyTooLarge is a pointer declaring a size larger than any
+	// plausible object.
+	SizeAnomalyTooLarge
+	// SizeAnomalyConflict is a pointer declaring a different size than
+	// another pointer to the same object.
+	SizeAnomalyConflict
+	// SizeAnomalyMismatch is a pointer declaring a different size than
+	// that of its object in the local store.
+	SizeAnomalyMismatch
+)
+
+// SizeAnomaly is a pointer whose declared size cannot be right, which would
+// otherwise only surface as a failure to transfer or verify its object.
+type SizeAnomaly struct {
+	Kind    SizeAnomalyKind
+	Pointer *WrappedPointer
+
+	// Other is the pointer that a SizeAnomalyConflict conflicts with.
+	Other *WrappedPointer
+	// ActualSize is the size of the local object, for a
+	// SizeAnomalyMismatch.
+	ActualSize int64
+}
+
+// Error describes the anomaly.
+func (a *SizeAnomaly) Error() string {
+	p := a.Pointer
+	switch a.Kind {
+	case SizeAnomalyZero:
+		return fmt.Sprintf("Pointer %s (%s) declares a size of 0 bytes", p.Name, p.Oid)
+	case SizeAnomalyTooLarge:
+		return fmt.Sprintf("Pointer %s (%s) declares an implausible size of %d bytes", p.Name, p.Oid, p.Size)
+	case SizeAnomalyConflict:
+		return fmt.Sprintf("Pointer %s (%s) declares a size of %d bytes, but %s declares %d bytes",
+			p.Name, p.Oid, p.Size, a.Other.Name, a.Other.Size)
+	case SizeAnomalyMismatch:
+		return fmt.Sprintf("Pointer %s (%s) declares a size of %d bytes, but the object is %d bytes",
+			p.Name, p.Oid, p.Size, a.ActualSize)
+	}
+	return fmt.Sprintf("Pointer %s (%s) has an invalid size", p.Name, p.Oid)
+}
+
+// Fix describes how to repair the pointer: by converting its file back into a
+// correct pointer from the file's contents. If pointerText is true, the file in
+// the working tree holds pointer text instead, from which adding it again would
+// only give the same pointer, so its contents have to be restored first.
+func (a *SizeAnomaly) Fix(pointerText bool) string {
+	name := a.Pointer.Name
+	rewrite := fmt.Sprintf("run `git rm --cached -- %s && git add -- %s` and commit to rewrite its pointer.", name, name)
+	if pointerText {
+		return RestoreContentsFix(name) + ", then " + rewrite
+	}
+	return fmt.Sprintf("If %s holds the right contents, %s", name, rewrite)
+}
+
+// RestoreContentsFix describes how to replace the pointer text in the working
+// tree file with the given name by the file's contents.
+func RestoreContentsFix(name string) string {
+	return fmt.Sprintf("%s holds a pointer rather than its contents: run `git lfs checkout %s`, or `git lfs pull --include=%s` if its object is not present locally, to restore them", name, name, name)
+}
+
+// SizeAnomalyChecker finds pointers with anomalous sizes, including pointers to
+// the same object with conflicting sizes, amongst all pointers given to Check.
+// A pointer given again at the same path is only checked the first time. It is
+// safe for concurrent use.
+type SizeAnomalyChecker struct {
+	maxSize int64
+
+	mu      sync.Mutex
+	seen    map[string]*WrappedPointer
+	checked map[string]struct{}
+}
+
+// NewSizeAnomalyChecker returns a SizeAnomalyChecker considering sizes above
+// maxSize to be implausible. A maxSize less than 1 uses
+// DefaultMaxPlausibleSize.
+func NewSizeAnomalyChecker(maxSize int64) *SizeAnomalyChecker {
+	if maxSize < 1 {
+		maxSize = DefaultMaxPlausibleSize
+	}
+
+	return &SizeAnomalyChecker{
+		maxSize: maxSize,
+		seen:    make(map[string]*WrappedPointer),
+		checked: make(map[string]struct{}),
+	}
+}
+
+// Check returns the anomaly with the given pointer's size, or nil if there is
+// none.
+func (c *SizeAnomalyChecker) Check(p *WrappedPointer) *SizeAnomaly {
+	if p == nil || p.Pointer == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := fmt.Sprintf("%s\x00%s\x00%d", p.Name, p.Oid, p.Size)
+	if _, ok := c.checked[key]; ok {
+		return nil
+	}
+	c.checked[key] = struct{}{}
+
+	if !c.IsPlausibleSize(p.Size) {
+		if p.Size == 0 {
+			return &SizeAnomaly{Kind: SizeAnomalyZero, Pointer: p}
+		}
+		return &SizeAnomaly{Kind: SizeAnomalyTooLarge, Pointer: p}
+	}
+
+	other, ok := c.seen[p.Oid]
+	if !ok {
+		c.seen[p.Oid] = p
+		return nil
+	}
+	if other.Size != p.Size {
+		return &SizeAnomaly{Kind: SizeAnomalyConflict, Pointer: p, Other: other}
+	}
+	return nil
+}
+
+// IsPlausibleSize returns whether a pointer could declare the given size.
+func (c *SizeAnomalyChecker) IsPlausibleSize(size int64) bool {
+	return size > 0 && size <= c.maxSize
+}
+
+// CheckObjectSize returns a SizeAnomalyMismatch if the given pointer's size
+// differs from "actual", the size of its object, or nil otherwise.
+func CheckObjectSize(p *WrappedPointer, actual int64) *SizeAnomaly {
+	if p.Size == actual {
+		return nil
+	}
+	return &SizeAnomaly{Kind: SizeAnomalyMismatch, Pointer: p, ActualSize: actual}
+}
@@ -0,0 +1,64 @@
+package lfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func wrappedPointer(name, oid string, size int64) *WrappedPointer {
+	return &WrappedPointer{Name: name, Pointer: NewPointer(oid, size, nil)}
+}
+
+func TestSizeAnomalyCheckerZero(t *testing.T) {
+	c := NewSizeAnomalyChecker(0)
+
+	a := c.Check(wrappedPointer("a.dat", "oid", 0))
+	require.NotNil(t, a)
+	assert.Equal(t, SizeAnomalyZero, a.Kind)
+	assert.Equal(t, "Pointer a.dat (oid) declares a size of 0 bytes", a.Error())
+
+	assert.Nil(t, c.Check(wrappedPointer("a.dat", "oid", 0)))
+}
+
+func TestSizeAnomalyFix(t *testing.T) {
+	a := &SizeAnomaly{Kind: SizeAnomalyZero, Pointer: wrappedPointer("a.dat", "oid", 0)}
+
+	assert.Equal(t, "If a.dat holds the right contents, run `git rm --cached -- a.dat && git add -- a.dat` and commit to rewrite its pointer.", a.Fix(false))
+	assert.Equal(t, "a.dat holds a pointer rather than its contents: run `git lfs checkout a.dat`, or `git lfs pull --include=a.dat` if its object is not present locally, to restore them, then run `git rm --cached -- a.dat && git add -- a.dat` and commit to rewrite its pointer.", a.Fix(true))
+}
+
+func TestSizeAnomalyCheckerTooLarge(t *testing.T) {
+	c := NewSizeAnomalyChecker(100)
+
+	assert.Nil(t, c.Check(wrappedPointer("a.dat", "oid", 100)))
+
+	a := c.Check(wrappedPointer("b.dat", "oid2", 101))
+	require.NotNil(t, a)
+	assert.Equal(t, SizeAnomalyTooLarge, a.Kind)
+}
+
+func TestSizeAnomalyCheckerConflict(t *testing.T) {
+	c := NewSizeAnomalyChecker(0)
+
+	assert.Nil(t, c.Check(wrappedPointer("a.dat", "oid", 10)))
+	assert.Nil(t, c.Check(wrappedPointer("b.dat", "oid", 10)))
+
+	a := c.Check(wrappedPointer("c.dat", "oid", 11))
+	require.NotNil(t, a)
+	assert.Equal(t, SizeAnomalyConflict, a.Kind)
+	assert.Equal(t, "a.dat", a.Other.Name)
+	assert.Equal(t, "Pointer c.dat (oid) declares a size of 11 bytes, but a.dat declares 10 bytes", a.Error())
+}
+
+func TestCheckObjectSize(t *testing.T) {
+	p := wrappedPointer("a.dat", "oid", 10)
+
+	assert.Nil(t, CheckObjectSize(p, 10))
+
+	a := CheckObjectSize(p, 12)
+	require.NotNil(t, a)
+	assert.Equal(t, SizeAnomalyMismatch, a.Kind)
+	assert.EqualValues(t, 12, a.ActualSize)
+}
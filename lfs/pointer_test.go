@@ -3,10 +3,12 @@ package lfs
 import (
 	"bufio"
 	"bytes"
+	"io"
 	"io/ioutil"
 	"reflect"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/stretchr/testify/assert"
@@ -127,6 +129,67 @@ size 12345`
 	assertEqualWithExample(t, ex, "sha256", p.Extensions[2].OidType)
 }
 
+func TestEncodeHashAlgo(t *testing.T) {
+	oid := strings.Repeat("ab", 64)
+	pointer := NewPointer(oid, 12345, nil)
+	pointer.OidType = "sha512"
+
+	assert.Equal(t, "version https://git-lfs.github.com/spec/v1\n"+
+		"hash-algo sha512\n"+
+		"oid sha512:"+oid+"\n"+
+		"size 12345\n", pointer.Encoded())
+}
+
+func TestDecodeHashAlgo(t *testing.T) {
+	oid := strings.Repeat("ab", 64)
+	for _, ex := range []string{
+		"version https://git-lfs.github.com/spec/v1\nhash-algo sha512\noid sha512:" + oid + "\nsize 12345",
+		"version https://git-lfs.github.com/spec/v1\noid sha512:" + oid + "\nsize 12345",
+	} {
+		p, err := DecodePointer(bytes.NewBufferString(ex))
+		assertEqualWithExample(t, ex, nil, err)
+		assertEqualWithExample(t, ex, oid, p.Oid)
+		assertEqualWithExample(t, ex, "sha512", p.OidType)
+		assertEqualWithExample(t, ex, int64(12345), p.Size)
+	}
+}
+
+func TestDecodeUnknownKeys(t *testing.T) {
+	ex := `version https://git-lfs.github.com/spec/v1
+com.example.owner art
+ext-0-foo sha256:ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff
+oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+review approved
+size 12345
+x-checksum crc32:1234abcd
+`
+
+	p, err := DecodePointer(bytes.NewBufferString(ex))
+	assertEqualWithExample(t, ex, nil, err)
+	assertEqualWithExample(t, ex, "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393", p.Oid)
+	assertEqualWithExample(t, ex, int64(12345), p.Size)
+	assertEqualWithExample(t, ex, 1, len(p.Extensions))
+	assertEqualWithExample(t, ex, map[string]string{
+		"com.example.owner": "art",
+		"review":            "approved",
+		"x-checksum":        "crc32:1234abcd",
+	}, p.Extra)
+
+	assertEqualWithExample(t, ex, ex, p.Encoded())
+}
+
+func TestEncodeUnknownKeys(t *testing.T) {
+	pointer := NewPointer("booya", 12345, nil)
+	pointer.Extra = map[string]string{"zzz": "last", "abc": "first", "pdf": "middle"}
+
+	assert.Equal(t, "version https://git-lfs.github.com/spec/v1\n"+
+		"abc first\n"+
+		"oid sha256:booya\n"+
+		"pdf middle\n"+
+		"size 12345\n"+
+		"zzz last\n", pointer.Encoded())
+}
+
 func TestDecodeExtensionsSort(t *testing.T) {
 	ex := `version https://git-lfs.github.com/spec/v1
 ext-2-baz sha256:aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa
@@ -178,6 +241,70 @@ func TestDecodeFromEmptyReader(t *testing.T) {
 	assert.Empty(t, by)
 }
 
+func TestDecodeFromShortReads(t *testing.T) {
+	ex := `version https://git-lfs.github.com/spec/v1
+oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+size 12345`
+
+	p, buf, err := DecodeFrom(iotest.OneByteReader(strings.NewReader(ex)))
+	assert.Nil(t, err)
+	assertEqualWithExample(t, ex, int64(12345), p.Size)
+
+	by, err := ioutil.ReadAll(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, ex, string(by))
+}
+
+func TestDecodeFromReadsOnlyAPrefix(t *testing.T) {
+	contents := &countingReader{r: bytes.NewReader(make([]byte, 10*blobSizeCutoff))}
+
+	p, buf, err := DecodeFrom(contents)
+	assert.NotNil(t, err)
+	assert.Nil(t, p)
+	assert.EqualValues(t, blobSizeCutoff, contents.n)
+
+	n, err := io.Copy(ioutil.Discard, buf)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 10*blobSizeCutoff, n)
+}
+
+func TestDecodeFromDoesNotReadPastEOF(t *testing.T) {
+	contents := &eofOnceReader{r: iotest.DataErrReader(bytes.NewReader(make([]byte, blobSizeCutoff)))}
+
+	_, buf, err := DecodeFrom(contents)
+	assert.NotNil(t, err)
+
+	n, err := io.Copy(ioutil.Discard, buf)
+	assert.Nil(t, err)
+	assert.EqualValues(t, blobSizeCutoff, n)
+}
+
+// eofOnceReader fails if it is read again once it has returned io.EOF.
+type eofOnceReader struct {
+	r   io.Reader
+	eof bool
+}
+
+func (r *eofOnceReader) Read(p []byte) (int, error) {
+	if r.eof {
+		return 0, errors.New("read past EOF")
+	}
+	n, err := r.r.Read(p)
+	r.eof = err == io.EOF
+	return n, err
+}
+
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += n
+	return n, err
+}
+
 func TestDecodeInvalid(t *testing.T) {
 	examples := []string{
 		"invalid stuff",
@@ -199,6 +326,23 @@ size 12345`,
 		`version https://git-lfs.github.com/spec/v1
 size 12345`,
 
+		// hash-algo not matching the oid type
+		`version https://git-lfs.github.com/spec/v1
+hash-algo sha256
+oid sha512:abababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababab
+size 12345`,
+
+		// sha512 oid of the wrong length
+		`version https://git-lfs.github.com/spec/v1
+oid sha512:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+size 12345`,
+
+		// hash-algo after the oid
+		`version https://git-lfs.github.com/spec/v1
+oid sha512:abababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababababab
+hash-algo sha512
+size 12345`,
+
 		// bad version
 		`version http://git-media.io/v/whatever
 oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
@@ -227,12 +371,31 @@ size=fif`,
 oid=sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
 size=fif`,
 
-		// extra key
+		// unknown key out of order
 		`version https://git-lfs.github.com/spec/v1
 oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
 size 12345
+abc wat`,
+
+		// unknown keys out of order
+		`version https://git-lfs.github.com/spec/v1
+oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+size 12345
+zzz wat
 wat wat`,
 
+		// duplicate key
+		`version https://git-lfs.github.com/spec/v1
+oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+size 12345
+size 12345`,
+
+		// unknown key with invalid characters
+		`version https://git-lfs.github.com/spec/v1
+oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393
+size 12345
+Wat wat`,
+
 		// keys out of order
 		`version https://git-lfs.github.com/spec/v1
 size 12345
@@ -287,3 +450,17 @@ size 12345`,
 func assertEqualWithExample(t *testing.T, example string, expected, actual interface{}) {
 	assert.Equal(t, expected, actual, "Example:\n%s", strings.TrimSpace(example))
 }
+
+func TestDecodeEntirePointer(t *testing.T) {
+	ptr := "version https://git-lfs.github.com/spec/v1\n" +
+		"oid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\n" +
+		"size 12345\n"
+
+	p, err := DecodeEntirePointer(strings.NewReader(ptr))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(12345), p.Size)
+
+	p, err = DecodeEntirePointer(strings.NewReader(ptr + strings.Repeat("x", 1024)))
+	assert.True(t, errors.IsNotAPointerError(err))
+	assert.Nil(t, p)
+}
@@ -23,6 +23,9 @@ type WrappedPointer struct {
 	Name    string
 	SrcName string
 	Status  string
+	// Commit is the commit whose diff the pointer was found in, when
+	// scanning with git log.
+	Commit string
 	*Pointer
 }
 
@@ -107,6 +107,7 @@ func TestLogScannerAdditionsNoFiltering(t *testing.T) {
 		assert.Equal(t, "radial_1.png", p.Name)
 		assert.Equal(t, "3301b3da173d231f0f6b1f9bf075e573758cd79b3cfeff7623a953d708d6688b", p.Oid)
 		assert.Equal(t, int64(3152388), p.Size)
+		assert.Equal(t, "07d571b413957508679042e45508af5945b3f1e5", p.Commit)
 	}
 
 	// modification, + side with extensions
@@ -116,6 +117,7 @@ func TestLogScannerAdditionsNoFiltering(t *testing.T) {
 		assert.Equal(t, "radial_2.png", p.Name)
 		assert.Equal(t, "4b666195c133d8d0541ad0bc0e77399b9dc81861577a98314ac1ff1e9877893a", p.Oid)
 		assert.Equal(t, int64(3152388), p.Size)
+		assert.Equal(t, "07d571b413957508679042e45508af5945b3f1e5", p.Commit)
 	}
 
 	// addition, + side
@@ -125,6 +127,7 @@ func TestLogScannerAdditionsNoFiltering(t *testing.T) {
 		assert.Equal(t, "1D_Noise.png", p.Name)
 		assert.Equal(t, "f5d84da40ab1f6aa28df2b2bf1ade2cdcd4397133f903c12b4106641b10e1ed6", p.Oid)
 		assert.Equal(t, int64(1289), p.Size)
+		assert.Equal(t, "60fde3d23553e10a55e2a32ed18c20f65edd91e7", p.Commit)
 	}
 
 	// addition, + side
@@ -134,6 +137,7 @@ func TestLogScannerAdditionsNoFiltering(t *testing.T) {
 		assert.Equal(t, "waveNM.png", p.Name)
 		assert.Equal(t, "fe2c2f236b97bba4585d9909a227a8fa64897d9bbe297fa272f714302d86c908", p.Oid)
 		assert.Equal(t, int64(125873), p.Size)
+		assert.Equal(t, "60fde3d23553e10a55e2a32ed18c20f65edd91e7", p.Commit)
 	}
 
 	// addition, + side with extensions
@@ -143,6 +147,7 @@ func TestLogScannerAdditionsNoFiltering(t *testing.T) {
 		assert.Equal(t, "hobbit_5armies_2.mov", p.Name)
 		assert.Equal(t, "ebff26d6b557b1416a6fded097fd9b9102e2d8195532c377ac365c736c87d4bc", p.Oid)
 		assert.Equal(t, int64(127142413), p.Size)
+		assert.Equal(t, "64b3372e108daaa593412d5e1d9df8169a9547ea", p.Commit)
 	}
 
 	assertScannerDone(t, scanner)
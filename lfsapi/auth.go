@@ -58,8 +58,11 @@ func (c *Client) DoWithAuth(remote string, req *http.Request) (*http.Response, e
 }
 
 func (c *Client) doWithCreds(req *http.Request, credHelper CredentialHelper, creds Creds, credsURL *url.URL, access Access) (*http.Response, error) {
-	if access == NTLMAccess {
+	switch access {
+	case NTLMAccess:
 		return c.doWithNTLM(req, credHelper, creds, credsURL)
+	case NegotiateAccess:
+		return c.doHTTP1(req)
 	}
 	return c.do(req)
 }
@@ -24,7 +24,7 @@ const MediaType = "application/vnd.git-lfs+json; charset=utf-8"
 
 var (
 	UserAgent = "git-lfs"
-	httpRE    = regexp.MustCompile(`\Ahttps?://`)
+	httpRE    = regexp.MustCompile(`\A(https?|http\+unix)://`)
 )
 
 func (c *Client) NewRequest(method string, e Endpoint, suffix string, body interface{}) (*http.Request, error) {
@@ -45,6 +45,10 @@ func (c *Client) NewRequest(method string, e Endpoint, suffix string, body inter
 		prefix = sshRes.Href
 	}
 
+	if isFileUrl(prefix) {
+		return nil, errors.Errorf("lfsapi: %q is a local repository, which has no Git LFS API. Set lfs.url to the URL of your Git LFS server.", prefix)
+	}
+
 	if !httpRE.MatchString(prefix) {
 		urlfragment := strings.SplitN(prefix, "?", 2)[0]
 		return nil, fmt.Errorf("missing protocol: %q", urlfragment)
@@ -91,9 +95,20 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 // as defined in c.handleResponse. Notably, it does not alter the headers for
 // the request argument in any way.
 func (c *Client) do(req *http.Request) (*http.Response, error) {
-	req.Header.Set("User-Agent", UserAgent)
+	return c.doWithClient(c.httpClient(req.Host), req)
+}
+
+// doHTTP1 is as do, but never uses HTTP/2. NTLM and Negotiate authenticate the
+// connection a request is sent over rather than the request itself, which
+// HTTP/2 does not allow for, since it sends many requests over one connection.
+func (c *Client) doHTTP1(req *http.Request) (*http.Response, error) {
+	return c.doWithClient(c.hostClient(req.Host, false), req)
+}
+
+func (c *Client) doWithClient(cli *http.Client, req *http.Request) (*http.Response, error) {
+	req.Header.Set("User-Agent", c.userAgentFor(req))
 
-	res, err := c.doWithRedirects(c.httpClient(req.Host), req, nil)
+	res, err := c.doWithRedirects(cli, req, nil)
 	if err != nil {
 		return res, err
 	}
@@ -157,6 +172,7 @@ func (c *Client) doWithRedirects(cli *http.Client, req *http.Request, via []*htt
 
 	var res *http.Response
 
+	start := time.Now()
 	requests := tools.MaxInt(0, retries) + 1
 	for i := 0; i < requests; i++ {
 		res, err = cli.Do(req)
@@ -173,6 +189,7 @@ func (c *Client) doWithRedirects(cli *http.Client, req *http.Request, via []*htt
 
 	if err != nil {
 		c.traceResponse(req, tracedReq, nil)
+		tools.PerformanceSince(fmt.Sprintf("HTTP: %s failed", traceReq(req)), start)
 		return nil, err
 	}
 
@@ -181,6 +198,7 @@ func (c *Client) doWithRedirects(cli *http.Client, req *http.Request, via []*htt
 	}
 
 	c.traceResponse(req, tracedReq, res)
+	tools.PerformanceSince(fmt.Sprintf("HTTP: %s %d", traceReq(req), res.StatusCode), start)
 
 	if res.StatusCode != 301 &&
 		res.StatusCode != 302 &&
@@ -212,10 +230,28 @@ func (c *Client) doWithRedirects(cli *http.Client, req *http.Request, via []*htt
 		return res, err
 	}
 
+	if redirectedReq.URL.Host != req.URL.Host {
+		// The headers of http.extraHeader for the original URL are
+		// not passed on by newRequestForRetry, so apply those for
+		// the new one, if any.
+		for k, vs := range c.extraHeaders(redirectedReq.URL) {
+			for _, v := range vs {
+				redirectedReq.Header.Add(k, v)
+			}
+		}
+	}
+
 	return c.doWithRedirects(cli, redirectedReq, via)
 }
 
 func (c *Client) httpClient(host string) *http.Client {
+	return c.hostClient(host, true)
+}
+
+// hostClient returns the *http.Client for requests to the given host, which
+// uses HTTP/2 if allowHTTP2 is true, the server supports it, and http.version
+// does not rule it out.
+func (c *Client) hostClient(host string, allowHTTP2 bool) *http.Client {
 	c.clientMu.Lock()
 	defer c.clientMu.Unlock()
 
@@ -230,8 +266,15 @@ func (c *Client) httpClient(host string) *http.Client {
 	if c.hostClients == nil {
 		c.hostClients = make(map[string]*http.Client)
 	}
+	if c.http1HostClients == nil {
+		c.http1HostClients = make(map[string]*http.Client)
+	}
 
-	if client, ok := c.hostClients[host]; ok {
+	clients := c.hostClients
+	if !allowHTTP2 {
+		clients = c.http1HostClients
+	}
+	if client, ok := clients[host]; ok {
 		return client
 	}
 
@@ -255,10 +298,18 @@ func (c *Client) httpClient(host string) *http.Client {
 		tlstime = 30
 	}
 
+	// Keep at least one idle connection per worker, so that transfers of
+	// many small objects can reuse connections instead of paying for a
+	// new TCP and TLS handshake each time.
+	maxIdleConns := c.MaxIdleConnsPerHost
+	if maxIdleConns < 1 {
+		maxIdleConns = concurrentTransfers
+	}
+
 	tr := &http.Transport{
 		Proxy:               proxyFromClient(c),
 		TLSHandshakeTimeout: time.Duration(tlstime) * time.Second,
-		MaxIdleConnsPerHost: concurrentTransfers,
+		MaxIdleConnsPerHost: maxIdleConns,
 	}
 
 	activityTimeout := 30
@@ -293,6 +344,10 @@ func (c *Client) httpClient(host string) *http.Client {
 		tr.DialContext = dialer.DialContext
 	}
 
+	// Servers listening on Unix domain sockets are dialed through the
+	// same dialer, but by a transport of their own.
+	tr.RegisterProtocol(unixScheme, newUnixTransport(tr.DialContext, maxIdleConns))
+
 	tr.TLSClientConfig = &tls.Config{}
 
 	if isClientCertEnabledForHost(c, host) {
@@ -307,6 +362,9 @@ func (c *Client) httpClient(host string) *http.Client {
 		tr.TLSClientConfig.RootCAs = getRootCAsForHost(c, host)
 	}
 
+	httpVersion, _ := c.uc.Get("http", fmt.Sprintf("https://%v", host), "version")
+	configureHTTP2(tr, allowHTTP2 && httpVersion != "HTTP/1.1")
+
 	httpClient := &http.Client{
 		Transport: tr,
 		CheckRedirect: func(*http.Request, []*http.Request) error {
@@ -314,7 +372,7 @@ func (c *Client) httpClient(host string) *http.Client {
 		},
 	}
 
-	c.hostClients[host] = httpClient
+	clients[host] = httpClient
 	if c.VerboseOut == nil {
 		c.VerboseOut = os.Stderr
 	}
@@ -328,6 +386,24 @@ func (c *Client) CurrentUser() (string, string) {
 	return userName, userEmail
 }
 
+// redirectHeaders are the headers of a request which are passed on when it is
+// redirected to a different host, such as the storage host of a pre-signed URL
+// or a CDN. Others, such as Authorization, those given by the action of a
+// batch response, or by http.extraHeader, are meant for the original host, and
+// could leak credentials to the new one, or make it reject the request.
+var redirectHeaders = map[string]bool{
+	"Accept":            true,
+	"Accept-Encoding":   true,
+	"Content-Encoding":  true,
+	"Content-Length":    true,
+	"Content-Type":      true,
+	"Range":             true,
+	"Transfer-Encoding": true,
+	"Tus-Resumable":     true,
+	"Upload-Offset":     true,
+	"User-Agent":        true,
+}
+
 func newRequestForRetry(req *http.Request, location string) (*http.Request, error) {
 	newReq, err := http.NewRequest(req.Method, location, nil)
 	if err != nil {
@@ -340,10 +416,8 @@ func newRequestForRetry(req *http.Request, location string) (*http.Request, erro
 
 	sameHost := req.URL.Host == newReq.URL.Host
 	for key := range req.Header {
-		if key == "Authorization" {
-			if !sameHost {
-				continue
-			}
+		if !sameHost && !redirectHeaders[http.CanonicalHeaderKey(key)] {
+			continue
 		}
 		newReq.Header.Set(key, req.Header.Get(key))
 	}
@@ -3,9 +3,11 @@ package lfsapi
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"sync/atomic"
 	"testing"
 
@@ -38,9 +40,10 @@ func TestClientRedirect(t *testing.T) {
 			// Since srv3 listens on both a TLS-enabled socket and a
 			// TLS-disabled one, they are two different hosts.
 			// Ensure that, even though this is a "secure" upgrade,
-			// the authorization header is stripped.
+			// the authorization header is stripped, and so are
+			// others meant for the original host.
 			assert.Equal(t, "", r.Header.Get("Authorization"))
-			assert.Equal(t, "1", r.Header.Get("A"))
+			assert.Equal(t, "", r.Header.Get("A"))
 
 		case "/downgrade":
 			assert.Equal(t, "auth", r.Header.Get("Authorization"))
@@ -61,7 +64,7 @@ func TestClientRedirect(t *testing.T) {
 		switch r.URL.Path {
 		case "/ok":
 			assert.Equal(t, "", r.Header.Get("Authorization"))
-			assert.Equal(t, "1", r.Header.Get("A"))
+			assert.Equal(t, "", r.Header.Get("A"))
 			body := &redirectTest{}
 			err := json.NewDecoder(r.Body).Decode(body)
 			assert.Nil(t, err)
@@ -175,6 +178,86 @@ func TestClientRedirect(t *testing.T) {
 	assert.EqualError(t, err, "lfsapi/client: refusing insecure redirect, https->http")
 }
 
+func TestClientRedirectSendsFileBodyAgain(t *testing.T) {
+	srv2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		by, err := ioutil.ReadAll(r.Body)
+		assert.Nil(t, err)
+		assert.Equal(t, "contents", string(by))
+
+		w.WriteHeader(200)
+	}))
+	defer srv2.Close()
+
+	srv1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", srv2.URL+"/ok")
+		w.WriteHeader(307)
+	}))
+	defer srv1.Close()
+
+	f, err := ioutil.TempFile("", "lfsapi-redirect")
+	require.Nil(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	_, err = f.WriteString("contents")
+	require.Nil(t, err)
+
+	c, err := NewClient(nil)
+	require.Nil(t, err)
+
+	req, err := http.NewRequest("PUT", srv1.URL+"/storage", nil)
+	require.Nil(t, err)
+	req.Body = f
+	req.ContentLength = 8
+
+	res, err := c.Do(req)
+	require.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+}
+
+func TestClientRedirectToOtherHostStripsHeaders(t *testing.T) {
+	var called uint32
+	srv2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&called, 1)
+
+		assert.Equal(t, "", r.Header.Get("Authorization"))
+		assert.Equal(t, "", r.Header.Get("X-Lfs-Token"))
+		assert.Equal(t, "", r.Header.Get("X-Srv1"))
+		assert.Equal(t, "2", r.Header.Get("X-Srv2"))
+		assert.Equal(t, "bytes=2-7", r.Header.Get("Range"))
+		assert.NotEmpty(t, r.Header.Get("User-Agent"))
+
+		w.WriteHeader(200)
+	}))
+	defer srv2.Close()
+
+	srv1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "auth", r.Header.Get("Authorization"))
+		assert.Equal(t, "1", r.Header.Get("X-Srv1"))
+
+		w.Header().Set("Location", srv2.URL+"/storage?signature=abc")
+		w.WriteHeader(302)
+	}))
+	defer srv1.Close()
+
+	c, err := NewClient(NewContext(nil, nil, map[string]string{
+		fmt.Sprintf("http.%s.extraheader", srv1.URL): "X-Srv1: 1",
+		fmt.Sprintf("http.%s.extraheader", srv2.URL): "X-Srv2: 2",
+	}))
+	require.Nil(t, err)
+
+	req, err := http.NewRequest("GET", srv1.URL+"/storage", nil)
+	require.Nil(t, err)
+	req.Header.Set("Authorization", "auth")
+	req.Header.Set("X-Lfs-Token", "token")
+	req.Header.Set("Range", "bytes=2-7")
+
+	res, err := c.Do(req)
+	require.Nil(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.EqualValues(t, 1, atomic.LoadUint32(&called))
+}
+
 func TestNewClient(t *testing.T) {
 	c, err := NewClient(NewContext(nil, nil, map[string]string{
 		"lfs.dialtimeout":         "151",
@@ -259,6 +342,18 @@ func TestNewRequest(t *testing.T) {
 	}
 }
 
+func TestNewRequestWithFileEndpoint(t *testing.T) {
+	c, err := NewClient(NewContext(nil, nil, map[string]string{
+		"remote.origin.url": "file:///srv/repo.git",
+	}))
+	require.Nil(t, err)
+
+	req, err := c.NewRequest("POST", c.Endpoints.Endpoint("download", ""), "objects/batch", nil)
+	assert.Nil(t, req)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Set lfs.url")
+}
+
 func TestNewRequestWithBody(t *testing.T) {
 	c, err := NewClient(NewContext(nil, nil, map[string]string{
 		"lfs.url": "https://example.com",
@@ -293,3 +388,26 @@ func TestMarshalToRequest(t *testing.T) {
 	assert.Equal(t, "15", req.Header.Get("Content-Length"))
 	assert.EqualValues(t, 15, req.ContentLength)
 }
+
+func TestHttpClientMaxIdleConnsPerHostDefaultsToConcurrentTransfers(t *testing.T) {
+	c, err := NewClient(NewContext(nil, nil, map[string]string{
+		"lfs.concurrenttransfers": "5",
+	}))
+	require.Nil(t, err)
+
+	tr, ok := c.httpClient("anyhost.com").Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, 5, tr.MaxIdleConnsPerHost)
+}
+
+func TestHttpClientMaxIdleConnsPerHostFromConfig(t *testing.T) {
+	c, err := NewClient(NewContext(nil, nil, map[string]string{
+		"lfs.concurrenttransfers": "5",
+		"lfs.maxidleconnsperhost": "20",
+	}))
+	require.Nil(t, err)
+
+	tr, ok := c.httpClient("anyhost.com").Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, 20, tr.MaxIdleConnsPerHost)
+}
@@ -7,8 +7,10 @@ import (
 	"os/exec"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/tools"
 	"github.com/rubyist/tracerx"
 )
 
@@ -65,12 +67,33 @@ func (c *Client) getCredentialHelper(u *url.URL) (CredentialHelper, Creds) {
 		helper, _ := c.uc.Get("credential", rawurl, "helper")
 		if len(helper) == 0 {
 			helpers = append(helpers, c.askpassCredHelper)
+		} else if !hasTerminal() {
+			// Without a terminal to prompt on, fall back to the
+			// ASKPASS program if no credential helper fills the
+			// credentials, so that GUI clients can prompt for
+			// them.
+			helpers = append(helpers, c.commandCredHelper, c.askpassCredHelper)
+			return NewCredentialHelpers(helpers), input
 		}
 	}
 
 	return NewCredentialHelpers(append(helpers, c.commandCredHelper)), input
 }
 
+// hasTerminal returns whether there is a terminal which the user can be
+// prompted for credentials on, by Git or ssh. They prompt on the controlling
+// terminal rather than on standard input, which may well be redirected, as it
+// is in the pre-push hook, while the terminal is still there. It is a variable
+// so that tests can replace it.
+var hasTerminal = func() bool {
+	tty, err := tools.OpenTTY()
+	if err != nil {
+		return false
+	}
+	tty.Close()
+	return true
+}
+
 // AskPassCredentialHelper implements the CredentialHelper type for GIT_ASKPASS
 // and 'core.askpass' configuration values.
 type AskPassCredentialHelper struct {
@@ -198,10 +221,12 @@ func (h *commandCredentialHelper) exec(subcommand string, input Creds) (Creds, e
 	   See https://github.com/git-lfs/git-lfs/issues/117 for more details.
 	*/
 
+	start := time.Now()
 	err := cmd.Start()
 	if err == nil {
 		err = cmd.Wait()
 	}
+	tools.PerformanceSince(fmt.Sprintf("git credential %s", subcommand), start)
 
 	if _, ok := err.(*exec.ExitError); ok {
 		if h.SkipPrompt {
@@ -241,17 +266,25 @@ func newCredentialCacher() *credentialCacher {
 	return &credentialCacher{creds: make(map[string]Creds)}
 }
 
-func credCacheKey(creds Creds) string {
+// credCacheKey returns the key under which credentials for the given user of
+// the URL described by creds are cached. Those approved last for any user of
+// the URL are also cached under the key for the empty username, and given out
+// when no user is asked for.
+func credCacheKey(creds Creds, username string) string {
 	parts := []string{
 		creds["protocol"],
 		creds["host"],
 		creds["path"],
+		username,
 	}
 	return strings.Join(parts, "//")
 }
 
 func (c *credentialCacher) Fill(what Creds) (Creds, error) {
-	key := credCacheKey(what)
+	// Credentials cached for one user must not be handed out when a
+	// different user was asked for explicitly, as in
+	// "https://user@host/repo.git".
+	key := credCacheKey(what, what["username"])
 	c.mu.Lock()
 	cached, ok := c.creds[key]
 	c.mu.Unlock()
@@ -266,7 +299,7 @@ func (c *credentialCacher) Fill(what Creds) (Creds, error) {
 }
 
 func (c *credentialCacher) Approve(what Creds) error {
-	key := credCacheKey(what)
+	key := credCacheKey(what, what["username"])
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -276,13 +309,18 @@ func (c *credentialCacher) Approve(what Creds) error {
 	}
 
 	c.creds[key] = what
+	c.creds[credCacheKey(what, "")] = what
 	return credHelperNoOp
 }
 
 func (c *credentialCacher) Reject(what Creds) error {
-	key := credCacheKey(what)
+	anyKey := credCacheKey(what, "")
+
 	c.mu.Lock()
-	delete(c.creds, key)
+	delete(c.creds, credCacheKey(what, what["username"]))
+	if cached, ok := c.creds[anyKey]; ok && cached["username"] == what["username"] {
+		delete(c.creds, anyKey)
+	}
 	c.mu.Unlock()
 	return credHelperNoOp
 }
@@ -2,6 +2,7 @@ package lfsapi
 
 import (
 	"errors"
+	"net/url"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -264,3 +265,112 @@ func TestCredHelperSetAllFillErrors(t *testing.T) {
 	assert.Equal(t, 0, len(helper1.reject))
 	assert.Equal(t, 0, len(helper2.reject))
 }
+
+func TestCredentialCacherFillsApprovedCreds(t *testing.T) {
+	cache := newCredentialCacher()
+	creds := Creds{"protocol": "https", "host": "example.com", "username": "monalisa", "password": "secret"}
+
+	_, err := cache.Fill(Creds{"protocol": "https", "host": "example.com"})
+	assert.Equal(t, credHelperNoOp, err)
+
+	cache.Approve(creds)
+
+	out, err := cache.Fill(Creds{"protocol": "https", "host": "example.com"})
+	assert.Nil(t, err)
+	assert.Equal(t, creds, out)
+
+	out, err = cache.Fill(Creds{"protocol": "https", "host": "example.com", "username": "monalisa"})
+	assert.Nil(t, err)
+	assert.Equal(t, creds, out)
+
+	cache.Reject(creds)
+
+	_, err = cache.Fill(Creds{"protocol": "https", "host": "example.com"})
+	assert.Equal(t, credHelperNoOp, err)
+}
+
+func TestCredentialCacherSkipsCredsForOtherUser(t *testing.T) {
+	cache := newCredentialCacher()
+	cache.Approve(Creds{"protocol": "https", "host": "example.com", "username": "monalisa", "password": "secret"})
+
+	out, err := cache.Fill(Creds{"protocol": "https", "host": "example.com", "username": "hubot"})
+	assert.Equal(t, credHelperNoOp, err)
+	assert.Nil(t, out)
+}
+
+func TestCredentialCacherKeepsCredsPerUser(t *testing.T) {
+	cache := newCredentialCacher()
+	monalisa := Creds{"protocol": "https", "host": "example.com", "username": "monalisa", "password": "secret"}
+	hubot := Creds{"protocol": "https", "host": "example.com", "username": "hubot", "password": "beep"}
+
+	cache.Approve(monalisa)
+	cache.Approve(hubot)
+
+	out, err := cache.Fill(Creds{"protocol": "https", "host": "example.com", "username": "monalisa"})
+	assert.Nil(t, err)
+	assert.Equal(t, monalisa, out)
+
+	out, err = cache.Fill(Creds{"protocol": "https", "host": "example.com", "username": "hubot"})
+	assert.Nil(t, err)
+	assert.Equal(t, hubot, out)
+
+	out, err = cache.Fill(Creds{"protocol": "https", "host": "example.com"})
+	assert.Nil(t, err)
+	assert.Equal(t, hubot, out)
+
+	// Rejecting one user's credentials leaves the other's cached.
+	cache.Reject(monalisa)
+
+	_, err = cache.Fill(Creds{"protocol": "https", "host": "example.com", "username": "monalisa"})
+	assert.Equal(t, credHelperNoOp, err)
+
+	out, err = cache.Fill(Creds{"protocol": "https", "host": "example.com"})
+	assert.Nil(t, err)
+	assert.Equal(t, hubot, out)
+
+	cache.Reject(hubot)
+
+	_, err = cache.Fill(Creds{"protocol": "https", "host": "example.com"})
+	assert.Equal(t, credHelperNoOp, err)
+}
+
+func TestGetCredentialHelperFallsBackToAskPassWithoutTerminal(t *testing.T) {
+	defer func(f func() bool) { hasTerminal = f }(hasTerminal)
+	hasTerminal = func() bool { return false }
+
+	c, err := NewClient(NewContext(nil, map[string]string{
+		"GIT_ASKPASS": "askpass",
+	}, map[string]string{
+		"credential.helper":    "store",
+		"lfs.cachecredentials": "false",
+	}))
+	assert.Nil(t, err)
+
+	u, _ := url.Parse("https://git-server.com/repo")
+	helper, _ := c.getCredentialHelper(u)
+	helpers := helper.(*CredentialHelpers).helpers
+	if assert.Len(t, helpers, 2) {
+		assert.Equal(t, c.commandCredHelper, helpers[0])
+		assert.Equal(t, c.askpassCredHelper, helpers[1])
+	}
+}
+
+func TestGetCredentialHelperSkipsAskPassWithTerminal(t *testing.T) {
+	defer func(f func() bool) { hasTerminal = f }(hasTerminal)
+	hasTerminal = func() bool { return true }
+
+	c, err := NewClient(NewContext(nil, map[string]string{
+		"GIT_ASKPASS": "askpass",
+	}, map[string]string{
+		"credential.helper":    "store",
+		"lfs.cachecredentials": "false",
+	}))
+	assert.Nil(t, err)
+
+	u, _ := url.Parse("https://git-server.com/repo")
+	helper, _ := c.getCredentialHelper(u)
+	helpers := helper.(*CredentialHelpers).helpers
+	if assert.Len(t, helpers, 1) {
+		assert.Equal(t, c.commandCredHelper, helpers[0])
+	}
+}
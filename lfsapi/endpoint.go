@@ -102,7 +102,35 @@ func endpointFromHttpUrl(u *url.URL) Endpoint {
 	return Endpoint{Url: u.String()}
 }
 
+// endpointFromGitUrl constructs a new endpoint from a git:// URL. The port, if
+// any, is that of the git daemon, and is dropped since the Git LFS API is
+// served over lfs.gitprotocol on its default port. IPv6 literals keep their
+// brackets.
 func endpointFromGitUrl(u *url.URL, e *endpointGitFinder) Endpoint {
 	u.Scheme = e.gitProtocol
+	u.Host = u.Hostname()
+	if strings.Contains(u.Host, ":") {
+		u.Host = "[" + u.Host + "]"
+	}
+	return Endpoint{Url: u.String()}
+}
+
+// endpointFromFileUrl constructs a new endpoint from a file:// URL, which is
+// passed through as-is.
+func endpointFromFileUrl(u *url.URL) Endpoint {
 	return Endpoint{Url: u.String()}
 }
+
+// endpointFromLocalPath constructs a new endpoint from an absolute path to a
+// local repository, such as one given to `git clone /path/to/repo`:
+//
+//   /path/to/repo.git
+//
+func endpointFromLocalPath(path string) Endpoint {
+	u := &url.URL{Scheme: "file", Path: path}
+	return Endpoint{Url: u.String()}
+}
+
+func isFileUrl(rawurl string) bool {
+	return strings.HasPrefix(rawurl, "file://")
+}
@@ -41,8 +41,9 @@ type endpointGitFinder struct {
 	gitEnv      config.Environment
 	gitProtocol string
 
-	aliasMu sync.Mutex
-	aliases map[string]string
+	aliasMu     sync.Mutex
+	aliases     map[string]string
+	pushAliases map[string]string
 
 	accessMu  sync.Mutex
 	urlAccess map[string]Access
@@ -59,6 +60,7 @@ func NewEndpointFinder(ctx Context) EndpointFinder {
 		gitEnv:      ctx.GitEnv(),
 		gitProtocol: "https",
 		aliases:     make(map[string]string),
+		pushAliases: make(map[string]string),
 		urlAccess:   make(map[string]Access),
 	}
 
@@ -120,7 +122,13 @@ func (e *endpointGitFinder) RemoteEndpoint(operation, remote string) Endpoint {
 		return e.NewEndpoint(url)
 	}
 
-	// finally fall back on git remote url (also supports pushurl)
+	// finally fall back on git remote url (also supports pushurl and
+	// pushinsteadof)
+	if operation == "upload" {
+		if url, ok := e.pushURLFromAlias(remote); ok {
+			return e.NewEndpointFromCloneURL(url)
+		}
+	}
 	if url := e.GitRemoteURL(remote, operation == "upload"); url != "" {
 		return e.NewEndpointFromCloneURL(url)
 	}
@@ -150,7 +158,9 @@ func (e *endpointGitFinder) GitRemoteURL(remote string, forpush bool) string {
 
 func (e *endpointGitFinder) NewEndpointFromCloneURL(rawurl string) Endpoint {
 	ep := e.NewEndpoint(rawurl)
-	if ep.Url == UrlUnknown {
+	if ep.Url == UrlUnknown || isFileUrl(ep.Url) {
+		// Local repositories have no Git LFS API of their own, so there
+		// is no sensible "info/lfs" suffix to append.
 		return ep
 	}
 
@@ -182,7 +192,12 @@ func (e *endpointGitFinder) NewEndpoint(rawurl string) Endpoint {
 		return endpointFromHttpUrl(u)
 	case "git":
 		return endpointFromGitUrl(u, e)
+	case "file":
+		return endpointFromFileUrl(u)
 	case "":
+		if strings.HasPrefix(rawurl, "/") {
+			return endpointFromLocalPath(rawurl)
+		}
 		return endpointFromBareSshUrl(u.String())
 	default:
 		// Just passthrough to preserve
@@ -263,8 +278,34 @@ func (e *endpointGitFinder) ReplaceUrlAlias(rawurl string) string {
 	e.aliasMu.Lock()
 	defer e.aliasMu.Unlock()
 
+	url, _ := replaceAlias(e.aliases, rawurl)
+	return url
+}
+
+// pushURLFromAlias returns the URL that Git pushes to for the given remote if
+// its URL is rewritten by a `url.*.pushinsteadof` git config setting. As with
+// Git, such settings don't apply to remotes with a push URL of their own.
+func (e *endpointGitFinder) pushURLFromAlias(remote string) (string, bool) {
+	if _, ok := e.gitEnv.Get("remote." + remote + ".pushurl"); ok {
+		return "", false
+	}
+
+	rawurl, ok := e.gitEnv.Get("remote." + remote + ".url")
+	if !ok {
+		return "", false
+	}
+
+	e.aliasMu.Lock()
+	defer e.aliasMu.Unlock()
+
+	return replaceAlias(e.pushAliases, rawurl)
+}
+
+// replaceAlias replaces the longest of the given aliases which prefixes
+// rawurl, returning whether there was one.
+func replaceAlias(aliases map[string]string, rawurl string) (string, bool) {
 	var longestalias string
-	for alias, _ := range e.aliases {
+	for alias, _ := range aliases {
 		if !strings.HasPrefix(rawurl, alias) {
 			continue
 		}
@@ -275,22 +316,33 @@ func (e *endpointGitFinder) ReplaceUrlAlias(rawurl string) string {
 	}
 
 	if len(longestalias) > 0 {
-		return e.aliases[longestalias] + rawurl[len(longestalias):]
+		return aliases[longestalias] + rawurl[len(longestalias):], true
 	}
 
-	return rawurl
+	return rawurl, false
 }
 
 func initAliases(e *endpointGitFinder, git config.Environment) {
 	prefix := "url."
-	suffix := ".insteadof"
 	for gitkey, gitval := range git.All() {
-		if len(gitval) == 0 || !(strings.HasPrefix(gitkey, prefix) && strings.HasSuffix(gitkey, suffix)) {
+		if len(gitval) == 0 || !strings.HasPrefix(gitkey, prefix) {
 			continue
 		}
-		if _, ok := e.aliases[gitval[len(gitval)-1]]; ok {
-			fmt.Fprintf(os.Stderr, "WARNING: Multiple 'url.*.insteadof' keys with the same alias: %q\n", gitval)
+
+		var aliases map[string]string
+		var suffix string
+		switch {
+		case strings.HasSuffix(gitkey, ".insteadof"):
+			aliases, suffix = e.aliases, ".insteadof"
+		case strings.HasSuffix(gitkey, ".pushinsteadof"):
+			aliases, suffix = e.pushAliases, ".pushinsteadof"
+		default:
+			continue
+		}
+
+		if _, ok := aliases[gitval[len(gitval)-1]]; ok {
+			fmt.Fprintf(os.Stderr, "WARNING: Multiple 'url.*%s' keys with the same alias: %q\n", suffix, gitval)
 		}
-		e.aliases[gitval[len(gitval)-1]] = gitkey[len(prefix) : len(gitkey)-len(suffix)]
+		aliases[gitval[len(gitval)-1]] = gitkey[len(prefix) : len(gitkey)-len(suffix)]
 	}
 }
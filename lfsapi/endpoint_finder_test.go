@@ -128,6 +128,48 @@ func TestEndpointGlobalSeparateLfsPush(t *testing.T) {
 	assert.Equal(t, "", e.SshPath)
 }
 
+func TestEndpointInsteadOf(t *testing.T) {
+	finder := NewEndpointFinder(NewContext(nil, nil, map[string]string{
+		"remote.origin.url":                  "git@example.com:foo/bar.git",
+		"url.https://example.com/.insteadof": "git@example.com:",
+	}))
+
+	for _, operation := range []string{"download", "upload"} {
+		e := finder.Endpoint(operation, "")
+		assert.Equal(t, "https://example.com/foo/bar.git/info/lfs", e.Url)
+		assert.Equal(t, "", e.SshUserAndHost)
+		assert.Equal(t, "", e.SshPath)
+	}
+}
+
+func TestEndpointPushInsteadOf(t *testing.T) {
+	finder := NewEndpointFinder(NewContext(nil, nil, map[string]string{
+		"remote.origin.url":                  "https://example.com/foo/bar.git",
+		"url.git@example.com:.pushinsteadof": "https://example.com/",
+	}))
+
+	e := finder.Endpoint("download", "")
+	assert.Equal(t, "https://example.com/foo/bar.git/info/lfs", e.Url)
+	assert.Equal(t, "", e.SshUserAndHost)
+
+	e = finder.Endpoint("upload", "")
+	assert.Equal(t, "https://example.com/foo/bar.git/info/lfs", e.Url)
+	assert.Equal(t, "git@example.com", e.SshUserAndHost)
+	assert.Equal(t, "foo/bar.git", e.SshPath)
+}
+
+func TestEndpointPushInsteadOfIgnoredWithPushUrl(t *testing.T) {
+	finder := NewEndpointFinder(NewContext(nil, nil, map[string]string{
+		"remote.origin.url":                    "https://example.com/foo/bar.git",
+		"remote.origin.pushurl":                "https://readwrite.com/foo/bar.git",
+		"url.git@readwrite.com:.pushinsteadof": "https://readwrite.com/",
+	}))
+
+	e := finder.Endpoint("upload", "")
+	assert.Equal(t, "https://readwrite.com/foo/bar.git/info/lfs", e.Url)
+	assert.Equal(t, "", e.SshUserAndHost)
+}
+
 func TestSSHEndpointOverridden(t *testing.T) {
 	finder := NewEndpointFinder(NewContext(nil, nil, map[string]string{
 		"remote.origin.url":    "git@example.com:foo/bar",
@@ -250,6 +292,56 @@ func TestBareGitEndpointAddsLfsSuffix(t *testing.T) {
 	assert.Equal(t, "", e.SshPort)
 }
 
+func TestGitEndpointDropsGitDaemonPort(t *testing.T) {
+	finder := NewEndpointFinder(NewContext(nil, nil, map[string]string{
+		"remote.origin.url": "git://example.com:9418/foo/bar.git",
+	}))
+
+	e := finder.Endpoint("download", "")
+	assert.Equal(t, "https://example.com/foo/bar.git/info/lfs", e.Url)
+	assert.Equal(t, "", e.SshUserAndHost)
+	assert.Equal(t, "", e.SshPath)
+	assert.Equal(t, "", e.SshPort)
+}
+
+func TestGitEndpointKeepsIPv6Brackets(t *testing.T) {
+	for _, remote := range []string{
+		"git://[::1]:9418/foo/bar.git",
+		"git://[::1]/foo/bar.git",
+	} {
+		finder := NewEndpointFinder(NewContext(nil, nil, map[string]string{
+			"remote.origin.url": remote,
+		}))
+
+		e := finder.Endpoint("download", "")
+		assert.Equal(t, "https://[::1]/foo/bar.git/info/lfs", e.Url, remote)
+	}
+}
+
+func TestFileEndpointDoesNotAddLfsSuffix(t *testing.T) {
+	finder := NewEndpointFinder(NewContext(nil, nil, map[string]string{
+		"remote.origin.url": "file:///srv/foo/bar.git",
+	}))
+
+	e := finder.Endpoint("download", "")
+	assert.Equal(t, "file:///srv/foo/bar.git", e.Url)
+	assert.Equal(t, "", e.SshUserAndHost)
+	assert.Equal(t, "", e.SshPath)
+	assert.Equal(t, "", e.SshPort)
+}
+
+func TestLocalPathEndpointBecomesFileUrl(t *testing.T) {
+	finder := NewEndpointFinder(NewContext(nil, nil, map[string]string{
+		"remote.origin.url": "/srv/foo/bar.git",
+	}))
+
+	e := finder.Endpoint("download", "")
+	assert.Equal(t, "file:///srv/foo/bar.git", e.Url)
+	assert.Equal(t, "", e.SshUserAndHost)
+	assert.Equal(t, "", e.SshPath)
+	assert.Equal(t, "", e.SshPort)
+}
+
 func TestAccessConfig(t *testing.T) {
 	type accessTest struct {
 		Access        string
@@ -0,0 +1,12 @@
+// +build go1.13
+
+package lfsapi
+
+import "net/http"
+
+// configureHTTP2 enables HTTP/2 on the given transport, if the server supports
+// it. The net/http package only does this by default for transports without a
+// custom dialer or TLS configuration, both of which are always set here.
+func configureHTTP2(tr *http.Transport, enabled bool) {
+	tr.ForceAttemptHTTP2 = enabled
+}
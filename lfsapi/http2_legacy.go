@@ -0,0 +1,9 @@
+// +build !go1.13
+
+package lfsapi
+
+import "net/http"
+
+// configureHTTP2 is a no-op on versions of Go whose net/http package cannot
+// be asked to use HTTP/2 with a custom dialer or TLS configuration.
+func configureHTTP2(tr *http.Transport, enabled bool) {}
@@ -0,0 +1,49 @@
+// +build go1.13
+
+package lfsapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHttpClientAttemptsHTTP2(t *testing.T) {
+	c, err := NewClient(nil)
+	require.Nil(t, err)
+
+	tr, ok := c.httpClient("anyhost.com").Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.True(t, tr.ForceAttemptHTTP2)
+}
+
+func TestHttpClientHTTP2DisabledByHttpVersion(t *testing.T) {
+	c, err := NewClient(NewContext(nil, nil, map[string]string{
+		"http.https://specifichost.com.version": "HTTP/1.1",
+	}))
+	require.Nil(t, err)
+
+	tr, ok := c.httpClient("specifichost.com").Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.False(t, tr.ForceAttemptHTTP2)
+
+	tr, ok = c.httpClient("otherhost.com").Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.True(t, tr.ForceAttemptHTTP2)
+}
+
+func TestHttpClientHTTP2DisabledForConnectionAuth(t *testing.T) {
+	c, err := NewClient(nil)
+	require.Nil(t, err)
+
+	tr, ok := c.hostClient("anyhost.com", false).Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.False(t, tr.ForceAttemptHTTP2)
+
+	// Other requests to the same host still use HTTP/2.
+	tr, ok = c.httpClient("anyhost.com").Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.True(t, tr.ForceAttemptHTTP2)
+}
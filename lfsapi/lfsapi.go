@@ -29,6 +29,7 @@ type Client struct {
 	KeepaliveTimeout    int
 	TLSTimeout          int
 	ConcurrentTransfers int
+	MaxIdleConnsPerHost int
 	SkipSSLVerify       bool
 
 	Verbose          bool
@@ -36,7 +37,10 @@ type Client struct {
 	VerboseOut       io.Writer
 
 	hostClients map[string]*http.Client
-	clientMu    sync.Mutex
+	// http1HostClients are the clients of hostClients which never use
+	// HTTP/2, for NTLM and Negotiate authentication.
+	http1HostClients map[string]*http.Client
+	clientMu         sync.Mutex
 
 	ntlmSessions map[string]ntlm.ClientSession
 	ntlmMu       sync.Mutex
@@ -71,8 +75,16 @@ func NewClient(ctx Context) (*Client, error) {
 		return nil, errors.Wrap(err, fmt.Sprintf("bad netrc file %s", netrcfile))
 	}
 
+	askpass, ok := osEnv.Get("GIT_ASKPASS")
+	if !ok {
+		askpass, ok = gitEnv.Get("core.askpass")
+	}
+	if !ok {
+		askpass, _ = osEnv.Get("SSH_ASKPASS")
+	}
+
 	cacheCreds := gitEnv.Bool("lfs.cachecredentials", true)
-	var sshResolver SSHResolver = &sshAuthClient{os: osEnv}
+	var sshResolver SSHResolver = &sshAuthClient{os: osEnv, askpass: askpass}
 	if cacheCreds {
 		sshResolver = withSSHCache(sshResolver)
 	}
@@ -85,6 +97,7 @@ func NewClient(ctx Context) (*Client, error) {
 		KeepaliveTimeout:    gitEnv.Int("lfs.keepalive", 0),
 		TLSTimeout:          gitEnv.Int("lfs.tlstimeout", 0),
 		ConcurrentTransfers: gitEnv.Int("lfs.concurrenttransfers", 3),
+		MaxIdleConnsPerHost: gitEnv.Int("lfs.maxidleconnsperhost", 0),
 		SkipSSLVerify:       !gitEnv.Bool("http.sslverify", true) || osEnv.Bool("GIT_SSL_NO_VERIFY", false),
 		Verbose:             osEnv.Bool("GIT_CURL_VERBOSE", false),
 		DebuggingVerbose:    osEnv.Bool("LFS_DEBUG_HTTP", false),
@@ -96,13 +109,6 @@ func NewClient(ctx Context) (*Client, error) {
 		uc:     config.NewURLConfig(gitEnv),
 	}
 
-	askpass, ok := osEnv.Get("GIT_ASKPASS")
-	if !ok {
-		askpass, ok = gitEnv.Get("core.askpass")
-	}
-	if !ok {
-		askpass, _ = osEnv.Get("SSH_ASKPASS")
-	}
 	if len(askpass) > 0 {
 		c.askpassCredHelper = &AskPassCredentialHelper{
 			Program: askpass,
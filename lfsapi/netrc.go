@@ -12,13 +12,15 @@ type NetrcFinder interface {
 	FindMachine(string) *netrc.Machine
 }
 
+// ParseNetrc parses the user's netrc file, if there is one. Like curl, the
+// NETRC environment variable may be used to name a file other than the default
+// ~/.netrc (or %HOME%\_netrc on Windows).
 func ParseNetrc(osEnv config.Environment) (NetrcFinder, string, error) {
-	home, _ := osEnv.Get("HOME")
-	if len(home) == 0 {
+	nrcfilename := netrcFilename(osEnv)
+	if len(nrcfilename) == 0 {
 		return &noFinder{}, "", nil
 	}
 
-	nrcfilename := filepath.Join(home, netrcBasename)
 	if _, err := os.Stat(nrcfilename); err != nil {
 		return &noFinder{}, nrcfilename, nil
 	}
@@ -27,6 +29,18 @@ func ParseNetrc(osEnv config.Environment) (NetrcFinder, string, error) {
 	return f, nrcfilename, err
 }
 
+func netrcFilename(osEnv config.Environment) string {
+	if nrcfilename, _ := osEnv.Get("NETRC"); len(nrcfilename) > 0 {
+		return nrcfilename
+	}
+
+	home, _ := osEnv.Get("HOME")
+	if len(home) == 0 {
+		return ""
+	}
+	return filepath.Join(home, netrcBasename)
+}
+
 type noFinder struct{}
 
 func (f *noFinder) FindMachine(host string) *netrc.Machine {
@@ -1,12 +1,18 @@
 package lfsapi
 
 import (
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/bgentry/go-netrc/netrc"
+	"github.com/git-lfs/git-lfs/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNetrcWithHostAndPort(t *testing.T) {
@@ -75,6 +81,52 @@ func TestNetrcWithBadHost(t *testing.T) {
 	}
 }
 
+func TestParseNetrcFromNetrcEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfsapi-netrc")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "custom-netrc")
+	require.Nil(t, ioutil.WriteFile(filename, []byte("machine netrc-host login abc password def\n"), 0600))
+
+	finder, path, err := ParseNetrc(config.EnvironmentOf(config.UniqMapFetcher(map[string]string{
+		"HOME":  filepath.Join(dir, "home"),
+		"NETRC": filename,
+	})))
+	require.Nil(t, err)
+	assert.Equal(t, filename, path)
+
+	machine := finder.FindMachine("netrc-host")
+	require.NotNil(t, machine)
+	assert.Equal(t, "abc", machine.Login)
+	assert.Equal(t, "def", machine.Password)
+}
+
+func TestParseNetrcFromHome(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfsapi-netrc")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, netrcBasename)
+	require.Nil(t, ioutil.WriteFile(filename, []byte("machine netrc-host login abc password def\n"), 0600))
+
+	finder, path, err := ParseNetrc(config.EnvironmentOf(config.UniqMapFetcher(map[string]string{
+		"HOME": dir,
+	})))
+	require.Nil(t, err)
+	assert.Equal(t, filename, path)
+	assert.NotNil(t, finder.FindMachine("netrc-host"))
+}
+
+func TestParseNetrcWithMissingFile(t *testing.T) {
+	finder, path, err := ParseNetrc(config.EnvironmentOf(config.UniqMapFetcher(map[string]string{
+		"NETRC": "/does/not/exist/netrc",
+	})))
+	require.Nil(t, err)
+	assert.Equal(t, "/does/not/exist/netrc", path)
+	assert.Nil(t, finder.FindMachine("netrc-host"))
+}
+
 type fakeNetrc struct{}
 
 func (n *fakeNetrc) FindMachine(host string) *netrc.Machine {
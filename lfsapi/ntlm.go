@@ -14,7 +14,7 @@ import (
 )
 
 func (c *Client) doWithNTLM(req *http.Request, credHelper CredentialHelper, creds Creds, credsURL *url.URL) (*http.Response, error) {
-	res, err := c.do(req)
+	res, err := c.doHTTP1(req)
 	if err != nil && !errors.IsAuthError(err) {
 		return res, err
 	}
@@ -69,7 +69,7 @@ func (c *Client) ntlmReAuth(req *http.Request, credHelper CredentialHelper, cred
 
 func (c *Client) ntlmNegotiate(req *http.Request, message string) (*http.Response, []byte, error) {
 	req.Header.Add("Authorization", message)
-	res, err := c.do(req)
+	res, err := c.doHTTP1(req)
 	if err != nil && !errors.IsAuthError(err) {
 		return res, nil, err
 	}
@@ -100,7 +100,7 @@ func (c *Client) ntlmChallenge(req *http.Request, challengeBytes []byte, creds C
 
 	authMsg := base64.StdEncoding.EncodeToString(authenticate.Bytes())
 	req.Header.Set("Authorization", "NTLM "+authMsg)
-	return c.do(req)
+	return c.doHTTP1(req)
 }
 
 func (c *Client) ntlmClientSession(creds Creds) (ntlm.ClientSession, error) {
@@ -133,8 +133,19 @@ func (c *Client) ntlmClientSession(creds Creds) (ntlm.ClientSession, error) {
 	return session, nil
 }
 
+// parseChallengeResponse returns the decoded NTLM challenge message from the
+// given response. Servers such as IIS may send several Www-Authenticate headers
+// (for example, "Negotiate" alongside "NTLM <challenge>"), so the first one
+// carrying an NTLM challenge is used.
 func parseChallengeResponse(res *http.Response) ([]byte, error) {
 	header := res.Header.Get("Www-Authenticate")
+	for _, value := range res.Header["Www-Authenticate"] {
+		if len(value) > 5 && strings.EqualFold(value[:5], "NTLM ") {
+			header = value
+			break
+		}
+	}
+
 	if len(header) < 6 {
 		return nil, fmt.Errorf("Invalid NTLM challenge response: %q", header)
 	}
@@ -135,6 +135,16 @@ func TestNtlmHeaderParseValid(t *testing.T) {
 	assert.False(t, strings.HasPrefix(string(bytes), "NTLM"))
 }
 
+func TestNtlmHeaderParseMultipleChallenges(t *testing.T) {
+	res := http.Response{}
+	res.Header = make(map[string][]string)
+	res.Header.Add("Www-Authenticate", "Negotiate")
+	res.Header.Add("Www-Authenticate", "NTLM "+base64.StdEncoding.EncodeToString([]byte("I am a moose")))
+	bytes, err := parseChallengeResponse(&res)
+	assert.Nil(t, err)
+	assert.Equal(t, "I am a moose", string(bytes))
+}
+
 func TestNtlmHeaderParseInvalidLength(t *testing.T) {
 	res := http.Response{}
 	res.Header = make(map[string][]string)
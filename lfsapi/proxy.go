@@ -48,6 +48,16 @@ func proxyFromClient(c *Client) func(req *http.Request) (*url.URL, error) {
 	}
 }
 
+// ProxyFor returns the URL of the proxy through which requests to "rawurl" are
+// made, or nil if they are made directly.
+func (c *Client) ProxyFor(rawurl string) (*url.URL, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	return proxyFromClient(c)(&http.Request{URL: u})
+}
+
 func getProxyServers(u *url.URL, urlCfg *config.URLConfig, osEnv config.Environment) (httpsProxy string, httpProxy string, noProxy string) {
 	if urlCfg != nil {
 		httpProxy, _ = urlCfg.Get("http", u.String(), "proxy")
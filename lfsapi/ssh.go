@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
@@ -71,6 +72,10 @@ func (r *sshAuthResponse) IsExpiredWithin(d time.Duration) (time.Time, bool) {
 
 type sshAuthClient struct {
 	os config.Environment
+
+	// askpass is the ASKPASS program, given by GIT_ASKPASS, core.askpass
+	// or SSH_ASKPASS, if any.
+	askpass string
 }
 
 func (c *sshAuthClient) Resolve(e Endpoint, method string) (sshAuthResponse, error) {
@@ -81,6 +86,7 @@ func (c *sshAuthClient) Resolve(e Endpoint, method string) (sshAuthResponse, err
 
 	exe, args := sshGetLFSExeAndArgs(c.os, e, method)
 	cmd := exec.Command(exe, args...)
+	cmd.Env = sshAskPassEnv(c.os, c.askpass)
 
 	// Save stdout and stderr in separate buffers
 	var outbuf, errbuf bytes.Buffer
@@ -106,6 +112,27 @@ func (c *sshAuthClient) Resolve(e Endpoint, method string) (sshAuthResponse, err
 	return res, err
 }
 
+// sshAskPassEnv returns the environment to run ssh with, so that it prompts
+// for the passphrase of a key, if one is needed, with the given ASKPASS program
+// when there is no terminal to prompt on. Otherwise, it returns nil, for ssh to
+// inherit the environment of this process.
+func sshAskPassEnv(osEnv config.Environment, askpass string) []string {
+	if len(askpass) == 0 || hasTerminal() {
+		return nil
+	}
+
+	env := os.Environ()
+	if v, _ := osEnv.Get("SSH_ASKPASS"); len(v) == 0 {
+		env = append(env, "SSH_ASKPASS="+askpass)
+	}
+	if _, ok := osEnv.Get("SSH_ASKPASS_REQUIRE"); !ok {
+		// OpenSSH only uses SSH_ASKPASS without a terminal, or
+		// when told to prefer it.
+		env = append(env, "SSH_ASKPASS_REQUIRE=prefer")
+	}
+	return env
+}
+
 func sshGetLFSExeAndArgs(osEnv config.Environment, e Endpoint, method string) (string, []string) {
 	exe, args := sshGetExeAndArgs(osEnv, e)
 	operation := endpointOperation(e, method)
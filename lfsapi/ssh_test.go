@@ -595,3 +595,42 @@ func TestSSHGetExeAndArgsTortoisePlinkCommandCustomPort(t *testing.T) {
 	assert.Equal(t, plink, exe)
 	assert.Equal(t, []string{"-batch", "-P", "8888", "user@foo.com"}, args)
 }
+
+func TestSSHAskPassEnvWithoutTerminal(t *testing.T) {
+	defer func(f func() bool) { hasTerminal = f }(hasTerminal)
+	hasTerminal = func() bool { return false }
+
+	cli, err := NewClient(NewContext(nil, map[string]string{
+		"GIT_ASKPASS": "askpass",
+	}, nil))
+	require.Nil(t, err)
+
+	env := sshAskPassEnv(cli.OSEnv(), "askpass")
+	assert.Contains(t, env, "SSH_ASKPASS=askpass")
+	assert.Contains(t, env, "SSH_ASKPASS_REQUIRE=prefer")
+}
+
+func TestSSHAskPassEnvKeepsSSHAskPass(t *testing.T) {
+	defer func(f func() bool) { hasTerminal = f }(hasTerminal)
+	hasTerminal = func() bool { return false }
+
+	cli, err := NewClient(NewContext(nil, map[string]string{
+		"SSH_ASKPASS":         "ssh-askpass",
+		"SSH_ASKPASS_REQUIRE": "never",
+	}, nil))
+	require.Nil(t, err)
+
+	env := sshAskPassEnv(cli.OSEnv(), "ssh-askpass")
+	assert.NotContains(t, env, "SSH_ASKPASS=ssh-askpass")
+	assert.NotContains(t, env, "SSH_ASKPASS_REQUIRE=prefer")
+}
+
+func TestSSHAskPassEnvWithTerminal(t *testing.T) {
+	defer func(f func() bool) { hasTerminal = f }(hasTerminal)
+	hasTerminal = func() bool { return true }
+
+	cli, err := NewClient(nil)
+	require.Nil(t, err)
+
+	assert.Nil(t, sshAskPassEnv(cli.OSEnv(), "askpass"))
+}
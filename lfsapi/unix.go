@@ -0,0 +1,139 @@
+package lfsapi
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/errors"
+)
+
+// unixScheme is the scheme of the URLs of Git LFS servers which listen on a
+// Unix domain socket, rather than on a TCP port, like:
+//
+//   http+unix:///var/run/lfs.sock:/api
+//
+// The path up to the first ':' is that of the socket, and the rest that of the
+// API on the server listening on it, which is spoken to over HTTP. Colons in
+// the path of the socket are percent-encoded as "%3A", so a socket on a Windows
+// drive is given like:
+//
+//   http+unix:///C%3A/lfs/lfs.sock:/api
+const unixScheme = "http+unix"
+
+// unixTransport is an http.RoundTripper sending requests for unixScheme URLs
+// over the socket in their URL.
+type unixTransport struct {
+	dial         func(ctx context.Context, network, addr string) (net.Conn, error)
+	maxIdleConns int
+
+	mu         sync.Mutex
+	transports map[string]*http.Transport
+}
+
+// newUnixTransport returns a unixTransport which connects to sockets with the
+// given dial function, as the http.Transport DialContext field.
+func newUnixTransport(dial func(ctx context.Context, network, addr string) (net.Conn, error), maxIdleConns int) *unixTransport {
+	return &unixTransport{
+		dial:         dial,
+		maxIdleConns: maxIdleConns,
+		transports:   make(map[string]*http.Transport),
+	}
+}
+
+func (t *unixTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	socket, u, err := splitUnixURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	sreq := new(http.Request)
+	*sreq = *req
+	sreq.URL = u
+	sreq.Host = u.Host
+
+	res, err := t.transport(socket).RoundTrip(sreq)
+	if res != nil {
+		res.Request = req
+	}
+	return res, err
+}
+
+// transport returns the transport sending requests over the given socket,
+// which keeps connections to it open between requests.
+func (t *unixTransport) transport(socket string) *http.Transport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if tr, ok := t.transports[socket]; ok {
+		return tr
+	}
+
+	tr := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return t.dial(ctx, "unix", socket)
+		},
+		MaxIdleConnsPerHost: t.maxIdleConns,
+	}
+	t.transports[socket] = tr
+	return tr
+}
+
+// splitUnixURL returns the path of the socket in the given unixScheme URL, and
+// the HTTP URL to request over it. The host of the HTTP URL is that of the
+// given URL, if it has one, or "localhost".
+func splitUnixURL(u *url.URL) (string, *url.URL, error) {
+	// Split the escaped path, so that percent-encoded colons are kept in
+	// the path of the socket.
+	rawSocket, rawPath := u.EscapedPath(), "/"
+	if i := strings.Index(rawSocket, ":"); i >= 0 {
+		rawSocket, rawPath = rawSocket[:i], rawSocket[i+1:]
+	}
+
+	socket, err := url.PathUnescape(rawSocket)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "lfsapi/unix: invalid socket in %q", u)
+	}
+	path, err := url.PathUnescape(rawPath)
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "lfsapi/unix: invalid path in %q", u)
+	}
+
+	if len(socket) == 0 {
+		return "", nil, errors.Errorf("lfsapi/unix: no socket in %q, expected %s://<socket>:<path>", u, unixScheme)
+	}
+	if isWindowsDrivePath(socket) {
+		// "/C:/lfs.sock" is the path "C:/lfs.sock" on Windows.
+		socket = socket[1:]
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	host := u.Host
+	if len(host) == 0 {
+		host = "localhost"
+	}
+
+	return socket, &url.URL{
+		Scheme:   "http",
+		Host:     host,
+		Path:     path,
+		RawQuery: u.RawQuery,
+	}, nil
+}
+
+// isWindowsDrivePath returns whether the given URL path names a file on a
+// Windows drive, like "/C:/lfs.sock", when running on Windows.
+func isWindowsDrivePath(path string) bool {
+	if runtime.GOOS != "windows" || len(path) < 3 || path[0] != '/' || path[2] != ':' {
+		return false
+	}
+
+	c := path[1]
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
@@ -0,0 +1,102 @@
+package lfsapi
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitUnixURL(t *testing.T) {
+	for desc, c := range map[string]struct {
+		URL    string
+		Socket string
+		HTTP   string
+	}{
+		"with path":     {"http+unix:///var/run/lfs.sock:/api/objects/batch", "/var/run/lfs.sock", "http://localhost/api/objects/batch"},
+		"without slash": {"http+unix:///var/run/lfs.sock:api", "/var/run/lfs.sock", "http://localhost/api"},
+		"without path":  {"http+unix:///var/run/lfs.sock", "/var/run/lfs.sock", "http://localhost/"},
+		"with query":    {"http+unix:///var/run/lfs.sock:/storage/oid?r=repo", "/var/run/lfs.sock", "http://localhost/storage/oid?r=repo"},
+		"with host":     {"http+unix://lfs.example.com/var/run/lfs.sock:/api", "/var/run/lfs.sock", "http://lfs.example.com/api"},
+		"with colons":   {"http+unix:///tmp/a%3Ab/lfs.sock:/api", "/tmp/a:b/lfs.sock", "http://localhost/api"},
+		"escaped path":  {"http+unix:///var/run/lfs.sock:/a%20b", "/var/run/lfs.sock", "http://localhost/a%20b"},
+	} {
+		u, err := url.Parse(c.URL)
+		require.Nil(t, err, desc)
+
+		socket, httpURL, err := splitUnixURL(u)
+		require.Nil(t, err, desc)
+		assert.Equal(t, c.Socket, socket, desc)
+		assert.Equal(t, c.HTTP, httpURL.String(), desc)
+	}
+}
+
+func TestSplitUnixURLWithDrive(t *testing.T) {
+	u, err := url.Parse("http+unix:///C%3A/lfs/lfs.sock:/api")
+	require.Nil(t, err)
+
+	socket, httpURL, err := splitUnixURL(u)
+	require.Nil(t, err)
+	if runtime.GOOS == "windows" {
+		assert.Equal(t, "C:/lfs/lfs.sock", socket)
+	} else {
+		assert.Equal(t, "/C:/lfs/lfs.sock", socket)
+	}
+	assert.Equal(t, "http://localhost/api", httpURL.String())
+}
+
+func TestSplitUnixURLWithoutSocket(t *testing.T) {
+	u, err := url.Parse("http+unix://localhost")
+	require.Nil(t, err)
+
+	_, _, err = splitUnixURL(u)
+	assert.NotNil(t, err)
+}
+
+func TestClientUnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix domain sockets are not supported on Windows")
+	}
+
+	dir, err := ioutil.TempDir("", "lfsapi-unix")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// Test a socket with a colon in its path, which is percent-encoded in
+	// the URL.
+	socket := filepath.Join(dir, "lfs:test.sock")
+	l, err := net.Listen("unix", socket)
+	require.Nil(t, err)
+	defer l.Close()
+
+	var paths []string
+	go http.Serve(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		assert.Equal(t, "localhost", r.Host)
+		w.WriteHeader(200)
+	}))
+
+	c, err := NewClient(nil)
+	require.Nil(t, err)
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("GET", "http+unix://"+strings.Replace(socket, ":", "%3A", -1)+":/api/test", nil)
+		require.Nil(t, err)
+
+		res, err := c.Do(req)
+		require.Nil(t, err)
+		res.Body.Close()
+		assert.Equal(t, 200, res.StatusCode)
+		assert.Equal(t, req, res.Request)
+	}
+
+	assert.Equal(t, []string{"/api/test", "/api/test"}, paths)
+}
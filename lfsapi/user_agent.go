@@ -0,0 +1,53 @@
+package lfsapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// contextKeyTransferAdapter is a context.Context key for storing the
+	// name of the transfer adapter making a given request.
+	contextKeyTransferAdapter ckey = "transfer-adapter"
+)
+
+// WithTransferAdapter stores the name of the transfer adapter making the given
+// http.Request, so that it is included in its User-Agent.
+func WithTransferAdapter(req *http.Request, name string) *http.Request {
+	ctx := req.Context()
+	ctx = context.WithValue(ctx, contextKeyTransferAdapter, name)
+
+	return req.WithContext(ctx)
+}
+
+// TransferAdapter returns the name of the transfer adapter making the given
+// http.Request, if any.
+func TransferAdapter(req *http.Request) (string, bool) {
+	name, ok := req.Context().Value(contextKeyTransferAdapter).(string)
+
+	return name, ok && len(name) > 0
+}
+
+// userAgentFor returns the User-Agent to send with the given request: the
+// client's version, followed by the transfer adapter making the request, if
+// any, and then by the tokens configured with lfs.useragent or
+// lfs.<url>.useragent, such as the name of a CI system and pipeline.
+func (c *Client) userAgentFor(req *http.Request) string {
+	tokens := []string{UserAgent}
+
+	if name, ok := TransferAdapter(req); ok {
+		tokens = append(tokens, fmt.Sprintf("transfer/%s", name))
+	}
+
+	if c.uc != nil {
+		if extra, ok := c.uc.Get("lfs", req.URL.String(), "useragent"); ok {
+			// Fields() drops any line breaks, which are not
+			// allowed in headers.
+			tokens = append(tokens, strings.Fields(extra)...)
+		}
+	}
+
+	return strings.Join(tokens, " ")
+}
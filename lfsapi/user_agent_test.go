@@ -0,0 +1,70 @@
+package lfsapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTransferAdapter(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+
+	_, ok := TransferAdapter(req)
+	assert.False(t, ok)
+
+	name, ok := TransferAdapter(WithTransferAdapter(req, "basic"))
+	assert.True(t, ok)
+	assert.Equal(t, "basic", name)
+}
+
+func TestUserAgent(t *testing.T) {
+	var userAgents []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.Header.Get("User-Agent"))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(NewContext(nil, nil, map[string]string{
+		"lfs.useragent":                       "ci/jenkins",
+		"lfs." + srv.URL + "/repo.useragent": "ci/travis\npipeline/42",
+	}))
+	require.Nil(t, err)
+
+	for _, path := range []string{"/other", "/repo/objects/batch"} {
+		req, err := http.NewRequest("GET", srv.URL+path, nil)
+		require.Nil(t, err)
+
+		res, err := c.Do(WithTransferAdapter(req, "basic"))
+		require.Nil(t, err)
+		res.Body.Close()
+	}
+
+	require.Equal(t, 2, len(userAgents))
+	assert.Equal(t, UserAgent+" transfer/basic ci/jenkins", userAgents[0])
+	assert.Equal(t, UserAgent+" transfer/basic ci/travis pipeline/42", userAgents[1])
+}
+
+func TestUserAgentWithoutConfig(t *testing.T) {
+	var userAgent string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(nil)
+	require.Nil(t, err)
+
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	require.Nil(t, err)
+
+	res, err := c.Do(req)
+	require.Nil(t, err)
+	res.Body.Close()
+
+	assert.Equal(t, UserAgent, userAgent)
+}
@@ -53,6 +53,13 @@ func (r *tracedRequest) Read(b []byte) (int, error) {
 	return n, err
 }
 
+// Close does not close the body, which is rewound and sent again when the
+// request is retried or redirected, though the http.Transport closes the body
+// of each request it sends. Its caller closes it instead.
+func (r *tracedRequest) Close() error {
+	return nil
+}
+
 func (c *Client) traceResponse(req *http.Request, tracedReq *tracedRequest, res *http.Response) {
 	if tracedReq != nil {
 		c.httpLogger.LogRequest(req, tracedReq.BodySize)
@@ -14,17 +14,22 @@ import (
 
 // ProgressMeter provides a progress bar type output for the TransferQueue. It
 // is given an estimated file count and size up front and tracks the number of
-// files and bytes transferred as well as the number of files and bytes that
-// get skipped because the transfer is unnecessary.
+// files and bytes transferred, the number of files and bytes that get skipped
+// because the transfer is unnecessary, and the number of files that fail. From
+// these it works out the transfer rate and the time left.
 type ProgressMeter struct {
 	finishedFiles     int64 // int64s must come first for struct alignment
 	skippedFiles      int64
+	failedFiles       int64
 	transferringFiles int64
 	estimatedBytes    int64
 	currentBytes      int64
 	skippedBytes      int64
+	failedBytes       int64
+	startedAt         int64 // UnixNano of the first transferred byte
 	estimatedFiles    int32
 	paused            uint32
+	finished          uint32
 	logger            *progressLogger
 	fileIndex         map[string]int64 // Maps a file name to its transfer number
 	fileIndexMutex    *sync.Mutex
@@ -132,6 +137,14 @@ func (p *ProgressMeter) Skip(size int64) {
 	atomic.AddInt64(&p.estimatedBytes, -size)
 }
 
+// Fail tells the progress meter that a file of size `size` could not be
+// transferred.
+func (p *ProgressMeter) Fail(size int64) {
+	defer p.update()
+	atomic.AddInt64(&p.failedFiles, 1)
+	atomic.AddInt64(&p.failedBytes, size)
+}
+
 // StartTransfer tells the progress meter that a transferring file is being
 // added to the TransferQueue.
 func (p *ProgressMeter) StartTransfer(name string) {
@@ -145,6 +158,7 @@ func (p *ProgressMeter) StartTransfer(name string) {
 // TransferBytes increments the number of bytes transferred
 func (p *ProgressMeter) TransferBytes(direction, name string, read, total int64, current int) {
 	defer p.update()
+	atomic.CompareAndSwapInt64(&p.startedAt, 0, time.Now().UnixNano())
 	atomic.AddInt64(&p.currentBytes, int64(current))
 	p.logBytes(direction, name, read, total)
 }
@@ -158,8 +172,11 @@ func (p *ProgressMeter) FinishTransfer(name string) {
 	p.fileIndexMutex.Unlock()
 }
 
-// Finish shuts down the ProgressMeter
+// Finish shuts down the ProgressMeter, sending a final update even if the
+// meter was paused.
 func (p *ProgressMeter) Finish() {
+	atomic.StoreUint32(&p.finished, 1)
+	atomic.StoreUint32(&p.paused, 0)
 	p.update()
 	close(p.updates)
 }
@@ -190,15 +207,19 @@ func (p *ProgressMeter) skipUpdate() bool {
 }
 
 func (p *ProgressMeter) str() string {
-	// (%d of %d files, %d skipped) %f B / %f B, %f B skipped
-	// skipped counts only show when > 0
+	// (%d of %d files, %d skipped, %d failed) %f B / %f B, %f B skipped, %f B/s, ETA %s
+	// skipped and failed counts only show when > 0, the rate once bytes
+	// have been transferred, and the ETA until the meter has finished.
 
-	out := fmt.Sprintf("\rGit LFS: (%d of %d files",
+	out := fmt.Sprintf("Git LFS: (%d of %d files",
 		p.finishedFiles,
 		p.estimatedFiles)
 	if p.skippedFiles > 0 {
 		out += fmt.Sprintf(", %d skipped", p.skippedFiles)
 	}
+	if p.failedFiles > 0 {
+		out += fmt.Sprintf(", %d failed", p.failedFiles)
+	}
 	out += fmt.Sprintf(") %s / %s",
 		humanize.FormatBytes(uint64(p.currentBytes)),
 		humanize.FormatBytes(uint64(p.estimatedBytes)))
@@ -207,9 +228,35 @@ func (p *ProgressMeter) str() string {
 			humanize.FormatBytes(uint64(p.skippedBytes)))
 	}
 
+	rate := p.rate()
+	if rate > 0 {
+		out += fmt.Sprintf(", %s/s", humanize.FormatBytes(uint64(rate+0.5)))
+
+		remaining := p.estimatedBytes - p.currentBytes - p.failedBytes
+		if remaining > 0 && atomic.LoadUint32(&p.finished) == 0 {
+			eta := time.Duration(float64(remaining)/rate+0.5) * time.Second
+			out += fmt.Sprintf(", ETA %s", eta)
+		}
+	}
+
 	return out
 }
 
+// rate returns the average number of bytes transferred per second since the
+// first byte was transferred, or 0 if nothing has been transferred yet.
+func (p *ProgressMeter) rate() float64 {
+	startedAt := atomic.LoadInt64(&p.startedAt)
+	if startedAt == 0 {
+		return 0
+	}
+
+	elapsed := time.Since(time.Unix(0, startedAt)).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(atomic.LoadInt64(&p.currentBytes)) / elapsed
+}
+
 func (p *ProgressMeter) logBytes(direction, name string, read, total int64) {
 	p.fileIndexMutex.Lock()
 	idx := p.fileIndex[name]
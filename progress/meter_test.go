@@ -0,0 +1,51 @@
+package progress
+
+import (
+	"testing"
+	"time"
+
+	"github.com/git-lfs/git-lfs/tasklog"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMeter() *ProgressMeter {
+	m := NewMeter(DryRun(true))
+	m.updates = make(chan *tasklog.Update, 100)
+	return m
+}
+
+func TestMeterShowsCounts(t *testing.T) {
+	m := newTestMeter()
+	m.Add(100)
+	m.Add(200)
+	m.Add(300)
+	m.Skip(100)
+	m.Fail(200)
+
+	assert.Equal(t, "Git LFS: (0 of 2 files, 1 skipped, 1 failed) 0 B / 500 B, 100 B skipped", m.str())
+}
+
+func TestMeterShowsRateAndETA(t *testing.T) {
+	m := newTestMeter()
+	m.Add(3000)
+	m.StartTransfer("a")
+	m.TransferBytes("download", "a", 1000, 3000, 1000)
+	m.startedAt = time.Now().Add(-10 * time.Second).UnixNano()
+
+	assert.Equal(t, "Git LFS: (0 of 1 files) 1.0 KB / 3.0 KB, 100 B/s, ETA 20s", m.str())
+}
+
+func TestMeterLeavesOutETAOnceFinished(t *testing.T) {
+	m := newTestMeter()
+	m.Add(3000)
+	m.Add(1000)
+	m.StartTransfer("a")
+	m.TransferBytes("download", "a", 1000, 3000, 1000)
+	m.Fail(1000)
+	m.startedAt = time.Now().Add(-10 * time.Second).UnixNano()
+
+	assert.Contains(t, m.str(), "ETA 20s")
+
+	m.Finish()
+	assert.Equal(t, "Git LFS: (0 of 2 files, 1 failed) 1.0 KB / 4.0 KB, 100 B/s", m.str())
+}
@@ -16,6 +16,7 @@ func (m *nonMeter) Start()
 func (m *nonMeter) Pause()                                                               {}
 func (m *nonMeter) Add(size int64)                                                       {}
 func (m *nonMeter) Skip(size int64)                                                      {}
+func (m *nonMeter) Fail(size int64)                                                      {}
 func (m *nonMeter) StartTransfer(name string)                                            {}
 func (m *nonMeter) TransferBytes(direction, name string, read, total int64, current int) {}
 func (m *nonMeter) FinishTransfer(name string)                                           {}
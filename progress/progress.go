@@ -11,6 +11,7 @@ type Meter interface {
 	Pause()
 	Add(int64)
 	Skip(size int64)
+	Fail(size int64)
 	StartTransfer(name string)
 	TransferBytes(direction, name string, read, total int64, current int)
 	FinishTransfer(name string)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -13,6 +14,10 @@ import (
 
 const (
 	DefaultLoggingThrottle = 200 * time.Millisecond
+
+	// DefaultNonTTYLoggingThrottle is the throttle used when the sink is
+	// not a terminal, where each update is written on a line of its own.
+	DefaultNonTTYLoggingThrottle = 5 * time.Second
 )
 
 // Logger logs a series of tasks to an io.Writer, processing each task in order
@@ -21,6 +26,10 @@ type Logger struct {
 	// sink is the writer to write to.
 	sink io.Writer
 
+	// tty is whether the sink is a terminal. If it is not, updates are
+	// written as separate lines instead of overwriting each other.
+	tty bool
+
 	// widthFn is a function that returns the width of the terminal that
 	// this logger is running within.
 	widthFn func() int
@@ -45,9 +54,16 @@ func NewLogger(sink io.Writer) *Logger {
 		sink = ioutil.Discard
 	}
 
+	throttle := DefaultLoggingThrottle
+	tty := isTerminal(sink)
+	if !tty {
+		throttle = DefaultNonTTYLoggingThrottle
+	}
+
 	l := &Logger{
 		sink:     sink,
-		throttle: DefaultLoggingThrottle,
+		tty:      tty,
+		throttle: throttle,
 		widthFn: func() int {
 			size, err := ts.GetSize()
 			if err != nil {
@@ -188,12 +204,16 @@ func (l *Logger) consume() {
 //
 // If the duration if 0, or the task is "durable" (by implementing
 // github.com/git-lfs/git-lfs/tasklog#DurableTask), then all entries will be
-// logged.
+// logged. When the sink is not a terminal, the first entry of a throttled task
+// waits for the throttle, so that short tasks log only their "done" message.
 func (l *Logger) logTask(task Task) {
 	defer l.wg.Done()
 
 	logAll := !task.Throttled()
 	var last time.Time
+	if !l.tty {
+		last = time.Now()
+	}
 
 	var update *Update
 	for update = range task.Updates() {
@@ -212,11 +232,18 @@ func (l *Logger) logTask(task Task) {
 }
 
 // logLine writes a complete line and moves the cursor to the beginning of the
-// line.
+// line. If the sink is not a terminal, it ends the line instead.
 //
 // It returns the number of bytes "n" written to the sink and the error "err",
 // if one was encountered.
 func (l *Logger) logLine(str string) (n int, err error) {
+	if !l.tty {
+		if strings.HasSuffix(str, "\n") {
+			return l.log(str)
+		}
+		return l.log(str + "\n")
+	}
+
 	padding := strings.Repeat(" ", maxInt(0, l.widthFn()-len(str)))
 
 	return l.log(str + padding + "\r")
@@ -230,6 +257,20 @@ func (l *Logger) log(str string) (n int, err error) {
 	return fmt.Fprint(l.sink, str)
 }
 
+// isTerminal returns whether the given writer is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return (stat.Mode() & os.ModeCharDevice) != 0
+}
+
 func maxInt(a, b int) int {
 	if a > b {
 		return a
@@ -32,6 +32,7 @@ func TestLoggerLogsTasks(t *testing.T) {
 	}()
 
 	l := NewLogger(&buf)
+	l.tty = true
 	l.throttle = 0
 	l.widthFn = func() int { return 0 }
 	l.Enqueue(ChanTask(task))
@@ -57,6 +58,7 @@ func TestLoggerLogsMultipleTasksInOrder(t *testing.T) {
 	}()
 
 	l := NewLogger(&buf)
+	l.tty = true
 	l.throttle = 0
 	l.widthFn = func() int { return 0 }
 	l.Enqueue(ChanTask(t1), ChanTask(t2))
@@ -76,6 +78,7 @@ func TestLoggerLogsMultipleTasksWithoutBlocking(t *testing.T) {
 	var buf bytes.Buffer
 
 	l := NewLogger(&buf)
+	l.tty = true
 	l.throttle = 0
 	t1, t2 := make(chan *Update), make(chan *Update)
 
@@ -113,6 +116,7 @@ func TestLoggerThrottlesWrites(t *testing.T) {
 	}()
 
 	l := NewLogger(&buf)
+	l.tty = true
 	l.widthFn = func() int { return 0 }
 	l.throttle = 15 * time.Millisecond
 
@@ -140,6 +144,7 @@ func TestLoggerThrottlesLastWrite(t *testing.T) {
 	}()
 
 	l := NewLogger(&buf)
+	l.tty = true
 	l.widthFn = func() int { return 0 }
 	l.throttle = 15 * time.Millisecond
 
@@ -156,6 +161,7 @@ func TestLoggerLogsAllDurableUpdates(t *testing.T) {
 	var buf bytes.Buffer
 
 	l := NewLogger(&buf)
+	l.tty = true
 	l.widthFn = func() int { return 0 }
 	l.throttle = 15 * time.Minute
 
@@ -183,8 +189,49 @@ func TestLoggerHandlesSilentTasks(t *testing.T) {
 	close(task)
 
 	l := NewLogger(&buf)
+	l.tty = true
 	l.Enqueue(ChanTask(task))
 	l.Close()
 
 	assert.Equal(t, "", buf.String())
 }
+
+func TestLoggerWritesLinesWhenNotATerminal(t *testing.T) {
+	var buf bytes.Buffer
+
+	l := NewLogger(&buf)
+	l.widthFn = func() int { return 80 }
+	l.throttle = 15 * time.Minute
+
+	t1 := make(chan *Update)
+	go func() {
+		t1 <- &Update{"first", time.Now(), false}
+		t1 <- &Update{"second", time.Now(), false}
+		close(t1)
+	}()
+
+	t2 := make(chan *Update)
+	go func() {
+		t2 <- &Update{"entry\n", time.Now(), false}
+		t2 <- &Update{"list: ...", time.Now(), false}
+		close(t2)
+	}()
+
+	l.Enqueue(ChanTask(t1), UnthrottledChanTask(t2))
+	l.Close()
+
+	assert.Equal(t, strings.Join([]string{
+		"second, done\n",
+		"entry\n",
+		"list: ...\n",
+		"list: ..., done\n",
+	}, ""), buf.String())
+}
+
+func TestNewLoggerThrottlesMoreWhenNotATerminal(t *testing.T) {
+	l := NewLogger(&bytes.Buffer{})
+	defer l.Close()
+
+	assert.False(t, l.tty)
+	assert.Equal(t, DefaultNonTTYLoggingThrottle, l.throttle)
+}
@@ -5,9 +5,12 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
+	"crypto/sha512"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
@@ -17,14 +20,16 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"io/ioutil"
 	"log"
-	"math"
 	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/textproto"
+	"net/url"
 	"os"
 	"os/exec"
 	"regexp"
@@ -35,15 +40,21 @@ import (
 	"time"
 
 	"github.com/ThomsonReutersEikon/go-ntlm/ntlm"
+	"github.com/git-lfs/git-lfs/test"
 )
 
 var (
 	repoDir          string
-	largeObjects     = newLfsStorage()
+	largeObjects     objectStorage = newLfsStorage()
 	server           *httptest.Server
 	serverTLS        *httptest.Server
 	serverClientCert *httptest.Server
 
+	// socketURL is the http+unix:// URL of the Unix domain socket which
+	// the server also listens on if LFSTEST_SOCKET is set, without the
+	// path after its ':'.
+	socketURL string
+
 	// maps OIDs to content strings. Both the LFS and Storage test servers below
 	// see OIDs.
 	oidHandlers map[string]string
@@ -58,13 +69,35 @@ var (
 		"status-batch-403", "status-batch-404", "status-batch-410", "status-batch-422", "status-batch-500",
 		"status-storage-403", "status-storage-404", "status-storage-410", "status-storage-422", "status-storage-500", "status-storage-503",
 		"status-batch-resume-206", "batch-resume-fail-fallback", "return-expired-action", "return-expired-action-forever", "return-invalid-size",
-		"object-authenticated", "storage-download-retry", "storage-upload-retry", "unknown-oid",
+		"object-authenticated", "storage-download-retry", "storage-download-corrupt", "storage-upload-retry", "unknown-oid",
 		"send-verify-action", "send-deprecated-links",
+		"chaos-500", "chaos-slow", "chaos-truncate", "chaos-drop", "chaos-expired-token",
 	}
 )
 
 func main() {
 	repoDir = os.Getenv("LFSTEST_DIR")
+	if faults := os.Getenv("LFSTEST_CHAOS"); len(faults) > 0 {
+		chaosFaults = strings.Split(faults, ",")
+	}
+	if limit := os.Getenv("LFSTEST_RATE_LIMIT"); len(limit) > 0 {
+		var err error
+		rateLimit, rateLimitWindow, err = parseRateLimit(limit)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+	if endpoint := os.Getenv("LFSTEST_S3_ENDPOINT"); len(endpoint) > 0 {
+		s, err := newS3Storage(endpoint, os.Getenv("LFSTEST_S3_BUCKET"), &test.S3Credentials{
+			AccessKey: os.Getenv("LFSTEST_S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("LFSTEST_S3_SECRET_KEY"),
+			Region:    os.Getenv("LFSTEST_S3_REGION"),
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+		largeObjects = s
+	}
 
 	mux := http.NewServeMux()
 	server = httptest.NewServer(mux)
@@ -100,6 +133,7 @@ func main() {
 
 	mux.HandleFunc("/storage/", storageHandler)
 	mux.HandleFunc("/verify", verifyHandler)
+	mux.HandleFunc("/existence-cache/", existenceCacheHandler)
 	mux.HandleFunc("/redirect307/", redirect307Handler)
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		id, ok := reqId(w)
@@ -119,6 +153,21 @@ func main() {
 		gitHandler(w, r)
 	})
 
+	if socket := os.Getenv("LFSTEST_SOCKET"); len(socket) > 0 {
+		l, err := net.Listen("unix", socket)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer l.Close()
+
+		socketURL = "http+unix://" + socket + ":"
+		go http.Serve(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), viaSocketKey{}, true)
+			mux.ServeHTTP(w, r.WithContext(ctx))
+		}))
+		debug("init", "server socket url: %s", socketURL)
+	}
+
 	urlname := writeTestStateFile([]byte(server.URL), "LFSTEST_URL", "lfstest-gitserver")
 	defer os.RemoveAll(urlname)
 
@@ -174,6 +223,7 @@ type lfsObject struct {
 	Actions       map[string]*lfsLink `json:"actions,omitempty"`
 	Links         map[string]*lfsLink `json:"_links,omitempty"`
 	Err           *lfsError           `json:"error,omitempty"`
+	Linked        bool                `json:"linked,omitempty"`
 }
 
 type lfsLink struct {
@@ -213,7 +263,7 @@ func lfsHandler(w http.ResponseWriter, r *http.Request, id string) {
 	w.Header().Set("Content-Type", "application/vnd.git-lfs+json")
 	switch r.Method {
 	case "POST":
-		if strings.HasSuffix(r.URL.String(), "batch") {
+		if strings.HasSuffix(strings.TrimSuffix(r.URL.Path, "/"), "batch") {
 			lfsBatchHandler(w, r, id, repo)
 		} else {
 			locksHandler(w, r, repo)
@@ -232,8 +282,21 @@ func lfsHandler(w http.ResponseWriter, r *http.Request, id string) {
 	}
 }
 
-func lfsUrl(repo, oid string) string {
-	return server.URL + "/storage/" + oid + "?r=" + repo
+func lfsUrl(r *http.Request, repo, oid string) string {
+	return baseUrl(r) + "/storage/" + oid + "?r=" + repo
+}
+
+// viaSocketKey is the context key marking requests which came in over the
+// LFSTEST_SOCKET socket.
+type viaSocketKey struct{}
+
+// baseUrl returns the URL of the server which the given request came in on, so
+// that the actions in a response to it are requested the same way.
+func baseUrl(r *http.Request) string {
+	if viaSocket, _ := r.Context().Value(viaSocketKey{}).(bool); viaSocket {
+		return socketURL
+	}
+	return server.URL
 }
 
 var (
@@ -275,6 +338,11 @@ func lfsDeleteHandler(w http.ResponseWriter, r *http.Request, id, repo string) {
 	w.WriteHeader(200)
 }
 
+// batchBodyLimit is the size of the largest batch request body accepted for
+// repositories whose names start with "batch-too-large", which is room for
+// about 5 objects.
+const batchBodyLimit = 512
+
 func lfsBatchHandler(w http.ResponseWriter, r *http.Request, id, repo string) {
 	checkingObject := r.Header.Get("X-Check-Object") == "1"
 	if !checkingObject && repo == "batchunsupported" {
@@ -298,15 +366,23 @@ func lfsBatchHandler(w http.ResponseWriter, r *http.Request, id, repo string) {
 	if missingRequiredCreds(w, r, repo) {
 		return
 	}
+	if skipIfRateLimited(w, id, "batch", repo) {
+		return
+	}
 
 	type batchReq struct {
 		Transfers []string    `json:"transfers"`
 		Operation string      `json:"operation"`
 		Objects   []lfsObject `json:"objects"`
+		Source    *struct {
+			Href string `json:"href"`
+		} `json:"source"`
+		HashAlgo string `json:"hash_algo"`
 	}
 	type batchResp struct {
 		Transfer string      `json:"transfer,omitempty"`
 		Objects  []lfsObject `json:"objects"`
+		HashAlgo string      `json:"hash_algo,omitempty"`
 	}
 
 	buf := &bytes.Buffer{}
@@ -323,6 +399,11 @@ func lfsBatchHandler(w http.ResponseWriter, r *http.Request, id, repo string) {
 		log.Fatal(err)
 	}
 
+	if strings.HasPrefix(repo, "batch-too-large") && buf.Len() > batchBodyLimit {
+		w.WriteHeader(413)
+		return
+	}
+
 	res := []lfsObject{}
 	testingChunked := testingChunkedTransferEncoding(r)
 	testingTus := testingTusUploadInBatchReq(r)
@@ -366,8 +447,24 @@ func lfsBatchHandler(w http.ResponseWriter, r *http.Request, id, repo string) {
 			if !exists {
 				o.Err = &lfsError{Code: 404, Message: fmt.Sprintf("Object %v does not exist", obj.Oid)}
 				addAction = false
+			} else if strings.HasPrefix(repo, "verify-remote") {
+				// Give the size of the stored object, rather
+				// than the one requested.
+				by, _ := largeObjects.Get(repo, obj.Oid)
+				o.Size = int64(len(by))
 			}
 		} else {
+			if !exists && objs.Source != nil {
+				// Link objects from the repository this one
+				// was forked from, rather than receiving them
+				// again.
+				if by, ok := linkedObject(objs.Source.Href, obj.Oid); ok {
+					largeObjects.Set(repo, obj.Oid, by)
+					o.Linked = true
+					exists = true
+				}
+			}
+
 			if exists {
 				// not an error but don't add an action
 				addAction = false
@@ -400,11 +497,16 @@ func lfsBatchHandler(w http.ResponseWriter, r *http.Request, id, repo string) {
 
 			if addAction {
 				a := &lfsLink{
-					Href:   lfsUrl(repo, obj.Oid),
+					Href:   lfsUrl(r, repo, obj.Oid),
 					Header: map[string]string{},
 				}
 				a = serveExpired(a, repo, handler)
 
+				if chaosFault(action, repo, obj.Oid, false) == chaosExpiredToken {
+					a.Header["Authorization"] = chaosToken
+					o.Authenticated = true
+				}
+
 				if handler == "send-deprecated-links" {
 					o.Links[action] = a
 				} else {
@@ -414,7 +516,7 @@ func lfsBatchHandler(w http.ResponseWriter, r *http.Request, id, repo string) {
 
 			if handler == "send-verify-action" {
 				o.Actions["verify"] = &lfsLink{
-					Href: server.URL + "/verify",
+					Href: baseUrl(r) + "/verify",
 					Header: map[string]string{
 						"repo": repo,
 					},
@@ -442,6 +544,12 @@ func lfsBatchHandler(w http.ResponseWriter, r *http.Request, id, repo string) {
 
 	ores := batchResp{Transfer: transferChoice, Objects: res}
 
+	// Repositories whose name starts with "sha256-only" act like servers
+	// which predate the hash_algo field, and ignore it.
+	if objs.HashAlgo == "sha512" && !strings.HasPrefix(repo, "sha256-only") {
+		ores.HashAlgo = objs.HashAlgo
+	}
+
 	by, err := json.Marshal(ores)
 	if err != nil {
 		log.Fatal(err)
@@ -454,6 +562,30 @@ func lfsBatchHandler(w http.ResponseWriter, r *http.Request, id, repo string) {
 	w.Write(by)
 }
 
+// newHashForOid returns a new Hash of the algorithm which named the given OID,
+// sha512 if it is 128 characters long, and sha256 otherwise.
+func newHashForOid(oid string) hash.Hash {
+	if len(oid) == 128 {
+		return sha512.New()
+	}
+	return sha256.New()
+}
+
+// linkedObject returns the contents of the object with the given OID in the
+// repository at the Git LFS endpoint "href", if there is one.
+func linkedObject(href, oid string) ([]byte, bool) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return nil, false
+	}
+
+	repo, err := repoFromLfsUrl(u.Path)
+	if err != nil {
+		return nil, false
+	}
+	return largeObjects.Get(repo, oid)
+}
+
 // emu guards expiredRepos
 var emu sync.Mutex
 
@@ -505,6 +637,7 @@ func canServeExpired(repo string) bool {
 
 // Persistent state across requests
 var batchResumeFailFallbackStorageAttempts = 0
+var storageDownloadCorruptAttempts = 0
 var tusStorageAttempts = 0
 
 var (
@@ -547,6 +680,281 @@ func verifyHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+var (
+	existenceCache   = make(map[string]map[string]bool)
+	existenceCacheMu sync.Mutex
+)
+
+// existenceCacheHandler implements a shared cache of which objects are on which
+// Git LFS servers, for the lfs.existencecache option, at
+// /existence-cache/lookup and /existence-cache/record.
+func existenceCacheHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Endpoint string   `json:"endpoint"`
+		Oids     []string `json:"oids"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	existenceCacheMu.Lock()
+	defer existenceCacheMu.Unlock()
+
+	known := existenceCache[payload.Endpoint]
+	if known == nil {
+		known = make(map[string]bool)
+		existenceCache[payload.Endpoint] = known
+	}
+
+	switch r.URL.Path {
+	case "/existence-cache/record":
+		for _, oid := range payload.Oids {
+			known[oid] = true
+		}
+	case "/existence-cache/lookup":
+		found := make([]string, 0, len(payload.Oids))
+		for _, oid := range payload.Oids {
+			if known[oid] {
+				found = append(found, oid)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string][]string{"oids": found})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// The faults which the server can inject into the transfer of an object, so
+// that the client's retry and resume logic gets exercised. Each is injected
+// into objects whose contents are "chaos-" followed by its name, i.e.:
+//
+//   printf "chaos-truncate" > a.dat
+//
+// and, if the LFSTEST_CHAOS environment variable lists them separated by
+// commas, into all other objects which have no magic contents.
+const (
+	// chaosStatus500 answers a storage request with a 500.
+	chaosStatus500 = "500"
+	// chaosSlow answers a storage request only after chaosSlowDelay.
+	chaosSlow = "slow"
+	// chaosTruncate declares the full length of a download, but only sends
+	// half of it before closing the connection.
+	chaosTruncate = "truncate"
+	// chaosDrop closes the connection of a storage request without
+	// answering it.
+	chaosDrop = "drop"
+	// chaosExpiredToken hands out an action whose token the storage then
+	// rejects as expired.
+	chaosExpiredToken = "expired-token"
+
+	chaosSlowDelay = 2 * time.Second
+)
+
+var (
+	// chaosFaults are the faults from LFSTEST_CHAOS.
+	chaosFaults []string
+
+	// chaosAttempts counts the storage requests made for each object, by
+	// direction, repository and OID.
+	chaosAttempts   = make(map[string]int)
+	chaosAttemptsMu sync.Mutex
+)
+
+// chaosFaultsFor returns the faults to inject into the transfers of the object
+// with the given OID, one per attempt, after which they succeed.
+func chaosFaultsFor(oid string) []string {
+	handler, ok := oidHandlers[oid]
+	if !ok {
+		return chaosFaults
+	}
+	if strings.HasPrefix(handler, "chaos-") {
+		return []string{strings.TrimPrefix(handler, "chaos-")}
+	}
+	return nil
+}
+
+// chaosFault returns the fault to inject into the current attempt to transfer
+// the given object, or an empty string if there is none. If "next" is true, the
+// attempt is counted, so that the following one gets the next fault.
+func chaosFault(direction, repo, oid string, next bool) string {
+	faults := chaosFaultsFor(oid)
+	if len(faults) == 0 {
+		return ""
+	}
+
+	chaosAttemptsMu.Lock()
+	defer chaosAttemptsMu.Unlock()
+
+	key := strings.Join([]string{direction, repo, oid}, ":")
+	attempt := chaosAttempts[key]
+	if next {
+		chaosAttempts[key]++
+	}
+
+	if attempt < len(faults) {
+		return faults[attempt]
+	}
+	return ""
+}
+
+// chaosToken is the token of the actions handed out for an object which is to
+// be rejected with chaosExpiredToken.
+const chaosToken = "Token expired"
+
+// injectChaos injects the given fault into the response to a storage request,
+// returning whether it was answered.
+func injectChaos(w http.ResponseWriter, r *http.Request, id, fault string, by []byte) bool {
+	switch fault {
+	case chaosStatus500:
+		debug(id, "chaos: 500")
+		w.WriteHeader(500)
+		w.Write([]byte("chaos"))
+		return true
+	case chaosSlow:
+		debug(id, "chaos: sleeping for %s", chaosSlowDelay)
+		time.Sleep(chaosSlowDelay)
+	case chaosDrop:
+		debug(id, "chaos: dropping connection")
+		dropConnection(w)
+		return true
+	case chaosExpiredToken:
+		if r.Header.Get("Authorization") == chaosToken {
+			debug(id, "chaos: token expired")
+			writeLFSError(w, 401, "Token expired")
+			return true
+		}
+	case chaosTruncate:
+		if r.Method == "GET" && len(by) > 1 {
+			debug(id, "chaos: sending %d of %d bytes", len(by)/2, len(by))
+			w.Header().Set("Content-Length", strconv.Itoa(len(by)))
+			w.WriteHeader(200)
+			w.Write(by[:len(by)/2])
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+			dropConnection(w)
+			return true
+		}
+	}
+	return false
+}
+
+// dropConnection closes the connection of the request being answered by "w".
+func dropConnection(w http.ResponseWriter) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		log.Fatal("chaos: unable to hijack the connection")
+	}
+
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		log.Fatal("chaos: unable to hijack the connection: ", err)
+	}
+	conn.Close()
+}
+
+// serveRange answers a download request for part of an object, as the client
+// makes to resume a download, returning whether it did.
+func serveRange(w http.ResponseWriter, r *http.Request, by []byte) bool {
+	match := regexp.MustCompile(`\Abytes=(\d+)-`).FindStringSubmatch(r.Header.Get("Range"))
+	if match == nil {
+		return false
+	}
+
+	from, err := strconv.Atoi(match[1])
+	if err != nil || from >= len(by) {
+		w.WriteHeader(416)
+		return true
+	}
+
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", from, len(by)-1, len(by)))
+	w.WriteHeader(206)
+	w.Write(by[from:])
+	return true
+}
+
+// The LFSTEST_RATE_LIMIT environment variable limits the number of requests
+// made to the batch and storage endpoints, i.e.:
+//
+//   LFSTEST_RATE_LIMIT=10/1m
+//
+// lets ten requests through to each endpoint of each repository per minute.
+// Those over the limit are answered with a 429, along with a Retry-After header
+// giving the number of seconds until the window resets.
+var (
+	rateLimit       int
+	rateLimitWindow time.Duration
+
+	// rateLimitWindows are the windows of requests made to each endpoint
+	// of each repository, by endpoint and repository.
+	rateLimitWindows   = make(map[string]*requestWindow)
+	rateLimitWindowsMu sync.Mutex
+)
+
+// requestWindow counts the requests made since it started.
+type requestWindow struct {
+	start time.Time
+	count int
+}
+
+// parseRateLimit parses the value of LFSTEST_RATE_LIMIT, given as the number
+// of requests, followed by a slash and the duration of the window.
+func parseRateLimit(limit string) (int, time.Duration, error) {
+	parts := strings.SplitN(limit, "/", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid LFSTEST_RATE_LIMIT: %q", limit)
+	}
+
+	n, err := strconv.Atoi(parts[0])
+	if err != nil || n < 0 {
+		return 0, 0, fmt.Errorf("invalid LFSTEST_RATE_LIMIT: %q", limit)
+	}
+	window, err := time.ParseDuration(parts[1])
+	if err != nil || window <= 0 {
+		return 0, 0, fmt.Errorf("invalid LFSTEST_RATE_LIMIT: %q", limit)
+	}
+	return n, window, nil
+}
+
+// skipIfRateLimited answers a request to the given endpoint of the given
+// repository with a 429 if it is over the rate limit, returning whether it
+// did.
+func skipIfRateLimited(w http.ResponseWriter, id, endpoint, repo string) bool {
+	if rateLimitWindow == 0 {
+		return false
+	}
+
+	rateLimitWindowsMu.Lock()
+	now := time.Now()
+	key := strings.Join([]string{endpoint, repo}, ":")
+	window, ok := rateLimitWindows[key]
+	if !ok || now.Sub(window.start) >= rateLimitWindow {
+		window = &requestWindow{start: now}
+		rateLimitWindows[key] = window
+	}
+	window.count++
+	over := window.count > rateLimit
+	retryAfter := window.start.Add(rateLimitWindow).Sub(now)
+	rateLimitWindowsMu.Unlock()
+
+	if !over {
+		return false
+	}
+
+	// Round up, so that a client waiting as long as it is told to finds
+	// the window reset.
+	seconds := int((retryAfter + time.Second - 1) / time.Second)
+	debug(id, "rate limit: %s %s, retry after %ds", endpoint, repo, seconds)
+
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	writeLFSError(w, 429, fmt.Sprintf("rate limit of %d requests per %s exceeded", rateLimit, rateLimitWindow))
+	return true
+}
+
 // handles any /storage/{oid} requests
 func storageHandler(w http.ResponseWriter, r *http.Request) {
 	id, ok := reqId(w)
@@ -560,10 +968,27 @@ func storageHandler(w http.ResponseWriter, r *http.Request) {
 	if missingRequiredCreds(w, r, repo) {
 		return
 	}
+	if skipIfRateLimited(w, id, "storage", repo) {
+		return
+	}
 
 	debug(id, "storage %s %s repo: %s", r.Method, oid, repo)
+
+	if s, ok := largeObjects.(*s3Storage); ok && s.Handles(oid) {
+		// Send the client to object storage, as servers keeping
+		// objects there do.
+		debug(id, "storage redirecting to object storage")
+		w.Header().Set("Location", s.URL(r.Method, repo, oid))
+		w.WriteHeader(307)
+		return
+	}
+
 	switch r.Method {
 	case "PUT":
+		if injectChaos(w, r, id, chaosFault("upload", repo, oid, true), nil) {
+			return
+		}
+
 		switch oidHandlers[oid] {
 		case "status-storage-403":
 			w.WriteHeader(403)
@@ -611,10 +1036,25 @@ func storageHandler(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		hash := sha256.New()
+		body := io.Reader(r.Body)
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			if strings.Contains(repo, "no-compression") {
+				w.WriteHeader(415)
+				return
+			}
+
+			gz, err := gzip.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(400)
+				return
+			}
+			body = gz
+		}
+
+		hash := newHashForOid(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
 		buf := &bytes.Buffer{}
 
-		io.Copy(io.MultiWriter(hash, buf), r.Body)
+		io.Copy(io.MultiWriter(hash, buf), body)
 		oid := hex.EncodeToString(hash.Sum(nil))
 		if !strings.HasSuffix(r.URL.Path, "/"+oid) {
 			w.WriteHeader(403)
@@ -631,11 +1071,25 @@ func storageHandler(w http.ResponseWriter, r *http.Request) {
 		resumeAt := int64(0)
 
 		if by, ok := largeObjects.Get(repo, oid); ok {
+			if injectChaos(w, r, id, chaosFault("download", repo, oid, true), by) {
+				return
+			}
+			if len(chaosFaultsFor(oid)) > 0 && serveRange(w, r, by) {
+				return
+			}
+
 			if len(by) == len("storage-download-retry") && string(by) == "storage-download-retry" {
 				if retries, ok := incrementRetriesFor("storage", "download", repo, oid, false); ok && retries < 3 {
 					statusCode = 500
 					by = []byte("malformed content")
 				}
+			} else if len(by) == len("storage-download-corrupt") && string(by) == "storage-download-corrupt" {
+				if storageDownloadCorruptAttempts == 0 {
+					// Send content of the right size, but the wrong
+					// OID, on the FIRST attempt only.
+					by = []byte(strings.ToUpper(string(by)))
+					storageDownloadCorruptAttempts++
+				}
 			} else if len(by) == len("status-batch-resume-206") && string(by) == "status-batch-resume-206" {
 				// Resume if header includes range, otherwise deliberately interrupt
 				if rangeHdr := r.Header.Get("Range"); rangeHdr != "" {
@@ -663,6 +1117,14 @@ func storageHandler(w http.ResponseWriter, r *http.Request) {
 					batchResumeFailFallbackStorageAttempts++
 				}
 			}
+			if statusCode == 200 && byteLimit == 0 && strings.Contains(repo, "compression") && r.Header.Get("Accept-Encoding") == "gzip" {
+				w.Header().Set("Content-Encoding", "gzip")
+				w.WriteHeader(statusCode)
+				gz := gzip.NewWriter(w)
+				gz.Write(by)
+				gz.Close()
+				return
+			}
 			w.WriteHeader(statusCode)
 			if byteLimit > 0 {
 				w.Write(by[0:byteLimit])
@@ -930,7 +1392,11 @@ func getLocks(repo string) []Lock {
 	return cp
 }
 
-func getFilteredLocks(repo, path, cursor, limit string) ([]Lock, string, error) {
+// lockPageSize is the largest number of locks returned in one page, so that
+// the client has to follow the cursor to list more.
+const lockPageSize = 3
+
+func getFilteredLocks(repo, path, id, cursor, limit string) ([]Lock, string, error) {
 	locks := getLocks(repo)
 	if cursor != "" {
 		lastSeen := -1
@@ -959,28 +1425,47 @@ func getFilteredLocks(repo, path, cursor, limit string) ([]Lock, string, error)
 		locks = filtered
 	}
 
+	if id != "" {
+		var filtered []Lock
+		for _, l := range locks {
+			if l.Id == id {
+				filtered = append(filtered, l)
+			}
+		}
+
+		locks = filtered
+	}
+
 	if limit != "" {
 		size, err := strconv.Atoi(limit)
-		if err != nil {
+		if err != nil || size < 0 {
 			return nil, "", errors.New("unable to parse limit amount")
 		}
 
-		size = int(math.Min(float64(len(locks)), 3))
-		if size < 0 {
-			return nil, "", nil
+		if size == 0 || size > lockPageSize {
+			size = lockPageSize
 		}
 
-		if size+1 < len(locks) {
-			return locks[:size], locks[size+1].Id, nil
+		if size < len(locks) {
+			return locks[:size], locks[size].Id, nil
 		}
 	}
 
 	return locks, "", nil
 }
 
+func getLock(repo, id string) (Lock, bool) {
+	for _, l := range getLocks(repo) {
+		if l.Id == id {
+			return l, true
+		}
+	}
+	return Lock{}, false
+}
+
 func delLock(repo string, id string) *Lock {
-	lmu.RLock()
-	defer lmu.RUnlock()
+	lmu.Lock()
+	defer lmu.Unlock()
 
 	var deleted *Lock
 	locks := make([]Lock, 0, len(repoLocks[repo]))
@@ -1001,6 +1486,21 @@ func (c LocksByCreatedAt) Len() int           { return len(c) }
 func (c LocksByCreatedAt) Less(i, j int) bool { return c[i].LockedAt.Before(c[j].LockedAt) }
 func (c LocksByCreatedAt) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
 
+// lockOwner returns the user whom a request to the locks API is made by, as
+// named by the username it is authenticated with. The usual test user, "user",
+// is "Git LFS Tests", and any other user whose name starts with "user-" is
+// named by the rest of it, so that tests can lock files as several users:
+//
+//   printf "user-alice:pass" > "$CREDSDIR/127.0.0.1--$reponame"
+//
+func lockOwner(r *http.Request) User {
+	user, _, err := extractAuth(r.Header.Get("Authorization"))
+	if err == nil && strings.HasPrefix(user, "user-") {
+		return User{Name: strings.TrimPrefix(user, "user-")}
+	}
+	return User{Name: "Git LFS Tests"}
+}
+
 var (
 	lockRe   = regexp.MustCompile(`/locks/?$`)
 	unlockRe = regexp.MustCompile(`locks/([^/]+)/unlock\z`)
@@ -1028,6 +1528,7 @@ func locksHandler(w http.ResponseWriter, r *http.Request, repo string) {
 		w.Header().Set("Content-Type", "application/json")
 		locks, nextCursor, err := getFilteredLocks(repo,
 			r.FormValue("path"),
+			r.FormValue("id"),
 			r.FormValue("cursor"),
 			r.FormValue("limit"))
 
@@ -1052,6 +1553,7 @@ func locksHandler(w http.ResponseWriter, r *http.Request, repo string) {
 
 			if len(lockId) == 0 {
 				enc.Encode(&UnlockResponse{Message: "Invalid lock"})
+				return
 			}
 
 			if err := dec.Decode(&unlockRequest); err != nil {
@@ -1059,9 +1561,25 @@ func locksHandler(w http.ResponseWriter, r *http.Request, repo string) {
 				return
 			}
 
+			l, ok := getLock(repo, lockId)
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				enc.Encode(&UnlockResponse{Message: "unable to find lock"})
+				return
+			}
+
+			if owner := lockOwner(r); l.Owner != owner && !unlockRequest.Force {
+				w.WriteHeader(http.StatusForbidden)
+				enc.Encode(&UnlockResponse{
+					Message: fmt.Sprintf("lock %s is owned by %s, not %s", l.Id, l.Owner.Name, owner.Name),
+				})
+				return
+			}
+
 			if l := delLock(repo, lockId); l != nil {
 				enc.Encode(&UnlockResponse{Lock: l})
 			} else {
+				w.WriteHeader(http.StatusNotFound)
 				enc.Encode(&UnlockResponse{Message: "unable to find lock"})
 			}
 			return
@@ -1114,7 +1632,7 @@ func locksHandler(w http.ResponseWriter, r *http.Request, repo string) {
 			}
 
 			ll := &VerifiableLockList{}
-			locks, nextCursor, err := getFilteredLocks(repo, "",
+			locks, nextCursor, err := getFilteredLocks(repo, "", "",
 				reqBody.Cursor,
 				strconv.Itoa(reqBody.Limit))
 			if err != nil {
@@ -1122,8 +1640,11 @@ func locksHandler(w http.ResponseWriter, r *http.Request, repo string) {
 			} else {
 				ll.NextCursor = nextCursor
 
+				// Locks owned by other users are theirs, as is any
+				// lock with "theirs" in its path, whoever owns it.
+				owner := lockOwner(r)
 				for _, l := range locks {
-					if strings.Contains(l.Path, "theirs") {
+					if l.Owner != owner || strings.Contains(l.Path, "theirs") {
 						ll.Theirs = append(ll.Theirs, l)
 					} else {
 						ll.Ours = append(ll.Ours, l)
@@ -1139,11 +1660,13 @@ func locksHandler(w http.ResponseWriter, r *http.Request, repo string) {
 			var lockRequest LockRequest
 			if err := dec.Decode(&lockRequest); err != nil {
 				enc.Encode(&LockResponse{Message: err.Error()})
+				return
 			}
 
 			for _, l := range getLocks(repo) {
 				if l.Path == lockRequest.Path {
-					enc.Encode(&LockResponse{Message: "lock already created"})
+					w.WriteHeader(http.StatusConflict)
+					enc.Encode(&LockResponse{Lock: &l, Message: "lock already created"})
 					return
 				}
 			}
@@ -1154,7 +1677,7 @@ func locksHandler(w http.ResponseWriter, r *http.Request, repo string) {
 			lock := &Lock{
 				Id:       fmt.Sprintf("%x", id[:]),
 				Path:     lockRequest.Path,
-				Owner:    User{Name: "Git LFS Tests"},
+				Owner:    lockOwner(r),
 				LockedAt: time.Now(),
 			}
 
@@ -1163,6 +1686,7 @@ func locksHandler(w http.ResponseWriter, r *http.Request, repo string) {
 			// TODO(taylor): commit_needed case
 			// TODO(taylor): err case
 
+			w.WriteHeader(http.StatusCreated)
 			enc.Encode(&LockResponse{
 				Lock: lock,
 			})
@@ -1222,6 +1746,18 @@ func repoFromLfsUrl(urlpath string) (string, error) {
 	return repo, nil
 }
 
+// objectStorage keeps the contents of the objects pushed to each repository,
+// along with those of incomplete uploads which may be resumed.
+type objectStorage interface {
+	Get(repo, oid string) ([]byte, bool)
+	Has(repo, oid string) bool
+	Set(repo, oid string, by []byte)
+	Delete(repo, oid string)
+	GetIncomplete(repo, oid string) ([]byte, bool)
+	SetIncomplete(repo, oid string, by []byte)
+	DeleteIncomplete(repo, oid string)
+}
+
 type lfsStorage struct {
 	objects    map[string]map[string][]byte
 	incomplete map[string]map[string][]byte
@@ -1312,6 +1848,127 @@ func newLfsStorage() *lfsStorage {
 	}
 }
 
+// s3Storage keeps objects in a bucket of an S3-compatible object storage
+// service, such as MinIO or lfstest-s3server, which the client transfers them
+// to and from through pre-signed URLs. Objects with magic contents, which the
+// server has to answer the requests for itself, are still kept in memory, as
+// are incomplete uploads.
+type s3Storage struct {
+	*lfsStorage
+
+	endpoint *url.URL
+	bucket   string
+	creds    *test.S3Credentials
+}
+
+// s3Expiry is how long the URLs handed out for an object are valid.
+const s3Expiry = time.Hour
+
+func newS3Storage(endpoint, bucket string, creds *test.S3Credentials) (*s3Storage, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if len(bucket) == 0 {
+		return nil, errors.New("missing LFSTEST_S3_BUCKET")
+	}
+	if len(creds.Region) == 0 {
+		creds.Region = "us-east-1"
+	}
+
+	return &s3Storage{
+		lfsStorage: newLfsStorage(),
+		endpoint:   u,
+		bucket:     bucket,
+		creds:      creds,
+	}, nil
+}
+
+// Handles returns whether the object with the given OID is kept in the bucket.
+func (s *s3Storage) Handles(oid string) bool {
+	_, ok := oidHandlers[oid]
+	return !ok
+}
+
+// URL returns a pre-signed URL to make a request with the given method to the
+// object with the given OID in the given repository.
+func (s *s3Storage) URL(method, repo, oid string) string {
+	u := *s.endpoint
+	u.Path = strings.Join([]string{strings.TrimSuffix(u.Path, "/"), s.bucket, repo, oid}, "/")
+
+	return s.creds.PresignURL(method, &u, time.Now(), s3Expiry).String()
+}
+
+func (s *s3Storage) do(method, repo, oid string, by []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.URL(method, repo, oid), bytes.NewReader(by))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		debug("s3", "%s %s/%s: %s", method, repo, oid, err)
+		return nil, err
+	}
+	debug("s3", "%s %s/%s: %d", method, repo, oid, res.StatusCode)
+	return res, nil
+}
+
+func (s *s3Storage) Get(repo, oid string) ([]byte, bool) {
+	if !s.Handles(oid) {
+		return s.lfsStorage.Get(repo, oid)
+	}
+
+	res, err := s.do("GET", repo, oid, nil)
+	if err != nil {
+		return nil, false
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != 200 {
+		return nil, false
+	}
+
+	by, err := ioutil.ReadAll(res.Body)
+	return by, err == nil
+}
+
+func (s *s3Storage) Has(repo, oid string) bool {
+	if !s.Handles(oid) {
+		return s.lfsStorage.Has(repo, oid)
+	}
+
+	res, err := s.do("HEAD", repo, oid, nil)
+	if err != nil {
+		return false
+	}
+	res.Body.Close()
+
+	return res.StatusCode == 200
+}
+
+func (s *s3Storage) Set(repo, oid string, by []byte) {
+	if !s.Handles(oid) {
+		s.lfsStorage.Set(repo, oid, by)
+		return
+	}
+
+	if res, err := s.do("PUT", repo, oid, by); err == nil {
+		res.Body.Close()
+	}
+}
+
+func (s *s3Storage) Delete(repo, oid string) {
+	if !s.Handles(oid) {
+		s.lfsStorage.Delete(repo, oid)
+		return
+	}
+
+	if res, err := s.do("DELETE", repo, oid, nil); err == nil {
+		res.Body.Close()
+	}
+}
+
 func extractAuth(auth string) (string, string, error) {
 	if strings.HasPrefix(auth, "Basic ") {
 		decodeBy, err := base64.StdEncoding.DecodeString(auth[6:len(auth)])
@@ -1361,6 +2018,11 @@ func skipIfBadAuth(w http.ResponseWriter, r *http.Request, id string, ntlmSessio
 			return false
 		}
 		debug(id, "auth attempt against: %q", r.URL.Path)
+	default:
+		// Other users of the locks API, see lockOwner().
+		if strings.HasPrefix(user, "user-") && pass == "pass" {
+			return false
+		}
 	}
 
 	w.WriteHeader(403)
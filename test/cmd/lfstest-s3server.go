@@ -0,0 +1,158 @@
+// +build testtools
+
+package main
+
+// lfstest-s3server is a minimal S3-compatible object storage service, which
+// keeps objects in memory. Like Amazon S3, it only accepts requests through
+// URLs pre-signed for its account, given by LFSTEST_S3_ACCESS_KEY and
+// LFSTEST_S3_SECRET_KEY, and rejects those which also carry an Authorization
+// header.
+//
+// It writes its URL to the file named by LFSTEST_S3_URL, and stops on a request
+// to /shutdown.
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/git-lfs/git-lfs/test"
+)
+
+var (
+	creds *test.S3Credentials
+
+	objects   = make(map[string][]byte)
+	objectsMu sync.Mutex
+)
+
+func main() {
+	creds = &test.S3Credentials{
+		AccessKey: os.Getenv("LFSTEST_S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("LFSTEST_S3_SECRET_KEY"),
+		Region:    os.Getenv("LFSTEST_S3_REGION"),
+	}
+	if len(creds.Region) == 0 {
+		creds.Region = "us-east-1"
+	}
+
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	stopch := make(chan bool)
+
+	mux.HandleFunc("/shutdown", func(w http.ResponseWriter, r *http.Request) {
+		stopch <- true
+	})
+	mux.HandleFunc("/", objectHandler)
+
+	urlname := os.Getenv("LFSTEST_S3_URL")
+	if len(urlname) == 0 {
+		urlname = "lfstest-s3server"
+	}
+	if err := ioutil.WriteFile(urlname, []byte(server.URL), 0644); err != nil {
+		log.Fatalln(err)
+	}
+	defer os.RemoveAll(urlname)
+
+	log.Printf("s3 server url: %s", server.URL)
+
+	<-stopch
+	log.Print("s3 server done")
+}
+
+// handles requests for the object named by the path, i.e.
+// "/{bucket}/{key}".
+func objectHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("%s %s", r.Method, r.URL.Path)
+	for k := range r.Header {
+		if strings.HasPrefix(k, "X-") {
+			// Logged so that tests can check that no headers
+			// meant for the Git server reach object storage.
+			log.Printf("header %s", k)
+		}
+	}
+
+	if len(r.URL.Query().Get("X-Amz-Signature")) == 0 {
+		writeS3Error(w, 403, "AccessDenied", "Access Denied")
+		return
+	}
+	if len(r.Header.Get("Authorization")) > 0 {
+		writeS3Error(w, 400, "InvalidArgument", "Only one auth mechanism allowed; only the X-Amz-Algorithm query parameter, Signature query string parameter or the Authorization header should be specified")
+		return
+	}
+	if err := creds.VerifyPresignedURL(r.Method, r.Host, r.URL, time.Now()); err != nil {
+		writeS3Error(w, 403, "AccessDenied", err.Error())
+		return
+	}
+
+	key := r.URL.Path
+
+	switch r.Method {
+	case "GET", "HEAD":
+		objectsMu.Lock()
+		by, ok := objects[key]
+		objectsMu.Unlock()
+
+		if !ok {
+			writeS3Error(w, 404, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+
+		w.Header().Set("Content-Length", strconv.Itoa(len(by)))
+		w.WriteHeader(200)
+		if r.Method == "GET" {
+			w.Write(by)
+		}
+	case "PUT":
+		by, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeS3Error(w, 500, "InternalError", err.Error())
+			return
+		}
+
+		objectsMu.Lock()
+		objects[key] = by
+		objectsMu.Unlock()
+
+		w.WriteHeader(200)
+	case "DELETE":
+		objectsMu.Lock()
+		delete(objects, key)
+		objectsMu.Unlock()
+
+		w.WriteHeader(204)
+	default:
+		writeS3Error(w, 405, "MethodNotAllowed", fmt.Sprintf("The specified method is not allowed against this resource: %s", r.Method))
+	}
+}
+
+type s3Error struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+func writeS3Error(w http.ResponseWriter, status int, code, msg string) {
+	log.Printf("error: %d %s: %s", status, code, msg)
+
+	by, err := xml.Marshal(&s3Error{Code: code, Message: msg})
+	if err != nil {
+		http.Error(w, "xml encoding error: "+err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	w.Write(by)
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	InitCmd = &cobra.Command{
+		Use:   "git-lfs-test-server-api init [--url=<apiurl> | --clone=<cloneurl>] [--format=shell|compose] [<dir>]",
+		Short: "Write a script running the tests against a server under development",
+		Run:   initScaffolding,
+	}
+	initFormat string
+	initImage  string
+	initForce  bool
+)
+
+// scaffoldFile is a file written by "init", from a template given the
+// scaffoldData.
+type scaffoldFile struct {
+	Name     string
+	Mode     os.FileMode
+	Template *template.Template
+}
+
+type scaffoldData struct {
+	URL      string
+	CloneURL string
+	Image    string
+	// ComposeURL and ComposeCloneURL are URL and CloneURL as seen from the
+	// "tests" service of docker-compose.yml, see composeServiceURL().
+	ComposeURL      string
+	ComposeCloneURL string
+}
+
+// composeService is the name of the service running the server under test in
+// docker-compose.yml, which is also its host name within the project.
+const composeService = "server"
+
+var scaffoldFormats = map[string][]scaffoldFile{
+	"shell": {
+		{"run-tests.sh", 0755, runTestsTemplate},
+	},
+	"compose": {
+		{"run-tests.sh", 0755, runTestsTemplate},
+		{"docker-compose.yml", 0644, composeTemplate},
+		{"Dockerfile.tests", 0644, dockerfileTemplate},
+	},
+}
+
+// initScaffolding writes the files of the requested format into the given
+// directory, "lfs-server-tests" by default.
+func initScaffolding(cmd *cobra.Command, args []string) {
+	if (len(apiUrl) == 0 && len(cloneUrl) == 0) ||
+		(len(apiUrl) != 0 && len(cloneUrl) != 0) {
+		exit("Must supply either --url or --clone (and not both)")
+	}
+
+	if len(args) > 1 {
+		exit("Must supply at most one directory")
+	}
+
+	files, ok := scaffoldFormats[initFormat]
+	if !ok {
+		exit("Unknown format %q, must be \"shell\" or \"compose\"", initFormat)
+	}
+
+	dir := "lfs-server-tests"
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	if !initForce {
+		for _, f := range files {
+			if _, err := os.Stat(filepath.Join(dir, f.Name)); err == nil {
+				exit("%s already exists, use --force to overwrite it", filepath.Join(dir, f.Name))
+			}
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		exit("Error creating %s: %s", dir, err)
+	}
+
+	data := &scaffoldData{
+		URL:             apiUrl,
+		CloneURL:        cloneUrl,
+		Image:           initImage,
+		ComposeURL:      composeServiceURL(apiUrl),
+		ComposeCloneURL: composeServiceURL(cloneUrl),
+	}
+	for _, f := range files {
+		if err := writeScaffoldFile(filepath.Join(dir, f.Name), f, data); err != nil {
+			exit("Error writing %s: %s", f.Name, err)
+		}
+		fmt.Printf("Wrote %s\n", filepath.Join(dir, f.Name))
+	}
+
+	switch initFormat {
+	case "compose":
+		if url := data.ComposeURL + data.ComposeCloneURL; url != apiUrl+cloneUrl {
+			fmt.Printf("The tests reach the server at %s within the project.\n", url)
+		}
+		fmt.Printf("Set the image of the \"server\" service in %s, then run:\n\n", filepath.Join(dir, "docker-compose.yml"))
+		fmt.Printf("  cd %s && docker-compose run --rm tests\n", dir)
+	default:
+		fmt.Printf("Start the server with an empty object store, then run:\n\n")
+		fmt.Printf("  %s\n", filepath.Join(dir, "run-tests.sh"))
+	}
+}
+
+func writeScaffoldFile(path string, f scaffoldFile, data *scaffoldData) error {
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, f.Mode)
+	if err != nil {
+		return err
+	}
+
+	if err := f.Template.Execute(out, data); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	// Make scripts executable even if they already existed.
+	return os.Chmod(path, f.Mode)
+}
+
+// composeServiceURL returns the given HTTP(S) URL with its host replaced by
+// that of the "server" service, keeping the port, since the server is only
+// reachable at its service name from the "tests" service. Other URLs, such as
+// SSH clone URLs, are returned as they are.
+func composeServiceURL(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return rawurl
+	}
+
+	if port := u.Port(); len(port) > 0 {
+		u.Host = net.JoinHostPort(composeService, port)
+	} else {
+		u.Host = composeService
+	}
+	return u.String()
+}
+
+// shellQuote quotes "s" as a single shell word.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+var scaffoldFuncs = template.FuncMap{"shellquote": shellQuote}
+
+var runTestsTemplate = template.Must(template.New("run-tests.sh").Funcs(scaffoldFuncs).Parse(`#!/usr/bin/env bash
+#
+# Runs the Git LFS server API compliance tests. Written by
+# "git-lfs-test-server-api init".
+#
+# The first run uploads generated test data to the server, which must not have
+# any objects yet, and saves the list of objects to data/. Later runs reuse that
+# list and do not change the server's content. Remove data/ whenever the
+# server's content is reset.
+#
+# The output of each run is saved to results/, and the number of passed and
+# failed tests, along with the names of the failed ones, to results/latest.txt.
+# The script exits non-zero if any test failed.
+#
+# Set LFS_API_URL or LFS_CLONE_URL to test another server, and
+# LFS_TEST_SERVER_API to the path of git-lfs-test-server-api if it is not on
+# the PATH.
+
+set -e
+
+cd "$(dirname "$0")"
+
+default_api_url={{shellquote .URL}}
+default_clone_url={{shellquote .CloneURL}}
+
+LFS_API_URL="${LFS_API_URL-$default_api_url}"
+LFS_CLONE_URL="${LFS_CLONE_URL-$default_clone_url}"
+LFS_TEST_SERVER_API="${LFS_TEST_SERVER_API:-git-lfs-test-server-api}"
+
+if [ -n "$LFS_API_URL" ]; then
+  target=(--url="$LFS_API_URL")
+else
+  target=(--clone="$LFS_CLONE_URL")
+fi
+
+mkdir -p data results
+
+if [ -s data/test_exists ] && [ -s data/test_missing ]; then
+  echo "Using test data from data/"
+  data=(data/test_exists data/test_missing)
+else
+  echo "Generating test data in data/"
+  data=(--save=data/test)
+fi
+
+log="results/$(date +%Y%m%d-%H%M%S).log"
+
+set +e
+"$LFS_TEST_SERVER_API" "${target[@]}" "${data[@]}" 2>&1 | tee "$log"
+status="${PIPESTATUS[0]}"
+set -e
+
+# Each test rewrites its line with a carriage return once it has finished.
+passed="$(tr '\r' '\n' < "$log" | grep -c ' OK$' || true)"
+failed="$(tr '\r' '\n' < "$log" | grep -c ' FAILED$' || true)"
+
+{
+  echo "$passed passed, $failed failed ($log)"
+  tr '\r' '\n' < "$log" | grep ' FAILED$' | sed -e 's/ *FAILED$//' || true
+} > results/latest.txt
+
+echo
+cat results/latest.txt
+
+exit "$status"
+`))
+
+var composeTemplate = template.Must(template.New("docker-compose.yml").Parse(`# Runs the Git LFS server API compliance tests against a server under
+# development. Written by "git-lfs-test-server-api init".
+#
+# Point the "server" service at an image of your server, or replace "image"
+# with "build" to build it from source. Then run:
+#
+#   docker-compose run --rm tests
+#
+# The tests expect the server to start without any objects, so run
+# "docker-compose down" and remove data/ to start over.
+version: "2"
+
+services:
+  server:
+    image: {{printf "%q" .Image}}
+
+  tests:
+    build:
+      context: .
+      dockerfile: Dockerfile.tests
+    depends_on:
+      - server
+    environment:
+      LFS_API_URL: {{printf "%q" .ComposeURL}}
+      LFS_CLONE_URL: {{printf "%q" .ComposeCloneURL}}
+    volumes:
+      - .:/tests
+    working_dir: /tests
+    command: ./run-tests.sh
+`))
+
+var dockerfileTemplate = template.Must(template.New("Dockerfile.tests").Parse(`# Builds git-lfs-test-server-api for the "tests" service of docker-compose.yml.
+# Written by "git-lfs-test-server-api init".
+#
+# The tests are built from the groenborg/git-lfs fork, which they are part of,
+# rather than fetched with "go get", which would build those of upstream Git
+# LFS. Set the GIT_LFS_REPO build arg to build them from another repository,
+# and GIT_LFS_REF to build them from a branch, tag or commit other than the
+# default branch.
+FROM golang:1.8
+
+ARG GIT_LFS_REPO=https://github.com/groenborg/git-lfs.git
+ARG GIT_LFS_REF=
+
+RUN git clone "$GIT_LFS_REPO" /go/src/github.com/git-lfs/git-lfs && \
+    cd /go/src/github.com/git-lfs/git-lfs && \
+    if [ -n "$GIT_LFS_REF" ]; then git checkout "$GIT_LFS_REF"; fi && \
+    go install ./test/git-lfs-test-server-api
+`))
+
+func init() {
+	InitCmd.Flags().StringVarP(&apiUrl, "url", "u", "", "URL of the API (must supply this or --clone)")
+	InitCmd.Flags().StringVarP(&cloneUrl, "clone", "c", "", "Clone URL from which to find API (must supply this or --url)")
+	InitCmd.Flags().StringVarP(&initFormat, "format", "f", "shell", "Write a shell script (\"shell\") or a Docker Compose project (\"compose\")")
+	InitCmd.Flags().StringVar(&initImage, "image", "lfs-server:latest", "Docker image of the server, with --format=compose")
+	InitCmd.Flags().BoolVar(&initForce, "force", false, "Overwrite existing files")
+}
@@ -6,7 +6,9 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -44,6 +46,13 @@ var (
 )
 
 func main() {
+	// "init" is not added as a subcommand of RootCmd, since cobra would then
+	// reject the data file arguments as unknown commands.
+	if len(os.Args) > 1 && os.Args[1] == "init" {
+		InitCmd.SetArgs(os.Args[2:])
+		InitCmd.Execute()
+		return
+	}
 	RootCmd.Execute()
 }
 
@@ -61,6 +70,9 @@ func testServerApi(cmd *cobra.Command, args []string) {
 		exit("Cannot combine input files and --save option")
 	}
 
+	// Resolve data file paths before changing into the test repository
+	args, savePrefix = absPaths(args), absPath(savePrefix)
+
 	// Build test data for existing files & upload
 	// Use test repo for this to simplify the process of making sure data matches oid
 	// We're not performing a real test at this point (although an upload fail will break it)
@@ -106,6 +118,26 @@ func testServerApi(cmd *cobra.Command, args []string) {
 	fmt.Println("All tests passed")
 }
 
+// absPath returns the absolute form of the given path, if any.
+func absPath(path string) string {
+	if len(path) == 0 {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		exit("Error resolving path %s: %s", path, err)
+	}
+	return abs
+}
+
+func absPaths(paths []string) []string {
+	abs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		abs = append(abs, absPath(path))
+	}
+	return abs
+}
+
 func readTestOids(filename string) []TestObject {
 	f, err := os.OpenFile(filename, os.O_RDONLY, 0644)
 	if err != nil {
@@ -292,6 +324,69 @@ func callBatchApi(manifest *tq.Manifest, dir tq.Direction, objs []TestObject) ([
 	return bres.Objects, nil
 }
 
+type rawBatchRequest struct {
+	Operation string         `json:"operation"`
+	Objects   []*tq.Transfer `json:"objects"`
+}
+
+type rawBatchResponse struct {
+	Objects []*tq.Transfer `json:"objects"`
+	Message string         `json:"message"`
+
+	// Header holds the headers of the response.
+	Header http.Header `json:"-"`
+}
+
+// callBatchApiRaw is like callBatchApi, but sends the given extra headers, and
+// returns the HTTP status code alongside the decoded response instead of
+// treating error responses as failures.
+func callBatchApiRaw(manifest *tq.Manifest, dir tq.Direction, objs []TestObject, headers map[string]string) (int, *rawBatchResponse, error) {
+	return sendBatchApiRaw(manifest, dir, objs, func(req *http.Request) {
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+	})
+}
+
+// sendBatchApiRaw is like callBatchApiRaw, but calls "prepare" to alter the
+// request before sending it.
+func sendBatchApiRaw(manifest *tq.Manifest, dir tq.Direction, objs []TestObject, prepare func(req *http.Request)) (int, *rawBatchResponse, error) {
+	apiobjs := make([]*tq.Transfer, 0, len(objs))
+	for _, o := range objs {
+		apiobjs = append(apiobjs, &tq.Transfer{Oid: o.Oid, Size: o.Size})
+	}
+
+	client := manifest.APIClient()
+	e := client.Endpoints.Endpoint(dir.String(), "origin")
+	req, err := client.NewRequest("POST", e, "objects/batch", &rawBatchRequest{
+		Operation: dir.String(),
+		Objects:   apiobjs,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	prepare(req)
+
+	res, err := client.DoWithAuth("origin", req)
+	if err != nil {
+		// Error responses have already been decoded by the client, so
+		// pull the message back out of the error.
+		if cliErr, ok := err.(*lfsapi.ClientError); ok && res != nil {
+			return res.StatusCode, &rawBatchResponse{Message: cliErr.Message, Header: res.Header}, nil
+		}
+		if res != nil && res.StatusCode >= 400 {
+			return res.StatusCode, &rawBatchResponse{Header: res.Header}, nil
+		}
+		return 0, nil, err
+	}
+
+	bres := &rawBatchResponse{Header: res.Header}
+	if err := lfsapi.DecodeJSON(res, bres); err != nil {
+		return res.StatusCode, nil, fmt.Errorf("Response is not valid Git LFS JSON: %s", err)
+	}
+	return res.StatusCode, bres, nil
+}
+
 // Combine 2 slices into one by "randomly" interleaving
 // Not actually random, same sequence each time so repeatable
 func interleaveTestData(slice1, slice2 []TestObject) []TestObject {
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/git-lfs/git-lfs/tq"
+)
+
+// acceptLanguage is sent with each request made by the tests below. Servers
+// are free to localize error messages accordingly, but clients match on the
+// structure of the response (status and error codes), so that must not change.
+const acceptLanguage = "de-DE, fr;q=0.8, ja;q=0.6, *;q=0.1"
+
+var acceptLanguageHeader = map[string]string{"Accept-Language": acceptLanguage}
+
+// "download" - all missing, with a non-English Accept-Language
+func i18nDownloadAllMissing(manifest *tq.Manifest, oidsExist, oidsMissing []TestObject) error {
+	status, bres, err := callBatchApiRaw(manifest, tq.Download, oidsMissing, acceptLanguageHeader)
+	if err != nil {
+		return err
+	}
+
+	if status != 200 {
+		return fmt.Errorf("Expected HTTP status 200, got %d", status)
+	}
+
+	if len(bres.Objects) != len(oidsMissing) {
+		return fmt.Errorf("Incorrect number of returned objects, expected %d, got %d", len(oidsMissing), len(bres.Objects))
+	}
+
+	var errbuf bytes.Buffer
+	for _, o := range bres.Objects {
+		if o.Error == nil {
+			errbuf.WriteString(fmt.Sprintf("Download should include an error for missing object %s\n", o.Oid))
+		} else if o.Error.Code != 404 {
+			errbuf.WriteString(fmt.Sprintf("Download error code for missing object %s should be 404 regardless of language, got %d\n", o.Oid, o.Error.Code))
+		}
+	}
+
+	if errbuf.Len() > 0 {
+		return errors.New(errbuf.String())
+	}
+
+	return nil
+}
+
+// "upload" - invalid objects, with a non-English Accept-Language
+func i18nUploadInvalid(manifest *tq.Manifest, oidsExist, oidsMissing []TestObject) error {
+	invalid := []TestObject{
+		{Oid: "a345cde", Size: 99},
+		{Oid: "e3bf3e2af9366a3b704af0c31de5afa64193ebabffde2091936ad237510bc03a", Size: -1},
+	}
+
+	status, bres, err := callBatchApiRaw(manifest, tq.Upload, invalid, acceptLanguageHeader)
+	if err != nil {
+		return err
+	}
+
+	// Servers may reject the request as a whole, in which case a JSON
+	// message is required, but may be in any language.
+	if status == 422 {
+		if len(bres.Message) == 0 {
+			return fmt.Errorf("HTTP 422 response should include a JSON message")
+		}
+		return nil
+	}
+
+	if status != 200 {
+		return fmt.Errorf("Expected HTTP status 200 or 422, got %d", status)
+	}
+
+	if len(bres.Objects) != len(invalid) {
+		return fmt.Errorf("Incorrect number of returned objects, expected %d, got %d", len(invalid), len(bres.Objects))
+	}
+
+	var errbuf bytes.Buffer
+	for _, o := range bres.Objects {
+		if o.Error == nil {
+			errbuf.WriteString(fmt.Sprintf("Upload should include an error for invalid object %s\n", o.Oid))
+		} else if o.Error.Code != 422 {
+			errbuf.WriteString(fmt.Sprintf("Upload error code for invalid object %s should be 422 regardless of language, got %d\n", o.Oid, o.Error.Code))
+		}
+	}
+
+	if errbuf.Len() > 0 {
+		return errors.New(errbuf.String())
+	}
+
+	return nil
+}
+
+func init() {
+	addTest("Test download: all missing, with Accept-Language", i18nDownloadAllMissing)
+	addTest("Test upload: invalid objects, with Accept-Language", i18nUploadInvalid)
+}
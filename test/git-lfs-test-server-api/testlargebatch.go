@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/git-lfs/git-lfs/tq"
+)
+
+// largeBatchSizes are the numbers of objects sent in a single batch request,
+// at and above the limits commonly imposed by servers.
+var largeBatchSizes = []int{100, 1000, 10000}
+
+// largeBatchTestData returns n objects which are known not to exist on the
+// server, generated the same way each time.
+func largeBatchTestData(n int) []TestObject {
+	objs := make([]TestObject, 0, n)
+	for i := 0; i < n; i++ {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("large batch object %d", i)))
+		objs = append(objs, TestObject{Oid: hex.EncodeToString(sum[:]), Size: 1})
+	}
+	return objs
+}
+
+// largeBatch sends batches of each of largeBatchSizes in the given direction.
+// The server may either accept the whole batch, returning an entry for every
+// object, or reject it with an HTTP 413 or 422 carrying a message that tells
+// the user what went wrong, but must not fail in any other way.
+func largeBatch(manifest *tq.Manifest, dir tq.Direction) error {
+	var errbuf bytes.Buffer
+	for _, n := range largeBatchSizes {
+		status, bres, err := callBatchApiRaw(manifest, dir, largeBatchTestData(n), nil)
+		if err != nil {
+			errbuf.WriteString(fmt.Sprintf("Batch of %d objects: %s\n", n, err))
+			continue
+		}
+
+		switch status {
+		case 200:
+			if len(bres.Objects) != n {
+				errbuf.WriteString(fmt.Sprintf("Batch of %d objects: incorrect number of returned objects, got %d\n", n, len(bres.Objects)))
+			}
+		case 413, 422:
+			if len(bres.Message) == 0 {
+				errbuf.WriteString(fmt.Sprintf("Batch of %d objects: HTTP %d response should include a JSON message\n", n, status))
+			}
+		default:
+			errbuf.WriteString(fmt.Sprintf("Batch of %d objects: expected HTTP status 200, 413 or 422, got %d\n", n, status))
+		}
+	}
+
+	if errbuf.Len() > 0 {
+		return errors.New(errbuf.String())
+	}
+
+	return nil
+}
+
+// "download" - very large batches
+func largeBatchDownload(manifest *tq.Manifest, oidsExist, oidsMissing []TestObject) error {
+	return largeBatch(manifest, tq.Download)
+}
+
+// "upload" - very large batches
+func largeBatchUpload(manifest *tq.Manifest, oidsExist, oidsMissing []TestObject) error {
+	return largeBatch(manifest, tq.Upload)
+}
+
+func init() {
+	addTest("Test download: very large batches", largeBatchDownload)
+	addTest("Test upload: very large batches", largeBatchUpload)
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/git-lfs/git-lfs/tq"
+)
+
+type apiLock struct {
+	Id    string `json:"id"`
+	Path  string `json:"path"`
+	Owner struct {
+		Name string `json:"name"`
+	} `json:"owner"`
+}
+
+type apiLockResponse struct {
+	Lock    *apiLock `json:"lock"`
+	Message string   `json:"message"`
+}
+
+type apiLockList struct {
+	Locks      []apiLock `json:"locks"`
+	NextCursor string    `json:"next_cursor"`
+	Message    string    `json:"message"`
+}
+
+type apiVerifiableLockList struct {
+	Ours       []apiLock `json:"ours"`
+	Theirs     []apiLock `json:"theirs"`
+	NextCursor string    `json:"next_cursor"`
+	Message    string    `json:"message"`
+}
+
+// callLocksApiRaw sends a request with the given method and JSON body, if
+// any, to the given resource of the locks API, decoding a successful response
+// into "v". It returns the HTTP status code, along with the message of an
+// error response, instead of treating error responses as failures.
+func callLocksApiRaw(client *lfsapi.Client, method, resource string, body, v interface{}) (int, string, error) {
+	e := client.Endpoints.Endpoint("upload", "origin")
+	req, err := client.NewRequest(method, e, resource, body)
+	if err != nil {
+		return 0, "", err
+	}
+
+	res, err := client.DoWithAuth("origin", req)
+	if err != nil {
+		// Error responses have already been decoded by the client, so
+		// pull the message back out of the error.
+		if cliErr, ok := err.(*lfsapi.ClientError); ok && res != nil {
+			return res.StatusCode, cliErr.Message, nil
+		}
+		if res != nil && res.StatusCode >= 400 {
+			return res.StatusCode, "", nil
+		}
+		return 0, "", err
+	}
+
+	if err := lfsapi.DecodeJSON(res, v); err != nil {
+		return res.StatusCode, "", fmt.Errorf("Response is not valid Git LFS JSON: %s", err)
+	}
+	return res.StatusCode, "", nil
+}
+
+// testLockPath returns a path to lock which no other run of the tests uses,
+// so that locks left behind by an earlier run do not get in the way.
+func testLockPath(name string) string {
+	return fmt.Sprintf("git-lfs-test-server-api/%d/%s.dat", time.Now().UnixNano(), name)
+}
+
+// createTestLock locks the given path, which must succeed.
+func createTestLock(client *lfsapi.Client, path string) (*apiLock, error) {
+	lres := &apiLockResponse{}
+	status, msg, err := callLocksApiRaw(client, "POST", "locks", map[string]string{"path": path}, lres)
+	if err != nil {
+		return nil, err
+	}
+	if status != 201 {
+		return nil, fmt.Errorf("Creating lock on %q: expected HTTP status 201, got %d: %s", path, status, msg)
+	}
+	if lres.Lock == nil || len(lres.Lock.Id) == 0 {
+		return nil, fmt.Errorf("Creating lock on %q: response should include the lock and its id", path)
+	}
+	if lres.Lock.Path != path {
+		return nil, fmt.Errorf("Creating lock on %q: response has lock on %q", path, lres.Lock.Path)
+	}
+	return lres.Lock, nil
+}
+
+// deleteTestLock unlocks the given lock, which must succeed.
+func deleteTestLock(client *lfsapi.Client, lock *apiLock) error {
+	lres := &apiLockResponse{}
+	status, msg, err := callLocksApiRaw(client, "POST", fmt.Sprintf("locks/%s/unlock", lock.Id), map[string]bool{"force": false}, lres)
+	if err != nil {
+		return err
+	}
+	if status != 200 {
+		return fmt.Errorf("Deleting lock %s: expected HTTP status 200, got %d: %s", lock.Id, status, msg)
+	}
+	if lres.Lock == nil || lres.Lock.Id != lock.Id {
+		return fmt.Errorf("Deleting lock %s: response should include the deleted lock", lock.Id)
+	}
+	return nil
+}
+
+// listTestLocks lists the locks matching the given query, following the cursor
+// through every page.
+func listTestLocks(client *lfsapi.Client, query url.Values) ([]apiLock, error) {
+	var locks []apiLock
+	for {
+		list := &apiLockList{}
+		status, msg, err := callLocksApiRaw(client, "GET", "locks?"+query.Encode(), nil, list)
+		if err != nil {
+			return nil, err
+		}
+		if status != 200 {
+			return nil, fmt.Errorf("Listing locks: expected HTTP status 200, got %d: %s", status, msg)
+		}
+
+		locks = append(locks, list.Locks...)
+		if len(list.NextCursor) == 0 {
+			return locks, nil
+		}
+		query.Set("cursor", list.NextCursor)
+	}
+}
+
+// "locks" - create and delete a lock
+func locksCreate(manifest *tq.Manifest, oidsExist, oidsMissing []TestObject) error {
+	client := manifest.APIClient()
+
+	lock, err := createTestLock(client, testLockPath("create"))
+	if err != nil {
+		return err
+	}
+	if len(lock.Owner.Name) == 0 {
+		return fmt.Errorf("Lock %s should include the name of its owner", lock.Id)
+	}
+	return deleteTestLock(client, lock)
+}
+
+// "locks" - lock a path which is already locked
+func locksConflict(manifest *tq.Manifest, oidsExist, oidsMissing []TestObject) error {
+	client := manifest.APIClient()
+
+	path := testLockPath("conflict")
+	lock, err := createTestLock(client, path)
+	if err != nil {
+		return err
+	}
+	defer deleteTestLock(client, lock)
+
+	status, msg, err := callLocksApiRaw(client, "POST", "locks", map[string]string{"path": path}, &apiLockResponse{})
+	if err != nil {
+		return err
+	}
+	if status != 409 {
+		return fmt.Errorf("Locking %q again: expected HTTP status 409, got %d", path, status)
+	}
+	if len(msg) == 0 {
+		return fmt.Errorf("Locking %q again: HTTP 409 response should include a JSON message", path)
+	}
+	return nil
+}
+
+// "locks" - list locks by path and id
+func locksList(manifest *tq.Manifest, oidsExist, oidsMissing []TestObject) error {
+	client := manifest.APIClient()
+
+	lock, err := createTestLock(client, testLockPath("list"))
+	if err != nil {
+		return err
+	}
+	defer deleteTestLock(client, lock)
+
+	for _, filter := range []string{"path", "id"} {
+		query := url.Values{}
+		if filter == "path" {
+			query.Set("path", lock.Path)
+		} else {
+			query.Set("id", lock.Id)
+		}
+
+		locks, err := listTestLocks(client, query)
+		if err != nil {
+			return err
+		}
+		if len(locks) != 1 || locks[0].Id != lock.Id {
+			return fmt.Errorf("Listing locks by %s: expected lock %s only, got %d locks", filter, lock.Id, len(locks))
+		}
+	}
+	return nil
+}
+
+// "locks" - verify locks, finding our own
+func locksVerify(manifest *tq.Manifest, oidsExist, oidsMissing []TestObject) error {
+	client := manifest.APIClient()
+
+	lock, err := createTestLock(client, testLockPath("verify"))
+	if err != nil {
+		return err
+	}
+	defer deleteTestLock(client, lock)
+
+	req := map[string]string{}
+	for {
+		list := &apiVerifiableLockList{}
+		status, msg, err := callLocksApiRaw(client, "POST", "locks/verify", req, list)
+		if err != nil {
+			return err
+		}
+		if status != 200 {
+			return fmt.Errorf("Verifying locks: expected HTTP status 200, got %d: %s", status, msg)
+		}
+
+		for _, l := range list.Ours {
+			if l.Id == lock.Id {
+				return nil
+			}
+		}
+		for _, l := range list.Theirs {
+			if l.Id == lock.Id {
+				return fmt.Errorf("Verifying locks: lock %s should be one of ours, not theirs", lock.Id)
+			}
+		}
+
+		if len(list.NextCursor) == 0 {
+			return fmt.Errorf("Verifying locks: lock %s not found", lock.Id)
+		}
+		req["cursor"] = list.NextCursor
+	}
+}
+
+// "locks" - delete a lock, which is then no longer listed
+func locksDelete(manifest *tq.Manifest, oidsExist, oidsMissing []TestObject) error {
+	client := manifest.APIClient()
+
+	lock, err := createTestLock(client, testLockPath("delete"))
+	if err != nil {
+		return err
+	}
+	if err := deleteTestLock(client, lock); err != nil {
+		return err
+	}
+
+	locks, err := listTestLocks(client, url.Values{"path": []string{lock.Path}})
+	if err != nil {
+		return err
+	}
+	if len(locks) != 0 {
+		return fmt.Errorf("Lock %s still listed after deleting it", lock.Id)
+	}
+	return nil
+}
+
+func init() {
+	addTest("Test locks: create and delete", locksCreate)
+	addTest("Test locks: conflicting lock", locksConflict)
+	addTest("Test locks: list by path and id", locksList)
+	addTest("Test locks: verify", locksVerify)
+	addTest("Test locks: deleted lock is not listed", locksDelete)
+}
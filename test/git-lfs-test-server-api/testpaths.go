@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/git-lfs/git-lfs/tq"
+)
+
+// pathVariant rewrites the path of a request to the API in a way that the
+// server must treat the same as the original path. Reverse proxies in front of
+// Git LFS servers commonly get these wrong, e.g. by redirecting or rejecting
+// them, or by passing a differently normalized path on to the server.
+type pathVariant struct {
+	Name string
+	// Rewrite alters the URL of a request for the given API resource,
+	// such as "objects/batch", under the API root.
+	Rewrite func(u *url.URL, resource string)
+}
+
+var pathVariants = []pathVariant{
+	{"trailing slash", func(u *url.URL, resource string) {
+		setPath(u, u.EscapedPath()+"/")
+	}},
+	{"duplicate slashes", func(u *url.URL, resource string) {
+		setPath(u, strings.Replace(u.EscapedPath(), "/", "//", -1))
+	}},
+	{"percent-encoded repository path", func(u *url.URL, resource string) {
+		segments := strings.Split(u.EscapedPath(), "/")
+		root := len(segments) - len(strings.Split(resource, "/"))
+		for i := 0; i < root; i++ {
+			segments[i] = percentEncodeFirst(segments[i])
+		}
+		setPath(u, strings.Join(segments, "/"))
+	}},
+}
+
+// setPath sets the path of "u" to the given escaped path.
+func setPath(u *url.URL, escaped string) {
+	path, err := url.PathUnescape(escaped)
+	if err != nil {
+		exit("invalid path %q: %s", escaped, err)
+	}
+	u.Path = path
+	u.RawPath = escaped
+}
+
+// percentEncodeFirst percent-encodes the first character of the given path
+// segment if it is alphanumeric, e.g. "repo" becomes "%72epo". By RFC 3986
+// both refer to the same resource.
+func percentEncodeFirst(segment string) string {
+	if len(segment) == 0 {
+		return segment
+	}
+
+	c := segment[0]
+	if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') {
+		return fmt.Sprintf("%%%02X%s", c, segment[1:])
+	}
+	return segment
+}
+
+func rewritePath(v pathVariant, resource string) func(req *http.Request) {
+	return func(req *http.Request) {
+		if v.Rewrite != nil {
+			v.Rewrite(req.URL, resource)
+		}
+	}
+}
+
+// "download" - mixed, with each path variant
+func pathsBatch(v pathVariant) func(manifest *tq.Manifest, oidsExist, oidsMissing []TestObject) error {
+	return func(manifest *tq.Manifest, oidsExist, oidsMissing []TestObject) error {
+		existSet := make(map[string]struct{}, len(oidsExist))
+		for _, o := range oidsExist {
+			existSet[o.Oid] = struct{}{}
+		}
+
+		objs := interleaveTestData(oidsExist[:5], oidsMissing[:5])
+		base, _, err := sendBatchApiRaw(manifest, tq.Download, objs, rewritePath(pathVariant{}, "objects/batch"))
+		if err != nil {
+			return err
+		}
+
+		status, bres, err := sendBatchApiRaw(manifest, tq.Download, objs, rewritePath(v, "objects/batch"))
+		if err != nil {
+			return err
+		}
+
+		if status != base {
+			return fmt.Errorf("Expected HTTP status %d, as without a %s, got %d", base, v.Name, status)
+		}
+
+		if status != 200 {
+			return nil
+		}
+
+		if len(bres.Objects) != len(objs) {
+			return fmt.Errorf("Incorrect number of returned objects, expected %d, got %d", len(objs), len(bres.Objects))
+		}
+
+		var errbuf bytes.Buffer
+		for _, o := range bres.Objects {
+			_, exists := existSet[o.Oid]
+			if exists {
+				if _, ok := o.Actions["download"]; !ok {
+					errbuf.WriteString(fmt.Sprintf("Missing download link for %s\n", o.Oid))
+				}
+			} else if o.Error == nil || o.Error.Code != 404 {
+				errbuf.WriteString(fmt.Sprintf("Download should include a 404 error for missing object %s\n", o.Oid))
+			}
+		}
+
+		if errbuf.Len() > 0 {
+			return errors.New(errbuf.String())
+		}
+
+		return nil
+	}
+}
+
+// "locks" - list, with each path variant
+func pathsLocks(v pathVariant) func(manifest *tq.Manifest, oidsExist, oidsMissing []TestObject) error {
+	return func(manifest *tq.Manifest, oidsExist, oidsMissing []TestObject) error {
+		client := manifest.APIClient()
+
+		base, err := listLocksRaw(client, pathVariant{})
+		if err != nil {
+			return err
+		}
+
+		status, err := listLocksRaw(client, v)
+		if err != nil {
+			return err
+		}
+
+		if status != base {
+			return fmt.Errorf("Expected HTTP status %d, as without a %s, got %d", base, v.Name, status)
+		}
+		return nil
+	}
+}
+
+// listLocksRaw lists locks with the given path variant, returning the HTTP
+// status code of the response.
+func listLocksRaw(client *lfsapi.Client, v pathVariant) (int, error) {
+	e := client.Endpoints.Endpoint("upload", "origin")
+	req, err := client.NewRequest("GET", e, "locks", nil)
+	if err != nil {
+		return 0, err
+	}
+	rewritePath(v, "locks")(req)
+
+	res, err := client.DoWithAuth("origin", req)
+	if res == nil {
+		return 0, err
+	}
+	res.Body.Close()
+	return res.StatusCode, nil
+}
+
+func init() {
+	for _, v := range pathVariants {
+		addTest(fmt.Sprintf("Test paths: batch, %s", v.Name), pathsBatch(v))
+		addTest(fmt.Sprintf("Test paths: locks, %s", v.Name), pathsLocks(v))
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/git-lfs/git-lfs/tq"
+)
+
+const (
+	// rateLimitProbes is the number of batch requests sent in a row to
+	// find out whether the server limits the rate of requests.
+	rateLimitProbes = 50
+
+	// rateLimitMaxWait is the longest Retry-After delay which is waited
+	// out to check that the server lets requests through again.
+	rateLimitMaxWait = 2 * time.Minute
+)
+
+// retryAfter returns the delay given by the value of a Retry-After header,
+// either as a number of seconds or as an HTTP date.
+func retryAfter(value string, now time.Time) (time.Duration, error) {
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, fmt.Errorf("negative Retry-After header: %q", value)
+		}
+		return time.Duration(secs) * time.Second, nil
+	}
+
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Retry-After header: %q", value)
+	}
+	if at.Before(now) {
+		return 0, nil
+	}
+	return at.Sub(now), nil
+}
+
+// rateLimit sends batch requests in the given direction until the server
+// rejects one with an HTTP 429, or rateLimitProbes have been sent. Servers are
+// not required to limit the rate of requests, but if they do, the response
+// must carry a Retry-After header and a JSON message, and the server must
+// accept requests again once the delay it gives has passed.
+func rateLimit(manifest *tq.Manifest, dir tq.Direction, oidsMissing []TestObject) error {
+	objs := oidsMissing[:1]
+
+	for i := 0; i < rateLimitProbes; i++ {
+		status, bres, err := callBatchApiRaw(manifest, dir, objs, nil)
+		if err != nil {
+			return err
+		}
+		if status != 429 {
+			continue
+		}
+
+		if len(bres.Message) == 0 {
+			return errors.New("HTTP 429 response should include a JSON message")
+		}
+		value := bres.Header.Get("Retry-After")
+		if len(value) == 0 {
+			return errors.New("HTTP 429 response should include a Retry-After header")
+		}
+		delay, err := retryAfter(value, time.Now())
+		if err != nil {
+			return err
+		}
+		if delay > rateLimitMaxWait {
+			// Too long to wait for in a test run.
+			return nil
+		}
+
+		time.Sleep(delay)
+
+		status, _, err = callBatchApiRaw(manifest, dir, objs, nil)
+		if err != nil {
+			return err
+		}
+		if status == 429 {
+			return fmt.Errorf("Batch request rejected with HTTP 429 after waiting %s, as given by Retry-After", delay)
+		}
+		return nil
+	}
+
+	return nil
+}
+
+// "download" - rate limiting
+func rateLimitDownload(manifest *tq.Manifest, oidsExist, oidsMissing []TestObject) error {
+	return rateLimit(manifest, tq.Download, oidsMissing)
+}
+
+// "upload" - rate limiting
+func rateLimitUpload(manifest *tq.Manifest, oidsExist, oidsMissing []TestObject) error {
+	return rateLimit(manifest, tq.Upload, oidsMissing)
+}
+
+func init() {
+	addTest("Test download: rate limiting", rateLimitDownload)
+	addTest("Test upload: rate limiting", rateLimitUpload)
+}
@@ -0,0 +1,159 @@
+package test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3Algorithm  = "AWS4-HMAC-SHA256"
+	s3DateFormat = "20060102"
+	s3TimeFormat = "20060102T150405Z"
+)
+
+// S3Credentials identify the account which signs requests to an S3-compatible
+// object storage service, such as MinIO, with AWS Signature Version 4.
+type S3Credentials struct {
+	AccessKey string
+	SecretKey string
+	Region    string
+}
+
+// PresignURL returns a copy of the URL of an object, which anyone can make a
+// request with the given method to until it expires, as those an LFS server
+// hands out to upload or download objects from object storage.
+func (c *S3Credentials) PresignURL(method string, u *url.URL, now time.Time, expires time.Duration) *url.URL {
+	now = now.UTC()
+
+	q := make(url.Values)
+	q.Set("X-Amz-Algorithm", s3Algorithm)
+	q.Set("X-Amz-Credential", c.AccessKey+"/"+c.scope(now))
+	q.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	q.Set("X-Amz-Expires", strconv.Itoa(int(expires/time.Second)))
+	q.Set("X-Amz-SignedHeaders", "host")
+
+	presigned := *u
+	presigned.RawQuery = fmt.Sprintf("%s&X-Amz-Signature=%s",
+		s3CanonicalQuery(q), c.signature(method, u.Host, u.Path, q, now))
+	return &presigned
+}
+
+// VerifyPresignedURL returns an error if a request with the given method to
+// the given host and URL was not pre-signed by these credentials, or if its
+// signature has expired.
+func (c *S3Credentials) VerifyPresignedURL(method, host string, u *url.URL, now time.Time) error {
+	q := u.Query()
+	signature := q.Get("X-Amz-Signature")
+	q.Del("X-Amz-Signature")
+
+	if algo := q.Get("X-Amz-Algorithm"); algo != s3Algorithm {
+		return fmt.Errorf("unsupported signing algorithm: %q", algo)
+	}
+	if headers := q.Get("X-Amz-SignedHeaders"); headers != "host" {
+		return fmt.Errorf("unsupported signed headers: %q", headers)
+	}
+
+	signed, err := time.Parse(s3TimeFormat, q.Get("X-Amz-Date"))
+	if err != nil {
+		return fmt.Errorf("invalid date: %q", q.Get("X-Amz-Date"))
+	}
+	expires, err := strconv.Atoi(q.Get("X-Amz-Expires"))
+	if err != nil || expires < 0 {
+		return fmt.Errorf("invalid expiry: %q", q.Get("X-Amz-Expires"))
+	}
+	if now.After(signed.Add(time.Duration(expires) * time.Second)) {
+		return fmt.Errorf("request has expired")
+	}
+
+	if cred := q.Get("X-Amz-Credential"); cred != c.AccessKey+"/"+c.scope(signed) {
+		return fmt.Errorf("invalid credential: %q", cred)
+	}
+	if !hmac.Equal([]byte(signature), []byte(c.signature(method, host, u.Path, q, signed))) {
+		return fmt.Errorf("signature does not match")
+	}
+	return nil
+}
+
+func (c *S3Credentials) scope(t time.Time) string {
+	return strings.Join([]string{t.Format(s3DateFormat), c.Region, "s3", "aws4_request"}, "/")
+}
+
+// signature returns the signature of a request with the given method to the
+// given host and path, pre-signed with the given query parameters at time "t".
+func (c *S3Credentials) signature(method, host, path string, q url.Values, t time.Time) string {
+	canonical := strings.Join([]string{
+		method,
+		s3EscapePath(path),
+		s3CanonicalQuery(q),
+		"host:" + host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	sum := sha256.Sum256([]byte(canonical))
+
+	toSign := strings.Join([]string{
+		s3Algorithm,
+		t.Format(s3TimeFormat),
+		c.scope(t),
+		hex.EncodeToString(sum[:]),
+	}, "\n")
+
+	key := []byte("AWS4" + c.SecretKey)
+	for _, part := range []string{t.Format(s3DateFormat), c.Region, "s3", "aws4_request"} {
+		key = s3HMAC(key, part)
+	}
+	return hex.EncodeToString(s3HMAC(key, toSign))
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3CanonicalQuery encodes the given query parameters sorted by name, as they
+// are signed.
+func s3CanonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for key := range q {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	params := make([]string, 0, len(keys))
+	for _, key := range keys {
+		params = append(params, s3Escape(key)+"="+s3Escape(q.Get(key)))
+	}
+	return strings.Join(params, "&")
+}
+
+func s3EscapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3Escape percent-encodes every byte of "s" but the unreserved characters of
+// RFC 3986, unlike url.QueryEscape.
+func s3Escape(s string) string {
+	var buf bytes.Buffer
+	for _, c := range []byte(s) {
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') ||
+			('0' <= c && c <= '9') || strings.IndexByte("-_.~", c) >= 0 {
+			buf.WriteByte(c)
+		} else {
+			fmt.Fprintf(&buf, "%%%02X", c)
+		}
+	}
+	return buf.String()
+}
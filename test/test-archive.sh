@@ -0,0 +1,94 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "archive"
+(
+  set -e
+
+  reponame="archive"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents_a="a contents"
+  contents_b="b contents"
+  printf "$contents_a" > a.dat
+  mkdir dir
+  printf "$contents_b" > dir/b.dat
+  git add .gitattributes a.dat dir/b.dat
+  git commit -m "add files"
+  git push origin master
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+
+  # Without the objects, and with downloads skipped, git archive writes
+  # pointers.
+  GIT_LFS_SKIP_SMUDGE=1 git archive HEAD | tar -xO a.dat | grep "version https://git-lfs"
+
+  GIT_LFS_SKIP_SMUDGE=1 git lfs archive HEAD 2>archive.log > archive.tar
+  [ "$contents_a" = "$(tar -xOf archive.tar a.dat)" ]
+  [ "$contents_b" = "$(tar -xOf archive.tar dir/b.dat)" ]
+  grep "(2 of 2 files)" archive.log
+
+  assert_local_object "$(calc_oid "$contents_a")" "${#contents_a}"
+  assert_local_object "$(calc_oid "$contents_b")" "${#contents_b}"
+)
+end_test
+
+begin_test "archive: with options and paths"
+(
+  set -e
+
+  reponame="archive-options"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents_a="a contents"
+  contents_b="b contents"
+  printf "$contents_a" > a.dat
+  mkdir dir
+  printf "$contents_b" > dir/b.dat
+  git add .gitattributes a.dat dir/b.dat
+  git commit -m "add files"
+  git push origin master
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+
+  git lfs archive --format=tar --prefix=release/ -o release.tar HEAD dir
+  [ "$contents_b" = "$(tar -xOf release.tar release/dir/b.dat)" ]
+  [ 0 -eq "$(tar -tf release.tar | grep -c "a.dat")" ]
+
+  assert_local_object "$(calc_oid "$contents_b")" "${#contents_b}"
+  refute_local_object "$(calc_oid "$contents_a")"
+)
+end_test
+
+begin_test "archive: in a bare repository"
+(
+  set -e
+
+  reponame="archive-bare"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="a contents"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  cd ..
+  git clone --bare "$GITSERVER/$reponame" "$reponame-bare.git"
+  cd "$reponame-bare.git"
+
+  git lfs archive master > archive.tar
+  [ "$contents" = "$(tar -xOf archive.tar a.dat)" ]
+)
+end_test
@@ -0,0 +1,57 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "audit log: disabled by default"
+(
+  set -e
+
+  reponame="audit-log-disabled"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "disabled" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  [ ! -e .git/lfs/audit.log ]
+)
+end_test
+
+begin_test "audit log: records uploads and downloads"
+(
+  set -e
+
+  reponame="audit-log-enabled"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.auditlog true
+
+  git lfs track "*.dat"
+  contents="enabled"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  [ "1" -eq "$(grep -c "" .git/lfs/audit.log)" ]
+  grep "\"direction\":\"upload\",\"oid\":\"$contents_oid\",\"size\":7" .git/lfs/audit.log
+  grep "\"remote\":\"origin\"" .git/lfs/audit.log
+
+  rm -rf .git/lfs/objects
+  git lfs fetch
+  assert_local_object "$contents_oid" 7
+
+  [ "2" -eq "$(grep -c "" .git/lfs/audit.log)" ]
+  tail -n 1 .git/lfs/audit.log | grep "\"direction\":\"download\",\"oid\":\"$contents_oid\""
+  tail -n 1 .git/lfs/audit.log | grep "\"command\":\"git-lfs fetch\""
+
+  # Objects that are already present are not downloaded, or recorded, again.
+  git lfs fetch
+  [ "2" -eq "$(grep -c "" .git/lfs/audit.log)" ]
+)
+end_test
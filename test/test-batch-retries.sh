@@ -77,3 +77,48 @@ begin_test "batch storage download causes retries"
   popd
 )
 end_test
+
+begin_test "batch storage download retries corrupt content"
+(
+  set -e
+
+  reponame="batch-storage-download-corrupt"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" batch-storage-repo-download-corrupt
+
+  contents="storage-download-corrupt"
+  oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+
+  git lfs track "*.dat"
+  git add .gitattributes a.dat
+  git commit -m "initial commit"
+
+  git push origin master
+  assert_server_object "$reponame" "$oid"
+
+  pushd ..
+    git \
+      -c "filter.lfs.process=" \
+      -c "filter.lfs.smudge=cat" \
+      -c "filter.lfs.required=false" \
+      clone "$GITSERVER/$reponame" "$reponame-assert"
+
+    cd "$reponame-assert"
+
+    git config credential.helper lfstest
+
+    GIT_TRACE=1 git lfs pull origin master 2>&1 | tee pull.log
+    if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+      echo >&2 "fatal: expected \`git lfs pull origin master\` to succeed ..."
+      exit 1
+    fi
+
+    grep "tq: retrying object $oid: .*Expected OID $oid" pull.log
+
+    assert_local_object "$oid" "${#contents}"
+    [ "$contents" = "$(cat a.dat)" ]
+    [ ! -e ".git/lfs/incomplete/$oid.tmp" ]
+  popd
+)
+end_test
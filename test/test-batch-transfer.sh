@@ -145,3 +145,66 @@ begin_test "batch transfers with ntlm server"
   GIT_CURL_VERBOSE=1 git push origin master 2>&1
 )
 end_test
+
+begin_test "batch transfers with lfs.transfer.batchsize"
+(
+  set -e
+
+  reponame="batch-transfer-batchsize"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  for i in 1 2 3 4 5; do
+    printf "$i" > "$i.dat"
+  done
+  git add .gitattributes *.dat
+  git commit -m "add 5 files"
+
+  git config lfs.transfer.batchsize 2
+  GIT_TRACE=1 git push origin master 2>&1 | tee push.log
+  grep "tq: running as batched queue, batch size of 2" push.log
+  [ "3" -eq "$(grep -c "api: batch [0-9]* files" push.log)" ]
+  [ "0" -eq "$(grep -c "api: batch [3-9] files" push.log)" ]
+
+  for i in 1 2 3 4 5; do
+    assert_server_object "$reponame" "$(calc_oid "$i")"
+  done
+)
+end_test
+
+begin_test "batch transfers split batches the server rejects as too large"
+(
+  set -e
+
+  # The test server rejects batch requests with bodies larger than 512 bytes
+  # for repositories whose names start with "batch-too-large".
+  reponame="batch-too-large"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  for i in $(seq 1 12); do
+    printf "$i" > "$i.dat"
+  done
+  git add .gitattributes *.dat
+  git commit -m "add 12 files"
+
+  GIT_TRACE=1 git push origin master 2>&1 | tee push.log
+  grep "tq: batch of 12 objects too large, splitting into batches of 6" push.log
+  grep "tq: batch of 6 objects too large, splitting into batches of 3" push.log
+
+  for i in $(seq 1 12); do
+    assert_server_object "$reponame" "$(calc_oid "$i")"
+  done
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+
+  git lfs fetch 2>&1 | tee fetch.log
+  for i in $(seq 1 12); do
+    assert_local_object "$(calc_oid "$i")" "${#i}"
+  done
+)
+end_test
@@ -0,0 +1,152 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+# chaos_round_trip pushes a file with the given contents to a new repository,
+# which the test server injects a fault into the transfers of, and then clones
+# it again, checking that both succeed. The trace of each is kept in push.log
+# and clone.log, respectively.
+chaos_round_trip() {
+  local reponame="$1"
+  local contents="$2"
+
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  GIT_TRACE=1 git push origin master 2>&1 | tee push.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git push origin master\` to succeed ..."
+    exit 1
+  fi
+  assert_server_object "$reponame" "$(calc_oid "$contents")"
+
+  cd "$TRASHDIR"
+  GIT_TRACE=1 git clone "$GITSERVER/$reponame" "$reponame-assert" 2>&1 | tee clone.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git clone\` to succeed ..."
+    exit 1
+  fi
+  [ "$contents" = "$(cat "$reponame-assert/a.dat")" ]
+}
+
+begin_test "chaos: 500"
+(
+  set -e
+
+  chaos_round_trip "chaos-500" "chaos-500"
+
+  grep "tq: retrying object" "chaos-500/push.log"
+  grep "tq: retrying object" clone.log
+)
+end_test
+
+begin_test "chaos: dropped connection"
+(
+  set -e
+
+  chaos_round_trip "chaos-drop" "chaos-drop"
+
+  # Go's HTTP client retries a download on a dropped connection by itself,
+  # so only the upload is retried by Git LFS.
+  grep "tq: retrying object" "chaos-drop/push.log"
+)
+end_test
+
+begin_test "chaos: truncated download"
+(
+  set -e
+
+  chaos_round_trip "chaos-truncate" "chaos-truncate"
+
+  [ "0" -eq "$(grep -c "tq: retrying object" "chaos-truncate/push.log")" ]
+  grep "xfer: Attempting to resume download" clone.log
+  grep "xfer: server accepted resume download request" clone.log
+)
+end_test
+
+begin_test "chaos: expired token"
+(
+  set -e
+
+  chaos_round_trip "chaos-expired-token" "chaos-expired-token"
+
+  grep "tq: retrying object" "chaos-expired-token/push.log"
+  grep "tq: retrying object" clone.log
+)
+end_test
+
+begin_test "chaos: slow response"
+(
+  set -e
+
+  # Time out the slow response, and retry.
+  git config --global "lfs.https://${GITSERVER#http://}.activitytimeout" 1
+
+  chaos_round_trip "chaos-slow" "chaos-slow"
+
+  # As with a dropped connection, Go's HTTP client retries a download which
+  # timed out by itself.
+  grep "tq: retrying object" "chaos-slow/push.log"
+)
+end_test
+
+begin_test "chaos: LFSTEST_CHAOS injects faults into every object"
+(
+  set -e
+
+  reponame="chaos-env"
+  setup_remote_repo "$reponame"
+
+  # Start another test server sharing the same repositories, which
+  # injects every fault in turn into the transfers of each object.
+  LFSTEST_URL="$TRASHDIR/chaos-url" \
+  LFSTEST_SSL_URL="$TRASHDIR/chaos-ssl-url" \
+  LFSTEST_CLIENT_CERT_URL="$TRASHDIR/chaos-client-cert-url" \
+  LFSTEST_CERT="$TRASHDIR/chaos-cert" \
+  LFSTEST_CLIENT_CERT="$TRASHDIR/chaos-client-cert" \
+  LFSTEST_CLIENT_KEY="$TRASHDIR/chaos-client-key" \
+  LFSTEST_DIR="$REMOTEDIR" \
+  LFSTEST_CHAOS="500,expired-token,truncate,drop" \
+    lfstest-gitserver > "$TRASHDIR/chaos-gitserver.log" 2>&1 &
+  wait_for_file "$TRASHDIR/chaos-url"
+  chaosserver="$(cat "$TRASHDIR/chaos-url")"
+  trap 'curl -s "$chaosserver/shutdown"' EXIT
+
+  cd "$TRASHDIR"
+  git clone "$chaosserver/$reponame" "$reponame"
+  cd "$reponame"
+  git config credential.helper lfstest
+
+  git lfs track "*.dat"
+  for i in 1 2 3; do
+    printf "contents $i" > "$i.dat"
+  done
+  git add .gitattributes *.dat
+  git commit -m "add files"
+
+  GIT_TRACE=1 git push origin master 2>&1 | tee push.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git push origin master\` to succeed ..."
+    exit 1
+  fi
+  for i in 1 2 3; do
+    GITSERVER="$chaosserver" assert_server_object "$reponame" "$(calc_oid "contents $i")"
+  done
+
+  cd "$TRASHDIR"
+  GIT_TRACE=1 git clone "$chaosserver/$reponame" "$reponame-assert" 2>&1 | tee clone.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git clone\` to succeed ..."
+    exit 1
+  fi
+  for i in 1 2 3; do
+    [ "contents $i" = "$(cat "$reponame-assert/$i.dat")" ]
+  done
+  grep "xfer: server accepted resume download request" clone.log
+)
+end_test
@@ -155,3 +155,107 @@ begin_test "checkout: outside git repository"
   grep "Not in a git repository" checkout.log
 )
 end_test
+
+begin_test "checkout: include/exclude"
+(
+  set -e
+
+  reponame="checkout-include-exclude"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  mkdir textures models
+  printf "texture" > textures/a.dat
+  printf "model" > models/a.dat
+  git add .gitattributes textures models
+  git commit -m "add files"
+
+  texture_oid="$(calc_oid "texture")"
+  model_oid="$(calc_oid "model")"
+
+  rm -r textures models
+  GIT_LFS_SKIP_SMUDGE=1 git checkout -- .
+  grep "$texture_oid" textures/a.dat
+  grep "$model_oid" models/a.dat
+
+  git lfs checkout --include="textures"
+  [ "texture" = "$(cat textures/a.dat)" ]
+  grep "$model_oid" models/a.dat
+
+  git config lfs.fetchexclude "models"
+  git lfs checkout
+  grep "$model_oid" models/a.dat
+
+  git lfs checkout --exclude=""
+  [ "model" = "$(cat models/a.dat)" ]
+)
+end_test
+
+begin_test "checkout: symbolic links in the working tree are not followed"
+(
+  set -e
+
+  reponame="checkout-symlinks"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  mkdir assets
+  printf "file" > a.dat
+  printf "asset" > assets/b.dat
+  git add .gitattributes a.dat assets
+  git commit -m "add files"
+
+  a_oid="$(calc_oid "file")"
+  b_oid="$(calc_oid "asset")"
+
+  # Point a.dat and the assets directory at pointers shared with another
+  # repository, which checkout must not overwrite.
+  mkdir ../shared-assets
+  pointer "$a_oid" 4 > ../shared-a.dat
+  pointer "$b_oid" 5 > ../shared-assets/b.dat
+  rm -r a.dat assets
+  ln -s ../shared-a.dat a.dat
+  ln -s ../shared-assets assets
+
+  git lfs checkout 2>&1 | tee checkout.log
+  grep "Skipped checkout for \"a.dat\", \"a.dat\" is a symbolic link" checkout.log
+  grep "Skipped checkout for \"assets/b.dat\", \"assets\" is a symbolic link" checkout.log
+
+  [ -L a.dat ]
+  [ -L assets ]
+  [ "$(pointer "$a_oid" 4)" = "$(cat ../shared-a.dat)" ]
+  [ "$(pointer "$b_oid" 5)" = "$(cat ../shared-assets/b.dat)" ]
+)
+end_test
+
+begin_test "checkout: --skip-download-errors reports files whose content is not local"
+(
+  set -e
+
+  reponame="checkout-skip-download-errors"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add a.dat and b.dat"
+
+  a_oid="$(calc_oid "a")"
+  rm ".git/lfs/objects/${a_oid:0:2}/${a_oid:2:2}/$a_oid"
+  rm a.dat b.dat
+
+  git lfs checkout --skip-download-errors 2>&1 | tee checkout.log
+  [ "0" = "${PIPESTATUS[0]}" ]
+
+  grep "Encountered 1 file(s) that could not be downloaded, and were left as pointers:" checkout.log
+  grep "	a.dat" checkout.log
+  [ "0" = "$(grep -c "Skipped checkout for" checkout.log)" ]
+
+  grep "oid sha256:$a_oid" a.dat
+  [ "b" = "$(cat b.dat)" ]
+)
+end_test
@@ -0,0 +1,156 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "compression: push compresses compressible objects"
+(
+  set -e
+
+  reponame="compression-push"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.compression gzip
+  git config lfs.compressionthreshold 1024
+
+  git lfs track "*.csv" "*.dat"
+  for i in $(seq 1 2000); do echo "$i,compressible,text"; done > a.csv
+  printf "too small" > small.dat
+  csv_oid="$(calc_oid_file a.csv)"
+  small_oid="$(calc_oid "too small")"
+  git add .gitattributes a.csv small.dat
+  git commit -m "add objects"
+
+  GIT_TRANSFER_TRACE=1 GIT_TRACE=1 git push origin master 2>&1 | tee push.log
+  grep "xfer: compressing upload of \"$csv_oid\" with gzip" push.log
+  [ "0" -eq "$(grep -c "compressing upload of \"$small_oid\"" push.log)" ]
+
+  assert_server_object "$reponame" "$csv_oid"
+  assert_server_object "$reponame" "$small_oid"
+)
+end_test
+
+begin_test "compression: push falls back when the server rejects it"
+(
+  set -e
+
+  reponame="compression-push-no-compression"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.compression gzip
+  git config lfs.compressionthreshold 1024
+
+  git lfs track "*.csv"
+  for i in $(seq 1 2000); do echo "$i,compressible,text"; done > a.csv
+  csv_oid="$(calc_oid_file a.csv)"
+  git add .gitattributes a.csv
+  git commit -m "add a.csv"
+
+  GIT_TRANSFER_TRACE=1 GIT_TRACE=1 git push origin master 2>&1 | tee push.log
+  grep "xfer: server rejected gzip upload of \"$csv_oid\"" push.log
+
+  assert_server_object "$reponame" "$csv_oid"
+)
+end_test
+
+begin_test "compression: disabled by default"
+(
+  set -e
+
+  reponame="compression-default"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.csv"
+  for i in $(seq 1 20000); do echo "$i,compressible,text"; done > a.csv
+  csv_oid="$(calc_oid_file a.csv)"
+  git add .gitattributes a.csv
+  git commit -m "add a.csv"
+
+  GIT_TRANSFER_TRACE=1 GIT_TRACE=1 git push origin master 2>&1 | tee push.log
+  [ "0" -eq "$(grep -c "compressing upload" push.log)" ]
+
+  assert_server_object "$reponame" "$csv_oid"
+)
+end_test
+
+begin_test "compression: lfs.incompressiblefiles and lfs.compressiblefiles"
+(
+  set -e
+
+  reponame="compression-hints"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.compression gzip
+  git config lfs.compressionthreshold 1024
+  git config lfs.incompressiblefiles "*.mp4"
+  git config lfs.compressiblefiles "*.exr"
+
+  git lfs track "*.mp4" "*.exr"
+  # Sampling would decide the opposite for both objects, since a.mp4 is
+  # text, and a.exr only compresses well after its first 64 KiB.
+  for i in $(seq 1 2000); do echo "$i,compressible,text"; done > a.mp4
+  { head -c 70000 /dev/urandom; head -c 1000000 /dev/zero; } > a.exr
+  mp4_oid="$(calc_oid_file a.mp4)"
+  exr_oid="$(calc_oid_file a.exr)"
+  git add .gitattributes a.mp4 a.exr
+  git commit -m "add objects"
+
+  GIT_TRANSFER_TRACE=1 GIT_TRACE=1 git push origin master 2>&1 | tee push.log
+  grep "xfer: compressing upload of \"$exr_oid\" with gzip" push.log
+  [ "0" -eq "$(grep -c "compressing upload of \"$mp4_oid\"" push.log)" ]
+
+  assert_server_object "$reponame" "$mp4_oid"
+  assert_server_object "$reponame" "$exr_oid"
+)
+end_test
+
+begin_test "compression: pull asks for compressed downloads"
+(
+  set -e
+
+  reponame="compression-pull"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.csv" "*.dat"
+  for i in $(seq 1 2000); do echo "$i,compressible,text"; done > a.csv
+  printf "too small" > small.dat
+  csv_oid="$(calc_oid_file a.csv)"
+  small_oid="$(calc_oid "too small")"
+  git add .gitattributes a.csv small.dat
+  git commit -m "add objects"
+  git push origin master
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 clone_repo "$reponame" "$reponame-default"
+  GIT_TRANSFER_TRACE=1 GIT_TRACE=1 git lfs pull 2>&1 | tee pull.log
+  [ "0" -eq "$(grep -c "xfer: decoding" pull.log)" ]
+  [ "$csv_oid" = "$(calc_oid_file a.csv)" ]
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 clone_repo "$reponame" "$reponame-gzip"
+  git config lfs.compression gzip
+  git config lfs.compressionthreshold 1024
+
+  GIT_TRANSFER_TRACE=1 GIT_TRACE=1 git lfs pull 2>&1 | tee pull.log
+  grep "xfer: decoding gzip download of \"$csv_oid\"" pull.log
+  [ "0" -eq "$(grep -c "decoding gzip download of \"$small_oid\"" pull.log)" ]
+
+  [ "$csv_oid" = "$(calc_oid_file a.csv)" ]
+  [ "too small" = "$(cat small.dat)" ]
+
+  # Files known not to compress well are not asked to be compressed.
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 clone_repo "$reponame" "$reponame-hints"
+  git config lfs.compression gzip
+  git config lfs.compressionthreshold 1024
+  git config lfs.incompressiblefiles "*.csv"
+
+  GIT_TRANSFER_TRACE=1 GIT_TRACE=1 git lfs pull 2>&1 | tee pull.log
+  [ "0" -eq "$(grep -c "xfer: decoding" pull.log)" ]
+  [ "$csv_oid" = "$(calc_oid_file a.csv)" ]
+)
+end_test
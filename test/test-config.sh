@@ -158,3 +158,42 @@ begin_test "config: ignoring unsafe lfsconfig keys"
   grep "  core.askpass" status.log
 )
 end_test
+
+begin_test "config: lfs.concurrenttransfers in lfsconfig"
+(
+  set -e
+
+  reponame="config-lfsconfig-concurrenttransfers"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config --file=.lfsconfig lfs.concurrenttransfers 5
+
+  git lfs env 2>&1 | tee env.log
+  grep "ConcurrentTransfers=5" env.log
+  grep "unsafe lfsconfig keys" env.log && exit 1
+
+  # Git config takes precedence over .lfsconfig.
+  git config lfs.concurrenttransfers 3
+  git lfs env | grep "ConcurrentTransfers=3"
+)
+end_test
+
+begin_test "url pushinsteadof config"
+(
+  set -e
+
+  mkdir url-pushinsteadof
+  cd url-pushinsteadof
+
+  git init
+  git remote add origin https://example.com/foo/bar.git
+  git config url."git@example.com:".pushInsteadOf https://example.com/
+
+  git lfs env | tee env.log
+  # Only pushes are rewritten.
+  grep "Endpoint=https://example.com/foo/bar.git/info/lfs (auth=none)" env.log
+  grep "^  SSH=" env.log && exit 1
+  true
+)
+end_test
@@ -0,0 +1,70 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "content-type: push sends the detected type"
+(
+  set -e
+
+  reponame="content-type-detect"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.contenttype true
+  git lfs track "*.dat" "*.css"
+  printf "\x89PNG\x0D\x0A\x1A\x0A\x00\x00\x00\x0DIHDR" > image.dat
+  printf "body { color: red; }" > style.css
+  git add .gitattributes image.dat style.css
+  git commit -m "add files"
+
+  GIT_CURL_VERBOSE=1 git push origin master 2>&1 | tee push.log
+  grep "> Content-Type: image/png" push.log
+  grep "> Content-Type: text/css" push.log
+  [ "0" -eq "$(grep -c "> Content-Type: application/octet-stream" push.log)" ]
+)
+end_test
+
+begin_test "content-type: push uses the recorded type"
+(
+  set -e
+
+  reponame="content-type-metadata"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.contenttype true
+  git config lfs.metadata true
+  git lfs track "*.dat"
+  contents="recorded type"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git lfs metadata a.dat | grep "content-type=text/plain"
+  git lfs metadata a.dat content-type=application/x-recorded
+
+  GIT_CURL_VERBOSE=1 git push origin master 2>&1 | tee push.log
+  grep "> Content-Type: application/x-recorded" push.log
+  assert_server_object "$reponame" "$contents_oid"
+)
+end_test
+
+begin_test "content-type: detection is disabled by default"
+(
+  set -e
+
+  reponame="content-type-disabled"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "\x89PNG\x0D\x0A\x1A\x0A\x00\x00\x00\x0DIHDR" > image.dat
+  git add .gitattributes image.dat
+  git commit -m "add image.dat"
+
+  GIT_CURL_VERBOSE=1 git push origin master 2>&1 | tee push.log
+  grep "> Content-Type: application/octet-stream" push.log
+  [ "0" -eq "$(grep -c "> Content-Type: image/png" push.log)" ]
+)
+end_test
@@ -306,7 +306,7 @@ begin_test "credentials from lfs.url"
   echo "bad push"
   git lfs env
   git lfs push origin master 2>&1 | tee push.log
-  grep "(0 of 1 files)" push.log
+  grep "(0 of 1 files, 1 failed)" push.log
 
   echo "good push"
   gitserverhost=$(echo "$GITSERVER" | cut -d'/' -f3)
@@ -320,7 +320,7 @@ begin_test "credentials from lfs.url"
   git config lfs.url http://$gitserverhost/$reponame.git/info/lfs
   git lfs env
   git lfs fetch --all 2>&1 | tee fetch.log
-  grep "(0 of 1 files)" fetch.log
+  grep "(0 of 1 files, 1 failed)" fetch.log
 
   echo "good fetch"
   rm -rf .git/lfs/objects
@@ -347,7 +347,7 @@ begin_test "credentials from remote.origin.url"
   echo "bad push"
   git lfs env
   git lfs push origin master 2>&1 | tee push.log
-  grep "(0 of 1 files)" push.log
+  grep "(0 of 1 files, 1 failed)" push.log
 
   echo "good push"
   gitserverhost=$(echo "$GITSERVER" | cut -d'/' -f3)
@@ -361,7 +361,7 @@ begin_test "credentials from remote.origin.url"
   git config remote.origin.url http://$gitserverhost/$reponame.git
   git lfs env
   git lfs fetch --all 2>&1 | tee fetch.log
-  grep "(0 of 1 files)" fetch.log
+  grep "(0 of 1 files, 1 failed)" fetch.log
 
   echo "good fetch"
   rm -rf .git/lfs/objects
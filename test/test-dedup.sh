@@ -0,0 +1,103 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+# dedup_supported returns whether files in the current repository's LFS
+# storage directory can be cloned, which depends on the file system the tests
+# run on.
+dedup_supported() {
+  ! git lfs dedup 2>&1 | grep -q "does not support deduplication"
+}
+
+begin_test "dedup"
+(
+  set -e
+
+  reponame="dedup"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  contents="dedup"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  if ! dedup_supported; then
+    set +e
+    git lfs dedup 2>&1 | tee dedup.log
+    res="${PIPESTATUS[0]}"
+    set -e
+
+    [ "0" -ne "$res" ]
+    grep "This system does not support deduplication." dedup.log
+    exit 0
+  fi
+
+  git lfs dedup 2>&1 | tee dedup.log
+  grep "Deduplicated 1 file(s)" dedup.log
+
+  [ "$contents" = "$(cat a.dat)" ]
+  [ -z "$(git status --porcelain)" ]
+)
+end_test
+
+begin_test "dedup: dirty working tree"
+(
+  set -e
+
+  reponame="dedup-dirty"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "dedup dirty" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  if ! dedup_supported; then
+    exit 0
+  fi
+
+  printf "changed" > a.dat
+
+  set +e
+  git lfs dedup 2>&1 | tee dedup.log
+  res="${PIPESTATUS[0]}"
+  set -e
+
+  [ "0" -ne "$res" ]
+  grep "Working tree is dirty" dedup.log
+  [ "changed" = "$(cat a.dat)" ]
+)
+end_test
+
+begin_test "checkout: lfs.dedup"
+(
+  set -e
+
+  reponame="checkout-dedup"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  contents="checkout dedup"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  rm a.dat
+  GIT_LFS_SKIP_SMUDGE=1 git checkout -- a.dat
+  assert_pointer "master" "a.dat" "$contents_oid" 14
+  grep "$contents_oid" a.dat
+
+  # Checking out falls back to copying objects where they cannot be cloned.
+  git config lfs.dedup true
+  git lfs checkout
+
+  [ "$contents" = "$(cat a.dat)" ]
+  [ -z "$(git status --porcelain)" ]
+)
+end_test
@@ -0,0 +1,76 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "encrypt: round trip"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  printf "0123456789abcdef0123456789abcdef\n" > "$TRASHDIR/$reponame.key"
+  git config lfs.encryption.keyfile "$TRASHDIR/$reponame.key"
+  git lfs encrypt install | tee install.log
+  grep "Git LFS encryption configured." install.log
+  [ "git-lfs encrypt clean %f" = "$(git config lfs.extension.encrypt.clean)" ]
+
+  git lfs track "*.dat"
+  contents="secret contents"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  # The pointer records the plaintext's OID as that of the extension's input,
+  # and the stored object is encrypted.
+  pointer="$(git cat-file -p :a.dat)"
+  echo "$pointer" | grep "ext-0-encrypt sha256:$contents_oid"
+  oid="$(echo "$pointer" | grep "^oid" | cut -d ":" -f 2)"
+  [ "$oid" != "$contents_oid" ]
+  grep "secret" ".git/lfs/objects/${oid:0:2}/${oid:2:2}/$oid" && exit 1
+
+  # Cleaning the same contents again gives the same pointer.
+  [ -z "$(git status --porcelain a.dat)" ]
+  touch a.dat
+  [ -z "$(git status --porcelain a.dat)" ]
+
+  git push origin master
+  assert_server_object "$reponame" "$oid"
+
+  cd "$TRASHDIR"
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+  git config lfs.encryption.keycommand "cat '$TRASHDIR/$reponame.key'"
+  git lfs encrypt install
+  git lfs pull
+  [ "$contents" = "$(cat a.dat)" ]
+)
+end_test
+
+begin_test "encrypt: wrong or missing key"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-wrong-key"
+  git init "$reponame"
+  cd "$reponame"
+
+  printf "0123456789abcdef" | git lfs encrypt smudge a.dat 2> err.log && exit 1
+  grep "No encryption key is configured" err.log
+
+  git config lfs.encryption.keyfile key
+  printf "short" > key
+  printf "0123456789abcdef" | git lfs encrypt clean a.dat 2> err.log && exit 1
+  grep "Invalid encryption key: encryption key must be at least 16 bytes long, got 5" err.log
+
+  printf "0123456789abcdef" > key
+  printf "contents" | git lfs encrypt clean a.dat > encrypted
+  [ "contents" = "$(git lfs encrypt smudge a.dat < encrypted)" ]
+
+  printf "fedcba9876543210" > key
+  git lfs encrypt smudge a.dat < encrypted 2> err.log && exit 1
+  grep "Error decrypting contents: contents could not be decrypted" err.log
+)
+end_test
@@ -0,0 +1,73 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "fetch: exits with the status for missing objects"
+(
+  set -e
+
+  reponame="error-classes-fetch-missing"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  contents="a"
+  oid="$(calc_oid "$contents")"
+
+  git lfs track "*.dat"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  delete_server_object "$reponame" "$oid"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+
+  set +e
+  git lfs fetch 2>&1 | tee fetch.log
+  res="${PIPESTATUS[0]}"
+  set -e
+
+  [ "66" -eq "$res" ]
+  [ "0" -eq "$(grep -c "\"class\"" fetch.log)" ]
+
+  set +e
+  git lfs pull --json-errors 2>pull.log
+  res="$?"
+  set -e
+
+  cat pull.log
+  [ "66" -eq "$res" ]
+  grep "{\"class\":\"object-missing\",\"exit_code\":66,\"oid\":\"$oid\",\"message\":\"\[$oid\] Object $oid does not exist" pull.log
+)
+end_test
+
+begin_test "push: exits with the status for authorization failures"
+(
+  set -e
+
+  reponame="error-classes-push-403"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  contents="status-batch-403"
+  oid="$(calc_oid "$contents")"
+
+  git lfs track "*.dat"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  set +e
+  git lfs push --json-errors origin master 2>push.log
+  res="$?"
+  set -e
+
+  cat push.log
+  [ "77" -eq "$res" ]
+  grep "{\"class\":\"auth\",\"exit_code\":77,\"oid\":\"$oid\",\"message\":\"\[$oid\] welp" push.log
+  refute_server_object "$reponame" "$oid"
+)
+end_test
@@ -0,0 +1,82 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "existence cache: shared cache skips batch requests"
+(
+  set -e
+
+  reponame="existence-cache-shared"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.existencecache "$GITSERVER/existence-cache"
+  git lfs track "*.dat"
+  contents="shared contents"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  GIT_TRACE=1 git push origin master 2>&1 | tee push.log
+  grep "(1 of 1 files)" push.log
+  assert_server_object "$reponame" "$contents_oid"
+
+  # Another clone, e.g. on another build agent, pushing the same object does
+  # not need to ask the server about it.
+  cd ..
+  clone_repo "$reponame" "$reponame-agent"
+  git config lfs.existencecache "$GITSERVER/existence-cache"
+
+  GIT_TRACE=1 git lfs push --object-id origin "$contents_oid" 2>&1 | tee push.log
+  grep "tq: skipping $contents_oid, known to exist on the server" push.log
+  [ "0" -eq "$(grep -c "tq: sending batch" push.log)" ]
+)
+end_test
+
+begin_test "existence cache: local cache records objects the server has"
+(
+  set -e
+
+  reponame="existence-cache-local"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.existencecache local
+  git lfs track "*.dat"
+  contents="local contents"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git push origin master
+  assert_server_object "$reponame" "$contents_oid"
+  grep "$contents_oid" .git/lfs/cache/exists/*
+
+  GIT_TRACE=1 git lfs push --all origin master 2>&1 | tee push.log
+  grep "tq: skipping $contents_oid, known to exist on the server" push.log
+)
+end_test
+
+begin_test "existence cache: disabled by default"
+(
+  set -e
+
+  reponame="existence-cache-disabled"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git push origin master
+  [ ! -d .git/lfs/cache/exists ]
+
+  GIT_TRACE=1 git lfs push --all origin master 2>&1 | tee push.log
+  grep "tq: sending batch of size 1" push.log
+  [ "0" -eq "$(grep -c "known to exist" push.log)" ]
+)
+end_test
@@ -0,0 +1,125 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "exists"
+(
+  set -e
+
+  reponame="exists"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="exists"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  missing_oid="$(calc_oid "missing")"
+
+  git lfs exists "$contents_oid" | tee exists.log
+  grep "$contents_oid present -" exists.log
+
+  set +e
+  git lfs exists "$contents_oid" "$missing_oid" > exists.log
+  res=$?
+  set -e
+  [ "1" -eq "$res" ]
+  grep "$missing_oid missing -" exists.log
+
+  git lfs exists --remote "$contents_oid" | tee exists.log
+  grep "$contents_oid present missing" exists.log
+
+  git push origin master
+  assert_server_object "$reponame" "$contents_oid"
+
+  delete_local_object "$contents_oid"
+
+  git lfs exists --remote "$contents_oid" | tee exists.log
+  grep "$contents_oid missing present" exists.log
+)
+end_test
+
+begin_test "exists --json"
+(
+  set -e
+
+  reponame="exists-json"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  contents="exists json"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  expected="{\"objects\":[{\"oid\":\"$contents_oid\",\"size\":11,\"local\":true}]}"
+  [ "$expected" = "$(git lfs exists --json "$contents_oid")" ]
+)
+end_test
+
+begin_test "exists --remote: objects which could not be checked"
+(
+  set -e
+
+  reponame="exists-remote-errors"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  gone_oid="$(calc_oid "status-batch-410")"
+  failed_oid="$(calc_oid "status-batch-500")"
+
+  set +e
+  git lfs exists --remote "$gone_oid" > exists.log
+  res=$?
+  set -e
+  [ "1" -eq "$res" ]
+  grep "$gone_oid missing missing" exists.log
+
+  set +e
+  git lfs exists --remote "$failed_oid" > exists.log 2> exists.err
+  res=$?
+  set -e
+  [ "2" -eq "$res" ]
+  grep "$failed_oid missing unknown" exists.log
+  grep "Could not check $failed_oid on \"origin\": \[500\] welp" exists.err
+
+  # a missing object takes precedence over one which could not be checked
+  set +e
+  git lfs exists --remote "$failed_oid" "$gone_oid" > exists.log 2>&1
+  res=$?
+  set -e
+  [ "1" -eq "$res" ]
+
+  git config lfs.url "http://127.0.0.1:1/$reponame.git/info/lfs"
+
+  set +e
+  git lfs exists --remote --json "$gone_oid" > exists.json
+  res=$?
+  set -e
+  [ "2" -eq "$res" ]
+  grep "\"oid\":\"$gone_oid\",\"size\":0,\"local\":false,\"error\":" exists.json
+  [ "0" -eq "$(grep -c "\"remote\":" exists.json)" ]
+)
+end_test
+
+begin_test "exists with invalid oid"
+(
+  set -e
+
+  reponame="exists-invalid-oid"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs exists "not-an-oid" 2>&1 | tee exists.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs exists' to fail"
+    exit 1
+  fi
+  grep "Invalid object ID: \"not-an-oid\"" exists.log
+)
+end_test
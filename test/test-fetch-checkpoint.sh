@@ -0,0 +1,132 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+a="a"
+a_oid="$(calc_oid "$a")"
+b="b"
+b_oid="$(calc_oid "$b")"
+c="c"
+c_oid="$(calc_oid "$c")"
+
+# setup_interrupted_fetch pushes three objects to a new remote repository, one
+# of which then goes missing on the server, and clones it without them into
+# "$reponame-clone".
+setup_interrupted_fetch() {
+  local reponame="$1"
+
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "$a" > a.dat
+  printf "$b" > b.dat
+  printf "$c" > c.dat
+  git add .gitattributes a.dat b.dat c.dat
+  git commit -m "add a.dat, b.dat and c.dat"
+  git push origin master
+
+  delete_server_object "$reponame" "$b_oid"
+  refute_server_object "$reponame" "$b_oid"
+
+  cd "$TRASHDIR"
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+}
+
+begin_test "fetch checkpoint: interrupted fetch is resumed"
+(
+  set -e
+
+  reponame="fetch-checkpoint-fetch"
+  setup_interrupted_fetch "$reponame"
+  cd "$reponame-clone"
+
+  git lfs fetch 2>&1 | tee fetch.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fetch to fail"
+    exit 1
+  fi
+
+  checkpoint=".git/lfs/fetch-checkpoint"
+  [ -f "$checkpoint" ]
+  grep "remote origin" "$checkpoint"
+  grep "pending $b_oid 1" "$checkpoint"
+  grep "done $a_oid" "$checkpoint"
+  grep "done $c_oid" "$checkpoint"
+  [ "0" -eq "$(grep -c "done $b_oid" "$checkpoint")" ]
+
+  # The missing object turns up on the server again.
+  cd "../$reponame"
+  git lfs push --object-id origin "$b_oid"
+  assert_server_object "$reponame" "$b_oid"
+
+  cd "../$reponame-clone"
+  git lfs fetch 2>&1 | tee fetch.log
+  grep "Resuming interrupted fetch: 2 of 3 objects (2 B of 3 B) already downloaded" fetch.log
+
+  assert_local_object "$a_oid" 1
+  assert_local_object "$b_oid" 1
+  assert_local_object "$c_oid" 1
+  [ ! -e "$checkpoint" ]
+
+  # Nothing is resumed once the fetch has finished.
+  git lfs fetch 2>&1 | tee fetch.log
+  [ "0" -eq "$(grep -c "Resuming interrupted fetch" fetch.log)" ]
+)
+end_test
+
+begin_test "fetch checkpoint: interrupted pull is resumed"
+(
+  set -e
+
+  reponame="fetch-checkpoint-pull"
+  setup_interrupted_fetch "$reponame"
+  cd "$reponame-clone"
+
+  git lfs pull 2>&1 | tee pull.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected pull to fail"
+    exit 1
+  fi
+
+  checkpoint=".git/lfs/fetch-checkpoint"
+  grep "pending $b_oid 1" "$checkpoint"
+  [ "0" -eq "$(grep -c "done $b_oid" "$checkpoint")" ]
+
+  cd "../$reponame"
+  git lfs push --object-id origin "$b_oid"
+
+  cd "../$reponame-clone"
+  git lfs pull 2>&1 | tee pull.log
+  grep "Resuming interrupted fetch: 2 of 3 objects" pull.log
+
+  [ "$a" = "$(cat a.dat)" ]
+  [ "$b" = "$(cat b.dat)" ]
+  [ "$c" = "$(cat c.dat)" ]
+  [ ! -e "$checkpoint" ]
+)
+end_test
+
+begin_test "fetch checkpoint: interrupted fetch from another remote is not resumed"
+(
+  set -e
+
+  reponame="fetch-checkpoint-other-remote"
+  setup_interrupted_fetch "$reponame"
+  cd "$reponame-clone"
+
+  git lfs fetch || true
+  grep "remote origin" ".git/lfs/fetch-checkpoint"
+
+  cd "../$reponame"
+  git lfs push --object-id origin "$b_oid"
+
+  cd "../$reponame-clone"
+  git remote add other "$GITSERVER/$reponame"
+  git lfs fetch other 2>&1 | tee fetch.log
+  [ "0" -eq "$(grep -c "Resuming interrupted fetch" fetch.log)" ]
+
+  assert_local_object "$b_oid" 1
+  [ ! -e ".git/lfs/fetch-checkpoint" ]
+)
+end_test
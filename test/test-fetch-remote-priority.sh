@@ -0,0 +1,114 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+a="a"
+a_oid="$(calc_oid "$a")"
+b="b"
+b_oid="$(calc_oid "$b")"
+
+# setup_mirror pushes a.dat and b.dat to the remote repository $1, and the
+# object of a.dat to its mirror, $1-mirror, instead, then clones $1 into
+# $1-clone without any objects, with the mirror as the "mirror" remote. Each
+# object can only be downloaded from one of them.
+setup_mirror() {
+  local reponame="$1"
+
+  setup_remote_repo "$reponame"
+  setup_remote_repo "$reponame-mirror"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "$a" > a.dat
+  printf "$b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add a.dat and b.dat"
+  git push origin master
+
+  git remote add mirror "$GITSERVER/$reponame-mirror"
+  git lfs push --object-id mirror "$a_oid"
+  assert_server_object "$reponame-mirror" "$a_oid"
+  refute_server_object "$reponame-mirror" "$b_oid"
+  delete_server_object "$reponame" "$a_oid"
+
+  cd "$TRASHDIR"
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+  git remote add mirror "$GITSERVER/$reponame-mirror"
+}
+
+begin_test "fetch: lfs.remotepriority falls back to the remote for objects the mirror lacks"
+(
+  set -e
+
+  reponame="fetch-remote-priority"
+  setup_mirror "$reponame"
+
+  git config lfs.remotepriority "mirror"
+  GIT_TRACE=1 git lfs fetch 2>&1 | tee fetch.log
+
+  grep "1 object(s) not downloaded from \"mirror\", falling back to \"origin\"" fetch.log
+  grep "HTTP: POST .*/$reponame-mirror.git/info/lfs/objects/batch" fetch.log
+  grep "HTTP: POST .*/$reponame.git/info/lfs/objects/batch" fetch.log
+
+  assert_local_object "$a_oid" 1
+  assert_local_object "$b_oid" 1
+)
+end_test
+
+begin_test "pull: lfs.remotepriority falls back to the remote for objects the mirror lacks"
+(
+  set -e
+
+  reponame="pull-remote-priority"
+  setup_mirror "$reponame"
+
+  git config lfs.remotepriority "mirror, origin"
+  GIT_TRACE=1 git lfs pull 2>&1 | tee pull.log
+
+  grep "1 object(s) not downloaded from \"mirror\", falling back to \"origin\"" pull.log
+
+  [ "$a" = "$(cat a.dat)" ]
+  [ "$b" = "$(cat b.dat)" ]
+)
+end_test
+
+begin_test "fetch: lfs.remotepriority reports errors of the last remote"
+(
+  set -e
+
+  reponame="fetch-remote-priority-missing"
+  setup_mirror "$reponame"
+
+  # Neither the mirror nor the remote has b.dat.
+  delete_server_object "$reponame" "$b_oid"
+
+  git config lfs.remotepriority "mirror"
+  git lfs fetch 2>&1 | tee fetch.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected fetch to fail"
+    exit 1
+  fi
+
+  grep "Object $b_oid does not exist" fetch.log
+  grep "failed to fetch some objects from '$GITSERVER/$reponame.git/info/lfs'" fetch.log
+  assert_local_object "$a_oid" 1
+  refute_local_object "$b_oid"
+)
+end_test
+
+begin_test "fetch: lfs.remotepriority ignores unknown remotes"
+(
+  set -e
+
+  reponame="fetch-remote-priority-unknown"
+  setup_mirror "$reponame"
+
+  git config lfs.remotepriority "nonexistent mirror"
+  git lfs fetch 2>&1 | tee fetch.log
+
+  grep "Ignoring unknown remote \"nonexistent\" in lfs.remotepriority" fetch.log
+  assert_local_object "$a_oid" 1
+  assert_local_object "$b_oid" 1
+)
+end_test
@@ -79,6 +79,63 @@ begin_test "fetch with remote"
 )
 end_test
 
+begin_test "fetch with timeout"
+(
+  set -e
+  cd clone
+  rm -rf .git/lfs/objects
+
+  set +e
+  git lfs fetch --timeout 1ns origin 2>&1 | tee fetch.log
+  exit_code="${PIPESTATUS[0]}"
+  set -e
+
+  [ "124" -eq "$exit_code" ]
+  grep "Stopped after --timeout of 1ns: 1 object(s) were not transferred." fetch.log
+  refute_local_object "$contents_oid"
+
+  git lfs fetch --timeout 1h origin 2>&1 | grep "(1 of 1 files)"
+  assert_local_object "$contents_oid" 1
+)
+end_test
+
+begin_test "fetch with timeout: cancels downloads in progress"
+(
+  set -e
+
+  reponame="fetch-timeout-in-progress"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  # The test server answers the first download of this object only after
+  # two seconds.
+  git lfs track "*.dat"
+  contents="chaos-slow"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+  assert_server_object "$reponame" "$contents_oid"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 clone_repo "$reponame" "$reponame-clone"
+
+  set +e
+  GIT_TRACE=1 GIT_TRANSFER_TRACE=1 git lfs fetch --timeout 500ms origin 2>&1 | tee fetch.log
+  exit_code="${PIPESTATUS[0]}"
+  set -e
+
+  [ "124" -eq "$exit_code" ]
+  grep "cancelled \"$contents_oid\", deadline exceeded" fetch.log
+  grep "Stopped after --timeout of 500ms: 1 object(s) were not transferred." fetch.log
+  refute_local_object "$contents_oid"
+
+  git lfs fetch origin 2>&1 | grep "(1 of 1 files)"
+  assert_local_object "$contents_oid" "${#contents}"
+)
+end_test
+
 begin_test "fetch with remote and branches"
 (
   set -e
@@ -107,3 +107,37 @@ end_test
 
 
 
+
+begin_test "filter process: lfs.skipdownloaderrors leaves pointers and reports them"
+(
+  set -e
+
+  reponame="filter_process_skip_download_errors"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add a.dat and b.dat"
+  git push origin master
+
+  a_oid="$(calc_oid "a")"
+  delete_server_object "$reponame" "$a_oid"
+
+  pushd ..
+    git -c lfs.skipdownloaderrors=true \
+      clone "$GITSERVER/$reponame" "$reponame-assert" 2>&1 | tee clone.log
+    [ "0" = "${PIPESTATUS[0]}" ]
+
+    grep "Encountered 1 file(s) that could not be downloaded, and were left as pointers:" clone.log
+    grep "	a.dat" clone.log
+
+    cd "$reponame-assert"
+    grep "oid sha256:$a_oid" a.dat
+    [ "b" = "$(cat b.dat)" ]
+    assert_clean_status
+  popd
+)
+end_test
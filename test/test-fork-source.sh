@@ -0,0 +1,61 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "fork source: server links objects instead of receiving them"
+(
+  set -e
+
+  reponame="fork-source-upstream"
+  setup_remote_repo "$reponame"
+  forkname="fork-source-fork"
+  setup_remote_repo "$forkname"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="upstream contents"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git push origin master
+  assert_server_object "$reponame" "$contents_oid"
+
+  git remote add fork "$GITSERVER/$forkname"
+  git config lfs.forksource origin
+
+  GIT_TRACE=1 git push fork master 2>&1 | tee push.log
+  grep "tq: $contents_oid linked from $GITSERVER/$reponame.git/info/lfs, skipping upload" push.log
+  [ "0" -eq "$(grep -c "HTTP: PUT" push.log)" ]
+
+  assert_server_object "$forkname" "$contents_oid"
+)
+end_test
+
+begin_test "fork source: objects missing from the source are uploaded"
+(
+  set -e
+
+  reponame="fork-source-missing-upstream"
+  setup_remote_repo "$reponame"
+  forkname="fork-source-missing-fork"
+  setup_remote_repo "$forkname"
+  clone_repo "$forkname" "$forkname"
+
+  git config lfs.forksource "$GITSERVER/$reponame.git/info/lfs"
+  git lfs track "*.dat"
+  contents="fork contents"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  GIT_TRACE=1 git push origin master 2>&1 | tee push.log
+  [ "0" -eq "$(grep -c "linked from" push.log)" ]
+  grep "(1 of 1 files)" push.log
+
+  assert_server_object "$forkname" "$contents_oid"
+  refute_server_object "$reponame" "$contents_oid"
+)
+end_test
@@ -98,6 +98,75 @@ begin_test "fsck dry run"
 )
 end_test
 
+begin_test "fsck: pointer size anomalies"
+(
+  set -e
+
+  reponame="fsck-size-anomalies"
+  git init $reponame
+  cd $reponame
+
+  git lfs track "*.dat"
+  contents="real contents"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  printf "version https://git-lfs.github.com/spec/v1
+oid sha256:$contents_oid
+size 99
+" > b.dat
+  printf "version https://git-lfs.github.com/spec/v1
+oid sha256:$contents_oid
+size 0
+" > zero.dat
+  printf "version https://git-lfs.github.com/spec/v1
+oid sha256:$contents_oid
+size 9999999999999999
+" > huge.dat
+  git add .gitattributes a.dat b.dat zero.dat huge.dat
+  git commit -m "add pointers"
+
+  git lfs fsck 2>&1 | tee fsck.log
+  grep "Pointer b.dat ($contents_oid) declares a size of 99 bytes, but a.dat declares 13 bytes" fsck.log
+  grep "Pointer zero.dat ($contents_oid) declares a size of 0 bytes" fsck.log
+  grep "Pointer huge.dat ($contents_oid) declares an implausible size of 9999999999999999 bytes" fsck.log
+  grep "git rm --cached -- zero.dat && git add -- zero.dat" fsck.log
+  grep "zero.dat holds a pointer rather than its contents: run \`git lfs checkout zero.dat\`" fsck.log
+  [ "0" -eq "$(grep -c "fsck OK" fsck.log)" ]
+  [ "0" -eq "$(grep -c "corrupt" fsck.log)" ]
+
+  git config lfs.maxplausiblesize "100PB"
+  git lfs fsck 2>&1 | tee fsck.log
+  [ "0" -eq "$(grep -c "implausible" fsck.log)" ]
+  grep "Pointer huge.dat ($contents_oid) declares a size of 9999999999999999 bytes, but a.dat declares 13 bytes" fsck.log
+)
+end_test
+
+begin_test "fsck: pointer size does not match object"
+(
+  set -e
+
+  reponame="fsck-size-mismatch"
+  git init $reponame
+  cd $reponame
+
+  git lfs track "*.dat"
+  contents="real contents"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add a.dat
+  git rm --cached a.dat
+  printf "version https://git-lfs.github.com/spec/v1
+oid sha256:$contents_oid
+size 12
+" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git lfs fsck 2>&1 | tee fsck.log
+  grep "Pointer a.dat ($contents_oid) declares a size of 12 bytes, but the object is 13 bytes" fsck.log
+)
+end_test
+
 begin_test "fsck: outside git repository"
 (
   set +e
@@ -113,3 +182,235 @@ begin_test "fsck: outside git repository"
   grep "Not in a git repository" fsck.log
 )
 end_test
+
+begin_test "fsck: --pointers reports files which are not pointers"
+(
+  set -e
+
+  reponame="fsck-pointers-invalid"
+  git init $reponame
+  cd $reponame
+
+  printf "raw contents" > a.dat
+  git add a.dat
+  git commit -m "add a.dat without Git LFS"
+
+  git lfs track "*.dat"
+  printf "tracked contents" > b.dat
+  git add .gitattributes b.dat
+  git commit -m "track *.dat"
+
+  set +e
+  git lfs fsck --pointers 2>&1 | tee fsck.log
+  status="${PIPESTATUS[0]}"
+  set -e
+
+  [ "1" -eq "$status" ]
+  grep "File a.dat is tracked by Git LFS, but is not a valid pointer" fsck.log
+  grep "If a.dat holds the right contents, run \`git rm --cached -- a.dat && git add -- a.dat\`" fsck.log
+  [ "0" -eq "$(grep -c "holds a pointer" fsck.log)" ]
+  [ "0" -eq "$(grep -c "b.dat" fsck.log)" ]
+
+  git rm --cached -- a.dat
+  git add -- a.dat
+  git commit -m "store a.dat in Git LFS"
+
+  [ "Git LFS fsck OK" = "$(git lfs fsck --pointers)" ]
+)
+end_test
+
+begin_test "fsck: --remote reports objects missing locally and on the remote"
+(
+  set -e
+
+  reponame="fsck-pointers-missing"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents_a="pushed contents"
+  contents_b="unpushed contents"
+  oid_a="$(calc_oid "$contents_a")"
+  oid_b="$(calc_oid "$contents_b")"
+
+  printf "$contents_a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  delete_local_object "$oid_a"
+  [ "Git LFS fsck OK" = "$(git lfs fsck --pointers --remote)" ]
+
+  printf "$contents_b" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+  # Without its contents in the working tree, refreshing the index cannot
+  # store the object again.
+  rm b.dat
+  delete_local_object "$oid_b"
+
+  # the remote is only checked with --remote
+  [ "Git LFS fsck OK" = "$(GIT_TRACE=1 git lfs fsck 2>trace.log)" ]
+  [ "0" -eq "$(grep -c "objects/batch" trace.log)" ]
+
+  set +e
+  git lfs fsck --remote 2>&1 | tee fsck.log
+  status="${PIPESTATUS[0]}"
+  set -e
+
+  [ "1" -eq "$status" ]
+  grep "Object b.dat ($oid_b) is missing locally and could not be found on the remote" fsck.log
+  [ "0" -eq "$(grep -c "a.dat" fsck.log)" ]
+
+  # objects are not reported missing when the remote cannot be reached
+  git config lfs.url "http://127.0.0.1:1/$reponame.git/info/lfs"
+
+  set +e
+  git lfs fsck --remote 2>&1 | tee fsck.log
+  status="${PIPESTATUS[0]}"
+  set -e
+
+  [ "1" -eq "$status" ]
+  grep "Object b.dat ($oid_b) is missing locally, and could not be checked on \"origin\"" fsck.log
+  [ "0" -eq "$(grep -c "could not be found on the remote" fsck.log)" ]
+)
+end_test
+
+begin_test "fsck: --objects checks objects no pointer in HEAD refers to"
+(
+  set -e
+
+  reponame="fsck-objects-unreferenced"
+  git init $reponame
+  cd $reponame
+
+  git lfs track "*.dat"
+  printf "master contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git checkout -b other
+  contents="other contents"
+  oid="$(calc_oid "$contents")"
+  printf "$contents" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+  git checkout master
+
+  [ "Git LFS fsck OK" = "$(git lfs fsck --objects)" ]
+
+  oid12=$(echo $oid | cut -b 1-2)
+  oid34=$(echo $oid | cut -b 3-4)
+  echo "CORRUPTION" >> .git/lfs/objects/$oid12/$oid34/$oid
+
+  set +e
+  git lfs fsck --objects 2>&1 | tee fsck.log
+  status="${PIPESTATUS[0]}"
+  set -e
+
+  [ "1" -eq "$status" ]
+  grep "Object $oid ($oid) is corrupt" fsck.log
+  [ -e ".git/lfs/bad/$oid" ]
+  refute_local_object "$oid"
+)
+end_test
+
+begin_test "fsck: in a bare mirror"
+(
+  set -e
+
+  reponame="fsck-bare-mirror"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents_a="master contents"
+  contents_b="other contents"
+  oid_a="$(calc_oid "$contents_a")"
+  oid_b="$(calc_oid "$contents_b")"
+
+  printf "$contents_a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git checkout -b other
+  printf "$contents_b" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+  git push origin master other
+
+  cd ..
+  git clone --mirror "$GITSERVER/$reponame" "$reponame-mirror.git"
+  cd "$reponame-mirror.git"
+
+  git lfs fetch --all
+  assert_local_object "$oid_a" "${#contents_a}"
+  assert_local_object "$oid_b" "${#contents_b}"
+
+  [ "Git LFS fsck OK" = "$(git lfs fsck)" ]
+
+  oid12=$(echo $oid_b | cut -b 1-2)
+  oid34=$(echo $oid_b | cut -b 3-4)
+  echo "CORRUPTION" >> lfs/objects/$oid12/$oid34/$oid_b
+
+  set +e
+  git lfs fsck --objects 2>&1 | tee fsck.log
+  status="${PIPESTATUS[0]}"
+  set -e
+
+  [ "1" -eq "$status" ]
+  grep "Object b.dat ($oid_b) is corrupt" fsck.log
+  refute_local_object "$oid_b"
+
+  [ "Git LFS fsck OK" = "$(git lfs fsck --pointers --remote)" ]
+
+  git remote remove origin
+
+  set +e
+  git lfs fsck --pointers --remote 2>&1 | tee fsck.log
+  status="${PIPESTATUS[0]}"
+  set -e
+
+  [ "1" -eq "$status" ]
+  grep "Object b.dat ($oid_b) is missing locally and could not be found on the remote" fsck.log
+  [ "0" -eq "$(grep -c "a.dat" fsck.log)" ]
+)
+end_test
+
+begin_test "fsck: --repair downloads corrupt objects again"
+(
+  set -e
+
+  reponame="fsck-repair"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="repaired contents"
+  oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  oid12=$(echo $oid | cut -b 1-2)
+  oid34=$(echo $oid | cut -b 3-4)
+  echo "CORRUPTION" >> .git/lfs/objects/$oid12/$oid34/$oid
+
+  git lfs fsck --repair 2>&1 | tee fsck.log
+  grep "Object a.dat ($oid) is corrupt" fsck.log
+  grep "Downloading corrupt objects again from origin" fsck.log
+
+  [ -e ".git/lfs/bad/$oid" ]
+  assert_local_object "$oid" "${#contents}"
+  [ "$oid" = "$(calc_oid_file .git/lfs/objects/$oid12/$oid34/$oid)" ]
+  [ "Git LFS fsck OK" = "$(git lfs fsck)" ]
+
+  set +e
+  git lfs fsck --repair --dry-run 2>&1 | tee fsck.log
+  status="${PIPESTATUS[0]}"
+  set -e
+  [ "2" -eq "$status" ]
+  grep "Cannot combine --dry-run with --repair." fsck.log
+)
+end_test
@@ -0,0 +1,112 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+calc_sha512_oid() {
+  printf "$1" | shasum -a 512 | cut -f 1 -d " "
+}
+
+begin_test "hash-algo: sha512"
+(
+  set -e
+
+  reponame="hash-algo-sha512"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.hashalgo sha512
+  git lfs track "*.dat"
+  contents="sha512 contents"
+  oid="$(calc_sha512_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  expected="$(printf "version https://git-lfs.github.com/spec/v1
+hash-algo sha512
+oid sha512:%s
+size %d" "$oid" "${#contents}")"
+  [ "$expected" = "$(git cat-file -p :a.dat)" ]
+  assert_local_object "$oid" "${#contents}"
+
+  git lfs fsck | grep "Git LFS fsck OK"
+
+  git push origin master 2>&1 | tee push.log
+  grep "(1 of 1 files)" push.log
+  assert_server_object "$reponame" "$oid"
+
+  # Objects are verified with the algorithm of their pointer, whatever the
+  # configuration of the clone.
+  cd ..
+  git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+  [ "$contents" = "$(cat a.dat)" ]
+  assert_local_object "$oid" "${#contents}"
+  git lfs fsck | grep "Git LFS fsck OK"
+
+  # Commands which take or show OIDs handle those of either length.
+  git lfs ls-files --long | grep "^$oid \* a.dat$"
+  git lfs ls-files | grep "^${oid:0:10} \* a.dat$"
+  git lfs exists "$oid" | grep "$oid present"
+  git lfs metadata "$oid" owner=art
+  [ "owner=art" = "$(git lfs metadata "$oid")" ]
+  [ "owner=art" = "$(git -c lfs.hashalgo=sha512 lfs metadata a.dat)" ]
+
+  # Without lfs.hashalgo, new objects are named by their SHA-256, and can
+  # be pushed alongside the others.
+  printf "sha256 contents" > b.dat
+  git add b.dat
+  git cat-file -p :b.dat | grep "oid sha256:$(calc_oid "sha256 contents")"
+  git cat-file -p :b.dat | grep -v "hash-algo"
+  git commit -m "add b.dat"
+  git push origin master 2>&1 | tee push.log
+  grep "(1 of 1 files)" push.log
+)
+end_test
+
+begin_test "hash-algo: server without support"
+(
+  set -e
+
+  reponame="sha256-only-hash-algo"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "sha256 contents" > b.dat
+  git add .gitattributes b.dat
+
+  git config lfs.hashalgo sha512
+  contents="sha512 contents"
+  printf "$contents" > a.dat
+  git add a.dat
+  git commit -m "add a.dat and b.dat"
+
+  git push origin master 2>&1 | tee push.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected push to fail"
+    exit 1
+  fi
+  grep "server does not support the sha512 hash algorithm" push.log
+  refute_server_object "$reponame" "$(calc_sha512_oid "$contents")"
+
+  # Objects named by SHA-256 are pushed all the same.
+  assert_server_object "$reponame" "$(calc_oid "sha256 contents")"
+)
+end_test
+
+begin_test "hash-algo: unsupported algorithm"
+(
+  set -e
+
+  reponame="hash-algo-unsupported"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config lfs.hashalgo md5
+  git lfs track "*.dat"
+  printf "contents" > a.dat
+  git add a.dat 2>&1 | tee add.log
+  grep "unsupported hash algorithm: \"md5\"" add.log
+)
+end_test
@@ -105,7 +105,8 @@ Git LFS initialized." = "$(git lfs install)" ]
 
 To resolve this, either:
   1: run \`git lfs update --manual\` for instructions on how to merge hooks.
-  2: run \`git lfs update --force\` to overwrite your hook."
+  2: run \`git lfs update --force\` to overwrite your hook.
+  3: run \`git lfs update --chain\` to run Git LFS from the start of your hook."
 
   echo "test" > .git/hooks/pre-push
   echo "test" > .git/hooks/post-checkout
@@ -290,3 +291,179 @@ begin_test "can install when multiple global values registered"
   git lfs install --force
 )
 end_test
+
+begin_test "install --chain"
+(
+  set -e
+
+  mkdir install-chain
+  cd install-chain
+  git init
+
+  printf '#!/bin/sh\necho "running my pre-push"\n' > .git/hooks/pre-push
+  chmod +x .git/hooks/pre-push
+
+  [ "Updated git hooks.
+Git LFS initialized." = "$(git lfs install --chain)" ]
+
+  pre_push_chain="#!/bin/sh
+# BEGIN git-lfs hook
+command -v git-lfs >/dev/null 2>&1 || { echo >&2 \"\\nThis repository is configured for Git LFS but 'git-lfs' was not found on your path. If you no longer wish to use Git LFS, remove this hook by deleting .git/hooks/pre-push.\\n\"; exit 2; }
+lfs_stdin=\"\$(mktemp)\" || exit 2
+cat > \"\$lfs_stdin\"
+git lfs pre-push \"\$@\" < \"\$lfs_stdin\" || { lfs_status=\$?; rm -f \"\$lfs_stdin\"; exit \$lfs_status; }
+exec < \"\$lfs_stdin\"
+rm -f \"\$lfs_stdin\"
+# END git-lfs hook
+
+echo \"running my pre-push\""
+  [ "$pre_push_chain" = "$(cat .git/hooks/pre-push)" ]
+
+  # hooks which did not exist are written as usual
+  grep "git lfs post-checkout" .git/hooks/post-checkout
+  [ "0" -eq "$(grep -c "BEGIN git-lfs hook" .git/hooks/post-checkout)" ]
+
+  # installing again does not chain the hook twice
+  git lfs install --chain
+  git lfs install
+  [ "$pre_push_chain" = "$(cat .git/hooks/pre-push)" ]
+
+  # an outdated chained hook is upgraded
+  sed -e 's/^git lfs pre-push.*$/git lfs push --stdin $*/' .git/hooks/pre-push > pre-push.old
+  cp pre-push.old .git/hooks/pre-push
+  git lfs install
+  [ "$pre_push_chain" = "$(cat .git/hooks/pre-push)" ]
+
+  # a hook chained at its end is moved to its start
+  printf '#!/bin/sh\necho "running my pre-push"\n\n# BEGIN git-lfs hook\ngit lfs pre-push "$@"\n# END git-lfs hook\n' > .git/hooks/pre-push
+  git lfs install
+  [ "$pre_push_chain" = "$(cat .git/hooks/pre-push)" ]
+
+  # the user's part of the hook still runs
+  git remote add origin "$GITSERVER/install-chain"
+  git commit --allow-empty -m "initial commit"
+  GIT_TERMINAL_PROMPT=0 git push origin master 2>&1 | tee push.log || true
+  grep "running my pre-push" push.log
+
+  set +e
+  git lfs install --chain --force 2>&1 | tee install.log
+  res="${PIPESTATUS[0]}"
+  set -e
+  [ "2" -eq "$res" ]
+  grep "You cannot use --chain with the --force or --manual options" install.log
+)
+end_test
+
+begin_test "install --chain: hooks which are not shell scripts"
+(
+  set -e
+
+  mkdir install-chain-python
+  cd install-chain-python
+  git init
+
+  printf '#!/usr/bin/env python\nprint("running my pre-push")\n' > .git/hooks/pre-push
+  printf 'echo "running my post-merge"\n' > .git/hooks/post-merge
+  cp .git/hooks/pre-push pre-push.orig
+  cp .git/hooks/post-merge post-merge.orig
+
+  set +e
+  git lfs install --chain 2>&1 | tee install.log
+  res="${PIPESTATUS[0]}"
+  set -e
+  [ "2" -eq "$res" ]
+  grep "Hook pre-push is not a shell script (#!/usr/bin/env python)" install.log
+  grep "git lfs update --force" install.log
+
+  # no hook is chained to, or written
+  cmp pre-push.orig .git/hooks/pre-push
+  cmp post-merge.orig .git/hooks/post-merge
+  [ ! -e .git/hooks/post-checkout ]
+
+  rm .git/hooks/pre-push
+  set +e
+  git lfs update --chain 2>&1 | tee update.log
+  res="${PIPESTATUS[0]}"
+  set -e
+  [ "2" -eq "$res" ]
+  grep "Hook post-merge has no \"#!\" line" update.log
+  cmp post-merge.orig .git/hooks/post-merge
+)
+end_test
+
+begin_test "install --chain: pre-push hook reading its input"
+(
+  set -e
+
+  reponame="install-chain-stdin"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  printf '#!/bin/sh\nwhile read local_ref local_sha remote_ref remote_sha; do\n  echo "my pre-push saw $remote_ref"\ndone\nexit 0\n' > .git/hooks/pre-push
+  chmod +x .git/hooks/pre-push
+  git lfs install --chain
+
+  git lfs track "*.dat"
+  contents="chained"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git push origin master 2>&1 | tee push.log
+  grep "my pre-push saw refs/heads/master" push.log
+  assert_server_object "$reponame" "$contents_oid"
+)
+end_test
+
+begin_test "install --worktree"
+(
+  set -e
+
+  ensure_git_version_isnt $VERSION_LOWER "2.20.0"
+
+  git config --global filter.lfs.clean "git lfs clean %f"
+
+  reponame="install-worktree"
+  mkdir "$reponame"
+  cd "$reponame"
+  git init
+  git commit --allow-empty -m "initial commit"
+
+  git lfs install --worktree 2>&1 | tee install.log
+  grep "The --worktree option requires extensions.worktreeConfig to be enabled." install.log
+  [ "" = "$(git config --local filter.lfs.clean)" ]
+
+  git config extensions.worktreeConfig true
+  git worktree add ../"$reponame-other"
+
+  git lfs install --worktree
+  [ "git-lfs clean -- %f" = "$(git config --worktree filter.lfs.clean)" ]
+  [ "git-lfs filter-process" = "$(git config --worktree filter.lfs.process)" ]
+  [ "" = "$(git config --local filter.lfs.clean)" ]
+  [ "git lfs clean %f" = "$(git config --global filter.lfs.clean)" ]
+
+  cd ../"$reponame-other"
+  [ "git lfs clean %f" = "$(git config filter.lfs.clean)" ]
+  [ "" = "$(git config --worktree filter.lfs.process)" ]
+)
+end_test
+
+begin_test "install --worktree with --local"
+(
+  set -e
+
+  reponame="install-worktree-local"
+  mkdir "$reponame"
+  cd "$reponame"
+  git init
+
+  set +e
+  git lfs install --worktree --local 2> err.log
+  res=$?
+  set -e
+
+  [ "Only one of --local and --worktree options can be specified." = "$(cat err.log)" ]
+  [ "0" != "$res" ]
+)
+end_test
@@ -0,0 +1,97 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "local store: push and clone through a local path remote"
+(
+  set -e
+
+  reponame="local-store-path"
+  mkdir "$reponame"
+  cd "$reponame"
+
+  git init --bare remote.git
+  git init repo
+  cd repo
+  git remote add origin "$TRASHDIR/$reponame/remote.git"
+
+  git lfs track "*.dat"
+  contents="local store"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git push origin master 2>&1 | tee push.log
+  grep "(1 of 1 files)" push.log
+
+  stored="$TRASHDIR/$reponame/remote.git/lfs/objects/${contents_oid:0:2}/${contents_oid:2:2}/$contents_oid"
+  [ "$contents" = "$(cat "$stored")" ]
+
+  cd ..
+  git clone "$TRASHDIR/$reponame/remote.git" clone
+  cd clone
+
+  [ "$contents" = "$(cat a.dat)" ]
+  assert_local_object "$contents_oid" 11
+)
+end_test
+
+begin_test "local store: lfs.url pointing at a directory"
+(
+  set -e
+
+  reponame="local-store-url"
+  mkdir -p "$reponame/store"
+  cd "$reponame"
+
+  git init repo
+  cd repo
+  git remote add origin "https://example.com/$reponame.git"
+  git config lfs.url "file://$TRASHDIR/$reponame/store"
+
+  git lfs track "*.dat"
+  contents="local store url"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git lfs push --object-id origin "$contents_oid" 2>&1 | tee push.log
+  grep "(1 of 1 files)" push.log
+
+  stored="$TRASHDIR/$reponame/store/${contents_oid:0:2}/${contents_oid:2:2}/$contents_oid"
+  [ "$contents" = "$(cat "$stored")" ]
+
+  rm -rf .git/lfs/objects a.dat
+  git lfs pull origin 2>&1 | tee pull.log
+  grep "(1 of 1 files)" pull.log
+
+  [ "$contents" = "$(cat a.dat)" ]
+  assert_local_object "$contents_oid" 15
+)
+end_test
+
+begin_test "local store: missing object"
+(
+  set -e
+
+  reponame="local-store-missing"
+  mkdir -p "$reponame/store"
+  cd "$reponame"
+
+  git init repo
+  cd repo
+  git remote add origin "https://example.com/$reponame.git"
+  git config lfs.url "file://$TRASHDIR/$reponame/store"
+
+  git lfs track "*.dat"
+  printf "missing" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  rm -rf .git/lfs/objects a.dat
+  git lfs pull origin 2>&1 | tee pull.log
+  grep "not found in $TRASHDIR/$reponame/store" pull.log
+)
+end_test
@@ -151,3 +151,52 @@ begin_test "cached locks"
   [ $(wc -l < locks.log) -eq 1 ]
 )
 end_test
+
+begin_test "list locks of several users"
+(
+  set -e
+
+  reponame="locks_list_users"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "clone_$reponame"
+
+  git lfs track "*.dat"
+  echo "ours" > "ours.dat"
+  echo "alice" > "alice.dat"
+  git add .gitattributes ours.dat alice.dat
+  git commit -m "add files"
+  git push origin master 2>&1 | tee push.log
+  grep "master -> master" push.log
+
+  git lfs lock --json "ours.dat" | tee lock.log
+  assert_server_lock "$reponame" "$(assert_lock "lock.log" ours.dat)"
+
+  # Act as another user of the test server, see lockOwner() in
+  # lfstest-gitserver.
+  printf "user-alice:pass" > "$CREDSDIR/127.0.0.1--$reponame"
+
+  git lfs lock --json "alice.dat" | tee lock.log
+  assert_server_lock "$reponame" "$(assert_lock "lock.log" alice.dat)"
+
+  git lfs lock "ours.dat" 2>&1 | tee lock.log
+  [ ${PIPESTATUS[0]} -ne "0" ]
+  grep "lock already created" lock.log
+
+  git lfs locks | tee locks.log
+  grep "ours.dat.*Git LFS Tests" locks.log
+  grep "alice.dat.*alice" locks.log
+)
+end_test
+
+begin_test "locks: compliance tool lock tests pass against the test server"
+(
+  set -e
+
+  reponame="locks_compliance"
+  setup_remote_repo "$reponame"
+
+  git-lfs-test-server-api --url "$GITSERVER/$reponame.git/info/lfs" 2>&1 |
+    tee test-server-api.log
+  [ "5" -eq "$(grep -c "Test locks: .* OK" test-server-api.log)" ]
+)
+end_test
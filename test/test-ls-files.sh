@@ -119,3 +119,182 @@ a1fff0ffefb9eace7230c24e50731f0a91c62f9cefdfe77121c2f607125dffae * two.tgz")"
   [ "$expected" = "$(git lfs ls-files --long)" ]
 )
 end_test
+
+begin_test "ls-files: --size"
+(
+  set -e
+
+  reponame="ls-files-size"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "some data" > some.dat
+  git add .gitattributes some.dat
+  git commit -m "add some.dat"
+
+  [ "1307990e6b * some.dat (9 B)" = "$(git lfs ls-files --size)" ]
+
+  oid="$(calc_oid "some data")"
+  [ "$oid * some.dat (9 B)" = "$(git lfs ls-files --long --size)" ]
+)
+end_test
+
+begin_test "ls-files: ref and ref range"
+(
+  set -e
+
+  reponame="ls-files-ref-range"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git tag v1
+
+  printf "b" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+  git tag v2
+
+  git rm a.dat
+  git commit -m "remove a.dat"
+
+  git lfs ls-files v1 | tee ls.log
+  grep "a.dat" ls.log
+  [ 1 -eq "$(wc -l < ls.log)" ]
+
+  git lfs ls-files v2 | tee ls.log
+  grep "a.dat" ls.log
+  grep "b.dat" ls.log
+  [ 2 -eq "$(wc -l < ls.log)" ]
+
+  git lfs ls-files v1..v2 | tee ls.log
+  grep "b.dat" ls.log
+  [ 1 -eq "$(wc -l < ls.log)" ]
+
+  git lfs ls-files v1 v2 | tee ls2.log
+  diff -u ls.log ls2.log
+
+  set +e
+  git lfs ls-files v1...v2 2>&1 | tee ls.log
+  res="${PIPESTATUS[0]}"
+  set -e
+  [ "0" -ne "$res" ]
+  grep "Invalid ref range" ls.log
+)
+end_test
+
+begin_test "ls-files: --all and --deleted"
+(
+  set -e
+
+  reponame="ls-files-all-deleted"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  printf "b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add a.dat and b.dat"
+
+  git checkout -b other
+  printf "c" > c.dat
+  git add c.dat
+  git commit -m "add c.dat"
+  git checkout master
+
+  printf "b2" > b.dat
+  git rm a.dat
+  git add b.dat
+  git commit -m "remove a.dat, modify b.dat"
+
+  git lfs ls-files --all --long | tee ls.log
+  grep "$(calc_oid "a") - a.dat" ls.log
+  grep "$(calc_oid "b") - b.dat" ls.log
+  grep "$(calc_oid "b2") \* b.dat" ls.log
+  grep "$(calc_oid "c") - c.dat" ls.log
+  [ 4 -eq "$(wc -l < ls.log)" ]
+
+  git lfs ls-files --deleted --long | tee ls.log
+  grep "$(calc_oid "a") - a.dat" ls.log
+  grep "$(calc_oid "b") - b.dat" ls.log
+  [ 2 -eq "$(wc -l < ls.log)" ]
+
+  git lfs ls-files --deleted --long other | tee ls.log
+  [ 0 -eq "$(wc -l < ls.log)" ]
+
+  for args in "--all master" "--all --deleted" "--deleted master^..master"; do
+    set +e
+    git lfs ls-files $args 2>&1 | tee ls.log
+    res="${PIPESTATUS[0]}"
+    set -e
+    [ "0" -ne "$res" ]
+    grep "Cannot use" ls.log
+  done
+)
+end_test
+
+begin_test "ls-files: --json"
+(
+  set -e
+
+  reponame="ls-files-json"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "track *.dat"
+
+  [ '{"files":[]}' = "$(git lfs ls-files --json)" ]
+
+  printf "some data" > some.dat
+  git add some.dat
+  git commit -m "add some.dat"
+
+  oid="$(calc_oid "some data")"
+  expected="{\"files\":[{\"name\":\"some.dat\",\"size\":9,\"checkout\":true,\"downloaded\":true,\"oid_type\":\"sha256\",\"oid\":\"$oid\",\"version\":\"https://git-lfs.github.com/spec/v1\"}]}"
+  [ "$expected" = "$(git lfs ls-files --json)" ]
+)
+end_test
+
+begin_test "ls-files: symbolic links are not checked out files"
+(
+  set -e
+
+  reponame="ls-files-symlinks"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  mkdir assets
+  printf "file" > a.dat
+  printf "asset" > assets/b.dat
+  git add .gitattributes a.dat assets
+  git commit -m "add files"
+
+  git lfs ls-files | tee ls.log
+  grep "\* a.dat" ls.log
+  grep "\* assets/b.dat" ls.log
+
+  # Whatever the links point to has the same contents, but is not the
+  # checked out file.
+  mkdir ../ls-files-shared
+  printf "file" > ../ls-files-a.dat
+  printf "asset" > ../ls-files-shared/b.dat
+  rm -r a.dat assets
+  ln -s ../ls-files-a.dat a.dat
+  ln -s ../ls-files-shared assets
+
+  git lfs ls-files | tee ls.log
+  grep "\- a.dat" ls.log
+  grep "\- assets/b.dat" ls.log
+
+  git lfs ls-files --json | tee ls.json
+  grep '"name":"a.dat","size":4,"checkout":false' ls.json
+)
+end_test
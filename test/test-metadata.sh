@@ -0,0 +1,102 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "metadata: clean records path when enabled"
+(
+  set -e
+
+  reponame="metadata-clean"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "not recorded" > a.dat
+  git add a.dat
+  [ -z "$(git lfs metadata a.dat)" ]
+
+  git config lfs.metadata true
+  contents="metadata"
+  contents_oid="$(calc_oid "$contents")"
+  mkdir dir
+  printf "$contents" > dir/b.dat
+  git add dir/b.dat
+
+  expected="$(printf "content-type=text/plain; charset=utf-8\npath=dir/b.dat")"
+  [ "$expected" = "$(git lfs metadata "$contents_oid")" ]
+  [ "$expected" = "$(git lfs metadata dir/b.dat)" ]
+)
+end_test
+
+begin_test "metadata: files are hashed with lfs.hashalgo"
+(
+  set -e
+
+  reponame="metadata-hashalgo"
+  git init "$reponame"
+  cd "$reponame"
+
+  git config lfs.metadata true
+  git config lfs.hashalgo sha512
+  git lfs track "*.dat"
+  contents="metadata sha512"
+  oid="$(printf "$contents" | shasum -a 512 | cut -f 1 -d " ")"
+  printf "$contents" > a.dat
+  git add a.dat
+
+  # The file in the working tree is hashed to find its metadata, as its
+  # pointer in the index was.
+  expected="$(printf "content-type=text/plain; charset=utf-8\npath=a.dat")"
+  [ "$expected" = "$(git lfs metadata "$oid")" ]
+  [ "$expected" = "$(git lfs metadata a.dat)" ]
+)
+end_test
+
+begin_test "metadata: set and unset keys"
+(
+  set -e
+
+  reponame="metadata-set"
+  git init "$reponame"
+  cd "$reponame"
+
+  oid="4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+
+  git lfs metadata "$oid" owner=art "description=a, b"
+  git lfs metadata "$oid" > metadata.log
+  [ "$(printf "description=a, b\nowner=art")" = "$(cat metadata.log)" ]
+
+  git lfs metadata --json "$oid" > metadata.json
+  [ "{\"oid\":\"$oid\",\"metadata\":{\"description\":\"a, b\",\"owner\":\"art\"}}" = "$(cat metadata.json)" ]
+
+  git lfs metadata "$oid" owner= description=
+  [ -z "$(git lfs metadata "$oid")" ]
+  [ ! -e ".git/lfs/metadata/4d/7a/$oid.json" ]
+
+  git lfs metadata "$oid" novalue 2>&1 | tee error.log
+  grep "Invalid metadata \"novalue\"" error.log
+)
+end_test
+
+begin_test "metadata: sent on upload when enabled"
+(
+  set -e
+
+  reponame="metadata-upload"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.metadata true
+  git lfs track "*.dat"
+  contents="metadata upload"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git config lfs.sendmetadata true
+  GIT_CURL_VERBOSE=1 git push origin master 2>&1 | tee push.log
+  grep "\"oid\":\"$contents_oid\",\"size\":15,\"metadata\":{\"content-type\":\"text/plain; charset=utf-8\",\"path\":\"a.dat\"}" push.log
+  assert_server_object "$reponame" "$contents_oid"
+)
+end_test
@@ -0,0 +1,78 @@
+#!/usr/bin/env bash
+
+. "test/test-migrate-fixtures.sh"
+. "test/testlib.sh"
+
+begin_test "migrate export (default branch)"
+(
+  set -e
+
+  setup_multiple_local_branches
+
+  md_contents="$(git cat-file -p :a.md)"
+  txt_oid="$(calc_oid "$(git cat-file -p :a.txt)")"
+
+  git lfs migrate import --everything --include="*.md,*.txt"
+
+  git lfs migrate export --include="*.md"
+
+  [ "$md_contents" = "$(git cat-file -p refs/heads/master:a.md)" ]
+  [ "$md_contents" = "$(cat a.md)" ]
+  assert_pointer "refs/heads/master" "a.txt" "$txt_oid" "120"
+
+  # The other branch is left alone.
+  git cat-file -p refs/heads/my-feature:a.md | grep -q "version https://git-lfs"
+
+  master_attrs="$(git cat-file -p refs/heads/master:.gitattributes)"
+  echo "$master_attrs" | grep -q "*.md !text !filter !merge !diff"
+  echo "$master_attrs" | grep -q "*.txt filter=lfs diff=lfs merge=lfs"
+  [ 0 -eq "$(echo "$master_attrs" | grep -c "*.md filter=lfs")" ]
+)
+end_test
+
+begin_test "migrate export (no --include)"
+(
+  set -e
+
+  setup_multiple_local_branches
+
+  git lfs migrate import --everything --include="*.md"
+
+  git lfs migrate export 2>&1 | tee migrate.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs migrate export' to fail"
+    exit 1
+  fi
+
+  grep "one or more files must be specified with --include" migrate.log
+)
+end_test
+
+begin_test "migrate export (downloads missing objects)"
+(
+  set -e
+
+  reponame="migrate-export-download"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="$(base64 < /dev/urandom | head -c 200)"
+  printf "%s" "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  contents_oid="$(calc_oid "$contents")"
+  assert_server_object "$reponame" "$contents_oid"
+
+  rm -rf .git/lfs/objects
+  refute_local_object "$contents_oid"
+
+  git lfs migrate export --everything --include="*.dat"
+
+  [ "$contents" = "$(git cat-file -p refs/heads/master:a.dat)" ]
+  [ "$contents" = "$(cat a.dat)" ]
+  git cat-file -p refs/heads/master:.gitattributes | grep -q "*.dat !text !filter !merge !diff"
+)
+end_test
@@ -468,3 +468,75 @@ begin_test "migrate import (--everything with --exclude-ref)"
     "fatal: cannot use --everything with --include-ref or --exclude-ref" ]
 )
 end_test
+
+begin_test "migrate import (--legacy-pointers)"
+(
+  set -e
+
+  remove_and_create_local_repo "migrate-import-legacy-pointers"
+
+  media_contents="git-media contents"
+  fat_contents="git-fat contents"
+  lost_contents="lost contents"
+  media_sha1="$(printf "$media_contents" | shasum -a 1 | cut -f 1 -d ' ')"
+  fat_sha1="$(printf "$fat_contents" | shasum -a 1 | cut -f 1 -d ' ')"
+  lost_sha1="$(printf "$lost_contents" | shasum -a 1 | cut -f 1 -d ' ')"
+
+  # Write stubs, and the caches of the tools which wrote them, the way
+  # git-media and git-fat do.
+  mkdir -p .git/media/objects .git/fat/objects
+  printf "$media_contents" > ".git/media/objects/$media_sha1"
+  printf "$fat_contents" > ".git/fat/objects/$fat_sha1"
+  printf "%s\n" "$media_sha1" > a.psd
+  printf "#\$# git-fat %s %20d\n" "$fat_sha1" "${#fat_contents}" > b.bin
+  printf "#\$# git-fat %s %20d\n" "$lost_sha1" "${#lost_contents}" > lost.bin
+  printf "%s\n" "$media_sha1" > not-a-stub.txt
+  printf "plain" > plain.bin
+
+  git add a.psd b.bin lost.bin not-a-stub.txt plain.bin
+  git commit -m "initial commit"
+
+  git lfs migrate import --legacy-pointers --include="*.psd,*.bin" 2>&1 | tee migrate.log
+  grep "kept 1 stub(s)" migrate.log
+  grep "lost.bin (git-fat $lost_sha1)" migrate.log
+
+  media_oid="$(calc_oid "$media_contents")"
+  fat_oid="$(calc_oid "$fat_contents")"
+  assert_pointer "refs/heads/master" "a.psd" "$media_oid" "${#media_contents}"
+  assert_pointer "refs/heads/master" "b.bin" "$fat_oid" "${#fat_contents}"
+  assert_pointer "refs/heads/master" "plain.bin" "$(calc_oid "plain")" "5"
+  assert_local_object "$media_oid" "${#media_contents}"
+  assert_local_object "$fat_oid" "${#fat_contents}"
+
+  # The stub whose contents are missing, and files which are not included,
+  # are left alone.
+  git cat-file -p master:lost.bin | grep "git-fat $lost_sha1"
+  [ "$media_sha1" = "$(git cat-file -p master:not-a-stub.txt)" ]
+)
+end_test
+
+begin_test "migrate import (--legacy-pointers, corrupt cache)"
+(
+  set -e
+
+  remove_and_create_local_repo "migrate-import-legacy-pointers-corrupt"
+
+  sha1="$(printf "contents" | shasum -a 1 | cut -f 1 -d ' ')"
+  mkdir -p .git/media/objects
+  printf "corrupt" > ".git/media/objects/$sha1"
+  printf "%s\n" "$sha1" > a.psd
+
+  git add a.psd
+  git commit -m "initial commit"
+  original="$(git rev-parse master)"
+
+  git lfs migrate import --legacy-pointers --include="*.psd" 2>&1 | tee migrate.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected migrate to fail"
+    exit 1
+  fi
+  grep "git-media object $sha1 is corrupt" migrate.log
+
+  assert_ref_unmoved "master" "$original" "$(git rev-parse master)"
+)
+end_test
@@ -0,0 +1,121 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+# start_object_storage starts lfstest-s3server, and another test server which
+# keeps objects in it, pointing GITSERVER at the latter and S3SERVER at the
+# former. Both are shut down when the test exits.
+start_object_storage() {
+  export LFSTEST_S3_ACCESS_KEY="lfstest"
+  export LFSTEST_S3_SECRET_KEY="lfstest-secret"
+
+  LFSTEST_S3_URL="$TRASHDIR/s3-url" lfstest-s3server > "$TRASHDIR/s3server.log" 2>&1 &
+  wait_for_file "$TRASHDIR/s3-url"
+  S3SERVER="$(cat "$TRASHDIR/s3-url")"
+
+  LFSTEST_URL="$TRASHDIR/s3-gitserver-url" \
+  LFSTEST_SSL_URL="$TRASHDIR/s3-gitserver-ssl-url" \
+  LFSTEST_CLIENT_CERT_URL="$TRASHDIR/s3-gitserver-client-cert-url" \
+  LFSTEST_CERT="$TRASHDIR/s3-gitserver-cert" \
+  LFSTEST_CLIENT_CERT="$TRASHDIR/s3-gitserver-client-cert" \
+  LFSTEST_CLIENT_KEY="$TRASHDIR/s3-gitserver-client-key" \
+  LFSTEST_DIR="$REMOTEDIR" \
+  LFSTEST_S3_ENDPOINT="$S3SERVER" \
+  LFSTEST_S3_BUCKET="lfs" \
+    lfstest-gitserver > "$TRASHDIR/s3-gitserver.log" 2>&1 &
+  wait_for_file "$TRASHDIR/s3-gitserver-url"
+  GITSERVER="$(cat "$TRASHDIR/s3-gitserver-url")"
+
+  trap 'curl -s "$GITSERVER/shutdown"; curl -s "$S3SERVER/shutdown"' EXIT
+}
+
+begin_test "object storage: push and clone through pre-signed URLs"
+(
+  set -e
+
+  start_object_storage
+
+  reponame="object-storage"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="contents in object storage"
+  oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  GIT_TRACE=1 git push origin master 2>&1 | tee push.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git push origin master\` to succeed ..."
+    exit 1
+  fi
+  grep "api: redirect PUT $GITSERVER/storage/$oid to $S3SERVER/lfs/$reponame/$oid" push.log
+  assert_server_object "$reponame" "$oid"
+
+  cd "$TRASHDIR"
+  GIT_TRACE=1 git clone "$GITSERVER/$reponame" "$reponame-assert" 2>&1 | tee clone.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git clone\` to succeed ..."
+    exit 1
+  fi
+  grep "api: redirect GET $GITSERVER/storage/$oid to $S3SERVER/lfs/$reponame/$oid" clone.log
+  [ "$contents" = "$(cat "$reponame-assert/a.dat")" ]
+
+  # The object storage rejects a request which carries both a pre-signed URL
+  # and an Authorization header, so the client must not have sent it the
+  # credentials for the Git server.
+  [ "0" -eq "$(grep -c "InvalidArgument" "$TRASHDIR/s3server.log")" ]
+)
+end_test
+
+begin_test "object storage: headers for the Git server are not sent to object storage"
+(
+  set -e
+
+  start_object_storage
+
+  reponame="object-storage-headers"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config "http.$GITSERVER/.extraheader" "X-Git-Server-Token: secret"
+
+  git lfs track "*.dat"
+  contents="contents with headers"
+  oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  GIT_TRACE=1 git push origin master 2>&1 | tee push.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git push origin master\` to succeed ..."
+    exit 1
+  fi
+  grep "api: redirect PUT $GITSERVER/storage/$oid to $S3SERVER/lfs/$reponame/$oid" push.log
+  assert_server_object "$reponame" "$oid"
+
+  rm -rf .git/lfs/objects
+  GIT_TRACE=1 git lfs pull 2>&1 | tee pull.log
+  grep "api: redirect GET $GITSERVER/storage/$oid to $S3SERVER/lfs/$reponame/$oid" pull.log
+  [ "$contents" = "$(cat a.dat)" ]
+
+  [ "0" -eq "$(grep -c "X-Git-Server-Token" "$TRASHDIR/s3server.log")" ]
+  [ "0" -eq "$(grep -c "InvalidArgument" "$TRASHDIR/s3server.log")" ]
+)
+end_test
+
+begin_test "object storage: rejects unsigned requests"
+(
+  set -e
+
+  start_object_storage
+
+  curl -v "$S3SERVER/lfs/object-storage/$(calc_oid "unsigned")" \
+    -X PUT -d "unsigned" 2>&1 | tee http.log
+  grep "403 Forbidden" http.log
+  grep "AccessDenied" http.log
+)
+end_test
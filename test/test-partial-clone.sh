@@ -0,0 +1,46 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "partial clone: fetch --all does not download filtered Git blobs"
+(
+  set -e
+
+  reponame="partial-clone-fetch-all"
+  mkdir "$reponame"
+  cd "$reponame"
+
+  git init --bare remote.git
+  git --git-dir=remote.git config uploadpack.allowfilter true
+
+  git init repo
+  cd repo
+  git remote add origin "$TRASHDIR/$reponame/remote.git"
+
+  git lfs track "*.dat"
+  contents="partial clone"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  for i in $(seq 1 200); do echo "line $i of a large file"; done > big.txt
+  big_sha="$(git hash-object big.txt)"
+  git add .gitattributes a.dat big.txt
+  git commit -m "add a.dat and big.txt"
+
+  git push origin master
+
+  cd ..
+  git clone --no-checkout --filter=blob:limit=1k \
+    "file://$TRASHDIR/$reponame/remote.git" clone
+  cd clone
+
+  [ "true" = "$(git config remote.origin.promisor)" ]
+  git rev-list --objects --all --missing=print | grep "^?$big_sha"
+
+  git lfs fetch --all 2>&1 | tee fetch.log
+  grep "(1 of 1 files)" fetch.log
+  assert_local_object "$contents_oid" 13
+
+  # The large, non-LFS blob is still only available from the promisor remote.
+  git rev-list --objects --all --missing=print | grep "^?$big_sha"
+)
+end_test
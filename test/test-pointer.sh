@@ -300,3 +300,78 @@ begin_test "pointer to console"
   grep "oid sha256:e96ec1bd71eea8df78b24c64a7ab9d42dd7f821c4e503f0e2288273b9bff6c16" pointer.txt
 )
 end_test
+
+begin_test "pointer --check --file"
+(
+  set -e
+
+  echo "version https://git-lfs.github.com/spec/v1
+oid sha256:6c17f2007cbe934aee6e309b28b2dba3c119c5dff2ef813ed124699efe319868
+size 7" > valid-pointer
+  echo "not a pointer" > invalid-pointer
+  { cat valid-pointer; head -c 2048 /dev/zero; } > pointer-prefix
+
+  [ -z "$(git lfs pointer --check --file=valid-pointer 2>&1)" ]
+
+  set +e
+  git lfs pointer --check --file=invalid-pointer
+  invalid_status=$?
+  git lfs pointer --check --file=pointer-prefix
+  prefix_status=$?
+  git lfs pointer --check --file=missing-file
+  missing_status=$?
+  mkdir -p unreadable-dir
+  git lfs pointer --check --file=unreadable-dir 2>&1 | tee check.log
+  unreadable_status="${PIPESTATUS[0]}"
+  set -e
+
+  [ "1" -eq "$invalid_status" ]
+  [ "1" -eq "$prefix_status" ]
+  [ "2" -eq "$missing_status" ]
+
+  # Data which cannot be read is not mistaken for a non-pointer.
+  [ "2" -eq "$unreadable_status" ]
+  grep "Could not read the pointer" check.log
+)
+end_test
+
+begin_test "pointer --check --stdin"
+(
+  set -e
+
+  echo "version https://git-lfs.github.com/spec/v1
+oid sha256:6c17f2007cbe934aee6e309b28b2dba3c119c5dff2ef813ed124699efe319868
+size 7" | git lfs pointer --check --stdin
+
+  set +e
+  echo "not a pointer" | git lfs pointer --check --stdin
+  status=$?
+  set -e
+
+  [ "1" -eq "$status" ]
+)
+end_test
+
+begin_test "pointer --check with invalid arguments"
+(
+  set -e
+
+  echo "not a pointer" > some-file
+
+  set +e
+  git lfs pointer --check 2>&1 | tee check.log
+  none_status="${PIPESTATUS[0]}"
+  git lfs pointer --check --file=some-file --pointer=some-file 2>&1 | tee check-pointer.log
+  pointer_status="${PIPESTATUS[0]}"
+  echo "" | git lfs pointer --check --file=some-file --stdin 2>&1 | tee check-stdin.log
+  stdin_status="${PIPESTATUS[1]}"
+  set -e
+
+  [ "2" -eq "$none_status" ]
+  grep "Must specify either --file or --stdin with --check." check.log
+  [ "2" -eq "$pointer_status" ]
+  grep "Cannot combine --check with --pointer." check-pointer.log
+  [ "2" -eq "$stdin_status" ]
+  grep "With --check, --file cannot be combined with --stdin." check-stdin.log
+)
+end_test
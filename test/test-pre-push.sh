@@ -640,6 +640,48 @@ begin_test "pre-push with their lock on lfs file"
 )
 end_test
 
+begin_test "pre-push with a lock owned by another user"
+(
+  set -e
+
+  reponame="pre_push_other_user_lock"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "locked contents" > locked.dat
+  git add .gitattributes locked.dat
+  git commit -m "add locked.dat"
+
+  git push origin master
+
+  # Lock the file as another user of the test server, see lockOwner() in
+  # lfstest-gitserver, by naming them in the URL of the remote.
+  git -c remote.origin.url="$(echo "$GITSERVER" | sed "s#://#://user-alice@#")/$reponame" \
+    lfs lock --json "locked.dat" | tee lock.log
+  id=$(assert_lock lock.log locked.dat)
+  assert_server_lock "$reponame" "$id"
+
+  git config lfs.locksverify true
+
+  printf "unauthorized changes" >> locked.dat
+  git add locked.dat
+  # --no-verify is used to avoid the pre-commit hook which is not under test
+  git commit --no-verify -m "add unauthorized changes"
+
+  git push origin master 2>&1 | tee push.log
+  res="${PIPESTATUS[0]}"
+  if [ "0" -eq "$res" ]; then
+    echo "push should fail"
+    exit 1
+  fi
+
+  grep "Unable to push locked files" push.log
+  grep "* locked.dat - alice" push.log
+  refute_server_object "$reponame" "$(calc_oid_file locked.dat)"
+)
+end_test
+
 begin_test "pre-push with their lock on non-lfs lockable file"
 (
   set -e
@@ -1077,3 +1119,89 @@ begin_test "pre-push locks verify 403 with verification unset"
   [ -z "$(git config "lfs.$endpoint.locksverify")" ]
 )
 end_test
+
+begin_test "pre-push in a shallow clone"
+(
+  set -e
+
+  reponame="pre-push-shallow"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "one" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  printf "two" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+  git push origin master
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone --depth 1 "$GITSERVER/$reponame" "$reponame-shallow"
+  cd "$reponame-shallow"
+  [ "true" = "$(git rev-parse --is-shallow-repository)" ]
+  git config lfs.allowincompletepush false
+
+  printf "three" > c.dat
+  git add c.dat
+  git commit -m "add c.dat"
+
+  git lfs push --dry-run origin master 2>&1 | tee push.log
+  grep "push $(calc_oid "three") => c.dat" push.log
+  [ 1 -eq "$(grep -c "^push " push.log)" ]
+
+  git push origin master 2>&1 | tee push.log
+  grep "(1 of 1 files)" push.log
+  assert_server_object "$reponame" "$(calc_oid "three")"
+
+  # A new branch is scanned up to the commits on the remote.
+  printf "four" > d.dat
+  git add d.dat
+  git commit -m "add d.dat"
+  git push origin HEAD:refs/heads/new 2>&1 | tee push.log
+  grep "(1 of 1 files)" push.log
+  assert_server_object "$reponame" "$(calc_oid "four")"
+)
+end_test
+
+begin_test "pre-push from a shallow fetch without branches"
+(
+  set -e
+
+  reponame="pre-push-shallow-detached"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "one" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  # As in a CI job, fetch a single commit by URL, so that there are no
+  # local branches, tags or remote-tracking refs.
+  cd ..
+  mkdir "$reponame-ci"
+  cd "$reponame-ci"
+  git init
+  git remote add origin "$GITSERVER/$reponame"
+  GIT_LFS_SKIP_SMUDGE=1 git fetch --depth 1 "$GITSERVER/$reponame" master
+  GIT_LFS_SKIP_SMUDGE=1 git checkout FETCH_HEAD
+  [ -z "$(git for-each-ref)" ]
+  git config lfs.allowincompletepush false
+
+  printf "two" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+
+  # Without remote-tracking refs, the whole tree of the shallow commit is
+  # checked, and the objects the server already has are skipped.
+  git lfs push --dry-run origin HEAD 2>&1 | tee push.log
+  grep "push $(calc_oid "two") => b.dat" push.log
+
+  git push origin HEAD:refs/heads/ci 2>&1 | tee push.log
+  grep "(1 of 1 files, 1 skipped)" push.log
+  assert_server_object "$reponame" "$(calc_oid "two")"
+)
+end_test
@@ -18,8 +18,10 @@ begin_test "GIT_LFS_PROGRESS"
   echo "e" > e.dat
   git add .gitattributes *.dat
   git commit -m "add files"
-  git push origin master 2>&1 | tee push.log
+  GIT_LFS_PROGRESS="$TRASHDIR/push-progress.log" git push origin master 2>&1 | tee push.log
   grep "(5 of 5 files)" push.log
+  grep "upload 1/5" "$TRASHDIR/push-progress.log"
+  grep "upload 5/5" "$TRASHDIR/push-progress.log"
 
   cd ..
   GIT_LFS_PROGRESS="$TRASHDIR/progress.log" git lfs clone "$GITSERVER/$reponame" clone
@@ -244,6 +244,34 @@ begin_test "pull: with missing object"
 )
 end_test
 
+begin_test "pull: with missing object and --skip-download-errors"
+(
+  set -e
+
+  contents_oid=$(calc_oid "a")
+  contents2_oid=$(calc_oid "A")
+  reponame="$(basename "$0" ".sh")"
+
+  # the object of a.dat was deleted from the server in the previous test
+  refute_server_object "$reponame" "$contents_oid"
+
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" clone-skip-download-errors
+  cd clone-skip-download-errors
+
+  git lfs pull --skip-download-errors 2>&1 | tee pull.log
+  [ "0" = "${PIPESTATUS[0]}" ]
+
+  grep "Encountered 1 file(s) that could not be downloaded, and were left as pointers:" pull.log
+  grep "	a.dat" pull.log
+
+  grep "oid sha256:$contents_oid" a.dat
+  [ "A" = "$(cat "á.dat")" ]
+  refute_local_object "$contents_oid"
+  assert_local_object "$contents2_oid" 1
+  assert_clean_status
+)
+end_test
+
 begin_test "pull: outside git repository"
 (
   set +e
@@ -0,0 +1,120 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "push review: large push needs confirmation"
+(
+  set -e
+
+  reponame="push-review-large"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  base64 /dev/urandom | head -c 2048 > big.dat
+  base64 /dev/urandom | head -c 512 > small.dat
+  printf "small" > tiny.dat
+  git add .gitattributes *.dat
+  git commit -m "add files"
+
+  git config lfs.pushreviewthreshold 1KB
+
+  # Without a terminal to confirm on, the push is refused. Standard error is
+  # piped here, so the question is not asked, whether or not there is a
+  # controlling terminal.
+  git lfs push origin master 2>&1 | tee push.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git lfs push' to fail ..."
+    exit 1
+  fi
+  grep "Git LFS is about to upload .* in 3 files to origin" push.log
+  grep "Largest files:" push.log
+  [ "big.dat" = "$(grep -A1 "Largest files:" push.log | tail -n 1 | awk '{ print $NF }')" ]
+  grep "Push not confirmed" push.log
+  refute_server_object "$reponame" "$(calc_oid_file big.dat)"
+
+  git push origin master 2>&1 | tee push.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git push' to fail ..."
+    exit 1
+  fi
+  grep "Push not confirmed" push.log
+
+  git lfs push --yes origin master 2>&1 | tee push.log
+  grep "(3 of 3 files)" push.log
+  assert_server_object "$reponame" "$(calc_oid_file big.dat)"
+
+  git -c lfs.pushreviewnoninteractive=allow push origin master 2>&1 | tee push.log
+  grep "Git LFS is about to upload" push.log
+  grep "Pushing anyway, as lfs.pushreviewnoninteractive is \"allow\"." push.log
+  [ "$(git rev-parse master)" = "$(git rev-parse origin/master)" ]
+)
+end_test
+
+begin_test "push review: only the first push of a ref is reviewed"
+(
+  set -e
+
+  reponame="push-review-first"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "small" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  git config lfs.pushreviewthreshold 1KB
+
+  base64 /dev/urandom | head -c 2048 > big.dat
+  git add big.dat
+  git commit -m "add big.dat"
+
+  git lfs push origin master 2>&1 | tee push.log
+  [ "0" -eq "$(grep -c "about to upload" push.log)" ]
+  assert_server_object "$reponame" "$(calc_oid_file big.dat)"
+
+  base64 /dev/urandom | head -c 2048 > other.dat
+  git add other.dat
+  git commit -m "add other.dat"
+
+  git push origin master 2>&1 | tee push.log
+  [ "0" -eq "$(grep -c "about to upload" push.log)" ]
+  assert_server_object "$reponame" "$(calc_oid_file other.dat)"
+
+  # a new branch is reviewed, though
+  git checkout -b new-branch
+  base64 /dev/urandom | head -c 2048 > new.dat
+  git add new.dat
+  git commit -m "add new.dat"
+
+  git push origin new-branch 2>&1 | tee push.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected 'git push' to fail ..."
+    exit 1
+  fi
+  grep "Push not confirmed" push.log
+  refute_server_object "$reponame" "$(calc_oid_file new.dat)"
+)
+end_test
+
+begin_test "push review: small push is not reviewed"
+(
+  set -e
+
+  reponame="push-review-small"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "small" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git config lfs.pushreviewthreshold 1KB
+  git push origin master 2>&1 | tee push.log
+  [ "0" -eq "$(grep -c "about to upload" push.log)" ]
+  assert_server_object "$reponame" "$(calc_oid "small")"
+)
+end_test
@@ -19,7 +19,7 @@ begin_test "push"
 
   git lfs push --dry-run origin master 2>&1 | tee push.log
   grep "push 4c48d2a6991c9895bcddcf027e1e4907280bcf21975492b1afbade396d6a3340 => a.dat" push.log
-  [ $(grep -c "push" push.log) -eq 1 ]
+  [ $(grep -c "^push " push.log) -eq 1 ]
 
   git lfs push origin master 2>&1 | tee push.log
   grep "(1 of 1 files)" push.log
@@ -39,7 +39,7 @@ begin_test "push"
   git rev-parse HEAD > .git/refs/remotes/origin/HEAD
 
   git lfs push --dry-run origin push-b 2>&1 | tee push.log
-  [ $(grep -c "push" push.log) -eq 0 ]
+  [ $(grep -c "^push " push.log) -eq 0 ]
 
   rm -rf .git/refs/remotes
 
@@ -165,7 +165,7 @@ begin_test "push --all (no ref args)"
   grep "push $oid4 => file1.dat" push.log
   grep "push $oid5 => file1.dat" push.log
   grep "push $extraoid => file2.dat" push.log
-  [ $(grep -c "push" push.log) -eq 6 ]
+  [ $(grep -c "^push " push.log) -eq 6 ]
 
   git push --all origin 2>&1 | tee push.log
   grep "(5 of 5 files, 1 skipped)" push.log
@@ -217,7 +217,7 @@ begin_test "push --all (1 ref arg)"
   grep "push $oid1 => file1.dat" push.log
   grep "push $oid2 => file1.dat" push.log
   grep "push $oid3 => file1.dat" push.log
-  [ $(grep -c "push" push.log) -eq 3 ]
+  [ $(grep -c "^push " push.log) -eq 3 ]
 
   git push --all origin branch 2>&1 | tee push.log
   grep "5 files, 1 skipped" push.log # should be 5?
@@ -240,7 +240,7 @@ begin_test "push --all (multiple ref args)"
   grep "push $oid2 => file1.dat" push.log
   grep "push $oid3 => file1.dat" push.log
   grep "push $oid4 => file1.dat" push.log
-  [ $(grep -c "push" push.log) -eq 4 ]
+  [ $(grep -c "^push " push.log) -eq 4 ]
 
   git lfs push --all origin branch tag 2>&1 | tee push.log
   grep "4 files" push.log
@@ -269,7 +269,7 @@ begin_test "push --all (multiple ref args)"
   grep "push $oid2 => file1.dat" push.log
   grep "push $oid3 => file1.dat" push.log
   grep "push $oid4 => file1.dat" push.log
-  [ $(grep -c "push" push.log) -eq 3 ]
+  [ $(grep -c "^push " push.log) -eq 3 ]
 
   git push --all origin branch tag 2>&1 | tee push.log
   grep "5 files, 1 skipped" push.log # should be 5?
@@ -293,7 +293,7 @@ begin_test "push --all (ref with deleted files)"
   grep "push $oid4 => file1.dat" push.log
   grep "push $oid5 => file1.dat" push.log
   grep "push $extraoid => file2.dat" push.log
-  [ $(grep -c "push" push.log) -eq 5 ]
+  [ $(grep -c "^push " push.log) -eq 5 ]
 
   git lfs push --all origin master 2>&1 | tee push.log
   grep "5 files" push.log
@@ -323,7 +323,7 @@ begin_test "push --all (ref with deleted files)"
   grep "push $oid4 => file1.dat" push.log
   grep "push $oid5 => file1.dat" push.log
   grep "push $extraoid => file2.dat" push.log
-  [ $(grep -c "push" push.log) -eq 5 ]
+  [ $(grep -c "^push " push.log) -eq 5 ]
 
   git push --all origin master 2>&1 | tee push.log
   grep "5 files, 1 skipped" push.log # should be 5?
@@ -367,6 +367,35 @@ begin_test "push object id(s)"
 )
 end_test
 
+begin_test "push with timeout"
+(
+  set -e
+
+  reponame="push-with-timeout"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="push with timeout"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  set +e
+  git lfs push --timeout 1ns origin master 2>&1 | tee push.log
+  exit_code="${PIPESTATUS[0]}"
+  set -e
+
+  [ "124" -eq "$exit_code" ]
+  grep "1 object(s) were not transferred" push.log
+  refute_server_object "$reponame" "$contents_oid"
+
+  git lfs push --timeout 1h origin master
+  assert_server_object "$reponame" "$contents_oid"
+)
+end_test
+
 begin_test "push modified files"
 (
   set -e
@@ -698,3 +727,144 @@ begin_test "push reject missing objects (lfs.allowincompletepush=f)"
   refute_server_object "$reponame" "$missing_oid"
 )
 end_test
+
+begin_test "push with lfs.pushcache"
+(
+  set -e
+
+  reponame="push-with-pushcache"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git config lfs.pushcache true
+  git lfs push origin master 2>&1 | tee push.log
+  grep "(1 of 1 files)" push.log
+  [ $(cat .git/lfs/cache/pushed/* | wc -l) -eq 1 ]
+  grep "$(git rev-parse master)" .git/lfs/cache/pushed/*
+
+  printf "b" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+
+  # There are no remote-tracking refs, so without the cache the history of
+  # the first push is scanned again.
+  git -c lfs.pushcache=false lfs push --dry-run origin master 2>&1 | tee push.log
+  grep "push $(calc_oid "a") => a.dat" push.log
+  grep "push $(calc_oid "b") => b.dat" push.log
+
+  git lfs push --dry-run origin master 2>&1 | tee push.log
+  grep "push $(calc_oid "b") => b.dat" push.log
+  [ $(grep -c "^push " push.log) -eq 1 ]
+
+  # Commits that are no longer in the repository are left out.
+  git lfs push origin master
+  git reset --hard HEAD^
+  git reflog expire --expire=now --all
+  git gc --prune=now
+  git lfs push --dry-run origin master 2>&1 | tee push.log
+  [ $(grep -c "^push " push.log) -eq 0 ]
+)
+end_test
+
+begin_test "push --dry-run reports the size and commit of each object"
+(
+  set -e
+
+  reponame="push-dry-run-details"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "abc" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  a_commit="$(git rev-parse HEAD)"
+
+  printf "defgh" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+  b_commit="$(git rev-parse HEAD)"
+
+  # A copy of a.dat doesn't add another object.
+  cp a.dat c.dat
+  git add c.dat
+  git commit -m "add c.dat"
+
+  git lfs push --dry-run origin master 2>&1 | tee push.log
+  grep "push $(calc_oid "abc") => a.dat (3 B, commit $a_commit)" push.log
+  grep "push $(calc_oid "defgh") => b.dat (5 B, commit $b_commit)" push.log
+  [ $(grep -c "^push " push.log) -eq 2 ]
+  grep "Total: 2 objects, 8 B" push.log
+
+  git lfs push --dry-run --json origin master | tee push.json
+  expected="$(cat <<-EOJ
+{"remote":"origin","objects":[{"oid":"$(calc_oid "abc")","size":3,"name":"a.dat","commit":"$a_commit"},{"oid":"$(calc_oid "defgh")","size":5,"name":"b.dat","commit":"$b_commit"}],"total_size":8}
+EOJ
+)"
+  [ "$expected" = "$(cat push.json)" ]
+
+  refute_server_object "$reponame" "$(calc_oid "abc")"
+  refute_server_object "$reponame" "$(calc_oid "defgh")"
+
+  git push origin master
+  git lfs push --dry-run --json origin master | tee push.json
+  [ '{"remote":"origin","objects":[],"total_size":0}' = "$(cat push.json)" ]
+)
+end_test
+
+begin_test "push uploads objects found in several refs once"
+(
+  set -e
+
+  reponame="push-duplicate-objects"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "abc" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git checkout -b other
+  printf "defgh" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+  git checkout master
+
+  git lfs push --dry-run --all origin master other 2>&1 | tee push.log
+  [ $(grep -c "^push " push.log) -eq 2 ]
+  grep "Total: 2 objects, 8 B" push.log
+  grep "Skipped 1 duplicate object(s), saving 3 B of uploads" push.log
+
+  GIT_TRACE=1 git lfs push --all origin master other 2>&1 | tee push.log
+  grep "Skipped 1 duplicate object(s), saving 3 B of uploads" push.log
+  [ $(grep -c "HTTP: PUT" push.log) -eq 2 ]
+  assert_server_object "$reponame" "$(calc_oid "abc")"
+  assert_server_object "$reponame" "$(calc_oid "defgh")"
+
+  git lfs push --object-id origin "$(calc_oid "abc")" "$(calc_oid "abc")" 2>&1 | tee push.log
+  grep "Skipped 1 duplicate object(s), saving 3 B of uploads" push.log
+)
+end_test
+
+begin_test "push --json requires --dry-run"
+(
+  set -e
+
+  reponame="push-json-without-dry-run"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs push --json origin master 2>&1 | tee push.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected push to fail"
+    exit 1
+  fi
+  grep -- "--json can only be used with --dry-run." push.log
+)
+end_test
@@ -0,0 +1,123 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+# start_rate_limited_server starts another test server sharing the same
+# repositories, which limits requests as given by $1, i.e. "2/1m", pointing
+# GITSERVER at it. It is shut down when the test exits.
+start_rate_limited_server() {
+  LFSTEST_URL="$TRASHDIR/rate-limit-url" \
+  LFSTEST_SSL_URL="$TRASHDIR/rate-limit-ssl-url" \
+  LFSTEST_CLIENT_CERT_URL="$TRASHDIR/rate-limit-client-cert-url" \
+  LFSTEST_CERT="$TRASHDIR/rate-limit-cert" \
+  LFSTEST_CLIENT_CERT="$TRASHDIR/rate-limit-client-cert" \
+  LFSTEST_CLIENT_KEY="$TRASHDIR/rate-limit-client-key" \
+  LFSTEST_DIR="$REMOTEDIR" \
+  LFSTEST_RATE_LIMIT="$1" \
+    lfstest-gitserver > "$TRASHDIR/rate-limit-gitserver.log" 2>&1 &
+  wait_for_file "$TRASHDIR/rate-limit-url"
+  GITSERVER="$(cat "$TRASHDIR/rate-limit-url")"
+
+  trap 'curl -s "$GITSERVER/shutdown"' EXIT
+}
+
+# batch_status sends a batch request for a missing object to the repository
+# $1, writing the response to http.json and its headers to http.log, and
+# prints the HTTP status of the response.
+batch_status() {
+  curl -s "$GITSERVER/$1.git/info/lfs/objects/batch" \
+    -u "user:pass" \
+    -o http.json \
+    -D http.log \
+    -w "%{http_code}" \
+    -d "{\"operation\":\"download\",\"objects\":[{\"oid\":\"$(calc_oid "missing")\",\"size\":7}]}" \
+    -H "Accept: application/vnd.git-lfs+json" \
+    -H "Content-Type: application/vnd.git-lfs+json"
+}
+
+begin_test "rate limit: batch and storage answer 429 with Retry-After"
+(
+  set -e
+
+  start_rate_limited_server "2/1m"
+
+  reponame="rate-limit-status"
+  setup_remote_repo "$reponame"
+
+  [ "200" = "$(batch_status "$reponame")" ]
+  [ "200" = "$(batch_status "$reponame")" ]
+  [ "429" = "$(batch_status "$reponame")" ]
+  grep "Retry-After: [1-9][0-9]*" http.log
+  grep "rate limit of 2 requests per 1m0s exceeded" http.json
+
+  # Each repository is limited separately.
+  setup_remote_repo "$reponame-other"
+  [ "200" = "$(batch_status "$reponame-other")" ]
+
+  # So is each endpoint.
+  for i in 1 2 3; do
+    curl -s "$GITSERVER/storage/$(calc_oid "missing")?r=$reponame" \
+      -D http.log -o /dev/null -w "%{http_code}\n" >> storage.log
+  done
+  cat storage.log
+  [ "429" != "$(sed -n 2p storage.log)" ]
+  [ "429" = "$(sed -n 3p storage.log)" ]
+  grep "Retry-After: [1-9][0-9]*" http.log
+)
+end_test
+
+begin_test "rate limit: push fails while limited, and succeeds once the window resets"
+(
+  set -e
+
+  reponame="rate-limit-push"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "rate limited" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  start_rate_limited_server "1/3s"
+  git config remote.origin.url "$GITSERVER/$reponame"
+
+  # Use up the batch requests of the window.
+  [ "200" = "$(batch_status "$reponame")" ]
+
+  git push origin master 2>&1 | tee push.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git push origin master\` to fail ..."
+    exit 1
+  fi
+  grep "rate limit of 1 requests per 3s exceeded" push.log
+
+  sleep 3
+
+  git push origin master 2>&1 | tee push.log
+  if [ "0" -ne "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected \`git push origin master\` to succeed ..."
+    exit 1
+  fi
+)
+end_test
+
+begin_test "rate limit: compliance tool checks Retry-After"
+(
+  set -e
+
+  start_rate_limited_server "10/2s"
+
+  reponame="rate-limit-compliance"
+  setup_remote_repo "$reponame"
+
+  git-lfs-test-server-api --url "$GITSERVER/$reponame.git/info/lfs" 2>&1 |
+    tee test-server-api.log
+  grep "Test download: rate limiting.* OK" test-server-api.log
+  grep "Test upload: rate limiting.* OK" test-server-api.log
+
+  # The tool must have run into the limit, rather than passing because it
+  # never did.
+  grep "rate limit: batch $reponame" "$TRASHDIR/rate-limit-gitserver.log"
+)
+end_test
@@ -91,3 +91,46 @@ begin_test "fetch from clone reference"
   assert_same_inode "$TRASHDIR/$repo" "$TRASHDIR/$ref_repo" "$oid"
 )
 end_test
+
+begin_test "fetch from lfs.alternate"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-alternate"
+  setup_remote_repo "$reponame"
+
+  clone_repo "$reponame" "$reponame"
+  git lfs track "*.dat"
+  contents_a="a"
+  oid_a=$(calc_oid "$contents_a")
+  contents_b="b"
+  oid_b=$(calc_oid "$contents_b")
+  printf "$contents_a" > a.dat
+  printf "$contents_b" > b.dat
+  git add .gitattributes a.dat b.dat
+  git commit -m "add a.dat, b.dat"
+  git push origin master
+
+  # One object is in a plain object cache, the other in another repository.
+  cache="$TRASHDIR/$reponame-cache"
+  mkdir -p "$cache/${oid_a:0:2}/${oid_a:2:2}"
+  cp ".git/lfs/objects/${oid_a:0:2}/${oid_a:2:2}/$oid_a" "$cache/${oid_a:0:2}/${oid_a:2:2}/"
+  delete_local_object "$oid_a"
+
+  delete_server_object "$reponame" "$oid_a"
+  delete_server_object "$reponame" "$oid_b"
+
+  cd "$TRASHDIR"
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+  git config --add lfs.alternate "$cache"
+  git config --add lfs.alternate "../$reponame"
+
+  git lfs env | grep "LocalReferenceDir=$(native_path "$cache")"
+
+  git lfs pull
+  [ "$contents_a" = "$(cat a.dat)" ]
+  [ "$contents_b" = "$(cat b.dat)" ]
+  assert_same_inode "$TRASHDIR/$reponame-clone" "$TRASHDIR/$reponame" "$oid_b"
+)
+end_test
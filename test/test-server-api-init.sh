@@ -0,0 +1,55 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "server-api init: shell scaffolding runs the tests"
+(
+  set -e
+
+  reponame="server-api-init"
+  setup_remote_repo "$reponame"
+  cd "$TRASHDIR"
+
+  git-lfs-test-server-api init --url "$GITSERVER/$reponame.git/info/lfs" tests
+  [ -x tests/run-tests.sh ]
+
+  # The first run uploads the test data, and saves it for later runs.
+  tests/run-tests.sh 2>&1 | tee run.log
+  grep "Generating test data in data/" run.log
+  [ -s tests/data/test_exists ]
+  [ -s tests/data/test_missing ]
+  grep -E "^[0-9]+ passed, [0-9]+ failed" tests/results/latest.txt
+  [ "0" -lt "$(sed -n "s/^\([0-9]*\) passed.*/\1/p" tests/results/latest.txt)" ]
+
+  tests/run-tests.sh 2>&1 | tee run.log
+  grep "Using test data from data/" run.log
+  grep "Reading test data from files" run.log
+  grep -E "^[0-9]+ passed, [0-9]+ failed" tests/results/latest.txt
+
+  # Existing files are only overwritten with --force.
+  git-lfs-test-server-api init --url "$GITSERVER/$reponame.git/info/lfs" tests 2>&1 | tee init.log
+  grep "already exists, use --force to overwrite it" init.log
+  git-lfs-test-server-api init --force --url "$GITSERVER/$reponame.git/info/lfs" tests
+)
+end_test
+
+begin_test "server-api init: compose scaffolding uses the service host name"
+(
+  set -e
+
+  git-lfs-test-server-api init --format=compose --image=my-server \
+    --url "http://localhost:8080/info/lfs" compose-tests
+
+  grep 'image: "my-server"' compose-tests/docker-compose.yml
+  grep 'LFS_API_URL: "http://server:8080/info/lfs"' compose-tests/docker-compose.yml
+  grep "default_api_url='http://localhost:8080/info/lfs'" compose-tests/run-tests.sh
+
+  # The tests are built from this repository, rather than upstream.
+  grep "GIT_LFS_REPO=https://github.com/groenborg/git-lfs.git" compose-tests/Dockerfile.tests
+  [ "0" -eq "$(grep -c "^RUN go get" compose-tests/Dockerfile.tests)" ]
+
+  git-lfs-test-server-api init --format=compose --force \
+    --clone "git@example.com:repo.git" compose-tests
+  grep 'LFS_CLONE_URL: "git@example.com:repo.git"' compose-tests/docker-compose.yml
+)
+end_test
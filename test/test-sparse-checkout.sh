@@ -0,0 +1,102 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+# setup_sparse_repo creates a repository with a Git LFS file at its root and
+# in each of the "in", "in/nested", "out" and "in/out" directories, pushes it,
+# and clones it into "$reponame-clone" without downloading any objects, leaving
+# the clone as the current directory.
+setup_sparse_repo() {
+  local reponame="$1"
+
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  mkdir -p in/nested in/out out
+  for f in root in/a in/nested/b out/c in/out/d; do
+    printf "$f" > "$f.dat"
+  done
+  git add .gitattributes *.dat in out
+  git commit -m "add files"
+  git push origin master
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+}
+
+begin_test "sparse checkout: fetch only fetches sparse paths"
+(
+  set -e
+
+  setup_sparse_repo "sparse-checkout-fetch"
+
+  git config core.sparseCheckout true
+  printf "/*\n!/*/\n/in/\n!/in/*/\n/in/nested/\n" > .git/info/sparse-checkout
+  GIT_LFS_SKIP_SMUDGE=1 git read-tree -mu HEAD
+  [ ! -e out/c.dat ]
+  [ ! -e in/out/d.dat ]
+
+  git lfs fetch 2>&1 | tee fetch.log
+  grep "(3 of 3 files)" fetch.log
+  assert_local_object "$(calc_oid "root")" 4
+  assert_local_object "$(calc_oid "in/a")" 4
+  assert_local_object "$(calc_oid "in/nested/b")" 11
+  refute_local_object "$(calc_oid "out/c")"
+  refute_local_object "$(calc_oid "in/out/d")"
+
+  git lfs fetch --no-sparse 2>&1 | tee fetch.log
+  grep "(2 of 2 files)" fetch.log
+  assert_local_object "$(calc_oid "out/c")" 5
+  assert_local_object "$(calc_oid "in/out/d")" 8
+)
+end_test
+
+begin_test "sparse checkout: pull and checkout with full patterns"
+(
+  set -e
+
+  setup_sparse_repo "sparse-checkout-pull"
+
+  git config core.sparseCheckout true
+  printf "*.dat\n!out/*.dat\n!in/out/*.dat\n" > .git/info/sparse-checkout
+  GIT_LFS_SKIP_SMUDGE=1 git read-tree -mu HEAD
+  [ ! -e out/c.dat ]
+
+  git lfs pull 2>&1 | tee pull.log
+  grep "(3 of 3 files)" pull.log
+  [ "root" = "$(cat root.dat)" ]
+  [ "in/a" = "$(cat in/a.dat)" ]
+  [ "in/nested/b" = "$(cat in/nested/b.dat)" ]
+  refute_local_object "$(calc_oid "out/c")"
+  refute_local_object "$(calc_oid "in/out/d")"
+
+  # A file which is present although outside of the sparse checkout is only
+  # written with --no-sparse.
+  git lfs fetch --no-sparse
+  mkdir -p in/out
+  git show HEAD:in/out/d.dat > in/out/d.dat
+
+  git lfs checkout
+  grep "version https://git-lfs" in/out/d.dat
+
+  git lfs checkout --no-sparse
+  [ "in/out/d" = "$(cat in/out/d.dat)" ]
+)
+end_test
+
+begin_test "sparse checkout: disabled"
+(
+  set -e
+
+  setup_sparse_repo "sparse-checkout-disabled"
+
+  # Patterns are ignored unless core.sparseCheckout is set.
+  printf "/in/\n" > .git/info/sparse-checkout
+
+  git lfs pull 2>&1 | tee pull.log
+  grep "(5 of 5 files)" pull.log
+  [ "out/c" = "$(cat out/c.dat)" ]
+)
+end_test
@@ -98,15 +98,45 @@ begin_test "status --json"
   git commit -m "file1.dat"
 
   echo "other data" > file1.dat
+  other_oid="$(calc_oid "other data\n")"
 
-  expected='{"files":{"file1.dat":{"status":"M"}}}'
+  entry="{\"file1.dat\":{\"status\":\"M\",\"staged\":false,\"oid\":\"$other_oid\",\"size\":11}}"
+  expected="{\"files\":$entry,\"staged\":{},\"unstaged\":$entry}"
   [ "$expected" = "$(git lfs status --json)" ]
 
+  # Files are read relative to the root of the repository
+  mkdir -p dir
+  [ "$expected" = "$(cd dir && git lfs status --json)" ]
+
+  # and hashed with the configured algorithm
+  other_sha512="$(printf "other data\n" | shasum -a 512 | cut -f 1 -d " ")"
+  entry_sha512="{\"file1.dat\":{\"status\":\"M\",\"staged\":false,\"oid\":\"$other_sha512\",\"size\":11}}"
+  expected_sha512="{\"files\":$entry_sha512,\"staged\":{},\"unstaged\":$entry_sha512}"
+  [ "$expected_sha512" = "$(git -c lfs.hashalgo=sha512 lfs status --json)" ]
+
+  # A file with both staged and unstaged changes has both reported
   git add file1.dat
+  echo "more data" > file1.dat
+  more_oid="$(calc_oid "more data\n")"
+
+  staged="{\"file1.dat\":{\"status\":\"M\",\"staged\":true,\"oid\":\"$other_oid\",\"size\":11}}"
+  unstaged="{\"file1.dat\":{\"status\":\"M\",\"staged\":false,\"oid\":\"$more_oid\",\"size\":10}}"
+  expected="{\"files\":$staged,\"staged\":$staged,\"unstaged\":$unstaged}"
+  [ "$expected" = "$(git lfs status --json)" ]
+
+  # and empty files have their size
+  : > file1.dat
+  empty_oid="$(calc_oid "")"
+  unstaged="{\"file1.dat\":{\"status\":\"M\",\"staged\":false,\"oid\":\"$empty_oid\",\"size\":0}}"
+  expected="{\"files\":$staged,\"staged\":$staged,\"unstaged\":$unstaged}"
+  [ "$expected" = "$(git lfs status --json)" ]
+
+  echo "other data" > file1.dat
   git commit -m "file1.dat changed"
   git mv file1.dat file2.dat
 
-  expected='{"files":{"file2.dat":{"status":"R","from":"file1.dat"}}}'
+  entry="{\"file2.dat\":{\"status\":\"R\",\"from\":\"file1.dat\",\"staged\":true,\"oid\":\"$other_oid\",\"size\":11}}"
+  expected="{\"files\":$entry,\"staged\":$entry,\"unstaged\":{}}"
   [ "$expected" = "$(git lfs status --json)" ]
 
   git commit -m "file1.dat -> file2.dat"
@@ -114,7 +144,15 @@ begin_test "status --json"
   # Ensure status --json does not include non-lfs files
   echo hi > test1.txt
   git add test1.txt
-  expected='{"files":{}}'
+  expected='{"files":{},"staged":{},"unstaged":{}}'
+  [ "$expected" = "$(git lfs status --json)" ]
+
+  git commit -m "test1.txt"
+
+  # Deleted files have no object
+  git rm -q file2.dat
+  entry='{"file2.dat":{"status":"D","staged":true}}'
+  expected="{\"files\":$entry,\"staged\":$entry,\"unstaged\":{}}"
   [ "$expected" = "$(git lfs status --json)" ]
 )
 end_test
@@ -420,3 +458,80 @@ Git LFS objects not staged for commit:"
   [ "$expected" = "$(git lfs status)" ]
 )
 end_test
+
+begin_test "status (pointer size anomalies)"
+(
+  set -e
+
+  reponame="status-size-anomalies"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "initial commit"
+
+  git push origin master
+
+  oid="$(calc_oid "zero")"
+  printf "version https://git-lfs.github.com/spec/v1
+oid sha256:$oid
+size 0
+" > zero.dat
+  git add zero.dat
+  git commit -m "add zero.dat"
+
+  printf "version https://git-lfs.github.com/spec/v1
+oid sha256:$oid
+size 4
+" > staged.dat
+  git add staged.dat
+
+  git lfs status 2>&1 | tee status.log
+  grep "Git LFS pointers with invalid sizes:" status.log
+  [ "1" -eq "$(grep -c "Pointer zero.dat ($oid) declares a size of 0 bytes" status.log)" ]
+  grep "git rm --cached -- zero.dat && git add -- zero.dat" status.log
+  grep "zero.dat holds a pointer rather than its contents: run \`git lfs checkout zero.dat\`" status.log
+  [ "0" -eq "$(grep -c "Pointer staged.dat" status.log)" ]
+
+  git lfs status --porcelain 2>&1 | tee status.log
+  [ "0" -eq "$(grep -c "invalid sizes" status.log)" ]
+)
+end_test
+
+begin_test "status: symbolic link in place of an LFS file"
+(
+  set -e
+
+  reponame="status-symlink"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  contents_oid_short="$(calc_oid "contents" | head -c 7)"
+  link_sha_short="$(calc_oid "missing.dat" | head -c 7)"
+
+  # The link is dangling, and only what it points to is hashed.
+  rm a.dat
+  ln -s missing.dat a.dat
+
+  expected="On branch master
+
+Git LFS objects to be committed:
+
+
+Git LFS objects not staged for commit:
+
+	a.dat (LFS: $contents_oid_short -> Symlink: $link_sha_short)"
+  actual="$(git lfs status)"
+
+  [ "$expected" = "$actual" ]
+
+  git lfs status --json | tee status.json
+  grep '"a.dat":{"status":"T","staged":false}' status.json
+)
+end_test
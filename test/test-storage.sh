@@ -0,0 +1,140 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "storage: shared between clones"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-shared"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="shared"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  storage="$TRASHDIR/$reponame-storage"
+  cd "$TRASHDIR"
+  GIT_TRACE=1 git -c lfs.storage="$storage" clone "$GITSERVER/$reponame" "$reponame-one" 2>&1 | tee clone.log
+  grep '"actions":{"download"' clone.log
+  cd "$reponame-one"
+  git config lfs.storage "$storage"
+  [ "$contents" = "$(cat a.dat)" ]
+  [ -f "$storage/objects/${contents_oid:0:2}/${contents_oid:2:2}/$contents_oid" ]
+  [ ! -d .git/lfs/objects ]
+
+  git lfs env | grep "LocalMediaDir=$(native_path "$storage/objects")"
+  git lfs env | grep "TempDir=$(native_path "$storage/tmp")"
+
+  # The second clone finds the object in the shared storage without
+  # downloading it again.
+  cd "$TRASHDIR"
+  GIT_TRACE=1 git -c lfs.storage="$storage" clone "$GITSERVER/$reponame" "$reponame-two" 2>&1 | tee clone.log
+  cd "$reponame-two"
+  [ "$contents" = "$(cat a.dat)" ]
+  grep '"actions":{"download"' ../clone.log && exit 1
+  true
+)
+end_test
+
+begin_test "storage: keeps recent temporary files of other processes"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-tmp"
+  git init "$reponame"
+  cd "$reponame"
+
+  storage="$TRASHDIR/$reponame-storage"
+  git config lfs.storage "$storage"
+  git lfs track "*.dat"
+
+  mkdir -p "$storage/tmp"
+  printf "in use" > "$storage/tmp/123456"
+
+  printf "contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  [ "in use" = "$(cat "$storage/tmp/123456")" ]
+)
+end_test
+
+begin_test "storage: object already being downloaded by another process"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-locked"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="locked"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  storage="$TRASHDIR/$reponame-storage"
+  mkdir -p "$storage/incomplete"
+  touch "$storage/incomplete/$contents_oid.tmp.lock"
+
+  cd "$TRASHDIR"
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+  git config lfs.storage "$storage"
+
+  GIT_TRACE=1 git lfs pull 2>&1 | tee pull.log
+  grep "is already being downloaded by another process" pull.log
+  [ "$contents" = "$(cat a.dat)" ]
+
+  # The other process' lock is left alone, and no copy is left behind.
+  [ -f "$storage/incomplete/$contents_oid.tmp.lock" ]
+  [ "1" -eq "$(ls "$storage/incomplete" | wc -l)" ]
+)
+end_test
+
+begin_test "storage: download lock of a process which is no longer running"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-stale-lock"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  contents="stale lock"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  # Leave behind the lock of a process that was killed half way through
+  # the download.
+  sh -c 'exit 0' &
+  pid="$!"
+  wait "$pid"
+
+  storage="$TRASHDIR/$reponame-storage"
+  mkdir -p "$storage/incomplete"
+  printf "%s %s" "$(hostname)" "$pid" > "$storage/incomplete/$contents_oid.tmp.lock"
+
+  cd "$TRASHDIR"
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+  git config lfs.storage "$storage"
+
+  GIT_TRACE=1 git lfs pull 2>&1 | tee pull.log
+  grep "Removing stale download lock" pull.log
+  [ "$contents" = "$(cat a.dat)" ]
+
+  [ ! -e "$storage/incomplete/$contents_oid.tmp.lock" ]
+)
+end_test
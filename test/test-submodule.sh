@@ -83,3 +83,87 @@ begin_test "submodule env"
   grep "TempDir=$(native_path_escaped "$TRASHDIR/repo/.git/modules/sub/lfs/tmp$")" env.log
 )
 end_test
+
+# setup_nested_submodules creates a superproject, "$1", with a submodule, "$2",
+# which itself has a submodule, "$3", each of which has a file tracked by Git
+# LFS named after it.
+setup_nested_submodules() {
+  local top="$1" sub="$2" nested="$3"
+
+  setup_remote_repo "$top"
+  setup_remote_repo "$sub"
+  setup_remote_repo "$nested"
+
+  clone_repo "$nested" "$nested"
+  git lfs track "*.dat"
+  printf "$nested" > nested.dat
+  git add .gitattributes nested.dat
+  git commit -m "add nested.dat"
+  git push origin master
+
+  clone_repo "$sub" "$sub"
+  git lfs track "*.dat"
+  printf "$sub" > sub.dat
+  git submodule add "$GITSERVER/$nested" nested
+  git add .gitattributes .gitmodules sub.dat nested
+  git commit -m "add sub.dat and nested"
+  git push origin master
+
+  clone_repo "$top" "$top"
+  git lfs track "*.dat"
+  printf "$top" > top.dat
+  git submodule add "$GITSERVER/$sub" sub
+  git add .gitattributes .gitmodules top.dat sub
+  git commit -m "add top.dat and sub"
+  git push origin master
+
+  cd ..
+}
+
+begin_test "submodule: pull --recurse-submodules"
+(
+  set -e
+
+  setup_nested_submodules "pull-recurse" "pull-recurse-sub" "pull-recurse-nested"
+
+  GIT_LFS_SKIP_SMUDGE=1 git clone --recurse-submodules "$GITSERVER/pull-recurse" pull-recurse-clone
+  cd pull-recurse-clone
+  grep "version https://git-lfs" sub/sub.dat
+  grep "version https://git-lfs" sub/nested/nested.dat
+
+  git lfs pull 2>&1 | tee pull.log
+  [ "pull-recurse" = "$(cat top.dat)" ]
+  grep "version https://git-lfs" sub/sub.dat
+
+  git lfs pull --recurse-submodules 2>&1 | tee pull.log
+  grep "Entering 'sub'" pull.log
+  grep "Entering 'sub/nested'" pull.log
+  [ "pull-recurse-sub" = "$(cat sub/sub.dat)" ]
+  [ "pull-recurse-nested" = "$(cat sub/nested/nested.dat)" ]
+  [ -z "$(git status --porcelain -- sub)" ]
+)
+end_test
+
+begin_test "submodule: fetch and checkout with lfs.recursesubmodules"
+(
+  set -e
+
+  setup_nested_submodules "fetch-recurse" "fetch-recurse-sub" "fetch-recurse-nested"
+
+  GIT_LFS_SKIP_SMUDGE=1 git clone --recurse-submodules "$GITSERVER/fetch-recurse" fetch-recurse-clone
+  cd fetch-recurse-clone
+  git config lfs.recursesubmodules true
+
+  git lfs fetch 2>&1 | tee fetch.log
+  [ 1 -eq "$(grep -c "Entering 'sub'" fetch.log)" ]
+  [ 1 -eq "$(grep -c "Entering 'sub/nested'" fetch.log)" ]
+  (cd sub && assert_local_object "$(calc_oid "fetch-recurse-sub")" 17)
+  (cd sub/nested && assert_local_object "$(calc_oid "fetch-recurse-nested")" 20)
+  grep "version https://git-lfs" sub/nested/nested.dat
+
+  git lfs checkout 2>&1 | tee checkout.log
+  [ "fetch-recurse" = "$(cat top.dat)" ]
+  [ "fetch-recurse-sub" = "$(cat sub/sub.dat)" ]
+  [ "fetch-recurse-nested" = "$(cat sub/nested/nested.dat)" ]
+)
+end_test
@@ -0,0 +1,55 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+reponame="$(basename "$0" ".sh")"
+
+begin_test "GIT_TRACE_PERFORMANCE"
+(
+  set -e
+
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" repo
+
+  git lfs track "*.dat"
+  contents="a"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  GIT_TRACE=0 GIT_TRACE_PERFORMANCE=1 git push origin master 2>&1 | tee push.log
+  grep "performance HTTP: POST $GITSERVER/$reponame.git/info/lfs/objects/batch 200" push.log
+  grep "performance git credential fill" push.log
+  grep "performance transfer: upload $contents_oid (1 bytes) done" push.log
+  assert_server_object "$reponame" "$contents_oid"
+)
+end_test
+
+begin_test "GIT_TRACE_PERFORMANCE with a path"
+(
+  set -e
+
+  reponame="$reponame-path"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" repo-path
+
+  git lfs track "*.dat"
+  contents="a"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  cd ..
+  rm -f "$TRASHDIR/perf.log"
+  GIT_TRACE=0 GIT_TRACE_PERFORMANCE="$TRASHDIR/perf.log" git lfs clone "$GITSERVER/$reponame" clone 2>&1 | tee clone.log
+  [ "0" -eq "$(grep -c "performance" clone.log)" ]
+
+  cat "$TRASHDIR/perf.log"
+  grep "performance HTTP: POST $GITSERVER/$reponame.git/info/lfs/objects/batch 200" "$TRASHDIR/perf.log"
+  grep "performance transfer: download $contents_oid (1 bytes) done" "$TRASHDIR/perf.log"
+  [ "0" -eq "$(grep -c "trace git-lfs" "$TRASHDIR/perf.log")" ]
+)
+end_test
@@ -540,3 +540,185 @@ begin_test "track (with comments)"
   [ "0" -eq "$(grep -c "\.png" track.log)" ]
 )
 end_test
+
+begin_test "track --filename"
+(
+  set -e
+
+  reponame="track-filename"
+  git init "$reponame"
+  cd "$reponame"
+
+  printf "contents" > "a [1] b.dat"
+  printf "other" > "a 1 b.dat"
+  printf "star" > "*.bin"
+  git add "a [1] b.dat" "a 1 b.dat" "*.bin"
+  git commit -m "initial commit"
+
+  git lfs track --filename "a [1] b.dat" "*.bin" | tee track.log
+  grep "Tracking \"a \[1\] b.dat\"" track.log
+  grep "Tracking \"\*.bin\"" track.log
+
+  [ "a[[:space:]]\\[1\\][[:space:]]b.dat filter=lfs diff=lfs merge=lfs -text
+\\*.bin filter=lfs diff=lfs merge=lfs -text" = "$(cat .gitattributes)" ]
+
+  [ "a [1] b.dat: filter: lfs" = "$(git check-attr filter -- "a [1] b.dat")" ]
+  [ "a 1 b.dat: filter: unspecified" = "$(git check-attr filter -- "a 1 b.dat")" ]
+  [ "*.bin: filter: lfs" = "$(git check-attr filter -- "*.bin")" ]
+
+  # tracking the same file again does not add another line
+  git lfs track --filename "a [1] b.dat" | grep "already supported"
+  [ "1" -eq "$(grep -c "b.dat" .gitattributes)" ]
+)
+end_test
+
+begin_test "track --filename: backslashes"
+(
+  set -e
+
+  if [ "$IS_WINDOWS" -eq 1 ]; then
+    echo "skip: backslashes are path separators on Windows"
+    exit 0
+  fi
+
+  reponame="track-filename-backslash"
+  git init "$reponame"
+  cd "$reponame"
+
+  # Outside Windows, a backslash is part of the filename, rather than a
+  # path separator.
+  mkdir a
+  printf "contents" > 'a\b.dat'
+  printf "other" > a/b.dat
+  git add 'a\b.dat' a/b.dat
+  git commit -m "initial commit"
+
+  git lfs track --filename 'a\b.dat'
+  [ 'a\\b.dat filter=lfs diff=lfs merge=lfs -text' = "$(cat .gitattributes)" ]
+
+  # (check-attr quotes names with backslashes)
+  [ '"a\\b.dat": filter: lfs' = "$(git check-attr filter -- 'a\b.dat')" ]
+  [ "a/b.dat: filter: unspecified" = "$(git check-attr filter -- a/b.dat)" ]
+)
+end_test
+
+begin_test "track pattern with spaces again"
+(
+  set -e
+
+  reponame="track-spaces-again"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "with space.dat"
+  git lfs track "with space.dat" | grep "already supported"
+  git lfs track --lockable "with space.dat"
+
+  [ "with[[:space:]]space.dat filter=lfs diff=lfs merge=lfs -text lockable" = "$(cat .gitattributes)" ]
+)
+end_test
+
+begin_test "track keeps .gitattributes order and blank lines"
+(
+  set -e
+
+  reponame="track-attributes-order"
+  git init "$reponame"
+  cd "$reponame"
+
+  printf "*.txt text\n\n# images\n*.png binary\n" > .gitattributes
+
+  git lfs track "*.c" "*.b" "*.a"
+
+  [ "*.txt text
+
+# images
+*.png binary
+*.c filter=lfs diff=lfs merge=lfs -text
+*.b filter=lfs diff=lfs merge=lfs -text
+*.a filter=lfs diff=lfs merge=lfs -text" = "$(cat .gitattributes)" ]
+)
+end_test
+
+begin_test "track --no-touch"
+(
+  set -e
+
+  reponame="track-no-touch"
+  git init "$reponame"
+  cd "$reponame"
+
+  printf "contents" > a.dat
+  git add a.dat
+  git commit -m "initial commit"
+
+  touch -t 200001010000 a.dat
+  touch -t 200101010000 reference
+
+  git lfs track --no-touch --verbose "*.dat" | tee track.log
+  [ "0" -eq "$(grep -c "touching" track.log)" ]
+  [ -z "$(find a.dat -newer reference)" ]
+
+  git lfs untrack "*.dat"
+  git lfs track "*.dat"
+  [ -n "$(find a.dat -newer reference)" ]
+)
+end_test
+
+begin_test "track --json"
+(
+  set -e
+
+  reponame="track-json"
+  git init "$reponame"
+  cd "$reponame"
+
+  [ '{"patterns":[]}' = "$(git lfs track --json)" ]
+
+  git lfs track "*.dat"
+  git lfs track --lockable "*.psd"
+  mkdir dir
+  printf "*.bin filter=lfs diff=lfs merge=lfs -text\n" > dir/.gitattributes
+
+  git lfs track --json | tee track.json
+  [ '{"patterns":[{"pattern":"dir/*.bin","source":"dir/.gitattributes","lockable":false},{"pattern":"*.dat","source":".gitattributes","lockable":false},{"pattern":"*.psd","source":".gitattributes","lockable":true}]}' = "$(cat track.json)" ]
+)
+end_test
+
+begin_test "track --json with patterns"
+(
+  set -e
+
+  reponame="track-json-patterns"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat" "*.psd"
+
+  git lfs track --json --lockable "*.psd" "*.bin" 2>track.err | tee track.json
+  [ '{"patterns":[{"pattern":"*.psd","source":".gitattributes","lockable":true,"status":"updated"},{"pattern":"*.bin","source":".gitattributes","lockable":true,"status":"added"}]}' = "$(cat track.json)" ]
+  grep "Tracking \"\*.bin\"" track.err
+
+  git lfs track --json "*.dat" 2>/dev/null | tee track.json
+  [ '{"patterns":[{"pattern":"*.dat","source":".gitattributes","lockable":false,"status":"unchanged"}]}' = "$(cat track.json)" ]
+)
+end_test
+
+begin_test "track --dry-run does not modify .gitattributes"
+(
+  set -e
+
+  reponame="track-dry-run-attributes"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  cp .gitattributes attributes.before
+
+  git lfs track --dry-run "*.bin" | grep "Tracking \"\*.bin\""
+  git lfs track --dry-run --lockable "*.dat" | grep "Tracking \"\*.dat\""
+  git lfs track --dry-run --json "*.psd" 2>/dev/null | grep '"status":"added"'
+
+  diff -u attributes.before .gitattributes
+)
+end_test
@@ -182,3 +182,82 @@ begin_test "uninstall --local"
   [ "" = "$(git config --local filter.lfs.process)" ]
 )
 end_test
+
+begin_test "uninstall chained hooks"
+(
+  set -e
+
+  reponame="$(basename "$0" ".sh")-chained-hook"
+  mkdir "$reponame"
+  cd "$reponame"
+  git init
+
+  printf '#!/bin/sh\necho "running my pre-push"\n' > .git/hooks/pre-push
+  git lfs install --chain
+  grep "git lfs pre-push" .git/hooks/pre-push
+
+  git lfs uninstall hooks
+
+  [ "#!/bin/sh
+echo \"running my pre-push\"" = "$(cat .git/hooks/pre-push)" ]
+  [ ! -f .git/hooks/post-checkout ]
+)
+end_test
+
+begin_test "uninstall --worktree"
+(
+  set -e
+
+  ensure_git_version_isnt $VERSION_LOWER "2.20.0"
+
+  reponame="$(basename "$0" ".sh")-worktree"
+  mkdir "$reponame"
+  cd "$reponame"
+  git init
+  git commit --allow-empty -m "initial commit"
+  git config extensions.worktreeConfig true
+  git worktree add ../"$reponame-other"
+
+  git lfs install --local
+  cd ../"$reponame-other"
+  git lfs install --worktree
+  [ "git-lfs filter-process" = "$(git config --worktree filter.lfs.process)" ]
+
+  git lfs uninstall --worktree
+
+  # worktree configs override the local ones, turning the filter off
+  [ "" = "$(git config --worktree filter.lfs.clean)" ]
+  [ "" = "$(git config --worktree filter.lfs.smudge)" ]
+  [ "" = "$(git config --worktree filter.lfs.process)" ]
+  [ "false" = "$(git config --worktree filter.lfs.required)" ]
+  [ "" = "$(git config filter.lfs.process)" ]
+  [ "false" = "$(git config filter.lfs.required)" ]
+
+  # local configs are untouched
+  [ "git-lfs filter-process" = "$(git config --local filter.lfs.process)" ]
+
+  # files are no longer cleaned in this worktree, but still are in the
+  # other one
+  git lfs track "*.dat"
+  printf "b" > b.dat
+  git add .gitattributes b.dat
+  [ "b" = "$(git cat-file -p :b.dat)" ]
+
+  cd ../"$reponame"
+  printf "c" > c.dat
+  cp ../"$reponame-other"/.gitattributes .
+  git add .gitattributes c.dat
+  git cat-file -p :c.dat | grep "git-lfs.github.com/spec/v1"
+  cd ../"$reponame-other"
+
+  # installing again replaces the values which turn the filter off
+  git lfs install --worktree
+  [ "git-lfs filter-process" = "$(git config --worktree filter.lfs.process)" ]
+  [ "true" = "$(git config --worktree filter.lfs.required)" ]
+
+  # hooks shared with the other worktrees are kept
+  hooksdir="$(git rev-parse --git-common-dir)/hooks"
+  grep "git lfs pre-push" "$hooksdir/pre-push"
+  grep "git lfs post-checkout" "$hooksdir/post-checkout"
+)
+end_test
@@ -0,0 +1,89 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+# start_socket_server starts another test server sharing the same
+# repositories, which also listens on a Unix domain socket, whose path is
+# written to $TRASHDIR/socket. It is shut down when the test exits.
+start_socket_server() {
+  # Socket paths are limited to around 100 characters, which the trash
+  # directory may be longer than.
+  socketdir="$(mktemp -d "${TMPDIR:-/tmp}/lfs-socket.XXXXXX")"
+  echo "$socketdir/lfs.sock" > "$TRASHDIR/socket"
+
+  LFSTEST_URL="$TRASHDIR/socket-url" \
+  LFSTEST_SSL_URL="$TRASHDIR/socket-ssl-url" \
+  LFSTEST_CLIENT_CERT_URL="$TRASHDIR/socket-client-cert-url" \
+  LFSTEST_CERT="$TRASHDIR/socket-cert" \
+  LFSTEST_CLIENT_CERT="$TRASHDIR/socket-client-cert" \
+  LFSTEST_CLIENT_KEY="$TRASHDIR/socket-client-key" \
+  LFSTEST_DIR="$REMOTEDIR" \
+  LFSTEST_SOCKET="$socketdir/lfs.sock" \
+    lfstest-gitserver > "$TRASHDIR/socket-gitserver.log" 2>&1 &
+  wait_for_file "$TRASHDIR/socket-url"
+  GITSERVER="$(cat "$TRASHDIR/socket-url")"
+
+  trap "curl -s '$GITSERVER/shutdown'; rm -rf '$socketdir'" EXIT
+}
+
+begin_test "unix socket: push and clone over a Unix domain socket"
+(
+  set -e
+
+  start_socket_server
+  socket="$(cat "$TRASHDIR/socket")"
+
+  reponame="unix-socket"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  # Git itself is still served over TCP, only the LFS API is not.
+  lfsurl="http+unix://user:pass@$socket:/$reponame.git/info/lfs"
+  git config lfs.url "$lfsurl"
+
+  git lfs track "*.dat"
+  contents="over a socket"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  GIT_TRACE=1 git push origin master 2>&1 | tee push.log
+  grep "HTTP: POST http+unix://.*$socket:/$reponame.git/info/lfs/objects/batch" push.log
+  grep "HTTP: PUT http+unix://$socket:/storage/$contents_oid" push.log
+  assert_server_object "$reponame" "$contents_oid"
+
+  cd ..
+  GIT_LFS_SKIP_SMUDGE=1 git clone "$GITSERVER/$reponame" "$reponame-clone"
+  cd "$reponame-clone"
+  git config lfs.url "$lfsurl"
+
+  GIT_TRACE=1 git lfs pull 2>&1 | tee pull.log
+  grep "HTTP: GET http+unix://$socket:/storage/$contents_oid" pull.log
+  [ "$contents" = "$(cat a.dat)" ]
+)
+end_test
+
+begin_test "unix socket: missing socket"
+(
+  set -e
+
+  reponame="unix-socket-missing"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git config lfs.url "http+unix://$TRASHDIR/missing.sock:/$reponame.git/info/lfs"
+
+  git lfs track "*.dat"
+  printf "missing socket" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git lfs push origin master 2>&1 | tee push.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected push to fail"
+    exit 1
+  fi
+  grep "missing.sock" push.log
+)
+end_test
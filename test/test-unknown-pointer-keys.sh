@@ -0,0 +1,98 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+# add_unknown_key writes a pointer to the given file into the index, with the
+# given key and value added to the pointer the clean filter wrote for it.
+add_unknown_key() {
+  local file="$1"
+  local key="$2"
+  local value="$3"
+
+  local sha="$( (git cat-file -p ":$file"; echo "$key $value") | sort_pointer | git hash-object -w --stdin)"
+  git update-index --cacheinfo 100644 "$sha" "$file"
+}
+
+# sort_pointer sorts the keys of the pointer read from stdin, but for the
+# "version" key, which comes first.
+sort_pointer() {
+  local pointer="$(cat)"
+  echo "$pointer" | grep "^version "
+  echo "$pointer" | grep -v "^version " | LC_ALL=C sort
+}
+
+begin_test "unknown pointer keys survive a checkout and re-add"
+(
+  set -e
+
+  reponame="unknown-pointer-keys-checkout"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  contents="annotated contents"
+  printf "$contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  add_unknown_key a.dat "review" "approved"
+  add_unknown_key a.dat "zz.example.owner" "art"
+  git commit -m "annotate a.dat"
+
+  expected="$(git cat-file -p HEAD:a.dat)"
+  echo "$expected" | grep "^review approved$"
+  echo "$expected" | grep "^zz.example.owner art$"
+  [ "oid" = "$(echo "$expected" | sed -n 2p | cut -d' ' -f1)" ]
+  [ "review" = "$(echo "$expected" | sed -n 3p | cut -d' ' -f1)" ]
+
+  rm a.dat
+  git checkout -- a.dat
+  [ "$contents" = "$(cat a.dat)" ]
+
+  # Make Git clean the file again, rather than trust the index.
+  touch a.dat
+  git rm --cached -q a.dat
+  git add a.dat
+
+  [ "$expected" = "$(git cat-file -p :a.dat)" ]
+  git diff --cached --exit-code HEAD
+)
+end_test
+
+begin_test "unknown pointer keys are dropped with changed contents"
+(
+  set -e
+
+  cd unknown-pointer-keys-checkout
+
+  printf "changed contents" > a.dat
+  git add a.dat
+
+  [ "0" -eq "$(git cat-file -p :a.dat | grep -c "review")" ]
+  [ "0" -eq "$(git cat-file -p :a.dat | grep -c "owner")" ]
+)
+end_test
+
+begin_test "unknown pointer keys: fsck and pointer accept them"
+(
+  set -e
+
+  reponame="unknown-pointer-keys-fsck"
+  git init "$reponame"
+  cd "$reponame"
+
+  git lfs track "*.dat"
+  printf "annotated contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  add_unknown_key a.dat "review" "approved"
+  git commit -m "annotate a.dat"
+
+  [ "Git LFS fsck OK" = "$(git lfs fsck)" ]
+
+  git cat-file -p HEAD:a.dat > pointer.txt
+  git lfs pointer --file=a.dat --pointer=pointer.txt 2>&1 | tee pointer.log
+  grep "^review approved$" pointer.log
+)
+end_test
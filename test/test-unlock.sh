@@ -232,3 +232,29 @@ begin_test "unlocking a lock while untracked"
   refute_server_lock "$reponame" "$id"
 )
 end_test
+
+begin_test "unlocking another user's lock requires --force"
+(
+  set -e
+
+  reponame="unlock_other_user"
+  setup_remote_repo_with_file "$reponame" "c.dat"
+
+  git lfs lock --json "c.dat" | tee lock.log
+  id=$(assert_lock lock.log c.dat)
+  assert_server_lock "$reponame" "$id"
+
+  # Act as another user of the test server, see lockOwner() in
+  # lfstest-gitserver.
+  printf "user-alice:pass" > "$CREDSDIR/127.0.0.1--$reponame"
+
+  git lfs unlock "c.dat" 2>&1 | tee unlock.log
+  [ ${PIPESTATUS[0]} -ne "0" ]
+
+  grep "lock $id is owned by Git LFS Tests, not alice" unlock.log
+  assert_server_lock "$reponame" "$id"
+
+  git lfs unlock --force "c.dat" 2>&1 | tee unlock.log
+  refute_server_lock "$reponame" "$id"
+)
+end_test
@@ -93,7 +93,8 @@ git lfs pre-push \"$@\""
 
 To resolve this, either:
   1: run \`git lfs update --manual\` for instructions on how to merge hooks.
-  2: run \`git lfs update --force\` to overwrite your hook."
+  2: run \`git lfs update --force\` to overwrite your hook.
+  3: run \`git lfs update --chain\` to run Git LFS from the start of your hook."
 
   [ "$expected" = "$(git lfs update 2>&1)" ]
   [ "test" = "$(cat .git/hooks/pre-push)" ]
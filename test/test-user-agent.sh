@@ -0,0 +1,46 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "user agent: includes version and transfer adapter"
+(
+  set -e
+
+  reponame="user-agent-default"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  GIT_CURL_VERBOSE=1 git push origin master 2>&1 | tee push.log
+
+  version="$(git lfs version)"
+  grep "> User-Agent: $version$" push.log
+  grep "> User-Agent: $version transfer/basic$" push.log
+)
+end_test
+
+begin_test "user agent: lfs.useragent appends tokens"
+(
+  set -e
+
+  reponame="user-agent-tokens"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "other contents" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+
+  git config lfs.useragent "ci/example pipeline/42"
+  GIT_CURL_VERBOSE=1 git push origin master 2>&1 | tee push.log
+
+  version="$(git lfs version)"
+  grep "> User-Agent: $version ci/example pipeline/42$" push.log
+  grep "> User-Agent: $version transfer/basic ci/example pipeline/42$" push.log
+)
+end_test
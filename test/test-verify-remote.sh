@@ -0,0 +1,115 @@
+#!/usr/bin/env bash
+
+. "test/testlib.sh"
+
+begin_test "verify-remote"
+(
+  set -e
+
+  reponame="verify-remote"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  a_commit="$(git rev-parse HEAD)"
+
+  printf "bb" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+  b_commit="$(git rev-parse HEAD)"
+
+  # The object of the deleted file is still needed by the history.
+  git rm b.dat
+  git commit -m "remove b.dat"
+  git push origin master
+
+  git lfs verify-remote 2>&1 | tee verify.log
+  grep "All 2 object(s) are present on \"origin\"" verify.log
+
+  delete_server_object "$reponame" "$(calc_oid "bb")"
+
+  git lfs verify-remote origin master 2>&1 | tee verify.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected verify-remote to fail"
+    exit 1
+  fi
+
+  grep "Object $(calc_oid "bb") (2 B) is missing on \"origin\"" verify.log
+  grep "  needed by b.dat in $b_commit" verify.log
+  grep "1 of 2 object(s) are missing or invalid on \"origin\"" verify.log
+  [ "0" -eq "$(grep -c "$(calc_oid "a")" verify.log)" ]
+)
+end_test
+
+begin_test "verify-remote --all"
+(
+  set -e
+
+  reponame="verify-remote-all"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "a" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  git checkout -b other
+  printf "c" > c.dat
+  git add c.dat
+  git commit -m "add c.dat"
+  c_commit="$(git rev-parse HEAD)"
+  git checkout master
+
+  git lfs verify-remote origin 2>&1 | tee verify.log
+  grep "All 1 object(s) are present on \"origin\"" verify.log
+
+  git lfs verify-remote --all 2>&1 | tee verify.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected verify-remote to fail"
+    exit 1
+  fi
+
+  grep "Object $(calc_oid "c") (1 B) is missing on \"origin\"" verify.log
+  grep "  needed by c.dat in $c_commit" verify.log
+
+  git lfs verify-remote --all origin master 2>&1 | tee verify.log
+  grep "Cannot combine --all with ref arguments" verify.log
+)
+end_test
+
+begin_test "verify-remote: with the wrong size on the remote"
+(
+  set -e
+
+  reponame="verify-remote-size"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  printf "abc" > a.dat
+  git add .gitattributes a.dat
+  git commit -m "add a.dat"
+  git push origin master
+
+  # A pointer to the same object, which declares another size.
+  git lfs pointer --file=a.dat | sed -e "s/size 3/size 5/" > b.dat
+  git add b.dat
+  git commit -m "add b.dat"
+  b_commit="$(git rev-parse HEAD)"
+
+  git lfs verify-remote 2>&1 | tee verify.log
+  if [ "0" -eq "${PIPESTATUS[0]}" ]; then
+    echo >&2 "fatal: expected verify-remote to fail"
+    exit 1
+  fi
+
+  grep "Object $(calc_oid "abc") (5 B) has a size of 3 byte(s) on \"origin\", instead of 5" verify.log
+  grep "  needed by b.dat in $b_commit" verify.log
+  [ "0" -eq "$(grep -c "needed by a.dat" verify.log)" ]
+)
+end_test
@@ -62,7 +62,7 @@ LocalGitDir=$(native_path_escaped "$TRASHDIR/$reponame/.git/worktrees/$worktreen
 LocalGitStorageDir=$(native_path_escaped "$TRASHDIR/$reponame/.git")
 LocalMediaDir=$(native_path_escaped "$TRASHDIR/$reponame/.git/lfs/objects")
 LocalReferenceDir=
-TempDir=$(native_path_escaped "$TRASHDIR/$reponame/.git/worktrees/$worktreename/lfs/tmp")
+TempDir=$(native_path_escaped "$TRASHDIR/$reponame/.git/lfs/tmp")
 ConcurrentTransfers=3
 TusTransfers=false
 BasicTransfersOnly=false
@@ -86,3 +86,44 @@ $(escape_path "$(env | grep "^GIT")")
     contains_same_elements "$expected" "$actual"
 )
 end_test
+
+begin_test "git worktree: hooks and objects are shared"
+(
+  set -e
+
+  reponame="worktree-shared"
+  setup_remote_repo "$reponame"
+  clone_repo "$reponame" "$reponame"
+
+  git lfs track "*.dat"
+  git add .gitattributes
+  git commit -m "initial commit"
+
+  worktreename="$reponame-2"
+  git worktree add "$TRASHDIR/$worktreename"
+  cd "$TRASHDIR/$worktreename"
+
+  rm -f "$TRASHDIR/$reponame/.git/hooks/pre-push"
+  git lfs install
+  [ -f "$TRASHDIR/$reponame/.git/hooks/pre-push" ]
+  [ ! -d "$TRASHDIR/$reponame/.git/worktrees/$worktreename/hooks" ]
+
+  contents="worktree"
+  contents_oid="$(calc_oid "$contents")"
+  printf "$contents" > a.dat
+  git add a.dat
+  git commit -m "add a.dat"
+  [ -f "$TRASHDIR/$reponame/.git/lfs/objects/${contents_oid:0:2}/${contents_oid:2:2}/$contents_oid" ]
+  [ ! -d "$TRASHDIR/$reponame/.git/worktrees/$worktreename/lfs" ]
+
+  # The main worktree can check out the object without downloading it.
+  cd "$TRASHDIR/$reponame"
+  git merge "$worktreename"
+  [ "$contents" = "$(cat a.dat)" ]
+
+  # Patterns in the shared info/attributes are listed from either worktree.
+  printf "*.bin filter=lfs diff=lfs merge=lfs -text\n" > .git/info/attributes
+  cd "$TRASHDIR/$worktreename"
+  git lfs track | grep "\*.bin"
+)
+end_test
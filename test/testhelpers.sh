@@ -483,7 +483,7 @@ setup() {
     done
     if [ -z "$SKIPAPITESTCOMPILE" ]; then
       # Ensure API test util is built during tests to ensure it stays in sync
-      GO15VENDOREXPERIMENT=1 go build -o "$BINPATH/git-lfs-test-server-api$EXT" "test/git-lfs-test-server-api/main.go" "test/git-lfs-test-server-api/testdownload.go" "test/git-lfs-test-server-api/testupload.go"
+      GO15VENDOREXPERIMENT=1 go build -o "$BINPATH/git-lfs-test-server-api$EXT" "test/git-lfs-test-server-api/main.go" "test/git-lfs-test-server-api/testdownload.go" "test/git-lfs-test-server-api/testupload.go" "test/git-lfs-test-server-api/testi18n.go" "test/git-lfs-test-server-api/testlargebatch.go" "test/git-lfs-test-server-api/testlocks.go" "test/git-lfs-test-server-api/testpaths.go" "test/git-lfs-test-server-api/testratelimit.go" "test/git-lfs-test-server-api/init.go"
     fi
   fi
 
@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// contentTypeSniffLen is the number of bytes that http.DetectContentType
+	// considers.
+	contentTypeSniffLen = 512
+
+	// DefaultContentType is the content type of data whose type is not
+	// known.
+	DefaultContentType = "application/octet-stream"
+)
+
+// ContentTypeOf returns the MIME type of the data read from "r", judging by its
+// leading "magic" bytes. Where those only say that the data is plain text or
+// arbitrary binary, the extension of "name", if any, is used instead, since it
+// is likely to be more specific (e.g. "text/css", or "model/gltf-binary").
+func ContentTypeOf(r io.Reader, name string) (string, error) {
+	buf := make([]byte, contentTypeSniffLen)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+
+	sniffed := http.DetectContentType(buf[:n])
+	if sniffed == DefaultContentType || strings.HasPrefix(sniffed, "text/plain") {
+		if ext := filepath.Ext(name); len(ext) > 0 {
+			if byExt := mime.TypeByExtension(ext); len(byExt) > 0 {
+				return byExt, nil
+			}
+		}
+	}
+	return sniffed, nil
+}
+
+// FileContentType returns the MIME type of the file at the given path, as
+// given by ContentTypeOf. The name of the file is taken from "name", rather
+// than "path", since objects are stored under their OID.
+func FileContentType(path, name string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return ContentTypeOf(f, name)
+}
@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentTypeOfUsesMagicBytes(t *testing.T) {
+	png := []byte("\x89PNG\x0D\x0A\x1A\x0A\x00\x00\x00\x0DIHDR")
+
+	ct, err := ContentTypeOf(bytes.NewReader(png), "image.dat")
+	require.Nil(t, err)
+	assert.Equal(t, "image/png", ct)
+}
+
+func TestContentTypeOfFallsBackToExtension(t *testing.T) {
+	ct, err := ContentTypeOf(strings.NewReader("body { color: red; }"), "dir/style.css")
+	require.Nil(t, err)
+	assert.True(t, strings.HasPrefix(ct, "text/css"), ct)
+}
+
+func TestContentTypeOfUnknown(t *testing.T) {
+	ct, err := ContentTypeOf(bytes.NewReader([]byte{0x00, 0x01, 0x02}), "blob.unknownext")
+	require.Nil(t, err)
+	assert.Equal(t, DefaultContentType, ct)
+
+	ct, err = ContentTypeOf(strings.NewReader("plain"), "")
+	require.Nil(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", ct)
+}
@@ -65,6 +65,28 @@ func ResolveSymlinks(path string) string {
 	return path
 }
 
+// SymlinkInPath returns the first of the given path, relative to the given root
+// directory, and of the directories leading to it, which is a symbolic link, or
+// an empty string if none is. Writing to a path through a symbolic link would
+// write wherever it points to instead, such as over a file shared with another
+// repository.
+func SymlinkInPath(root, path string) string {
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	for i := range parts {
+		rel := strings.Join(parts[:i+1], "/")
+
+		fi, err := os.Lstat(filepath.Join(root, rel))
+		if err != nil {
+			// Nothing exists past here to be a link.
+			return ""
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return rel
+		}
+	}
+	return ""
+}
+
 // RenameFileCopyPermissions moves srcfile to destfile, replacing destfile if
 // necessary and also copying the permissions of destfile if it already exists
 func RenameFileCopyPermissions(srcfile, destfile string) error {
@@ -115,7 +137,7 @@ func VerifyFileHash(oid, path string) error {
 	}
 	defer f.Close()
 
-	h := NewLfsContentHash()
+	h := NewLfsContentHashForOid(oid)
 	_, err = io.Copy(h, f)
 	if err != nil {
 		return err
@@ -269,3 +269,27 @@ func TestSetWriteFlag(t *testing.T) {
 		assert.EqualValues(t, 0640, getFileMode(filename))
 	}
 }
+
+func TestSymlinkInPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links need extra privileges on Windows")
+	}
+
+	root, err := ioutil.TempDir("", "lfs-symlink-in-path")
+	assert.Nil(t, err)
+	defer os.RemoveAll(root)
+
+	assert.Nil(t, os.MkdirAll(filepath.Join(root, "shared"), 0755))
+	assert.Nil(t, os.MkdirAll(filepath.Join(root, "dir"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(root, "shared", "a.dat"), []byte("a"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(root, "dir", "b.dat"), []byte("b"), 0644))
+	assert.Nil(t, os.Symlink("shared", filepath.Join(root, "assets")))
+	assert.Nil(t, os.Symlink("../shared/a.dat", filepath.Join(root, "dir", "c.dat")))
+
+	assert.Equal(t, "", SymlinkInPath(root, "dir/b.dat"))
+	assert.Equal(t, "", SymlinkInPath(root, "dir/missing.dat"))
+	assert.Equal(t, "", SymlinkInPath(root, "missing/a.dat"))
+	assert.Equal(t, "assets", SymlinkInPath(root, "assets/a.dat"))
+	assert.Equal(t, "assets", SymlinkInPath(root, "assets"))
+	assert.Equal(t, "dir/c.dat", SymlinkInPath(root, "dir/c.dat"))
+}
@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"io"
+
+	"github.com/git-lfs/git-lfs/errors"
+)
+
+const (
+	// HashAlgoSHA256 is the algorithm with which Git LFS objects are named
+	// by default.
+	HashAlgoSHA256 = "sha256"
+	// HashAlgoSHA512 names Git LFS objects by their SHA-512.
+	HashAlgoSHA512 = "sha512"
+)
+
+var (
+	// hashAlgos maps each supported algorithm to its constructor. The
+	// digests of any two of them must differ in size, so that the
+	// algorithm which named an object can be told from its OID.
+	hashAlgos = map[string]func() hash.Hash{
+		HashAlgoSHA256: sha256.New,
+		HashAlgoSHA512: sha512.New,
+	}
+)
+
+// NewContentHash returns a new Hash instance of the given algorithm, or an error
+// if it is not supported.
+func NewContentHash(algo string) (hash.Hash, error) {
+	if fn, ok := hashAlgos[algo]; ok {
+		return fn(), nil
+	}
+	return nil, errors.Errorf("unsupported hash algorithm: %q", algo)
+}
+
+// HashAlgoForOid returns the algorithm whose hex-encoded digests are as long as
+// the given OID, or HashAlgoSHA256 if there is none.
+func HashAlgoForOid(oid string) string {
+	for algo, fn := range hashAlgos {
+		if len(oid) == 2*fn().Size() {
+			return algo
+		}
+	}
+	return HashAlgoSHA256
+}
+
+// IsValidOid returns whether the given OID is a lowercase hex-encoded digest of
+// any supported algorithm.
+func IsValidOid(oid string) bool {
+	if len(oid) != 2*hashAlgos[HashAlgoForOid(oid)]().Size() {
+		return false
+	}
+
+	for _, c := range oid {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// NewLfsContentHashForOid returns a new Hash instance of the algorithm which
+// named the given OID.
+func NewLfsContentHashForOid(oid string) hash.Hash {
+	return hashAlgos[HashAlgoForOid(oid)]()
+}
+
+// NewHashingReaderForOid returns a HashingReader which hashes the data it reads
+// with the algorithm which named the given OID.
+func NewHashingReaderForOid(r io.Reader, oid string) *HashingReader {
+	return NewHashingReaderPreloadHash(r, NewLfsContentHashForOid(oid))
+}
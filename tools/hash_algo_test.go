@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewContentHash(t *testing.T) {
+	for algo, expected := range map[string]string{
+		HashAlgoSHA256: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+		HashAlgoSHA512: "9b71d224bd62f3785d96d46ad3ea3d73319bfbc2890caadae2dff72519673ca72323c3d99ba5c11d7c7acc6e14b8c5da0c4663475c2e5c3adef46f73bcdec043",
+	} {
+		h, err := NewContentHash(algo)
+		assert.Nil(t, err)
+		h.Write([]byte("hello"))
+		assert.Equal(t, expected, hex.EncodeToString(h.Sum(nil)))
+
+		assert.Equal(t, algo, HashAlgoForOid(expected))
+
+		r := NewHashingReaderForOid(strings.NewReader("hello"), expected)
+		_, err = ioutil.ReadAll(r)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, r.Hash())
+	}
+}
+
+func TestNewContentHashUnsupported(t *testing.T) {
+	h, err := NewContentHash("md5")
+	assert.Nil(t, h)
+	assert.EqualError(t, err, `unsupported hash algorithm: "md5"`)
+}
+
+func TestHashAlgoForOidDefaultsToSHA256(t *testing.T) {
+	assert.Equal(t, HashAlgoSHA256, HashAlgoForOid("abc"))
+	assert.Equal(t, HashAlgoSHA256, HashAlgoForOid(""))
+}
+
+func TestIsValidOid(t *testing.T) {
+	sha256 := strings.Repeat("a1", 32)
+	sha512 := strings.Repeat("b2", 64)
+
+	assert.True(t, IsValidOid(sha256))
+	assert.True(t, IsValidOid(sha512))
+	assert.False(t, IsValidOid(sha256[1:]))
+	assert.False(t, IsValidOid(sha512+"00"))
+	assert.False(t, IsValidOid(strings.ToUpper(sha256)))
+	assert.False(t, IsValidOid(strings.Repeat("g", 64)))
+	assert.False(t, IsValidOid(""))
+}
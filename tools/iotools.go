@@ -8,6 +8,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"sync"
 
 	"github.com/git-lfs/git-lfs/errors"
 )
@@ -17,6 +18,10 @@ const (
 	// spooling the contents of an `io.Reader` in `Spool()` to a temporary
 	// file on disk.
 	memoryBufferLimit = 1024
+
+	// concurrentCopyBuffers is the number of buffers ConcurrentCopy fills
+	// from its reader ahead of the slowest of its writers.
+	concurrentCopyBuffers = 4
 )
 
 // CopyWithCallback copies reader to writer while performing a progress callback
@@ -39,6 +44,102 @@ func CopyWithCallback(writer io.Writer, reader io.Reader, totalSize int64, cb Co
 	return io.Copy(writer, cbReader)
 }
 
+// ConcurrentCopy copies reader to each of the writers, as
+// io.Copy(io.MultiWriter(writers...), reader) does, but gives each writer its
+// own goroutine, so that reading, hashing and writing to disk can all happen
+// at once instead of in turn. The reader is read in chunks of up to bufSize
+// bytes.
+//
+// The number of bytes read, and the first error encountered by the reader or
+// any of the writers, are returned.
+func ConcurrentCopy(reader io.Reader, bufSize int, writers ...io.Writer) (int64, error) {
+	type chunk struct {
+		buf []byte
+		n   int
+		wg  sync.WaitGroup
+	}
+
+	var (
+		mu     sync.Mutex
+		werr   error
+		copied int64
+		rerr   error
+	)
+
+	writerErr := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		return werr
+	}
+
+	chunks := make([]*chunk, concurrentCopyBuffers)
+	for i := range chunks {
+		chunks[i] = &chunk{buf: make([]byte, bufSize)}
+	}
+
+	queues := make([]chan *chunk, len(writers))
+	var done sync.WaitGroup
+	for i, w := range writers {
+		queues[i] = make(chan *chunk, len(chunks))
+
+		done.Add(1)
+		go func(w io.Writer, queue <-chan *chunk) {
+			defer done.Done()
+
+			for c := range queue {
+				if writerErr() == nil {
+					if _, err := w.Write(c.buf[:c.n]); err != nil {
+						mu.Lock()
+						if werr == nil {
+							werr = err
+						}
+						mu.Unlock()
+					}
+				}
+				c.wg.Done()
+			}
+		}(w, queues[i])
+	}
+
+	for i := 0; ; i++ {
+		// Wait for the writers to finish with the last contents of
+		// this buffer before filling it again.
+		c := chunks[i%len(chunks)]
+		c.wg.Wait()
+
+		if writerErr() != nil {
+			break
+		}
+
+		c.n, rerr = io.ReadFull(reader, c.buf)
+		if c.n > 0 {
+			copied += int64(c.n)
+
+			c.wg.Add(len(queues))
+			for _, queue := range queues {
+				queue <- c
+			}
+		}
+
+		if rerr != nil {
+			if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+				rerr = nil
+			}
+			break
+		}
+	}
+
+	for _, queue := range queues {
+		close(queue)
+	}
+	done.Wait()
+
+	if rerr != nil {
+		return copied, rerr
+	}
+	return copied, writerErr()
+}
+
 // Get a new Hash instance of the type used to hash LFS content
 func NewLfsContentHash() hash.Hash {
 	return sha256.New()
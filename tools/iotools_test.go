@@ -64,6 +64,46 @@ func TestRetriableReaderDoesNotRewrap(t *testing.T) {
 
 }
 
+func TestConcurrentCopyWritesToEachWriter(t *testing.T) {
+	contents := bytes.Repeat([]byte("0123456789"), 1000)
+
+	var w1, w2 bytes.Buffer
+	n, err := tools.ConcurrentCopy(bytes.NewReader(contents), 64, &w1, &w2)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, len(contents), n)
+	assert.Equal(t, contents, w1.Bytes())
+	assert.Equal(t, contents, w2.Bytes())
+}
+
+func TestConcurrentCopyHandlesEmptyReaders(t *testing.T) {
+	var w bytes.Buffer
+	n, err := tools.ConcurrentCopy(bytes.NewReader(nil), 64, &w)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 0, n)
+	assert.Empty(t, w.Bytes())
+}
+
+func TestConcurrentCopyReturnsReaderErrors(t *testing.T) {
+	expected := errors.New("example error")
+
+	var w bytes.Buffer
+	_, err := tools.ConcurrentCopy(&ErrReader{expected}, 64, &w)
+
+	assert.Equal(t, expected, err)
+}
+
+func TestConcurrentCopyReturnsWriterErrors(t *testing.T) {
+	expected := errors.New("example error")
+	contents := bytes.Repeat([]byte("0123456789"), 1000)
+
+	var w bytes.Buffer
+	_, err := tools.ConcurrentCopy(bytes.NewReader(contents), 64, &w, &ErrWriter{expected})
+
+	assert.Equal(t, expected, err)
+}
+
 // ErrReader implements io.Reader and only returns errors.
 type ErrReader struct {
 	// err is the error that this reader will return.
@@ -74,3 +114,14 @@ type ErrReader struct {
 func (e *ErrReader) Read(p []byte) (n int, err error) {
 	return 0, e.err
 }
+
+// ErrWriter implements io.Writer and only returns errors.
+type ErrWriter struct {
+	// err is the error that this writer will return.
+	err error
+}
+
+// Write implements io.Writer#Write, and returns (0, e.err).
+func (e *ErrWriter) Write(p []byte) (n int, err error) {
+	return 0, e.err
+}
@@ -0,0 +1,9 @@
+// +build !windows
+
+package tools
+
+// LongPath returns the given path unchanged: only Windows limits the length
+// of paths.
+func LongPath(path string) string {
+	return path
+}
@@ -0,0 +1,38 @@
+// +build windows
+
+package tools
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// longPathLimit is the length from which paths need to be written in their
+// extended-length form, one less than MAX_PATH (260) minus the 12 characters
+// which Windows reserves for an 8.3 file name in directories.
+const longPathLimit = 248
+
+// LongPath returns the extended-length ("\\?\") form of the given path if it,
+// once made absolute, is too long to pass to Windows otherwise, so that objects
+// and working tree files can be written in repositories with deep directory
+// trees or long file names. Shorter paths are returned unchanged, so that they
+// read as they did in messages.
+//
+// Go only does this for absolute paths, and not for relative ones, such as
+// those of working tree files relative to the current directory.
+func LongPath(path string) string {
+	if len(path) == 0 || strings.HasPrefix(path, `\\?\`) || strings.HasPrefix(path, `\\.\`) {
+		return path
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil || len(abs) < longPathLimit {
+		return path
+	}
+
+	if strings.HasPrefix(abs, `\\`) {
+		// \\server\share\dir becomes \\?\UNC\server\share\dir.
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
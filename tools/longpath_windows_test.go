@@ -0,0 +1,44 @@
+// +build windows
+
+package tools
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLongPathLeavesShortPathsAlone(t *testing.T) {
+	assert.Equal(t, `C:\repo\a.dat`, LongPath(`C:\repo\a.dat`))
+	assert.Equal(t, `a.dat`, LongPath(`a.dat`))
+	assert.Equal(t, ``, LongPath(``))
+}
+
+func TestLongPathExtendsLongPaths(t *testing.T) {
+	dir := `C:\repo\` + strings.Repeat(`deep\`, 60)
+
+	assert.Equal(t, `\\?\`+dir+`a.dat`, LongPath(dir+`a.dat`))
+	assert.Equal(t, `\\?\`+dir+`a.dat`, LongPath(strings.Replace(dir, `\`, `/`, -1)+`a.dat`))
+}
+
+func TestLongPathExtendsLongRelativePaths(t *testing.T) {
+	rel := strings.Repeat(`deep\`, 60) + `a.dat`
+	abs, err := filepath.Abs(rel)
+	assert.Nil(t, err)
+
+	assert.Equal(t, `\\?\`+abs, LongPath(rel))
+}
+
+func TestLongPathExtendsLongUNCPaths(t *testing.T) {
+	dir := `\\server\share\` + strings.Repeat(`deep\`, 60)
+
+	assert.Equal(t, `\\?\UNC\server\share\`+dir[len(`\\server\share\`):]+`a.dat`, LongPath(dir+`a.dat`))
+}
+
+func TestLongPathLeavesExtendedPathsAlone(t *testing.T) {
+	path := `\\?\C:\repo\` + strings.Repeat(`deep\`, 60) + `a.dat`
+
+	assert.Equal(t, path, LongPath(path))
+}
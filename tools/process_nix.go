@@ -0,0 +1,19 @@
+// +build !windows
+
+package tools
+
+import (
+	"syscall"
+)
+
+// ProcessExists returns whether a process with the given ID is running.
+func ProcessExists(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	// Signal 0 checks for the process without sending it anything. A
+	// process owned by another user can't be signalled, but exists.
+	err := syscall.Kill(pid, syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
@@ -0,0 +1,31 @@
+// +build windows
+
+package tools
+
+import (
+	"syscall"
+)
+
+const processQueryLimitedInformation = 0x1000
+
+// ProcessExists returns whether a process with the given ID is running.
+func ProcessExists(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		// Access is denied to processes of other users, which
+		// exist all the same.
+		return err == syscall.ERROR_ACCESS_DENIED
+	}
+	defer syscall.CloseHandle(h)
+
+	var code uint32
+	if err := syscall.GetExitCodeProcess(h, &code); err != nil {
+		return true
+	}
+	// STILL_ACTIVE
+	return code == 259
+}
@@ -120,3 +120,28 @@ var (
 func Undent(str string) string {
 	return tabRe.ReplaceAllString(str, "")
 }
+
+var (
+	// urlUserinfoRe matches the scheme and userinfo of URLs embedded in a
+	// string, such as "https://user:token@".
+	urlUserinfoRe = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)([^/?#@\s]+)@`)
+)
+
+// RedactURLCredentials returns "str" with the credentials of any URLs within it
+// replaced by "REDACTED", so that it can be shown to others. The username is
+// kept if a password follows it, as is the username of SSH URLs, but a lone
+// username of other URLs is redacted, since it is often a token.
+func RedactURLCredentials(str string) string {
+	return urlUserinfoRe.ReplaceAllStringFunc(str, func(m string) string {
+		parts := urlUserinfoRe.FindStringSubmatch(m)
+		scheme, userinfo := parts[1], parts[2]
+
+		if i := strings.Index(userinfo, ":"); i >= 0 {
+			return scheme + userinfo[:i] + ":REDACTED@"
+		}
+		if strings.Contains(strings.ToLower(scheme), "ssh") {
+			return m
+		}
+		return scheme + "REDACTED@"
+	})
+}
@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rubyist/tracerx"
+)
+
+var (
+	performanceOnce sync.Once
+	performanceOut  io.Writer
+)
+
+// PerformanceSince writes the time since "t" to the performance trace, as
+// tracerx.PerformanceSince does. Like Git, it also accepts an absolute path or
+// a file descriptor greater than 2 in GIT_TRACE_PERFORMANCE, in which case
+// the performance trace is written there, and not to the GIT_TRACE output.
+func PerformanceSince(what string, t time.Time) {
+	performanceOnce.Do(func() {
+		performanceOut = performanceWriter(os.Getenv("GIT_TRACE_PERFORMANCE"))
+	})
+
+	if performanceOut == nil {
+		tracerx.PerformanceSince(what, t)
+		return
+	}
+
+	fmt.Fprintf(performanceOut, "%s performance %s: %.9f s\n",
+		time.Now().Format("15:04:05.000000"), what, time.Since(t).Seconds())
+}
+
+// performanceWriter returns the writer given by the GIT_TRACE_PERFORMANCE
+// value "v", or nil if tracerx should handle it.
+func performanceWriter(v string) io.Writer {
+	if filepath.IsAbs(v) {
+		f, err := os.OpenFile(v, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not open '%s' for performance tracing: %s\n", v, err)
+			return nil
+		}
+		return f
+	}
+
+	if fd, err := strconv.Atoi(v); err == nil && fd > 2 {
+		return os.NewFile(uintptr(fd), "trace-performance")
+	}
+	return nil
+}
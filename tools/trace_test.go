@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPerformanceWriterOpensAbsolutePaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "git-lfs-trace")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "perf.log")
+	w := performanceWriter(name)
+	require.NotNil(t, w)
+
+	w.Write([]byte("performance\n"))
+	w.(*os.File).Close()
+
+	contents, err := ioutil.ReadFile(name)
+	require.Nil(t, err)
+	assert.Equal(t, "performance\n", string(contents))
+}
+
+func TestPerformanceWriterLeavesOtherValuesToTracerx(t *testing.T) {
+	for _, v := range []string{"", "0", "1", "2", "true", "false", "relative/path"} {
+		assert.Nil(t, performanceWriter(v), "value %q", v)
+	}
+}
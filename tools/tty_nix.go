@@ -0,0 +1,11 @@
+// +build !windows
+
+package tools
+
+import "os"
+
+// OpenTTY opens the controlling terminal, to read answers from the user when
+// stdin is in use, as it is in the pre-push hook.
+func OpenTTY() (*os.File, error) {
+	return os.OpenFile("/dev/tty", os.O_RDONLY, 0)
+}
@@ -0,0 +1,11 @@
+// +build windows
+
+package tools
+
+import "os"
+
+// OpenTTY opens the console, to read answers from the user when stdin is in
+// use, as it is in the pre-push hook.
+func OpenTTY() (*os.File, error) {
+	return os.OpenFile("CONIN$", os.O_RDONLY, 0)
+}
@@ -0,0 +1,94 @@
+// +build cgo
+
+package tools
+
+/*
+#include <stdlib.h>
+#include <sys/attr.h>
+#include <sys/clonefile.h>
+*/
+import "C"
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// CloneFile is not supported on macOS, which can only clone a file to a new
+// path. See CloneFileByPath.
+func CloneFile(writer io.Writer, reader io.Reader) (bool, error) {
+	return false, nil
+}
+
+// CheckCloneFileSupported returns whether files in the given directory can be
+// cloned with CloneFileByPath, by cloning a temporary file there.
+func CheckCloneFileSupported(dir string) (bool, error) {
+	src, err := ioutil.TempFile(dir, "clone-src")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(src.Name())
+	src.Close()
+
+	dst := src.Name() + "-dst"
+	defer os.Remove(dst)
+
+	err = clonefile(src.Name(), dst)
+	if isCloneNotSupported(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// CloneFileByPath replaces the file at "dst" with a copy-on-write clone of the
+// file at "src", keeping the permissions of "dst" if it exists. It returns false
+// and leaves "dst" alone if the file system does not support cloning.
+func CloneFileByPath(dst, src string) (bool, error) {
+	tmp, err := ioutil.TempFile(filepath.Dir(dst), ".lfs-clone")
+	if err != nil {
+		return false, err
+	}
+	tmp.Close()
+
+	// clonefile(2) refuses to replace an existing file.
+	os.Remove(tmp.Name())
+	defer os.Remove(tmp.Name())
+
+	if err := clonefile(src, tmp.Name()); err != nil {
+		if isCloneNotSupported(err) {
+			err = nil
+		}
+		return false, err
+	}
+
+	if err := RenameFileCopyPermissions(tmp.Name(), dst); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func clonefile(src, dst string) error {
+	csrc := C.CString(src)
+	defer C.free(unsafe.Pointer(csrc))
+	cdst := C.CString(dst)
+	defer C.free(unsafe.Pointer(cdst))
+
+	if ret, err := C.clonefile(csrc, cdst, C.CLONE_NOFOLLOW); ret != 0 {
+		return err
+	}
+	return nil
+}
+
+// isCloneNotSupported returns whether the given error from cloning a file means
+// that the file system, or the pair of files given, cannot be cloned.
+func isCloneNotSupported(err error) bool {
+	switch err {
+	case syscall.ENOTSUP, syscall.EXDEV:
+		return true
+	}
+	return false
+}
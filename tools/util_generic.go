@@ -1,4 +1,6 @@
+// +build !windows
 // +build !linux !cgo
+// +build !darwin !cgo
 
 package tools
 
@@ -9,3 +11,15 @@ import (
 func CloneFile(writer io.Writer, reader io.Reader) (bool, error) {
 	return false, nil
 }
+
+// CheckCloneFileSupported returns whether files in the given directory can be
+// cloned with CloneFileByPath. Cloning is not supported on this platform.
+func CheckCloneFileSupported(dir string) (bool, error) {
+	return false, nil
+}
+
+// CloneFileByPath replaces the file at "dst" with a copy-on-write clone of the
+// file at "src". Cloning is not supported on this platform.
+func CloneFileByPath(dst, src string) (bool, error) {
+	return false, nil
+}
@@ -14,7 +14,9 @@ import "C"
 
 import (
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"syscall"
 )
 
@@ -33,3 +35,74 @@ func CloneFile(writer io.Writer, reader io.Reader) (bool, error) {
 	}
 	return false, nil
 }
+
+// CheckCloneFileSupported returns whether files in the given directory can be
+// cloned with CloneFileByPath, by cloning a temporary file there.
+func CheckCloneFileSupported(dir string) (bool, error) {
+	src, err := ioutil.TempFile(dir, "clone-src")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(src.Name())
+	defer src.Close()
+
+	if _, err := src.WriteString("clone"); err != nil {
+		return false, err
+	}
+
+	dst, err := ioutil.TempFile(dir, "clone-dst")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(dst.Name())
+	defer dst.Close()
+
+	ok, err := CloneFile(dst, src)
+	if isCloneNotSupported(err) {
+		return false, nil
+	}
+	return ok, err
+}
+
+// CloneFileByPath replaces the file at "dst" with a copy-on-write clone of the
+// file at "src", keeping the permissions of "dst" if it exists. It returns false
+// and leaves "dst" alone if the file system does not support cloning.
+func CloneFileByPath(dst, src string) (bool, error) {
+	fsrc, err := os.Open(src)
+	if err != nil {
+		return false, err
+	}
+	defer fsrc.Close()
+
+	tmp, err := ioutil.TempFile(filepath.Dir(dst), ".lfs-clone")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+
+	ok, err := CloneFile(tmp, fsrc)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if !ok || err != nil {
+		if isCloneNotSupported(err) {
+			err = nil
+		}
+		return false, err
+	}
+
+	if err := RenameFileCopyPermissions(tmp.Name(), dst); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// isCloneNotSupported returns whether the given error from cloning a file means
+// that the file system, or the pair of files given, cannot be cloned.
+func isCloneNotSupported(err error) bool {
+	switch err {
+	case syscall.EOPNOTSUPP, syscall.EINVAL, syscall.EXDEV, syscall.ENOTTY:
+		return true
+	}
+	return false
+}
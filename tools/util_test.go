@@ -3,9 +3,12 @@ package tools
 import (
 	"bytes"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCopyWithCallback(t *testing.T) {
@@ -27,3 +30,38 @@ func TestCopyWithCallback(t *testing.T) {
 	assert.Len(t, calledWritten, 1)
 	assert.Equal(t, 5, int(calledWritten[0]))
 }
+
+func TestCloneFileByPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clone")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	require.Nil(t, ioutil.WriteFile(src, []byte("source"), 0644))
+	require.Nil(t, ioutil.WriteFile(dst, []byte("destination"), 0600))
+
+	supported, err := CheckCloneFileSupported(dir)
+	require.Nil(t, err)
+
+	ok, err := CloneFileByPath(dst, src)
+	require.Nil(t, err)
+	assert.Equal(t, supported, ok)
+
+	contents, err := ioutil.ReadFile(dst)
+	require.Nil(t, err)
+	if ok {
+		assert.Equal(t, "source", string(contents))
+	} else {
+		// Without support for cloning, the destination is left alone.
+		assert.Equal(t, "destination", string(contents))
+	}
+
+	stat, err := os.Stat(dst)
+	require.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+
+	entries, err := ioutil.ReadDir(dir)
+	require.Nil(t, err)
+	assert.Len(t, entries, 2)
+}
@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	fsctlDuplicateExtentsToFile  = 0x00098344
+	fsctlGetIntegrityInformation = 0x0009027C
+
+	// maxCloneChunk is the most bytes cloned at once, which must be less
+	// than 4GiB and a multiple of the cluster size.
+	maxCloneChunk = 1 << 31
+)
+
+// duplicateExtentsData is DUPLICATE_EXTENTS_DATA. FileHandle is 64 bits wide
+// so that the layout, including the padding after a 32-bit HANDLE, matches on
+// all architectures.
+type duplicateExtentsData struct {
+	FileHandle       uint64
+	SourceFileOffset int64
+	TargetFileOffset int64
+	ByteCount        int64
+}
+
+// integrityInformation is FSCTL_GET_INTEGRITY_INFORMATION_BUFFER, which only
+// ReFS, the one file system supporting block cloning, returns.
+type integrityInformation struct {
+	ChecksumAlgorithm        uint16
+	Reserved                 uint16
+	Flags                    uint32
+	ChecksumChunkSizeInBytes uint32
+	ClusterSizeInBytes       uint32
+}
+
+// CloneFile is not supported on Windows, where files are only cloned when
+// asked for explicitly. See CloneFileByPath.
+func CloneFile(writer io.Writer, reader io.Reader) (bool, error) {
+	return false, nil
+}
+
+// CheckCloneFileSupported returns whether files in the given directory can be
+// cloned with CloneFileByPath, by cloning a temporary file there.
+func CheckCloneFileSupported(dir string) (bool, error) {
+	src, err := ioutil.TempFile(dir, "clone-src")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(src.Name())
+	defer src.Close()
+
+	if _, err := src.WriteString("clone"); err != nil {
+		return false, err
+	}
+
+	dst, err := ioutil.TempFile(dir, "clone-dst")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(dst.Name())
+	defer dst.Close()
+
+	if ok, err := cloneFile(dst, src); ok && err == nil {
+		return true, nil
+	}
+	return false, nil
+}
+
+// CloneFileByPath replaces the file at "dst" with a copy-on-write clone of the
+// file at "src", keeping the permissions of "dst" if it exists. It returns false
+// and leaves "dst" alone if the file system does not support cloning.
+func CloneFileByPath(dst, src string) (bool, error) {
+	dst, src = LongPath(dst), LongPath(src)
+
+	fsrc, err := os.Open(src)
+	if err != nil {
+		return false, err
+	}
+	defer fsrc.Close()
+
+	tmp, err := ioutil.TempFile(filepath.Dir(dst), ".lfs-clone")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+
+	ok, err := cloneFile(tmp, fsrc)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if !ok || err != nil {
+		return false, err
+	}
+
+	if err := RenameFileCopyPermissions(tmp.Name(), dst); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func cloneFile(dst, src *os.File) (bool, error) {
+	var integrity integrityInformation
+	var n uint32
+
+	err := syscall.DeviceIoControl(syscall.Handle(src.Fd()),
+		fsctlGetIntegrityInformation, nil, 0,
+		(*byte)(unsafe.Pointer(&integrity)), uint32(unsafe.Sizeof(integrity)),
+		&n, nil)
+	if err != nil {
+		// Not on ReFS.
+		return false, nil
+	}
+
+	stat, err := src.Stat()
+	if err != nil {
+		return false, err
+	}
+	size := stat.Size()
+
+	// Extents can only be cloned into a file which is already large
+	// enough to hold them.
+	if err := dst.Truncate(size); err != nil {
+		return false, err
+	}
+
+	cluster := int64(integrity.ClusterSizeInBytes)
+	for offset := int64(0); offset < size; offset += maxCloneChunk {
+		count := size - offset
+		if count > maxCloneChunk {
+			count = maxCloneChunk
+		}
+
+		// The last extent is rounded up to a whole cluster, which
+		// may extend past the end of the file.
+		data := duplicateExtentsData{
+			FileHandle:       uint64(src.Fd()),
+			SourceFileOffset: offset,
+			TargetFileOffset: offset,
+			ByteCount:        (count + cluster - 1) / cluster * cluster,
+		}
+
+		err := syscall.DeviceIoControl(syscall.Handle(dst.Fd()),
+			fsctlDuplicateExtentsToFile,
+			(*byte)(unsafe.Pointer(&data)), uint32(unsafe.Sizeof(data)),
+			nil, 0, &n, nil)
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
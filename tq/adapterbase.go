@@ -1,14 +1,18 @@
 package tq
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/git-lfs/git-lfs/fs"
 	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/git-lfs/git-lfs/tools"
 	"github.com/rubyist/tracerx"
 )
 
@@ -22,13 +26,35 @@ type adapterBase struct {
 	transferImpl transferImplementation
 	apiClient    *lfsapi.Client
 	remote       string
-	jobChan      chan *job
 	debugging    bool
-	cb           ProgressCallback
+	deadline     time.Time
+	// deadlineCtx is done once the deadline passes, cancelling the
+	// requests of any transfers still in flight. It is nil if there is no
+	// deadline.
+	deadlineCtx    context.Context
+	cancelDeadline context.CancelFunc
+	cb             ProgressCallback
+	// poolByHost specifies whether transfers are given a separate pool of
+	// workers for each host that they are sent to, so that a slow host
+	// (e.g. a mirror) does not hold up transfers to any other.
+	poolByHost bool
+	// concurrency is the number of workers in each pool.
+	concurrency int
+	// pools maps a host (or "" when not pooling by host) to the pool of
+	// workers handling transfers to it, and is guarded by poolMu.
+	pools  map[string]*workerPool
+	poolMu sync.Mutex
+	// nextWorker is the number given to the next worker to start.
+	nextWorker int
 	// WaitGroup to sync the completion of all workers
 	workerWait sync.WaitGroup
 	// WaitGroup to sync the completion of all in-flight jobs
 	jobWait *sync.WaitGroup
+}
+
+// workerPool is a set of workers processing jobs from the same channel.
+type workerPool struct {
+	jobChan chan *job
 	// WaitGroup to serialise the first transfer response to perform login if needed
 	authWait sync.WaitGroup
 }
@@ -72,26 +98,83 @@ func (a *adapterBase) Direction() Direction {
 func (a *adapterBase) Begin(cfg AdapterConfig, cb ProgressCallback) error {
 	a.apiClient = cfg.APIClient()
 	a.remote = cfg.Remote()
+	a.deadline = cfg.Deadline()
+	if !a.deadline.IsZero() {
+		a.deadlineCtx, a.cancelDeadline = context.WithDeadline(context.Background(), a.deadline)
+	}
 	a.cb = cb
-	a.jobChan = make(chan *job, 100)
+	a.pools = make(map[string]*workerPool)
+	a.nextWorker = 0
 	a.debugging = a.apiClient.OSEnv().Bool("GIT_TRANSFER_TRACE", false)
-	maxConcurrency := cfg.ConcurrentTransfers()
+	a.concurrency = cfg.ConcurrentTransfers()
 
-	a.Trace("xfer: adapter %q Begin() with %d workers", a.Name(), maxConcurrency)
+	a.Trace("xfer: adapter %q Begin() with %d workers", a.Name(), a.concurrency)
 
-	a.workerWait.Add(maxConcurrency)
-	a.authWait.Add(1)
-	for i := 0; i < maxConcurrency; i++ {
-		ctx, err := a.transferImpl.WorkerStarting(i)
+	if !a.poolByHost {
+		// Start all workers up front, so that any error doing so is
+		// reported by Begin.
+		a.poolMu.Lock()
+		_, err := a.poolFor("")
+		a.poolMu.Unlock()
 		if err != nil {
 			return err
 		}
-		go a.worker(i, ctx)
 	}
+
 	a.Trace("xfer: adapter %q started", a.Name())
 	return nil
 }
 
+// poolFor returns the pool of workers for the given host, starting it if
+// needed. It must be called with poolMu held.
+func (a *adapterBase) poolFor(host string) (*workerPool, error) {
+	if p, ok := a.pools[host]; ok {
+		return p, nil
+	}
+
+	if len(host) > 0 {
+		a.Trace("xfer: adapter %q starting %d workers for %q", a.Name(), a.concurrency, host)
+	}
+
+	p := &workerPool{jobChan: make(chan *job, 100)}
+	p.authWait.Add(1)
+	for i := 0; i < a.concurrency; i++ {
+		ctx, err := a.transferImpl.WorkerStarting(a.nextWorker)
+		if err != nil {
+			if i == 0 {
+				// No worker will signal authentication.
+				p.authWait.Done()
+			}
+			close(p.jobChan)
+			return nil, err
+		}
+
+		a.workerWait.Add(1)
+		go a.worker(p, a.nextWorker, i == 0, ctx)
+		a.nextWorker++
+	}
+	a.pools[host] = p
+	return p, nil
+}
+
+// hostFor returns the key of the pool that should process the given transfer.
+func (a *adapterBase) hostFor(t *Transfer) string {
+	if !a.poolByHost {
+		return ""
+	}
+
+	rel, err := t.Rel(a.direction.String())
+	if err != nil || rel == nil {
+		return ""
+	}
+
+	u, err := url.Parse(rel.Href)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
 type job struct {
 	T *Transfer
 
@@ -111,7 +194,17 @@ func (a *adapterBase) Add(transfers ...*Transfer) <-chan TransferResult {
 
 	go func() {
 		for _, t := range transfers {
-			a.jobChan <- &job{t, results, a.jobWait}
+			j := &job{t, results, a.jobWait}
+
+			a.poolMu.Lock()
+			p, err := a.poolFor(a.hostFor(t))
+			a.poolMu.Unlock()
+			if err != nil {
+				j.Done(err)
+				continue
+			}
+
+			p.jobChan <- j
 		}
 		a.jobWait.Wait()
 
@@ -125,11 +218,21 @@ func (a *adapterBase) End() {
 	a.Trace("xfer: adapter %q End()", a.Name())
 
 	a.jobWait.Wait()
-	close(a.jobChan)
+
+	a.poolMu.Lock()
+	for _, p := range a.pools {
+		close(p.jobChan)
+	}
+	a.pools = nil
+	a.poolMu.Unlock()
 
 	// wait for all transfers to complete
 	a.workerWait.Wait()
 
+	if a.cancelDeadline != nil {
+		a.cancelDeadline()
+	}
+
 	a.Trace("xfer: adapter %q stopped", a.Name())
 }
 
@@ -140,29 +243,29 @@ func (a *adapterBase) Trace(format string, args ...interface{}) {
 	tracerx.Printf(format, args...)
 }
 
-// worker function, many of these run per adapter
-func (a *adapterBase) worker(workerNum int, ctx interface{}) {
+// worker function, many of these run per pool
+func (a *adapterBase) worker(p *workerPool, workerNum int, first bool, ctx interface{}) {
 	a.Trace("xfer: adapter %q worker %d starting", a.Name(), workerNum)
-	waitForAuth := workerNum > 0
-	signalAuthOnResponse := workerNum == 0
+	waitForAuth := !first
+	signalAuthOnResponse := first
 
-	// First worker is the only one allowed to start immediately
+	// First worker in the pool is the only one allowed to start immediately
 	// The rest wait until successful response from 1st worker to
 	// make sure only 1 login prompt is presented if necessary
-	// Deliberately outside jobChan processing so we know worker 0 will process 1st item
+	// Deliberately outside jobChan processing so we know the first worker will process 1st item
 	if waitForAuth {
 		a.Trace("xfer: adapter %q worker %d waiting for Auth", a.Name(), workerNum)
-		a.authWait.Wait()
+		p.authWait.Wait()
 		a.Trace("xfer: adapter %q worker %d auth signal received", a.Name(), workerNum)
 	}
 
-	for job := range a.jobChan {
+	for job := range p.jobChan {
 		t := job.T
 
 		var authCallback func()
 		if signalAuthOnResponse {
 			authCallback = func() {
-				a.authWait.Done()
+				p.authWait.Done()
 				signalAuthOnResponse = false
 			}
 		}
@@ -172,8 +275,25 @@ func (a *adapterBase) worker(workerNum int, ctx interface{}) {
 		var err error
 		if t.Size < 0 {
 			err = fmt.Errorf("Git LFS: object %q has invalid size (got: %d)", t.Oid, t.Size)
+		} else if !a.deadline.IsZero() && time.Now().After(a.deadline) {
+			a.Trace("xfer: adapter %q worker %d skipping %q, deadline exceeded", a.Name(), workerNum, t.Oid)
+			err = &deadlineExceededError{Oid: t.Oid}
 		} else {
+			start := time.Now()
 			err = a.transferImpl.DoTransfer(ctx, t, a.cb, authCallback)
+			if err != nil && a.deadlineCtx != nil && a.deadlineCtx.Err() != nil {
+				// The transfer was cut short by the deadline,
+				// and is left to be resumed by the next run,
+				// rather than failing.
+				a.Trace("xfer: adapter %q worker %d cancelled %q, deadline exceeded: %s", a.Name(), workerNum, t.Oid, err)
+				err = &deadlineExceededError{Oid: t.Oid}
+			}
+
+			result := "done"
+			if err != nil {
+				result = "failed"
+			}
+			tools.PerformanceSince(fmt.Sprintf("transfer: %s %s (%d bytes) %s", a.direction, t.Oid, t.Size, result), start)
 		}
 
 		// Mark the job as completed, and alter all listeners
@@ -183,14 +303,14 @@ func (a *adapterBase) worker(workerNum int, ctx interface{}) {
 	}
 	// This will only happen if no jobs were submitted; just wake up all workers to finish
 	if signalAuthOnResponse {
-		a.authWait.Done()
+		p.authWait.Done()
 	}
 	a.Trace("xfer: adapter %q worker %d stopping", a.Name(), workerNum)
 	a.transferImpl.WorkerEnding(workerNum, ctx)
 	a.workerWait.Done()
 }
 
-var httpRE = regexp.MustCompile(`\Ahttps?://`)
+var httpRE = regexp.MustCompile(`\A(https?|http\+unix)://`)
 
 func (a *adapterBase) newHTTPRequest(method string, rel *Action) (*http.Request, error) {
 	if !httpRE.MatchString(rel.Href) {
@@ -202,6 +322,9 @@ func (a *adapterBase) newHTTPRequest(method string, rel *Action) (*http.Request,
 	if err != nil {
 		return nil, err
 	}
+	if a.deadlineCtx != nil {
+		req = req.WithContext(a.deadlineCtx)
+	}
 
 	for key, value := range rel.Header {
 		req.Header.Set(key, value)
@@ -211,6 +334,7 @@ func (a *adapterBase) newHTTPRequest(method string, rel *Action) (*http.Request,
 }
 
 func (a *adapterBase) doHTTP(t *Transfer, req *http.Request) (*http.Response, error) {
+	req = lfsapi.WithTransferAdapter(req, a.name)
 	if t.Authenticated {
 		return a.apiClient.Do(req)
 	}
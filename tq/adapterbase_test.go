@@ -0,0 +1,73 @@
+package tq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingTransferImpl blocks transfers to slow.example.com until released.
+type blockingTransferImpl struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (i *blockingTransferImpl) WorkerStarting(workerNum int) (interface{}, error) {
+	return nil, nil
+}
+
+func (i *blockingTransferImpl) WorkerEnding(workerNum int, ctx interface{}) {}
+
+func (i *blockingTransferImpl) DoTransfer(ctx interface{}, t *Transfer, cb ProgressCallback, authOkFunc func()) error {
+	if authOkFunc != nil {
+		authOkFunc()
+	}
+	if t.Actions["download"].Href == "https://slow.example.com/oid" {
+		close(i.started)
+		<-i.release
+	}
+	return nil
+}
+
+func TestAdapterBasePoolsWorkersByHost(t *testing.T) {
+	cli, err := lfsapi.NewClient(nil)
+	require.Nil(t, err)
+
+	impl := &blockingTransferImpl{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	a := newAdapterBase(nil, "test", Download, impl)
+	a.poolByHost = true
+	require.Nil(t, a.Begin(&adapterConfig{
+		apiClient:           cli,
+		concurrentTransfers: 1,
+	}, nil))
+
+	slow := a.Add(&Transfer{Oid: "slow", Actions: ActionSet{
+		"download": &Action{Href: "https://slow.example.com/oid"},
+	}})
+	<-impl.started
+
+	fast := a.Add(&Transfer{Oid: "fast", Actions: ActionSet{
+		"download": &Action{Href: "https://fast.example.com/oid"},
+	}})
+
+	select {
+	case res := <-fast:
+		assert.Equal(t, "fast", res.Transfer.Oid)
+		assert.Nil(t, res.Error)
+	case <-time.After(5 * time.Second):
+		t.Fatal("tq: transfer to fast host was held up by slow host")
+	}
+
+	close(impl.release)
+	res := <-slow
+	assert.Equal(t, "slow", res.Transfer.Oid)
+
+	a.End()
+	assert.Equal(t, 2, a.nextWorker)
+}
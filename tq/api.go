@@ -1,10 +1,15 @@
 package tq
 
 import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/git-lfs/git-lfs/tools"
 	"github.com/rubyist/tracerx"
 )
 
@@ -14,27 +19,201 @@ type tqClient struct {
 }
 
 type batchRequest struct {
-	Operation            string      `json:"operation"`
-	Objects              []*Transfer `json:"objects"`
-	TransferAdapterNames []string    `json:"transfers,omitempty"`
+	Operation            string       `json:"operation"`
+	Objects              []*Transfer  `json:"objects"`
+	TransferAdapterNames []string     `json:"transfers,omitempty"`
+	Source               *batchSource `json:"source,omitempty"`
+	// HashAlgorithm is the algorithm which named the objects, left out
+	// when it is the default, sha256.
+	HashAlgorithm string `json:"hash_algo,omitempty"`
 }
 
 type BatchResponse struct {
 	Objects             []*Transfer `json:"objects"`
 	TransferAdapterName string      `json:"transfer"`
+	HashAlgorithm       string      `json:"hash_algo,omitempty"`
 	endpoint            lfsapi.Endpoint
 }
 
+// Batch requests the actions to transfer the given objects. Objects named by
+// different hash algorithms are requested separately, since a batch request
+// names a single algorithm. If the server does not support one of them, its
+// objects fail on their own, and the others are still transferred: objects
+// can't fall back to another algorithm, since their pointers name them by
+// theirs.
 func Batch(m *Manifest, dir Direction, remote string, objects []*Transfer) (*BatchResponse, error) {
 	if len(objects) == 0 {
 		return &BatchResponse{}, nil
 	}
 
-	return m.batchClient().Batch(remote, &batchRequest{
-		Operation:            dir.String(),
-		Objects:              objects,
-		TransferAdapterNames: m.GetAdapterNames(dir),
-	})
+	algos := make([]string, 0, 1)
+	byAlgo := make(map[string][]*Transfer)
+	for _, t := range objects {
+		algo := tools.HashAlgoForOid(t.Oid)
+		if _, ok := byAlgo[algo]; !ok {
+			algos = append(algos, algo)
+		}
+		byAlgo[algo] = append(byAlgo[algo], t)
+	}
+
+	var res *BatchResponse
+	var declined []*Transfer
+	for _, algo := range algos {
+		bReq := &batchRequest{
+			Operation:            dir.String(),
+			Objects:              byAlgo[algo],
+			TransferAdapterNames: m.GetAdapterNames(dir),
+		}
+		if dir == Upload && len(m.forkSource) > 0 {
+			bReq.Source = &batchSource{Href: m.forkSource}
+		}
+		if algo != tools.HashAlgoSHA256 {
+			bReq.HashAlgorithm = algo
+		}
+
+		bRes, err := m.batch(remote, bReq)
+		if algoErr, ok := err.(*unsupportedHashAlgoError); ok {
+			tracerx.Printf("api error: %s", err)
+			declined = append(declined, declinedObjects(bReq.Objects, algoErr)...)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if res, err = mergeBatchResponses(res, bRes); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(declined) > 0 {
+		if res == nil {
+			res = &BatchResponse{}
+		}
+		res.Objects = append(res.Objects, declined...)
+	}
+	return res, nil
+}
+
+// declinedObjects returns a copy of each of the given objects, failed with the
+// given error, since the server does not support the algorithm naming them.
+func declinedObjects(objects []*Transfer, err *unsupportedHashAlgoError) []*Transfer {
+	declined := make([]*Transfer, 0, len(objects))
+	for _, t := range objects {
+		declined = append(declined, &Transfer{
+			Oid:  t.Oid,
+			Size: t.Size,
+			Error: &ObjectError{
+				Code:    http.StatusConflict,
+				Message: err.Error(),
+			},
+		})
+	}
+	return declined
+}
+
+// batch requests the actions for the objects in the given batch request. If
+// the server rejects it as too large, it is split in half, and the halves are
+// requested instead. Later requests with more objects than the server was
+// found to accept are split up front.
+func (m *Manifest) batch(remote string, bReq *batchRequest) (*BatchResponse, error) {
+	if limit := m.knownBatchLimit(); limit > 0 && len(bReq.Objects) > limit {
+		return m.splitBatch(remote, bReq, limit)
+	}
+
+	bRes, err := m.batchClient().Batch(remote, bReq)
+	if _, ok := err.(*batchTooLargeError); !ok || len(bReq.Objects) < 2 {
+		return bRes, err
+	}
+
+	half := (len(bReq.Objects) + 1) / 2
+	tracerx.Printf("tq: batch of %d objects too large, splitting into batches of %d", len(bReq.Objects), half)
+
+	m.limitBatch(half)
+	return m.splitBatch(remote, bReq, half)
+}
+
+// splitBatch requests the actions for the objects in the given batch request
+// in batch requests of at most the given number of objects, and merges the
+// responses.
+func (m *Manifest) splitBatch(remote string, bReq *batchRequest, size int) (*BatchResponse, error) {
+	var res *BatchResponse
+	for i := 0; i < len(bReq.Objects); i += size {
+		end := i + size
+		if end > len(bReq.Objects) {
+			end = len(bReq.Objects)
+		}
+
+		part := *bReq
+		part.Objects = bReq.Objects[i:end]
+
+		bRes, err := m.batch(remote, &part)
+		if err != nil {
+			return nil, err
+		}
+
+		if res, err = mergeBatchResponses(res, bRes); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// knownBatchLimit returns the number of objects in the largest batch request
+// the server is known to accept, or 0 if it is not known to limit them.
+func (m *Manifest) knownBatchLimit() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.batchLimit
+}
+
+// limitBatch records that the server rejects batch requests with more than
+// the given number of objects.
+func (m *Manifest) limitBatch(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.batchLimit == 0 || n < m.batchLimit {
+		m.batchLimit = n
+	}
+}
+
+// mergeBatchResponses appends the objects of the batch response "next" to
+// those of "res", which is returned, or returns "next" if "res" is nil.
+func mergeBatchResponses(res, next *BatchResponse) (*BatchResponse, error) {
+	if res == nil {
+		return next, nil
+	}
+	if res.TransferAdapterName != next.TransferAdapterName {
+		return nil, errors.Errorf("tq: server chose transfer adapters %q and %q for the same batch", res.TransferAdapterName, next.TransferAdapterName)
+	}
+	res.Objects = append(res.Objects, next.Objects...)
+	return res, nil
+}
+
+// unsupportedHashAlgoError is returned by tqClient.Batch when the server does
+// not support the hash algorithm of a batch request: it responds with 409
+// Conflict, or with another algorithm.
+type unsupportedHashAlgoError struct {
+	requested string
+	answered  string
+}
+
+func (e *unsupportedHashAlgoError) Error() string {
+	if len(e.answered) == 0 {
+		return fmt.Sprintf("server does not support the %s hash algorithm", e.requested)
+	}
+	return fmt.Sprintf("server does not support the %s hash algorithm, it uses %s", e.requested, e.answered)
+}
+
+// batchTooLargeError is returned by tqClient.Batch when the server rejects a
+// batch request with 413 Request Entity Too Large.
+type batchTooLargeError struct {
+	err error
+}
+
+func (e *batchTooLargeError) Error() string {
+	return fmt.Sprintf("batch response: %s", e.err)
 }
 
 func (c *tqClient) Batch(remote string, bReq *batchRequest) (*BatchResponse, error) {
@@ -61,6 +240,12 @@ func (c *tqClient) Batch(remote string, bReq *batchRequest) (*BatchResponse, err
 	res, err := c.DoWithAuth(remote, lfsapi.WithRetries(req, c.MaxRetries))
 	if err != nil {
 		tracerx.Printf("api error: %s", err)
+		if res != nil && res.StatusCode == http.StatusRequestEntityTooLarge {
+			return nil, &batchTooLargeError{err: err}
+		}
+		if res != nil && res.StatusCode == http.StatusConflict && len(bReq.HashAlgorithm) > 0 {
+			return nil, &unsupportedHashAlgoError{requested: bReq.HashAlgorithm}
+		}
 		return nil, errors.Wrap(err, "batch response")
 	}
 
@@ -72,7 +257,29 @@ func (c *tqClient) Batch(remote string, bReq *batchRequest) (*BatchResponse, err
 		return nil, lfsapi.NewStatusCodeError(res)
 	}
 
+	// A server which does not know of the hash_algo field answers as
+	// if the objects were named by their SHA-256.
+	if requested, answered := hashAlgoOrDefault(bReq.HashAlgorithm), hashAlgoOrDefault(bRes.HashAlgorithm); requested != answered {
+		return nil, &unsupportedHashAlgoError{requested: requested, answered: answered}
+	}
+
+	requested := make(map[string]bool, len(bReq.Objects))
+	for _, t := range bReq.Objects {
+		requested[t.Oid] = true
+	}
+
+	algo := hashAlgoOrDefault(bRes.HashAlgorithm)
 	for _, obj := range bRes.Objects {
+		if obj != nil && !requested[obj.Oid] {
+			// The transfer queue reports objects which were not
+			// requested itself.
+			continue
+		}
+		if err := validateObject(obj, algo, bRes.TransferAdapterName); err != nil {
+			tracerx.Printf("api error: %s", err)
+			return nil, err
+		}
+
 		for _, a := range obj.Actions {
 			a.createdAt = requestedAt
 		}
@@ -80,3 +287,74 @@ func (c *tqClient) Batch(remote string, bReq *batchRequest) (*BatchResponse, err
 
 	return bRes, nil
 }
+
+// validateObject returns an *InvalidObjectError if the given object of a batch
+// response is malformed: if its OID is not a digest of the given hash
+// algorithm, its size is negative, or any of its actions has no href which the
+// given transfer adapter can use.
+func validateObject(obj *Transfer, algo, adapter string) error {
+	if obj == nil {
+		return &InvalidObjectError{Reason: "missing object"}
+	}
+
+	if !isValidOid(obj.Oid, algo) {
+		return &InvalidObjectError{Oid: obj.Oid, Reason: fmt.Sprintf("OID is not a %s digest", algo)}
+	}
+	if obj.Size < 0 {
+		return &InvalidObjectError{Oid: obj.Oid, Reason: fmt.Sprintf("invalid size (got: %d)", obj.Size)}
+	}
+
+	for _, actions := range []ActionSet{obj.Actions, obj.Links} {
+		for rel, a := range actions {
+			if a == nil {
+				return &InvalidObjectError{Oid: obj.Oid, Reason: fmt.Sprintf("missing %q action", rel)}
+			}
+			if err := validateHref(a.Href, adapter); err != nil {
+				return &InvalidObjectError{Oid: obj.Oid, Reason: fmt.Sprintf("%q action: %s", rel, err)}
+			}
+		}
+	}
+	return nil
+}
+
+// isValidOid returns whether the given OID is a hex-encoded digest of the
+// given hash algorithm.
+func isValidOid(oid, algo string) bool {
+	h, err := tools.NewContentHash(algo)
+	if err != nil || len(oid) != 2*h.Size() {
+		return false
+	}
+
+	_, err = hex.DecodeString(oid)
+	return err == nil
+}
+
+// validateHref returns an error if the given href is not an absolute URL, or,
+// for the transfer adapters built into Git LFS, which transfer objects over
+// HTTP, if it is not one they can make requests to (see httpRE). Custom
+// transfer adapters are given hrefs of any scheme.
+func validateHref(href, adapter string) error {
+	u, err := url.Parse(href)
+	if err != nil {
+		return errors.Errorf("invalid href %q", href)
+	}
+	if !u.IsAbs() {
+		return errors.Errorf("href %q is not an absolute URL", href)
+	}
+
+	switch adapter {
+	case "", BasicAdapterName, TusAdapterName:
+		if !httpRE.MatchString(href) {
+			return errors.Errorf("href %q is not an HTTP URL", href)
+		}
+	}
+	return nil
+}
+
+// hashAlgoOrDefault returns the given hash algorithm, or sha256 if it is empty.
+func hashAlgoOrDefault(algo string) string {
+	if len(algo) == 0 {
+		return tools.HashAlgoSHA256
+	}
+	return algo
+}
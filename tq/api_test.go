@@ -27,7 +27,7 @@ func TestAPIBatch(t *testing.T) {
 		}
 
 		assert.Equal(t, "POST", r.Method)
-		assert.Equal(t, "80", r.Header.Get("Content-Length"))
+		assert.Equal(t, "143", r.Header.Get("Content-Length"))
 
 		bodyLoader, body := gojsonschema.NewReaderLoader(r.Body)
 		bReq := &batchRequest{}
@@ -38,7 +38,7 @@ func TestAPIBatch(t *testing.T) {
 
 		assert.EqualValues(t, []string{"basic", "whatev"}, bReq.TransferAdapterNames)
 		if assert.Equal(t, 1, len(bReq.Objects)) {
-			assert.Equal(t, "a", bReq.Objects[0].Oid)
+			assert.Equal(t, strings.Repeat("a", 64), bReq.Objects[0].Oid)
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -63,14 +63,14 @@ func TestAPIBatch(t *testing.T) {
 	bReq := &batchRequest{
 		TransferAdapterNames: []string{"basic", "whatev"},
 		Objects: []*Transfer{
-			&Transfer{Oid: "a", Size: 1},
+			&Transfer{Oid: strings.Repeat("a", 64), Size: 1},
 		},
 	}
 	bRes, err := tqc.Batch("remote", bReq)
 	require.Nil(t, err)
 	assert.Equal(t, "basic", bRes.TransferAdapterName)
 	if assert.Equal(t, 1, len(bRes.Objects)) {
-		assert.Equal(t, "a", bRes.Objects[0].Oid)
+		assert.Equal(t, strings.Repeat("a", 64), bRes.Objects[0].Oid)
 	}
 }
 
@@ -95,7 +95,7 @@ func TestAPIBatchOnlyBasic(t *testing.T) {
 
 		assert.Equal(t, 0, len(bReq.TransferAdapterNames))
 		if assert.Equal(t, 1, len(bReq.Objects)) {
-			assert.Equal(t, "a", bReq.Objects[0].Oid)
+			assert.Equal(t, strings.Repeat("a", 64), bReq.Objects[0].Oid)
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -119,7 +119,7 @@ func TestAPIBatchOnlyBasic(t *testing.T) {
 	bReq := &batchRequest{
 		TransferAdapterNames: []string{"basic"},
 		Objects: []*Transfer{
-			&Transfer{Oid: "a", Size: 1},
+			&Transfer{Oid: strings.Repeat("a", 64), Size: 1},
 		},
 	}
 	bRes, err := tqc.Batch("remote", bReq)
@@ -186,3 +186,280 @@ func assertSchema(t *testing.T, schema *sourcedSchema, dataLoader gojsonschema.J
 		t.Errorf("Schema: %s\n%s", schema.Source, strings.Join(valErrors, "\n"))
 	}
 }
+
+func TestAPIBatchHashAlgo(t *testing.T) {
+	sha256Oid := strings.Repeat("a", 64)
+	sha512Oid := strings.Repeat("b", 128)
+
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bReq := &batchRequest{}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(bReq))
+		requested = append(requested, bReq.HashAlgorithm)
+
+		for _, o := range bReq.Objects {
+			if bReq.HashAlgorithm == "sha512" {
+				assert.Equal(t, sha512Oid, o.Oid)
+			} else {
+				assert.Equal(t, sha256Oid, o.Oid)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&BatchResponse{
+			TransferAdapterName: "basic",
+			HashAlgorithm:       bReq.HashAlgorithm,
+			Objects:             bReq.Objects,
+		})
+	}))
+	defer srv.Close()
+
+	cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/repo",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, cli, "download", "origin")
+	bRes, err := Batch(m, Download, "origin", []*Transfer{
+		{Oid: sha256Oid, Size: 1},
+		{Oid: sha512Oid, Size: 2},
+	})
+	require.Nil(t, err)
+
+	assert.Equal(t, []string{"", "sha512"}, requested)
+	assert.Equal(t, "basic", bRes.TransferAdapterName)
+	if assert.Equal(t, 2, len(bRes.Objects)) {
+		assert.Equal(t, sha256Oid, bRes.Objects[0].Oid)
+		assert.Equal(t, sha512Oid, bRes.Objects[1].Oid)
+	}
+}
+
+func TestAPIBatchHashAlgoUnsupported(t *testing.T) {
+	sha256Oid := strings.Repeat("a", 64)
+	sha512Oid := strings.Repeat("b", 128)
+
+	for desc, decline := range map[string]func(w http.ResponseWriter, bReq *batchRequest){
+		"without hash_algo": func(w http.ResponseWriter, bReq *batchRequest) {
+			// Answer as a server which does not know of hash_algo.
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(&BatchResponse{Objects: bReq.Objects})
+		},
+		"409": func(w http.ResponseWriter, bReq *batchRequest) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(`{"message":"unsupported hash algorithm"}`))
+		},
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bReq := &batchRequest{}
+			require.Nil(t, json.NewDecoder(r.Body).Decode(bReq))
+
+			if bReq.HashAlgorithm == "sha512" {
+				decline(w, bReq)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(&BatchResponse{
+				TransferAdapterName: "basic",
+				Objects:             bReq.Objects,
+			})
+		}))
+
+		cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
+			"lfs.url": srv.URL + "/repo",
+		}))
+		require.Nil(t, err)
+
+		// The objects named by SHA-256 are still transferred, and only
+		// those named by SHA-512 fail.
+		m := NewManifest(nil, cli, "upload", "origin")
+		bRes, err := Batch(m, Upload, "origin", []*Transfer{
+			{Oid: sha256Oid, Size: 1},
+			{Oid: sha512Oid, Size: 2},
+		})
+		srv.Close()
+		require.Nil(t, err, desc)
+
+		assert.Equal(t, "basic", bRes.TransferAdapterName, desc)
+		if assert.Equal(t, 2, len(bRes.Objects), desc) {
+			assert.Equal(t, sha256Oid, bRes.Objects[0].Oid, desc)
+			assert.Nil(t, bRes.Objects[0].Error, desc)
+
+			assert.Equal(t, sha512Oid, bRes.Objects[1].Oid, desc)
+			assert.EqualValues(t, 2, bRes.Objects[1].Size, desc)
+			if assert.NotNil(t, bRes.Objects[1].Error, desc) {
+				assert.Equal(t, http.StatusConflict, bRes.Objects[1].Error.Code, desc)
+				assert.Contains(t, bRes.Objects[1].Error.Message, "server does not support the sha512 hash algorithm", desc)
+			}
+		}
+	}
+}
+
+func TestAPIBatchSplitsBatchesTooLarge(t *testing.T) {
+	var sizes []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bReq := &batchRequest{}
+		require.Nil(t, json.NewDecoder(r.Body).Decode(bReq))
+		sizes = append(sizes, len(bReq.Objects))
+
+		if len(bReq.Objects) > 2 {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&BatchResponse{
+			TransferAdapterName: "basic",
+			Objects:             bReq.Objects,
+		})
+	}))
+	defer srv.Close()
+
+	cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/repo",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, cli, "download", "origin")
+	objects := []*Transfer{
+		{Oid: strings.Repeat("a", 64), Size: 1},
+		{Oid: strings.Repeat("b", 64), Size: 1},
+		{Oid: strings.Repeat("c", 64), Size: 1},
+		{Oid: strings.Repeat("d", 64), Size: 1},
+		{Oid: strings.Repeat("e", 64), Size: 1},
+	}
+
+	bRes, err := Batch(m, Download, "origin", objects)
+	require.Nil(t, err)
+
+	// 5 objects are split into 3 and 2, and 3 into 2 and 1.
+	assert.Equal(t, []int{5, 3, 2, 1, 2}, sizes)
+	if assert.Equal(t, 5, len(bRes.Objects)) {
+		for i, o := range bRes.Objects {
+			assert.Equal(t, objects[i].Oid, o.Oid)
+		}
+	}
+
+	// Later batches are split up front.
+	sizes = nil
+	_, err = Batch(m, Download, "origin", objects[:4])
+	require.Nil(t, err)
+	assert.Equal(t, []int{2, 2}, sizes)
+}
+
+func TestAPIBatchSingleObjectTooLarge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	}))
+	defer srv.Close()
+
+	cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/repo",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, cli, "download", "origin")
+	_, err = Batch(m, Download, "origin", []*Transfer{{Oid: "a", Size: 1}})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "batch response")
+		assert.Contains(t, err.Error(), "413")
+	}
+}
+
+func TestAPIBatchRejectsInvalidObjects(t *testing.T) {
+	oid := strings.Repeat("a", 64)
+
+	for desc, obj := range map[string]*Transfer{
+		"OID is not a sha256 digest": {Oid: "../../a", Size: 1},
+		"invalid size (got: -1)":     {Oid: oid, Size: -1},
+		`"download" action: href "file:///etc/passwd" is not an HTTP URL`: {
+			Oid: oid, Size: 1,
+			Actions: ActionSet{"download": {Href: "file:///etc/passwd"}},
+		},
+		`"download" action: href "/relative" is not an absolute URL`: {
+			Oid: oid, Size: 1,
+			Actions: ActionSet{"download": {Href: "/relative"}},
+		},
+		`missing "download" action`: {
+			Oid: oid, Size: 1,
+			Actions: ActionSet{"download": nil},
+		},
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(&BatchResponse{
+				TransferAdapterName: "basic",
+				Objects:             []*Transfer{obj},
+			})
+		}))
+
+		cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
+			"lfs.url": srv.URL + "/repo",
+		}))
+		require.Nil(t, err)
+
+		m := NewManifest(nil, cli, "download", "origin")
+		_, err = Batch(m, Download, "origin", []*Transfer{{Oid: obj.Oid, Size: 1}})
+		srv.Close()
+
+		if assert.IsType(t, &InvalidObjectError{}, err, desc) {
+			assert.Equal(t, obj.Oid, err.(*InvalidObjectError).Oid, desc)
+			assert.Equal(t, desc, err.(*InvalidObjectError).Reason)
+		}
+	}
+}
+
+func TestAPIBatchAllowsAnyHrefForCustomAdapters(t *testing.T) {
+	oid := strings.Repeat("a", 64)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&BatchResponse{
+			TransferAdapterName: "custom",
+			Objects: []*Transfer{{
+				Oid: oid, Size: 1,
+				Actions: ActionSet{"download": {Href: "s3://bucket/" + oid}},
+			}},
+		})
+	}))
+	defer srv.Close()
+
+	cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/repo",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, cli, "download", "origin")
+	bRes, err := Batch(m, Download, "origin", []*Transfer{{Oid: oid, Size: 1}})
+	require.Nil(t, err)
+	assert.Equal(t, "custom", bRes.TransferAdapterName)
+}
+
+func TestAPIBatchAllowsUnixSocketHrefs(t *testing.T) {
+	oid := strings.Repeat("a", 64)
+	href := "http+unix:///var/run/lfs.sock:/storage/" + oid
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(&BatchResponse{
+			TransferAdapterName: "basic",
+			Objects: []*Transfer{{
+				Oid: oid, Size: 1,
+				Actions: ActionSet{"download": {Href: href}},
+			}},
+		})
+	}))
+	defer srv.Close()
+
+	cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
+		"lfs.url": srv.URL + "/repo",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, cli, "download", "origin")
+	bRes, err := Batch(m, Download, "origin", []*Transfer{{Oid: oid, Size: 1}})
+	require.Nil(t, err)
+	require.Len(t, bRes.Objects, 1)
+	assert.Equal(t, href, bRes.Objects[0].Actions["download"].Href)
+}
@@ -0,0 +1,86 @@
+package tq
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rubyist/tracerx"
+)
+
+// auditLog records each object that is transferred in an append-only local
+// file, so that it can later be determined when a machine obtained (or sent)
+// a given object. It is enabled with the "lfs.auditlog" configuration option.
+type auditLog struct {
+	path    string
+	command string
+
+	mu sync.Mutex
+}
+
+// auditEntry is a single line of the audit log.
+type auditEntry struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"`
+	Oid       string    `json:"oid"`
+	Size      int64     `json:"size"`
+	Name      string    `json:"name,omitempty"`
+	Remote    string    `json:"remote,omitempty"`
+	Command   string    `json:"command"`
+}
+
+// newAuditLog returns an auditLog writing to the given path, or nil if path is
+// empty.
+func newAuditLog(path string) *auditLog {
+	if len(path) == 0 {
+		return nil
+	}
+
+	// Only record the subcommand, and not its arguments, which may contain
+	// credentials in remote URLs.
+	command := filepath.Base(os.Args[0])
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		command = command + " " + os.Args[1]
+	}
+
+	return &auditLog{path: path, command: command}
+}
+
+// Record appends an entry for the transfer "t" to the audit log. Failures are
+// traced, but otherwise do not affect the transfer.
+func (l *auditLog) Record(dir Direction, remote string, t *Transfer) {
+	if l == nil {
+		return
+	}
+
+	by, err := json.Marshal(&auditEntry{
+		Time:      time.Now().UTC(),
+		Direction: dir.String(),
+		Oid:       t.Oid,
+		Size:      t.Size,
+		Name:      t.Name,
+		Remote:    remote,
+		Command:   l.command,
+	})
+	if err != nil {
+		tracerx.Printf("tq: unable to write audit log entry: %s", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		tracerx.Printf("tq: unable to open audit log %q: %s", l.path, err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(by, '\n')); err != nil {
+		tracerx.Printf("tq: unable to write audit log %q: %s", l.path, err)
+	}
+}
@@ -0,0 +1,57 @@
+package tq
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLogAppendsEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tq-audit")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+	l := newAuditLog(path)
+
+	l.Record(Download, "origin", &Transfer{Name: "a.dat", Oid: "oid1", Size: 1})
+	l.Record(Upload, "origin", &Transfer{Name: "b.dat", Oid: "oid2", Size: 2})
+
+	f, err := os.Open(path)
+	require.Nil(t, err)
+	defer f.Close()
+
+	var entries []*auditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e auditEntry
+		require.Nil(t, json.Unmarshal(scanner.Bytes(), &e))
+		entries = append(entries, &e)
+	}
+	require.Nil(t, scanner.Err())
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "download", entries[0].Direction)
+	assert.Equal(t, "oid1", entries[0].Oid)
+	assert.EqualValues(t, 1, entries[0].Size)
+	assert.Equal(t, "a.dat", entries[0].Name)
+	assert.Equal(t, "origin", entries[0].Remote)
+	assert.False(t, entries[0].Time.IsZero())
+
+	assert.Equal(t, "upload", entries[1].Direction)
+	assert.Equal(t, "oid2", entries[1].Oid)
+}
+
+func TestAuditLogDisabled(t *testing.T) {
+	l := newAuditLog("")
+	assert.Nil(t, l)
+
+	// Recording to a nil *auditLog is a no-op.
+	l.Record(Download, "origin", &Transfer{Oid: "oid"})
+}
@@ -4,19 +4,53 @@ import (
 	"fmt"
 	"hash"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/tools"
 	"github.com/rubyist/tracerx"
 )
 
+const (
+	// downloadLockAge is the age after which the lock of an incomplete
+	// download is assumed to have been left behind by a process which is
+	// no longer running, even if another process has since been given the
+	// same ID.
+	downloadLockAge = time.Hour
+
+	// downloadLockRefresh is how often the lock of a download in progress
+	// is touched, so that it is never older than downloadLockAge while
+	// data is still arriving, however long the download takes.
+	downloadLockRefresh = time.Minute
+)
+
 // Adapter for basic HTTP downloads, includes resuming via HTTP Range
 type basicDownloadAdapter struct {
 	*adapterBase
+
+	// compression is the Content-Encoding to ask for downloads to be
+	// compressed with, or "" not to ask for it, see transferCompression().
+	compression string
+	// compressionThreshold is the size below which objects are never asked
+	// to be compressed.
+	compressionThreshold int64
+	// compressionHints tell which objects are not worth asking to be
+	// compressed.
+	compressionHints *compressionHints
+}
+
+func (a *basicDownloadAdapter) Begin(cfg AdapterConfig, cb ProgressCallback) error {
+	a.compression, a.compressionThreshold = transferCompression(cfg.APIClient(), Download, cfg.Remote())
+	if len(a.compression) > 0 {
+		a.compressionHints = newCompressionHints(cfg.APIClient().GitEnv())
+	}
+	return a.adapterBase.Begin(cfg, cb)
 }
 
 func (a *basicDownloadAdapter) ClearTempStorage() error {
@@ -28,7 +62,7 @@ func (a *basicDownloadAdapter) tempDir() string {
 	// Also make local to this repo not global, and separate to localstorage temp,
 	// which gets cleared at the end of every invocation
 	d := filepath.Join(a.fs.LFSStorageDir, "incomplete")
-	if err := os.MkdirAll(d, 0755); err != nil {
+	if err := os.MkdirAll(tools.LongPath(d), 0755); err != nil {
 		return os.TempDir()
 	}
 	return d
@@ -41,11 +75,48 @@ func (a *basicDownloadAdapter) WorkerEnding(workerNum int, ctx interface{}) {
 }
 
 func (a *basicDownloadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb ProgressCallback, authOkFunc func()) error {
+	lock, err := a.lockDownload(t)
+	if err != nil {
+		return err
+	}
+	if len(lock) == 0 {
+		// Another process sharing the storage directory is downloading
+		// the same object, so download a copy of our own rather than
+		// writing to the same incomplete file.
+		f, err := ioutil.TempFile(a.tempDir(), t.Oid+"-")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+		return a.download(t, cb, authOkFunc, f, 0, nil)
+	}
+	defer os.Remove(lock)
+
 	f, fromByte, hashSoFar, err := a.checkResumeDownload(t)
 	if err != nil {
 		return err
 	}
-	return a.download(t, cb, authOkFunc, f, fromByte, hashSoFar)
+	return a.download(t, refreshLockCallback(lock, cb), authOkFunc, f, fromByte, hashSoFar)
+}
+
+// refreshLockCallback returns a ProgressCallback which calls "cb", touching the
+// download lock at the given path at most every downloadLockRefresh, so that
+// other processes don't take it as stale.
+func refreshLockCallback(lock string, cb ProgressCallback) ProgressCallback {
+	var refreshed time.Time
+	return func(name string, totalSize, readSoFar int64, readSinceLast int) error {
+		if now := time.Now(); now.Sub(refreshed) >= downloadLockRefresh {
+			if err := os.Chtimes(lock, now, now); err != nil {
+				tracerx.Printf("xfer: Could not refresh download lock %q: %v", lock, err)
+			}
+			refreshed = now
+		}
+
+		if cb != nil {
+			return cb(name, totalSize, readSoFar, readSinceLast)
+		}
+		return nil
+	}
 }
 
 // Checks to see if a download can be resumed, and if so returns a non-nil locked file, byte start and hash
@@ -62,21 +133,111 @@ func (a *basicDownloadAdapter) checkResumeDownload(t *Transfer) (outFile *os.Fil
 
 	// Successfully opened an existing file at this point
 	// Read any existing data into hash then return file handle at end
-	hash := tools.NewLfsContentHash()
+	hash := tools.NewLfsContentHashForOid(t.Oid)
 	n, err := io.Copy(hash, f)
 	if err != nil {
 		f.Close()
 		return nil, 0, nil, err
 	}
+	if n > t.Size {
+		// Longer than the object itself, so can't be a prefix of it.
+		tracerx.Printf("xfer: Discarding partial download of %q: %d bytes, expected %d", t.Oid, n, t.Size)
+		f.Close()
+		os.Remove(f.Name())
+		newfile, err := os.OpenFile(a.downloadFilename(t), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+		return newfile, 0, nil, err
+	}
 	tracerx.Printf("xfer: Attempting to resume download of %q from byte %d", t.Oid, n)
 	return f, n, hash, nil
 
 }
 
+// lockDownload marks the incomplete download of the given object as in use by
+// creating a lock file next to it, so that processes sharing the same storage
+// directory don't write to it at the same time. It returns the path of the
+// lock, or an empty string if another process holds it.
+//
+// The lock holds the host name and ID of the process which took it, so that a
+// lock left behind by a process which was interrupted, e.g. by a laptop going
+// to sleep, doesn't stop the next one from resuming the download.
+func (a *basicDownloadAdapter) lockDownload(t *Transfer) (string, error) {
+	path := a.downloadFilename(t) + ".lock"
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+	if os.IsExist(err) && isStaleDownloadLock(path) {
+		tracerx.Printf("xfer: Removing stale download lock %q", path)
+		os.Remove(path)
+		f, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+	}
+
+	if os.IsExist(err) {
+		tracerx.Printf("xfer: %q is already being downloaded by another process", t.Oid)
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	if _, err := f.WriteString(downloadLockOwner()); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// downloadLockOwner returns the contents of a download lock taken by this
+// process: the host name and process ID, separated by a space.
+func downloadLockOwner() string {
+	return fmt.Sprintf("%s %d", downloadLockHost(), os.Getpid())
+}
+
+func downloadLockHost() string {
+	host, err := os.Hostname()
+	if err != nil || len(host) == 0 {
+		return "localhost"
+	}
+	return host
+}
+
+// isStaleDownloadLock returns whether the process which took the download lock
+// at the given path no longer holds it: either because it is no longer
+// running, or because the lock is older than downloadLockAge, which it only
+// gets to be when no data has arrived for that long, see
+// refreshLockCallback().
+//
+// Whether the process is running can only be told for a lock taken on this
+// host; one taken on another host sharing the storage directory, e.g. over
+// NFS, is only stale by age, since its process ID means nothing here.
+func isStaleDownloadLock(path string) bool {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if time.Since(fi.ModTime()) > downloadLockAge {
+		return true
+	}
+
+	by, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	fields := strings.Fields(string(by))
+	if len(fields) != 2 || fields[0] != downloadLockHost() {
+		// Locks taken by older versions hold no host name, one being
+		// written hasn't got it yet, or it was taken on another host.
+		return false
+	}
+	pid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return false
+	}
+	return pid != os.Getpid() && !tools.ProcessExists(pid)
+}
+
 // Create or open a download file for resuming
 func (a *basicDownloadAdapter) downloadFilename(t *Transfer) string {
 	// Not a temp file since we will be resuming it
-	return filepath.Join(a.tempDir(), t.Oid+".tmp")
+	return tools.LongPath(filepath.Join(a.tempDir(), t.Oid+".tmp"))
 }
 
 // download starts or resumes and download. Always closes dlFile if non-nil
@@ -108,6 +269,11 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb ProgressCallback, authOk
 		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", fromByte, t.Size-1))
 	}
 
+	encoding := a.encodingFor(t, fromByte)
+	if len(encoding) > 0 {
+		req.Header.Set("Accept-Encoding", encoding)
+	}
+
 	req = a.apiClient.LogRequest(req, "lfs.data.download")
 	res, err := a.doHTTP(t, req)
 	if err != nil {
@@ -177,13 +343,21 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb ProgressCallback, authOk
 	}
 
 	var hasher *tools.HashingReader
-	httpReader := tools.NewRetriableReader(res.Body)
+	body := tools.NewRetriableReader(res.Body)
+	httpReader, err := decodedBody(body, res.Header.Get("Content-Encoding"), encoding)
+	if err != nil {
+		return errors.NewRetriableError(err)
+	}
+	decoded := httpReader != body
+	if decoded {
+		tracerx.Printf("xfer: decoding %s download of %q", encoding, t.Oid)
+	}
 
 	if fromByte > 0 && hash != nil {
 		// pre-load hashing reader with previous content
 		hasher = tools.NewHashingReaderPreloadHash(httpReader, hash)
 	} else {
-		hasher = tools.NewHashingReader(httpReader)
+		hasher = tools.NewHashingReaderForOid(httpReader, t.Oid)
 	}
 
 	if dlFile == nil {
@@ -202,7 +376,15 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb ProgressCallback, authOk
 		}
 		return nil
 	}
-	written, err := tools.CopyWithCallback(dlFile, hasher, res.ContentLength, ccb)
+	// Never write more than one byte past the expected size, so that a
+	// server sending the wrong content can't fill up the disk.
+	remaining := t.Size - fromByte
+	contentLength := res.ContentLength
+	if decoded {
+		// The length of a compressed body is not that of the object.
+		contentLength = remaining
+	}
+	written, err := tools.CopyWithCallback(dlFile, io.LimitReader(hasher, remaining+1), contentLength, ccb)
 	if err != nil {
 		return errors.Wrapf(err, "cannot write data to tempfile %q", dlfilename)
 	}
@@ -210,20 +392,46 @@ func (a *basicDownloadAdapter) download(t *Transfer, cb ProgressCallback, authOk
 		return fmt.Errorf("can't close tempfile %q: %v", dlfilename, err)
 	}
 
-	if actual := hasher.Hash(); actual != t.Oid {
-		return fmt.Errorf("Expected OID %s, got %s after %d bytes written", t.Oid, actual, written)
+	if written < remaining {
+		// Keep what was written, so that the retry can resume from it.
+		return errors.NewRetriableError(fmt.Errorf("Expected %d bytes for OID %s, got %d", t.Size, t.Oid, fromByte+written))
+	}
+
+	if actual := hasher.Hash(); written > remaining || actual != t.Oid {
+		// The content is corrupt, so don't resume from it.
+		os.Remove(dlfilename)
+		if written > remaining {
+			return errors.NewRetriableError(fmt.Errorf("Expected %d bytes for OID %s, got more", t.Size, t.Oid))
+		}
+		return errors.NewRetriableError(&HashMismatchError{Oid: t.Oid, Actual: actual, Written: written})
 	}
 
 	return tools.RenameFileCopyPermissions(dlfilename, t.Path)
 }
 
+// encodingFor returns the Content-Encoding to ask for the given transfer to be
+// compressed with, when downloading it from the given byte, or "" if it should
+// be sent as is. Resumed downloads are never compressed, since the range asked
+// for would be that of the compressed body, and neither are files matching
+// lfs.incompressiblefiles.
+func (a *basicDownloadAdapter) encodingFor(t *Transfer, fromByte int64) string {
+	if len(a.compression) == 0 || fromByte > 0 || t.Size < a.compressionThreshold {
+		return ""
+	}
+	if a.compressionHints.Incompressible(t.Name) {
+		return ""
+	}
+	return a.compression
+}
+
 func configureBasicDownloadAdapter(m *Manifest) {
 	m.RegisterNewAdapterFunc(BasicAdapterName, Download, func(name string, dir Direction) Adapter {
 		switch dir {
 		case Download:
-			bd := &basicDownloadAdapter{newAdapterBase(m.fs, name, dir, nil)}
+			bd := &basicDownloadAdapter{adapterBase: newAdapterBase(m.fs, name, dir, nil)}
 			// self implements impl
 			bd.transferImpl = bd
+			bd.poolByHost = true
 			return bd
 		case Upload:
 			panic("Should never ask this func to upload")
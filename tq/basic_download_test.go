@@ -0,0 +1,76 @@
+package tq
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshLockCallback(t *testing.T) {
+	f, err := ioutil.TempFile("", "download-lock")
+	require.Nil(t, err)
+	f.Close()
+	defer os.Remove(f.Name())
+
+	old := time.Now().Add(-2 * downloadLockAge).Truncate(time.Second)
+	require.Nil(t, os.Chtimes(f.Name(), old, old))
+
+	var called int
+	cb := refreshLockCallback(f.Name(), func(name string, totalSize, readSoFar int64, readSinceLast int) error {
+		called++
+		return nil
+	})
+	require.Nil(t, cb("a.dat", 10, 5, 5))
+
+	fi, err := os.Stat(f.Name())
+	require.Nil(t, err)
+	assert.True(t, time.Since(fi.ModTime()) < downloadLockAge)
+	assert.Equal(t, 1, called)
+
+	// Not touched again until downloadLockRefresh has passed.
+	require.Nil(t, os.Chtimes(f.Name(), old, old))
+	require.Nil(t, cb("a.dat", 10, 10, 5))
+
+	fi, err = os.Stat(f.Name())
+	require.Nil(t, err)
+	assert.True(t, fi.ModTime().Equal(old))
+	assert.Equal(t, 2, called)
+}
+
+func TestIsStaleDownloadLockComparesHost(t *testing.T) {
+	f, err := ioutil.TempFile("", "download-lock")
+	require.Nil(t, err)
+	f.Close()
+	defer os.Remove(f.Name())
+
+	// No process has this ID, since it is above the largest one Linux and
+	// Windows hand out.
+	const gone = 1 << 30
+
+	write := func(contents string) {
+		require.Nil(t, ioutil.WriteFile(f.Name(), []byte(contents), 0644))
+	}
+
+	write(fmt.Sprintf("%s %d", downloadLockHost(), gone))
+	assert.True(t, isStaleDownloadLock(f.Name()))
+
+	write(downloadLockOwner())
+	assert.False(t, isStaleDownloadLock(f.Name()))
+
+	write(fmt.Sprintf("%s-elsewhere %d", downloadLockHost(), gone))
+	assert.False(t, isStaleDownloadLock(f.Name()))
+
+	write(fmt.Sprintf("%d", gone))
+	assert.False(t, isStaleDownloadLock(f.Name()))
+
+	// Locks from other hosts are still stale by age.
+	write(fmt.Sprintf("%s-elsewhere %d", downloadLockHost(), gone))
+	old := time.Now().Add(-2 * downloadLockAge)
+	require.Nil(t, os.Chtimes(f.Name(), old, old))
+	assert.True(t, isStaleDownloadLock(f.Name()))
+}
@@ -7,8 +7,11 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 
+	"github.com/git-lfs/git-lfs/config"
 	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/fs"
 	"github.com/git-lfs/git-lfs/lfsapi"
 	"github.com/git-lfs/git-lfs/tools"
 )
@@ -20,6 +23,33 @@ const (
 // Adapter for basic uploads (non resumable)
 type basicUploadAdapter struct {
 	*adapterBase
+
+	// compression is the Content-Encoding to compress uploads with, or ""
+	// not to compress them, see transferCompression().
+	compression string
+	// compressionThreshold is the size below which objects are never
+	// compressed.
+	compressionThreshold int64
+	// compressionHints tell which objects compress well without sampling
+	// them.
+	compressionHints *compressionHints
+	// compressionRejected is non-zero once the server has responded to a
+	// compressed upload with 415 Unsupported Media Type. It is shared by
+	// every adapter created from the same Manifest, since a new adapter
+	// may be started for each batch.
+	compressionRejected *int32
+	// detectContentType is whether to send the detected content type of
+	// each object, rather than application/octet-stream.
+	detectContentType bool
+}
+
+func (a *basicUploadAdapter) Begin(cfg AdapterConfig, cb ProgressCallback) error {
+	a.compression, a.compressionThreshold = transferCompression(cfg.APIClient(), Upload, cfg.Remote())
+	if len(a.compression) > 0 {
+		a.compressionHints = newCompressionHints(cfg.APIClient().GitEnv())
+	}
+	a.detectContentType = uploadContentTypeDetection(cfg.APIClient(), cfg.Remote())
+	return a.adapterBase.Begin(cfg, cb)
 }
 
 func (a *basicUploadAdapter) ClearTempStorage() error {
@@ -57,33 +87,55 @@ func (a *basicUploadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progres
 	}
 
 	if len(req.Header.Get("Content-Type")) == 0 {
-		req.Header.Set("Content-Type", "application/octet-stream")
-	}
-
-	if req.Header.Get("Transfer-Encoding") == "chunked" {
-		req.TransferEncoding = []string{"chunked"}
-	} else {
-		req.Header.Set("Content-Length", strconv.FormatInt(t.Size, 10))
+		req.Header.Set("Content-Type", a.contentTypeFor(t))
 	}
 
-	req.ContentLength = t.Size
-
 	f, err := os.OpenFile(t.Path, os.O_RDONLY, 0644)
 	if err != nil {
 		return errors.Wrap(err, "basic upload")
 	}
 	defer f.Close()
 
+	body, size := f, t.Size
+	encoding := a.encodingFor(t)
+	if len(encoding) > 0 {
+		gz, gzSize, err := gzipFile(t.Path, a.tempDir())
+		if err != nil {
+			return errors.Wrap(err, "basic upload")
+		}
+		defer os.Remove(gz.Name())
+		defer gz.Close()
+
+		if gzSize < t.Size {
+			a.Trace("xfer: compressing upload of %q with %s: %d to %d bytes", t.Oid, encoding, t.Size, gzSize)
+			req.Header.Set("Content-Encoding", encoding)
+			body, size = gz, gzSize
+		} else {
+			encoding = ""
+		}
+	}
+
+	if req.Header.Get("Transfer-Encoding") == "chunked" {
+		req.TransferEncoding = []string{"chunked"}
+	} else {
+		req.Header.Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+
+	req.ContentLength = size
+
 	// Ensure progress callbacks made while uploading
 	// Wrap callback to give name context
-	ccb := func(totalSize int64, readSoFar int64, readSinceLast int) error {
+	var ccb tools.CopyCallback = func(totalSize int64, readSoFar int64, readSinceLast int) error {
 		if cb != nil {
 			return cb(t.Name, totalSize, readSoFar, readSinceLast)
 		}
 		return nil
 	}
+	if size != t.Size {
+		ccb = scaledCopyCallback(ccb, t.Size, size)
+	}
 
-	cbr := tools.NewBodyWithCallback(f, t.Size, ccb)
+	cbr := tools.NewBodyWithCallback(body, size, ccb)
 	var reader lfsapi.ReadSeekCloser = cbr
 
 	// Signal auth was ok on first read; this frees up other workers to start
@@ -98,6 +150,13 @@ func (a *basicUploadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progres
 
 	req = a.apiClient.LogRequest(req, "lfs.data.upload")
 	res, err := a.doHTTP(t, req)
+	if err != nil && len(encoding) > 0 && res != nil && res.StatusCode == 415 {
+		// The server doesn't accept compressed uploads, so send this
+		// object, and any others, uncompressed.
+		a.Trace("xfer: server rejected %s upload of %q, not compressing uploads", encoding, t.Oid)
+		atomic.StoreInt32(a.compressionRejected, 1)
+		err = errors.Errorf("server does not accept %s uploads", encoding)
+	}
 	if err != nil {
 		// We're about to return a retriable error, meaning that this
 		// transfer will either be retried, or it will fail.
@@ -133,6 +192,42 @@ func (a *basicUploadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb Progres
 	return verifyUpload(a.apiClient, a.remote, t)
 }
 
+// contentTypeFor returns the Content-Type to upload the given transfer with:
+// the content type recorded in its metadata, or else detected from its
+// contents, if lfs.contenttype is enabled, or application/octet-stream.
+func (a *basicUploadAdapter) contentTypeFor(t *Transfer) string {
+	if !a.detectContentType {
+		return tools.DefaultContentType
+	}
+
+	if ct := t.Metadata[fs.MetadataContentType]; len(ct) > 0 {
+		return ct
+	}
+	if a.fs != nil {
+		if md, err := a.fs.ReadObjectMetadata(t.Oid); err == nil && len(md[fs.MetadataContentType]) > 0 {
+			return md[fs.MetadataContentType]
+		}
+	}
+
+	ct, err := tools.FileContentType(t.Path, t.Name)
+	if err != nil {
+		return tools.DefaultContentType
+	}
+	return ct
+}
+
+// encodingFor returns the Content-Encoding to compress the given transfer with,
+// or "" if it should be sent as is.
+func (a *basicUploadAdapter) encodingFor(t *Transfer) string {
+	if len(a.compression) == 0 || atomic.LoadInt32(a.compressionRejected) != 0 {
+		return ""
+	}
+	if t.Size < a.compressionThreshold || !a.compressionHints.Compressible(t.Name, t.Path) {
+		return ""
+	}
+	return a.compression
+}
+
 // startCallbackReader is a reader wrapper which calls a function as soon as the
 // first Read() call is made. This callback is only made once
 type startCallbackReader struct {
@@ -157,13 +252,28 @@ func newStartCallbackReader(r lfsapi.ReadSeekCloser, cb func() error) *startCall
 	}
 }
 
+// uploadContentTypeDetection returns whether uploads to the given remote should
+// be sent with the detected content type of each object, according to
+// lfs.contenttype (or lfs.<url>.contenttype). It is disabled by default, as the
+// signature of pre-signed upload URLs can cover the Content-Type.
+func uploadContentTypeDetection(client *lfsapi.Client, remote string) bool {
+	ep := client.Endpoints.Endpoint("upload", remote)
+	return config.NewURLConfig(client.GitEnv()).Bool("lfs", ep.Url, "contenttype", false)
+}
+
 func configureBasicUploadAdapter(m *Manifest) {
+	var compressionRejected int32
+
 	m.RegisterNewAdapterFunc(BasicAdapterName, Upload, func(name string, dir Direction) Adapter {
 		switch dir {
 		case Upload:
-			bu := &basicUploadAdapter{newAdapterBase(m.fs, name, dir, nil)}
+			bu := &basicUploadAdapter{
+				adapterBase:         newAdapterBase(m.fs, name, dir, nil),
+				compressionRejected: &compressionRejected,
+			}
 			// self implements impl
 			bu.transferImpl = bu
+			bu.poolByHost = true
 			return bu
 		case Download:
 			panic("Should never ask this func for basic download")
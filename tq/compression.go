@@ -0,0 +1,221 @@
+package tq
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/git-lfs/git-lfs/config"
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/filepathfilter"
+	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/rubyist/tracerx"
+)
+
+const (
+	// gzipEncoding is the only Content-Encoding that transfers can
+	// currently be compressed with.
+	gzipEncoding = "gzip"
+
+	// defaultCompressionThreshold is the size, in bytes, below which
+	// objects are never compressed, since the savings would not be worth
+	// the extra work.
+	defaultCompressionThreshold = 64 * 1024
+
+	// compressionSampleSize is the number of bytes at the start of an
+	// object that are compressed to tell whether the object is
+	// compressible.
+	compressionSampleSize = 64 * 1024
+
+	// compressionMaxRatio is the largest compressed-to-original size ratio
+	// of an object's sample for the object to be considered compressible.
+	compressionMaxRatio = 0.8
+)
+
+// transferCompression returns the Content-Encoding to compress transfers in the
+// given direction to or from the given remote with, or "" if they should not be
+// compressed, and the size at or above which objects are considered for
+// compression, according to lfs.compression (or lfs.<url>.compression) and
+// lfs.compressionthreshold.
+func transferCompression(client *lfsapi.Client, dir Direction, remote string) (string, int64) {
+	ep := client.Endpoints.Endpoint(dir.String(), remote)
+	encoding, _ := config.NewURLConfig(client.GitEnv()).Get("lfs", ep.Url, "compression")
+
+	switch encoding {
+	case "", "none":
+		return "", 0
+	case gzipEncoding:
+	default:
+		tracerx.Printf("xfer: unsupported lfs.compression %q, not compressing %ss", encoding, dir)
+		return "", 0
+	}
+
+	threshold := int64(client.GitEnv().Int("lfs.compressionthreshold", defaultCompressionThreshold))
+	return encoding, threshold
+}
+
+// decodedBody returns a reader of the given response body with the
+// Content-Encoding of the response undone, if a compressed body was asked for
+// with the "accepted" encoding. Otherwise, the body is returned as it is, as it
+// was before downloads could be compressed. When one was asked for, encodings
+// other than the accepted one are an error, as the body could not be told
+// apart from the object's contents.
+func decodedBody(body io.Reader, contentEncoding, accepted string) (io.Reader, error) {
+	if len(accepted) == 0 {
+		return body, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "", "identity":
+		return body, nil
+	case accepted:
+		return gzip.NewReader(body)
+	default:
+		return nil, errors.Errorf("unsupported Content-Encoding %q", contentEncoding)
+	}
+}
+
+// compressionHints are the patterns of files known to compress well, or not at
+// all, configured with lfs.compressiblefiles and lfs.incompressiblefiles. They
+// save sampling each object to tell whether to compress it.
+type compressionHints struct {
+	compressible   *filepathfilter.Filter
+	incompressible *filepathfilter.Filter
+}
+
+func newCompressionHints(gitEnv config.Environment) *compressionHints {
+	return &compressionHints{
+		compressible:   hintFilter(gitEnv, "lfs.compressiblefiles"),
+		incompressible: hintFilter(gitEnv, "lfs.incompressiblefiles"),
+	}
+}
+
+func hintFilter(gitEnv config.Environment, key string) *filepathfilter.Filter {
+	value, _ := gitEnv.Get(key)
+	patterns := tools.CleanPaths(value, ",")
+	if len(patterns) == 0 {
+		return nil
+	}
+	return filepathfilter.New(patterns, nil)
+}
+
+// Compressible returns whether the file at the given path, named "name" in the
+// repository, compresses well. Files matching lfs.incompressiblefiles never do,
+// and files matching lfs.compressiblefiles always do. Others are sampled, see
+// isCompressible().
+func (h *compressionHints) Compressible(name, path string) bool {
+	if h.Incompressible(name) {
+		return false
+	}
+	if h != nil && len(name) > 0 && h.compressible != nil && h.compressible.Allows(name) {
+		return true
+	}
+	return isCompressible(path)
+}
+
+// Incompressible returns whether the file named "name" in the repository
+// matches lfs.incompressiblefiles, and so is known not to compress well. It is
+// all that can be told of files which are yet to be downloaded, since there is
+// nothing to sample.
+func (h *compressionHints) Incompressible(name string) bool {
+	return h != nil && len(name) > 0 && h.incompressible != nil && h.incompressible.Allows(name)
+}
+
+// isCompressible returns whether the contents of the file at the given path
+// compress well, judging by a sample from the start of the file.
+func isCompressible(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	counter := &countingWriter{}
+	gz, err := gzip.NewWriterLevel(counter, gzip.BestSpeed)
+	if err != nil {
+		return false
+	}
+
+	n, err := io.CopyN(gz, f, compressionSampleSize)
+	if err != nil && err != io.EOF {
+		return false
+	}
+	if err := gz.Close(); err != nil || n == 0 {
+		return false
+	}
+
+	return float64(counter.n)/float64(n) <= compressionMaxRatio
+}
+
+// gzipFile writes a gzip-compressed copy of the file at the given path to a
+// temporary file in dir, returning it open and positioned at its start,
+// along with its size. The caller is responsible for closing and removing it.
+func gzipFile(path, dir string) (*os.File, int64, error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer src.Close()
+
+	tmp, err := ioutil.TempFile(dir, "gzip-")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	gz := gzip.NewWriter(tmp)
+	_, err = io.Copy(gz, src)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+
+	var size int64
+	if err == nil {
+		size, err = tmp.Seek(0, io.SeekCurrent)
+	}
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, 0, err
+	}
+	return tmp, size, nil
+}
+
+// scaledCopyCallback returns a tools.CopyCallback reporting progress through
+// "cb" in terms of "size" bytes, while "actual" bytes are really copied. This
+// keeps the progress meter accurate for objects sent compressed.
+func scaledCopyCallback(cb tools.CopyCallback, size, actual int64) tools.CopyCallback {
+	var reported int64
+	return func(totalSize int64, readSoFar int64, readSinceLast int) error {
+		if readSinceLast < 0 {
+			// Progress is being reset, see
+			// tools.BodyWithCallback.ResetProgress().
+			since := reported
+			reported = 0
+			return cb(size, since, -int(since))
+		}
+
+		scaled := size
+		if actual > 0 && readSoFar < actual {
+			scaled = readSoFar * size / actual
+		}
+
+		since := scaled - reported
+		reported = scaled
+		return cb(size, scaled, int(since))
+	}
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
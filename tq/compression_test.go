@@ -0,0 +1,193 @@
+package tq
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUploadCompressionDisabledByDefault(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
+		"lfs.url": "http://foo",
+	}))
+	require.Nil(t, err)
+
+	encoding, _ := transferCompression(cli, Upload, "origin")
+	assert.Equal(t, "", encoding)
+}
+
+func TestUploadCompressionPerURL(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
+		"lfs.url":                    "http://foo",
+		"lfs.http://foo.compression": "gzip",
+		"lfs.compressionthreshold":   "1024",
+	}))
+	require.Nil(t, err)
+
+	encoding, threshold := transferCompression(cli, Upload, "origin")
+	assert.Equal(t, "gzip", encoding)
+	assert.EqualValues(t, 1024, threshold)
+}
+
+func TestUploadCompressionIgnoresUnsupportedEncodings(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
+		"lfs.url":         "http://foo",
+		"lfs.compression": "zstd",
+	}))
+	require.Nil(t, err)
+
+	encoding, _ := transferCompression(cli, Upload, "origin")
+	assert.Equal(t, "", encoding)
+}
+
+func TestIsCompressible(t *testing.T) {
+	dir, err := ioutil.TempDir("", "compression")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	text := filepath.Join(dir, "text")
+	require.Nil(t, ioutil.WriteFile(text, bytes.Repeat([]byte("hello world\n"), 10000), 0644))
+
+	random := make([]byte, 128*1024)
+	_, err = rand.Read(random)
+	require.Nil(t, err)
+	noise := filepath.Join(dir, "noise")
+	require.Nil(t, ioutil.WriteFile(noise, random, 0644))
+
+	assert.True(t, isCompressible(text))
+	assert.False(t, isCompressible(noise))
+	assert.False(t, isCompressible(filepath.Join(dir, "missing")))
+}
+
+func TestCompressionHints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "compression")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	text := filepath.Join(dir, "text")
+	require.Nil(t, ioutil.WriteFile(text, bytes.Repeat([]byte("hello world\n"), 10000), 0644))
+
+	random := make([]byte, 128*1024)
+	_, err = rand.Read(random)
+	require.Nil(t, err)
+	noise := filepath.Join(dir, "noise")
+	require.Nil(t, ioutil.WriteFile(noise, random, 0644))
+
+	cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
+		"lfs.compressiblefiles":   "*.exr",
+		"lfs.incompressiblefiles": "*.mp4, media/",
+	}))
+	require.Nil(t, err)
+
+	h := newCompressionHints(cli.GitEnv())
+
+	// Hints are taken at their word, without sampling.
+	assert.True(t, h.Compressible("render/a.exr", noise))
+	assert.False(t, h.Compressible("video/a.mp4", text))
+	assert.False(t, h.Compressible("media/a.txt", text))
+
+	// Other files, and those without a name, are sampled.
+	assert.True(t, h.Compressible("a.txt", text))
+	assert.False(t, h.Compressible("a.bin", noise))
+	assert.True(t, h.Compressible("", text))
+
+	var none *compressionHints
+	assert.True(t, none.Compressible("a.mp4", text))
+	assert.False(t, none.Incompressible("a.mp4"))
+}
+
+func TestScaledCopyCallback(t *testing.T) {
+	var reported []int64
+	var since []int
+	cb := scaledCopyCallback(func(total, read int64, current int) error {
+		assert.EqualValues(t, 1000, total)
+		reported = append(reported, read)
+		since = append(since, current)
+		return nil
+	}, 1000, 100)
+
+	require.Nil(t, cb(100, 50, 50))
+	require.Nil(t, cb(100, 100, 50))
+	require.Nil(t, cb(100, 0, -100))
+
+	assert.Equal(t, []int64{500, 1000, 1000}, reported)
+	assert.Equal(t, []int{500, 500, -1000}, since)
+}
+
+func TestDownloadCompressionPerURL(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
+		"lfs.url":                    "http://foo",
+		"lfs.http://foo.compression": "gzip",
+	}))
+	require.Nil(t, err)
+
+	encoding, threshold := transferCompression(cli, Download, "origin")
+	assert.Equal(t, "gzip", encoding)
+	assert.EqualValues(t, defaultCompressionThreshold, threshold)
+}
+
+func TestDownloadEncodingFor(t *testing.T) {
+	a := &basicDownloadAdapter{compression: "gzip", compressionThreshold: 1024}
+
+	assert.Equal(t, "gzip", a.encodingFor(&Transfer{Size: 2048}, 0))
+	assert.Equal(t, "", a.encodingFor(&Transfer{Size: 512}, 0))
+	assert.Equal(t, "", a.encodingFor(&Transfer{Size: 2048}, 1024))
+
+	a.compression = ""
+	assert.Equal(t, "", a.encodingFor(&Transfer{Size: 2048}, 0))
+}
+
+func TestDownloadEncodingForHints(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
+		"lfs.compressiblefiles":   "*.exr, *.mp4",
+		"lfs.incompressiblefiles": "*.mp4",
+	}))
+	require.Nil(t, err)
+
+	a := &basicDownloadAdapter{
+		compression:          "gzip",
+		compressionThreshold: 1024,
+		compressionHints:     newCompressionHints(cli.GitEnv()),
+	}
+
+	assert.Equal(t, "", a.encodingFor(&Transfer{Name: "a.mp4", Size: 2048}, 0))
+	assert.Equal(t, "gzip", a.encodingFor(&Transfer{Name: "a.exr", Size: 2048}, 0))
+	assert.Equal(t, "gzip", a.encodingFor(&Transfer{Name: "a.dat", Size: 2048}, 0))
+}
+
+func TestDecodedBody(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte("hello world"))
+	require.Nil(t, gz.Close())
+
+	r, err := decodedBody(&compressed, "gzip", "gzip")
+	require.Nil(t, err)
+	decoded, err := ioutil.ReadAll(r)
+	require.Nil(t, err)
+	assert.Equal(t, "hello world", string(decoded))
+
+	plain := strings.NewReader("hello world")
+	r, err = decodedBody(plain, "", "gzip")
+	require.Nil(t, err)
+	assert.Equal(t, plain, r)
+
+	// Bodies are passed on as they are when no compression was asked
+	// for, whatever their Content-Encoding.
+	plain = strings.NewReader("hello world")
+	r, err = decodedBody(plain, "br", "")
+	require.Nil(t, err)
+	assert.Equal(t, plain, r)
+
+	_, err = decodedBody(strings.NewReader("hello world"), "br", "gzip")
+	assert.NotNil(t, err)
+}
@@ -27,3 +27,42 @@ func (e MalformedObjectError) Error() string {
 	}
 	return fmt.Sprintf("missing object: %s (%s)", e.Name, e.Oid)
 }
+
+// InvalidObjectError is returned when a batch response gives an object which is
+// malformed, naming the object by its OID and giving what is wrong with it.
+type InvalidObjectError struct {
+	Oid    string
+	Reason string
+}
+
+func (e *InvalidObjectError) Error() string {
+	return fmt.Sprintf("batch response: invalid object %q: %s", e.Oid, e.Reason)
+}
+
+// HashMismatchError is returned when the content transferred for an object
+// does not hash to its OID.
+type HashMismatchError struct {
+	Oid     string
+	Actual  string
+	Written int64
+}
+
+func (e *HashMismatchError) Error() string {
+	return fmt.Sprintf("Expected OID %s, got %s after %d bytes written", e.Oid, e.Actual, e.Written)
+}
+
+// deadlineExceededError is returned for a transfer that was not started, or
+// was cancelled, because the deadline given to the TransferQueue (see
+// WithDeadline) had passed.
+type deadlineExceededError struct {
+	Oid string
+}
+
+func (e *deadlineExceededError) Error() string {
+	return fmt.Sprintf("deadline exceeded before finishing transferring %s", e.Oid)
+}
+
+func isDeadlineExceededError(err error) bool {
+	_, ok := err.(*deadlineExceededError)
+	return ok
+}
@@ -0,0 +1,243 @@
+package tq
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/git-lfs/git-lfs/config"
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/fs"
+	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/git-lfs/git-lfs/tools"
+	"github.com/rubyist/tracerx"
+)
+
+// existenceCache remembers which objects are known to be on the Git LFS server
+// that objects are being uploaded to, so that they can be left out of upload
+// batch requests altogether. It is enabled with the "lfs.existencecache"
+// configuration option.
+//
+// A cache may be shared, e.g. by every machine in a build farm, so it is only
+// ever told about objects that the server has said it has, or that have been
+// uploaded successfully.
+type existenceCache interface {
+	// Lookup returns those of the given OIDs that are known to be on the
+	// server.
+	Lookup(oids []string) (tools.StringSet, error)
+	// Record remembers that the given OIDs are on the server.
+	Record(oids []string) error
+}
+
+// newExistenceCache returns the existence cache configured for uploads to the
+// given remote, or nil if there is none.
+//
+// "local" keeps the cache in the local repository, a "file://" URL keeps it in
+// the given (possibly shared) directory, and an "http://" or "https://" URL
+// uses a cache service, see httpExistenceCache.
+func newExistenceCache(client *lfsapi.Client, f *fs.Filesystem, remote string) existenceCache {
+	ep := client.Endpoints.Endpoint("upload", remote)
+	if len(ep.Url) == 0 {
+		return nil
+	}
+
+	backend, _ := config.NewURLConfig(client.GitEnv()).Get("lfs", ep.Url, "existencecache")
+	switch {
+	case len(backend) == 0, backend == "none":
+		return nil
+	case backend == "local":
+		if f == nil {
+			return nil
+		}
+		return newFileExistenceCache(filepath.Join(f.LFSStorageDir, "cache", "exists"), ep.Url)
+	case strings.HasPrefix(backend, "file://"):
+		u, err := url.Parse(backend)
+		if err != nil {
+			tracerx.Printf("tq: invalid lfs.existencecache %q: %s", backend, err)
+			return nil
+		}
+		return newFileExistenceCache(filepath.FromSlash(u.Path), ep.Url)
+	case strings.HasPrefix(backend, "http://"), strings.HasPrefix(backend, "https://"):
+		return &httpExistenceCache{client: client, url: strings.TrimSuffix(backend, "/"), endpoint: existenceCacheKey(ep.Url)}
+	}
+
+	tracerx.Printf("tq: unsupported lfs.existencecache %q, not caching", backend)
+	return nil
+}
+
+// existenceCacheKey returns the key that objects on the server at the given
+// endpoint are cached under: the endpoint's URL, without any credentials.
+func existenceCacheKey(rawurl string) string {
+	return withoutCredentials(rawurl)
+}
+
+// fileExistenceCache is an existenceCache kept in a directory, with a file per
+// endpoint listing the OIDs known to be there, one per line.
+type fileExistenceCache struct {
+	path string
+
+	mu    sync.Mutex
+	known tools.StringSet
+}
+
+func newFileExistenceCache(dir, endpoint string) *fileExistenceCache {
+	sum := sha256.Sum256([]byte(existenceCacheKey(endpoint)))
+	return &fileExistenceCache{path: filepath.Join(dir, hex.EncodeToString(sum[:]))}
+}
+
+func (c *fileExistenceCache) Lookup(oids []string) (tools.StringSet, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.known == nil {
+		known, err := c.load()
+		if err != nil {
+			return nil, err
+		}
+		c.known = known
+	}
+
+	found := tools.NewStringSet()
+	for _, oid := range oids {
+		if c.known.Contains(oid) {
+			found.Add(oid)
+		}
+	}
+	return found, nil
+}
+
+func (c *fileExistenceCache) load() (tools.StringSet, error) {
+	known := tools.NewStringSet()
+
+	f, err := os.Open(c.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return known, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if oid := strings.TrimSpace(scanner.Text()); len(oid) > 0 {
+			known.Add(oid)
+		}
+	}
+	return known, scanner.Err()
+}
+
+func (c *fileExistenceCache) Record(oids []string) error {
+	if len(oids) == 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(c.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(strings.Join(oids, "\n") + "\n"); err != nil {
+		return err
+	}
+
+	if c.known != nil {
+		for _, oid := range oids {
+			c.known.Add(oid)
+		}
+	}
+	return nil
+}
+
+// httpExistenceCache is an existenceCache kept by a cache service, such as a
+// small HTTP front end to a key-value store shared by a build farm. Both of
+// its requests are POSTs of a JSON object with the "endpoint" of the Git LFS
+// server and a list of "oids":
+//
+//   - <url>/lookup responds with a JSON object with a list of those "oids"
+//     known to be on the server.
+//   - <url>/record remembers that the "oids" are on the server.
+type httpExistenceCache struct {
+	client   *lfsapi.Client
+	url      string
+	endpoint string
+}
+
+type existenceCacheRequest struct {
+	Endpoint string   `json:"endpoint"`
+	Oids     []string `json:"oids"`
+}
+
+type existenceCacheResponse struct {
+	Oids []string `json:"oids"`
+}
+
+func (c *httpExistenceCache) Lookup(oids []string) (tools.StringSet, error) {
+	res, err := c.post("lookup", oids)
+	if err != nil {
+		return nil, err
+	}
+
+	cres := &existenceCacheResponse{}
+	if err := lfsapi.DecodeJSON(res, cres); err != nil {
+		return nil, err
+	}
+
+	// Only trust the cache about objects that were asked about.
+	asked := tools.NewStringSetFromSlice(oids)
+	found := tools.NewStringSet()
+	for _, oid := range cres.Oids {
+		if asked.Contains(oid) {
+			found.Add(oid)
+		}
+	}
+	return found, nil
+}
+
+func (c *httpExistenceCache) Record(oids []string) error {
+	if len(oids) == 0 {
+		return nil
+	}
+
+	res, err := c.post("record", oids)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+func (c *httpExistenceCache) post(method string, oids []string) (*http.Response, error) {
+	req, err := http.NewRequest("POST", c.url+"/"+method, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lfsapi.MarshalToRequest(req, &existenceCacheRequest{Endpoint: c.endpoint, Oids: oids}); err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.client.Do(c.client.LogRequest(req, "lfs.existencecache"))
+	if err != nil {
+		if res != nil {
+			res.Body.Close()
+		}
+		return nil, errors.Wrap(err, "existence cache")
+	}
+	return res, nil
+}
@@ -0,0 +1,115 @@
+package tq
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExistenceCacheDisabledByDefault(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
+		"lfs.url": "http://foo/repo",
+	}))
+	require.Nil(t, err)
+
+	assert.Nil(t, newExistenceCache(cli, nil, "origin"))
+}
+
+func TestExistenceCacheSelectsBackend(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
+		"lfs.url":                         "http://user@foo/repo",
+		"lfs.http://foo.existencecache":   "https://cache.example.com/lfs/",
+		"lfs.http://other.existencecache": "file:///tmp/cache",
+	}))
+	require.Nil(t, err)
+
+	cache, ok := newExistenceCache(cli, nil, "origin").(*httpExistenceCache)
+	require.True(t, ok)
+	assert.Equal(t, "https://cache.example.com/lfs", cache.url)
+	assert.Equal(t, "http://foo/repo", cache.endpoint)
+}
+
+func TestFileExistenceCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "existence-cache")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	c := newFileExistenceCache(dir, "http://foo/repo")
+	found, err := c.Lookup([]string{"a", "b"})
+	require.Nil(t, err)
+	assert.Equal(t, 0, found.Cardinality())
+
+	require.Nil(t, c.Record([]string{"a"}))
+	found, err = c.Lookup([]string{"a", "b"})
+	require.Nil(t, err)
+	assert.True(t, found.Contains("a"))
+	assert.False(t, found.Contains("b"))
+
+	// Another cache for the same endpoint sees the same objects, while
+	// one for another endpoint does not.
+	found, err = newFileExistenceCache(dir, "http://user@foo/repo").Lookup([]string{"a"})
+	require.Nil(t, err)
+	assert.True(t, found.Contains("a"))
+
+	found, err = newFileExistenceCache(dir, "http://foo/other").Lookup([]string{"a"})
+	require.Nil(t, err)
+	assert.False(t, found.Contains("a"))
+}
+
+func TestHTTPExistenceCache(t *testing.T) {
+	var mu sync.Mutex
+	known := make(map[string]bool)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "POST", r.Method)
+
+		var req existenceCacheRequest
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "http://foo/repo", req.Endpoint)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.URL.Path {
+		case "/record":
+			for _, oid := range req.Oids {
+				known[oid] = true
+			}
+		case "/lookup":
+			res := existenceCacheResponse{Oids: []string{"not-asked"}}
+			for _, oid := range req.Oids {
+				if known[oid] {
+					res.Oids = append(res.Oids, oid)
+				}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(res)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	cli, err := lfsapi.NewClient(nil)
+	require.Nil(t, err)
+
+	c := &httpExistenceCache{client: cli, url: srv.URL, endpoint: "http://foo/repo"}
+	require.Nil(t, c.Record([]string{"a"}))
+
+	found, err := c.Lookup([]string{"a", "b"})
+	require.Nil(t, err)
+	assert.Equal(t, 1, found.Cardinality())
+	assert.True(t, found.Contains("a"))
+
+	c.url = srv.URL + "/missing"
+	_, err = c.Lookup([]string{"a"})
+	assert.NotNil(t, err)
+}
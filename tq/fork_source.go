@@ -0,0 +1,57 @@
+package tq
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/git-lfs/git-lfs/config"
+	"github.com/git-lfs/git-lfs/lfsapi"
+)
+
+// batchSource is the repository that the one being uploaded to was forked
+// from, sent with upload batch requests when "lfs.forksource" is set. A server
+// that supports it may link objects that it already has for the source
+// repository into this one, instead of receiving them again. It responds with
+// such objects marked as "linked", and without an "upload" action.
+type batchSource struct {
+	Href string `json:"href"`
+}
+
+// forkSource returns the URL of the Git LFS endpoint of the repository that
+// uploads to the given remote may link objects from, or an empty string if
+// there is none. It is configured with lfs.forksource, or
+// lfs.<url>.forksource, as either the name of a remote or the URL of a Git LFS
+// endpoint.
+func forkSource(client *lfsapi.Client, remote string) string {
+	ep := client.Endpoints.Endpoint("upload", remote)
+	if len(ep.Url) == 0 {
+		return ""
+	}
+
+	source, ok := config.NewURLConfig(client.GitEnv()).Get("lfs", ep.Url, "forksource")
+	if !ok || len(source) == 0 {
+		return ""
+	}
+
+	if _, isRemote := client.GitEnv().Get(fmt.Sprintf("remote.%s.url", source)); isRemote {
+		source = client.Endpoints.RemoteEndpoint("download", source).Url
+	} else {
+		source = client.Endpoints.NewEndpoint(source).Url
+	}
+
+	if len(source) == 0 || source == ep.Url {
+		return ""
+	}
+	return withoutCredentials(source)
+}
+
+// withoutCredentials returns the given URL without any user information, so
+// that it can be shared with others.
+func withoutCredentials(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return rawurl
+	}
+	u.User = nil
+	return u.String()
+}
@@ -0,0 +1,165 @@
+package tq
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/git-lfs/git-lfs/tools"
+)
+
+const (
+	// LocalAdapterName is the name of the built-in standalone adapter used
+	// for file:// endpoints. Rather than speaking to a Git LFS server, it
+	// copies objects to and from a local (or mounted) directory laid out
+	// like a Git LFS object store.
+	LocalAdapterName = "lfs-standalone-file"
+)
+
+// localAdapter transfers objects to and from a local object store, given by a
+// file:// endpoint URL.
+type localAdapter struct {
+	*adapterBase
+
+	// root is the directory containing the object store, laid out as
+	// "<root>/<oid[0:2]>/<oid[2:4]>/<oid>".
+	root string
+}
+
+func (a *localAdapter) Begin(cfg AdapterConfig, cb ProgressCallback) error {
+	ep := cfg.APIClient().Endpoints.Endpoint(a.direction.String(), cfg.Remote())
+
+	root, err := localStoreDir(ep.Url)
+	if err != nil {
+		return err
+	}
+	a.root = root
+
+	return a.adapterBase.Begin(cfg, cb)
+}
+
+func (a *localAdapter) ClearTempStorage() error {
+	return nil
+}
+
+func (a *localAdapter) WorkerStarting(workerNum int) (interface{}, error) {
+	return nil, nil
+}
+
+func (a *localAdapter) WorkerEnding(workerNum int, ctx interface{}) {
+}
+
+func (a *localAdapter) DoTransfer(ctx interface{}, t *Transfer, cb ProgressCallback, authOkFunc func()) error {
+	if authOkFunc != nil {
+		authOkFunc()
+	}
+
+	storePath := filepath.Join(a.root, t.Oid[0:2], t.Oid[2:4], t.Oid)
+
+	if a.direction == Upload {
+		if tools.FileExistsOfSize(storePath, t.Size) {
+			advanceCallbackProgress(cb, t, t.Size)
+			return nil
+		}
+		return a.copy(t, cb, t.Path, storePath)
+	}
+
+	if _, err := os.Stat(storePath); err != nil {
+		if os.IsNotExist(err) {
+			return errors.Errorf("Object %s not found in %s.", t.Oid, a.root)
+		}
+		return err
+	}
+	return a.copy(t, cb, storePath, t.Path)
+}
+
+// copy copies the object "t" from "from" to "to", verifying its contents along
+// the way. The destination is first written to a temporary file alongside it,
+// and then renamed into place, so that a partially copied object is never
+// visible to other readers of the store.
+func (a *localAdapter) copy(t *Transfer, cb ProgressCallback, from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return errors.Wrap(err, "local transfer")
+	}
+	defer src.Close()
+
+	dir := filepath.Dir(to)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrap(err, "local transfer")
+	}
+
+	tmp, err := ioutil.TempFile(dir, t.Oid+"-")
+	if err != nil {
+		return errors.Wrap(err, "local transfer")
+	}
+	defer os.Remove(tmp.Name())
+
+	ccb := func(totalSize int64, readSoFar int64, readSinceLast int) error {
+		if cb != nil {
+			return cb(t.Name, totalSize, readSoFar, readSinceLast)
+		}
+		return nil
+	}
+
+	hasher := tools.NewHashingReaderForOid(src, t.Oid)
+	written, err := tools.CopyWithCallback(tmp, hasher, t.Size, ccb)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrapf(err, "cannot write data to %q", tmp.Name())
+	}
+
+	if actual := hasher.Hash(); actual != t.Oid {
+		return &HashMismatchError{Oid: t.Oid, Actual: actual, Written: written}
+	}
+
+	return tools.RenameFileCopyPermissions(tmp.Name(), to)
+}
+
+// localStoreDir returns the object store directory for the given file:// URL.
+// If the URL points at a Git repository, its own Git LFS object store is used,
+// so that a file:// remote (or a clone of a local path) shares objects with
+// the repository it refers to. Otherwise, the directory itself is assumed to
+// be laid out like an object store.
+func localStoreDir(rawurl string) (string, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "file" {
+		return "", errors.Errorf("%s: expected a file:// URL, got %q", LocalAdapterName, rawurl)
+	}
+
+	root := filepath.FromSlash(u.Path)
+	if tools.DirExists(filepath.Join(root, ".git")) {
+		return filepath.Join(root, ".git", "lfs", "objects"), nil
+	}
+	if tools.FileExists(filepath.Join(root, "HEAD")) && tools.DirExists(filepath.Join(root, "objects")) {
+		return filepath.Join(root, "lfs", "objects"), nil
+	}
+	return root, nil
+}
+
+func configureLocalAdapter(m *Manifest) {
+	fn := func(name string, dir Direction) Adapter {
+		la := &localAdapter{adapterBase: newAdapterBase(m.fs, name, dir, nil)}
+		// self implements impl
+		la.transferImpl = la
+		return la
+	}
+
+	m.RegisterNewAdapterFunc(LocalAdapterName, Upload, fn)
+	m.RegisterNewAdapterFunc(LocalAdapterName, Download, fn)
+}
+
+// isLocalEndpoint returns whether the given endpoint refers to a local object
+// store, rather than a Git LFS server.
+func isLocalEndpoint(e lfsapi.Endpoint) bool {
+	u, err := url.Parse(e.Url)
+	return err == nil && u.Scheme == "file"
+}
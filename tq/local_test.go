@@ -0,0 +1,51 @@
+package tq
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStoreDirUsesDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-local-store")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	root, err := localStoreDir("file://" + filepath.ToSlash(dir))
+	require.Nil(t, err)
+	assert.Equal(t, dir, root)
+}
+
+func TestLocalStoreDirUsesRepositoryObjects(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-local-store")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	require.Nil(t, os.MkdirAll(filepath.Join(dir, ".git"), 0755))
+
+	root, err := localStoreDir("file://" + filepath.ToSlash(dir))
+	require.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, ".git", "lfs", "objects"), root)
+}
+
+func TestLocalStoreDirUsesBareRepositoryObjects(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-local-store")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	require.Nil(t, os.MkdirAll(filepath.Join(dir, "objects"), 0755))
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "HEAD"), []byte("ref: refs/heads/master\n"), 0644))
+
+	root, err := localStoreDir("file://" + filepath.ToSlash(dir))
+	require.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, "lfs", "objects"), root)
+}
+
+func TestLocalStoreDirRejectsOtherSchemes(t *testing.T) {
+	_, err := localStoreDir("https://example.com/foo")
+	assert.NotNil(t, err)
+}
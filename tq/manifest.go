@@ -1,6 +1,8 @@
 package tq
 
 import (
+	"fmt"
+	"path/filepath"
 	"sync"
 
 	"github.com/git-lfs/git-lfs/config"
@@ -17,11 +19,22 @@ const (
 type Manifest struct {
 	// maxRetries is the maximum number of retries a single object can
 	// attempt to make before it will be dropped.
-	maxRetries              int
-	concurrentTransfers     int
+	maxRetries          int
+	concurrentTransfers int
+	// batchSize is the number of objects to request the actions for in a
+	// single batch request, or 0 for the default.
+	batchSize int
+	// batchLimit is the number of objects in the largest batch request
+	// the server is known to accept, having rejected a larger one as too
+	// large, or 0 if it has not.
+	batchLimit              int
 	basicTransfersOnly      bool
 	standaloneTransferAgent string
 	tusTransfersAllowed     bool
+	sendMetadata            bool
+	auditLog                *auditLog
+	existenceCache          existenceCache
+	forkSource              string
 	downloadAdapterFuncs    map[string]NewAdapterFunc
 	uploadAdapterFuncs      map[string]NewAdapterFunc
 	fs                      *fs.Filesystem
@@ -42,6 +55,13 @@ func (m *Manifest) ConcurrentTransfers() int {
 	return m.concurrentTransfers
 }
 
+// BatchSize returns the number of objects to request the actions for in a
+// single batch request, as given by lfs.transfer.batchsize, or 0 for the
+// default.
+func (m *Manifest) BatchSize() int {
+	return m.batchSize
+}
+
 func (m *Manifest) IsStandaloneTransfer() bool {
 	return m.standaloneTransferAgent != ""
 }
@@ -76,15 +96,33 @@ func NewManifest(f *fs.Filesystem, apiClient *lfsapi.Client, operation, remote s
 		if v := git.Int("lfs.transfer.maxretries", 0); v > 0 {
 			m.maxRetries = v
 		}
+		if v := git.Int("lfs.transfer.batchsize", 0); v > 0 {
+			m.batchSize = v
+		}
 		if v := git.Int("lfs.concurrenttransfers", 0); v > 0 {
 			m.concurrentTransfers = v
 		}
+		if len(remote) > 0 {
+			if v := git.Int(fmt.Sprintf("remote.%s.lfsconcurrenttransfers", remote), 0); v > 0 {
+				m.concurrentTransfers = v
+			}
+		}
 		m.basicTransfersOnly = git.Bool("lfs.basictransfersonly", false)
 		m.standaloneTransferAgent = findStandaloneTransfer(
 			apiClient, operation, remote,
 		)
 		tusAllowed = git.Bool("lfs.tustransfers", false)
+		m.sendMetadata = sendMetadata(apiClient, operation, remote)
 		configureCustomAdapters(git, m)
+
+		if operation == "upload" {
+			m.existenceCache = newExistenceCache(apiClient, f, remote)
+			m.forkSource = forkSource(apiClient, remote)
+		}
+
+		if f != nil && git.Bool("lfs.auditlog", false) {
+			m.auditLog = newAuditLog(filepath.Join(f.LFSStorageDir, "audit.log"))
+		}
 	}
 
 	if m.maxRetries < 1 {
@@ -97,6 +135,9 @@ func NewManifest(f *fs.Filesystem, apiClient *lfsapi.Client, operation, remote s
 
 	configureBasicDownloadAdapter(m)
 	configureBasicUploadAdapter(m)
+	if m.standaloneTransferAgent == LocalAdapterName {
+		configureLocalAdapter(m)
+	}
 	if tusAllowed {
 		configureTusAdapter(m)
 	}
@@ -105,18 +146,39 @@ func NewManifest(f *fs.Filesystem, apiClient *lfsapi.Client, operation, remote s
 
 func findStandaloneTransfer(client *lfsapi.Client, operation, remote string) string {
 	if operation == "" || remote == "" {
-		v, _ := client.GitEnv().Get("lfs.standalonetransferagent")
-		return v
+		if v, ok := client.GitEnv().Get("lfs.standalonetransferagent"); ok {
+			return v
+		}
+
+		// Without an operation, assume a download, since that is what
+		// the smudge filter needs.
+		if isLocalEndpoint(client.Endpoints.Endpoint("download", remote)) {
+			return LocalAdapterName
+		}
+		return ""
 	}
 
 	ep := client.Endpoints.RemoteEndpoint(operation, remote)
 	uc := config.NewURLConfig(client.GitEnv())
-	v, ok := uc.Get("lfs", ep.Url, "standalonetransferagent")
-	if !ok {
-		return ""
+	if v, ok := uc.Get("lfs", ep.Url, "standalonetransferagent"); ok {
+		return v
 	}
 
-	return v
+	if isLocalEndpoint(client.Endpoints.Endpoint(operation, remote)) {
+		return LocalAdapterName
+	}
+	return ""
+}
+
+// sendMetadata returns whether object metadata should be sent with upload
+// requests, according to lfs.sendmetadata or lfs.<url>.sendmetadata.
+func sendMetadata(client *lfsapi.Client, operation, remote string) bool {
+	if operation != "upload" || remote == "" {
+		return client.GitEnv().Bool("lfs.sendmetadata", false)
+	}
+
+	ep := client.Endpoints.RemoteEndpoint(operation, remote)
+	return config.NewURLConfig(client.GitEnv()).Bool("lfs", ep.Url, "sendmetadata", false)
 }
 
 // GetAdapterNames returns a list of the names of adapters available to be created
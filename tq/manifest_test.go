@@ -18,6 +18,20 @@ func TestManifestIsConfigurable(t *testing.T) {
 	assert.Equal(t, 3, m.MaxRetries())
 }
 
+func TestManifestBatchSizeIsConfigurable(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
+		"lfs.transfer.batchsize": "25",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, cli, "", "")
+	assert.Equal(t, 25, m.BatchSize())
+
+	q := NewTransferQueue(Download, m, "origin")
+	assert.Equal(t, 25, q.BatchSize())
+	q.Wait()
+}
+
 func TestManifestChecksNTLM(t *testing.T) {
 	cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
 		"lfs.url":                 "http://foo",
@@ -49,3 +63,38 @@ func TestManifestIgnoresNonInts(t *testing.T) {
 	m := NewManifest(nil, cli, "", "")
 	assert.Equal(t, 8, m.MaxRetries())
 }
+
+func TestManifestUsesLocalAdapterForFileEndpoints(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
+		"lfs.url": "file:///srv/lfs",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, cli, "upload", "origin")
+	assert.True(t, m.IsStandaloneTransfer())
+	assert.Contains(t, m.GetUploadAdapterNames(), LocalAdapterName)
+	assert.Contains(t, m.GetDownloadAdapterNames(), LocalAdapterName)
+}
+
+func TestManifestPrefersConfiguredStandaloneAdapter(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
+		"lfs.url":                     "file:///srv/lfs",
+		"lfs.standalonetransferagent": "custom",
+	}))
+	require.Nil(t, err)
+
+	m := NewManifest(nil, cli, "", "")
+	assert.True(t, m.IsStandaloneTransfer())
+	assert.NotContains(t, m.GetUploadAdapterNames(), LocalAdapterName)
+}
+
+func TestManifestUsesRemoteConcurrentTransfers(t *testing.T) {
+	cli, err := lfsapi.NewClient(lfsapi.NewContext(nil, nil, map[string]string{
+		"lfs.concurrenttransfers":              "3",
+		"remote.mirror.lfsconcurrenttransfers": "1",
+	}))
+	require.Nil(t, err)
+
+	assert.Equal(t, 3, NewManifest(nil, cli, "download", "origin").ConcurrentTransfers())
+	assert.Equal(t, 1, NewManifest(nil, cli, "download", "mirror").ConcurrentTransfers())
+}
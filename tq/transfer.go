@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/git-lfs/git-lfs/errors"
+	"github.com/git-lfs/git-lfs/fs"
 	"github.com/git-lfs/git-lfs/lfsapi"
 	"github.com/git-lfs/git-lfs/tools"
 )
@@ -38,6 +39,13 @@ type Transfer struct {
 	Links         ActionSet    `json:"_links,omitempty"`
 	Error         *ObjectError `json:"error,omitempty"`
 	Path          string       `json:"path,omitempty"`
+	// Linked is set by servers that linked the object from the source
+	// repository of a fork instead of needing it uploaded, see
+	// lfs.forksource.
+	Linked bool `json:"linked,omitempty"`
+	// Metadata is sent with upload requests to servers that accept it,
+	// see lfs.sendmetadata.
+	Metadata fs.ObjectMetadata `json:"metadata,omitempty"`
 }
 
 func (t *Transfer) Rel(name string) (*Action, error) {
@@ -175,12 +183,16 @@ type AdapterConfig interface {
 	APIClient() *lfsapi.Client
 	ConcurrentTransfers() int
 	Remote() string
+	// Deadline returns the time after which no new transfers should be
+	// started, or the zero time if there is no such limit.
+	Deadline() time.Time
 }
 
 type adapterConfig struct {
 	apiClient           *lfsapi.Client
 	concurrentTransfers int
 	remote              string
+	deadline            time.Time
 }
 
 func (c *adapterConfig) ConcurrentTransfers() int {
@@ -195,6 +207,10 @@ func (c *adapterConfig) Remote() string {
 	return c.remote
 }
 
+func (c *adapterConfig) Deadline() time.Time {
+	return c.deadline
+}
+
 // Adapter is implemented by types which can upload and/or download LFS
 // file content to a remote store. Each Adapter accepts one or more requests
 // which it may schedule and parallelise in whatever way it chooses, clients of
@@ -232,6 +248,15 @@ type Adapter interface {
 	ClearTempStorage() error
 }
 
+// AbortableAdapter is implemented by adapters whose uploads can leave partial
+// state behind on the server, such as a tus.io upload that was only partly
+// sent. Once the TransferQueue has given up on an object, it calls Abort so
+// that the adapter can ask the server to discard that state. Abort is best
+// effort: any failure is traced and otherwise ignored.
+type AbortableAdapter interface {
+	Abort(t *Transfer)
+}
+
 // Result of a transfer returned through CompletionChannel()
 type TransferResult struct {
 	Transfer *Transfer
@@ -4,6 +4,8 @@ import (
 	"os"
 	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/lfsapi"
@@ -108,6 +110,8 @@ type TransferQueue struct {
 	adapterInProgress bool
 	adapterInitMutex  sync.Mutex
 	dryRun            bool
+	deadline          time.Time
+	timedOut          int32
 	cb                tools.CopyCallback
 	meter             progress.Meter
 	errors            []error
@@ -128,7 +132,9 @@ type TransferQueue struct {
 	rc       *retryCounter
 }
 
-// objects holds a set of objects.
+// objects holds a set of objects. Once they are completed, only a marker is
+// kept, for the lifetime of the queue: its memory use still grows with the
+// number of distinct OIDs added, if by much less than with their transfers.
 type objects struct {
 	completed bool
 	objects   []*objectTuple
@@ -190,6 +196,15 @@ func WithProgressCallback(cb tools.CopyCallback) Option {
 	}
 }
 
+// WithDeadline stops the TransferQueue from starting any new transfers once
+// the given time has passed, and cancels the requests of those already in
+// progress. Downloads cut short are kept to be resumed, and are never moved
+// into place partially written. Objects that were not transferred are counted
+// by TimedOut(), rather than being reported as errors.
+func WithDeadline(deadline time.Time) Option {
+	return func(tq *TransferQueue) { tq.deadline = deadline }
+}
+
 func WithBatchSize(size int) Option {
 	return func(tq *TransferQueue) { tq.batchSize = size }
 }
@@ -218,6 +233,9 @@ func NewTransferQueue(dir Direction, manifest *Manifest, remote string, options
 	q.rc.MaxRetries = q.manifest.maxRetries
 	q.client.MaxRetries = q.manifest.maxRetries
 
+	if q.batchSize <= 0 {
+		q.batchSize = q.manifest.BatchSize()
+	}
 	if q.batchSize <= 0 {
 		q.batchSize = defaultBatchSize
 	}
@@ -255,7 +273,7 @@ func (q *TransferQueue) Add(name, path, oid string, size int64) {
 		Size: size,
 	}
 
-	if objs := q.remember(t); len(objs.objects) > 1 {
+	if objs, isNew := q.remember(t); !isNew {
 		if objs.completed {
 			// If there is already a completed transfer chain for
 			// this OID, then this object is already "done", and can
@@ -277,23 +295,31 @@ func (q *TransferQueue) Add(name, path, oid string, size int64) {
 // remember remembers the *Transfer "t" if the *TransferQueue doesn't already
 // know about a Transfer with the same OID.
 //
-// It returns if the value is new or not.
-func (q *TransferQueue) remember(t *objectTuple) objects {
+// It returns the objects known for that OID, and whether or not "t" is new.
+func (q *TransferQueue) remember(t *objectTuple) (objects, bool) {
 	q.trMutex.Lock()
 	defer q.trMutex.Unlock()
 
-	if _, ok := q.transfers[t.Oid]; !ok {
+	objs, ok := q.transfers[t.Oid]
+	if !ok {
 		q.wait.Add(1)
 		q.transfers[t.Oid] = &objects{
 			objects: []*objectTuple{t},
 		}
 
-		return *q.transfers[t.Oid]
+		return *q.transfers[t.Oid], true
+	}
+
+	if objs.completed {
+		// Completed objects no longer hold on to their transfers
+		// (see handleTransferResult), so there is nothing to append
+		// to.
+		return *objs, false
 	}
 
-	q.transfers[t.Oid] = q.transfers[t.Oid].Append(t)
+	q.transfers[t.Oid] = objs.Append(t)
 
-	return *q.transfers[t.Oid]
+	return *q.transfers[t.Oid], false
 }
 
 // collectBatches collects batches in a loop, prioritizing failed items from the
@@ -355,7 +381,7 @@ func (q *TransferQueue) collectBatches() {
 		}()
 
 		var collected batch
-		collected, closing = q.collectPendingUntil(done)
+		collected, closing = q.collectPendingUntil(done, q.bufferDepth-len(pending))
 
 		// Ensure the next batch is filled with, in order:
 		//
@@ -372,15 +398,26 @@ func (q *TransferQueue) collectBatches() {
 	}
 }
 
-// collectPendingUntil collects items from q.incoming into a "pending" batch
-// until the given "done" channel is written to, or is closed.
+// collectPendingUntil collects up to "max" items from q.incoming into a
+// "pending" batch until the given "done" channel is written to, or is closed.
+//
+// Once "max" items have been collected, it stops reading from q.incoming, so
+// that callers of Add() block until there is room, rather than the queue
+// holding every item in memory while a batch is transferred.
 //
 // A "pending" batch is returned, along with whether or not "q.incoming" is
 // closed.
-func (q *TransferQueue) collectPendingUntil(done <-chan struct{}) (pending batch, closing bool) {
+func (q *TransferQueue) collectPendingUntil(done <-chan struct{}, max int) (pending batch, closing bool) {
 	for {
+		incoming := q.incoming
+		if len(pending) >= max {
+			// Receiving from a nil channel blocks forever, so
+			// only "done" can be selected.
+			incoming = nil
+		}
+
 		select {
-		case t, ok := <-q.incoming:
+		case t, ok := <-incoming:
 			if !ok {
 				closing = true
 				<-done
@@ -406,6 +443,20 @@ func (q *TransferQueue) collectPendingUntil(done <-chan struct{}) (pending batch
 // processed.
 func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error) {
 	next := q.makeBatch()
+
+	if q.pastDeadline() {
+		tracerx.Printf("tq: deadline exceeded, skipping batch of size %d", len(batch))
+		for _, t := range batch {
+			q.skipTimedOut(t.Size)
+		}
+		return next, nil
+	}
+
+	batch = q.skipKnownToExist(batch)
+	if len(batch) == 0 {
+		return next, nil
+	}
+
 	tracerx.Printf("tq: sending batch of size %d", len(batch))
 
 	q.meter.Pause()
@@ -424,7 +475,7 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 		// Query the Git LFS server for what transfer method to use and
 		// details such as URLs, authentication, etc.
 		var err error
-		bRes, err = Batch(q.manifest, q.direction, q.remote, batch.ToTransfers())
+		bRes, err = Batch(q.manifest, q.direction, q.remote, q.batchTransfers(batch))
 		if err != nil {
 			// If there was an error making the batch API call, mark all of
 			// the objects for retry, and return them along with the error
@@ -436,6 +487,7 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 
 					next = append(next, t)
 				} else {
+					q.meter.Fail(t.Size)
 					q.wait.Done()
 				}
 			}
@@ -452,11 +504,15 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 	q.meter.Start()
 
 	toTransfer := make([]*Transfer, 0, len(bRes.Objects))
+	present := make([]string, 0, len(bRes.Objects))
 
 	for _, o := range bRes.Objects {
 		if o.Error != nil {
-			q.errorc <- errors.Wrapf(o.Error, "[%v] %v", o.Oid, o.Error.Message)
-			q.Skip(o.Size)
+			err := errors.Wrapf(o.Error, "[%v] %v", o.Oid, o.Error.Message)
+			errors.SetContext(err, "oid", o.Oid)
+
+			q.errorc <- err
+			q.meter.Fail(o.Size)
 			q.wait.Done()
 
 			continue
@@ -468,18 +524,25 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 		if !ok {
 			// If we couldn't find any associated
 			// Transfer object, then we give up on the
-			// transfer by telling the progress meter to
-			// skip the number of bytes in "o".
+			// transfer by telling the progress meter that
+			// it failed.
 			q.errorc <- errors.Errorf("[%v] The server returned an unknown OID.", o.Oid)
 
-			q.Skip(o.Size)
+			q.meter.Fail(o.Size)
 			q.wait.Done()
 		} else {
 			// Pick t[0], since it will cover all transfers with the
 			// same OID.
 			tr := newTransfer(o, objects.First().Name, objects.First().Path)
 
-			if a, err := tr.Rel(q.direction.String()); err != nil {
+			if q.direction == Upload && o.Linked {
+				tracerx.Printf("tq: %s linked from %s, skipping upload", o.Oid, q.manifest.forkSource)
+
+				present = append(present, o.Oid)
+
+				q.Skip(o.Size)
+				q.wait.Done()
+			} else if a, err := tr.Rel(q.direction.String()); err != nil {
 				// XXX(taylor): duplication
 				if q.canRetryObject(tr.Oid, err) {
 					q.rc.Increment(tr.Oid)
@@ -490,10 +553,12 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 				} else {
 					q.errorc <- errors.Errorf("[%v] %v", tr.Name, err)
 
-					q.Skip(o.Size)
+					q.meter.Fail(o.Size)
 					q.wait.Done()
 				}
 			} else if a == nil && q.manifest.standaloneTransferAgent == "" {
+				present = append(present, o.Oid)
+
 				q.Skip(o.Size)
 				q.wait.Done()
 			} else {
@@ -503,6 +568,10 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 		}
 	}
 
+	if q.direction == Upload {
+		q.recordExisting(present...)
+	}
+
 	retries := q.addToAdapter(bRes.endpoint, toTransfer)
 	for t := range retries {
 		q.rc.Increment(t.Oid)
@@ -516,6 +585,56 @@ func (q *TransferQueue) enqueueAndCollectRetriesFor(batch batch) (batch, error)
 	return next, nil
 }
 
+// skipKnownToExist returns those objects in the given batch that the
+// existence cache, if any, does not know to be on the server already, and
+// skips the rest, which need not be uploaded.
+func (q *TransferQueue) skipKnownToExist(b batch) batch {
+	cache := q.manifest.existenceCache
+	if q.direction != Upload || cache == nil || len(b) == 0 {
+		return b
+	}
+
+	oids := make([]string, 0, len(b))
+	for _, t := range b {
+		oids = append(oids, t.Oid)
+	}
+
+	known, err := cache.Lookup(oids)
+	if err != nil {
+		tracerx.Printf("tq: unable to look up objects in existence cache: %s", err)
+		return b
+	}
+	if known.Cardinality() == 0 {
+		return b
+	}
+
+	unknown := make(batch, 0, len(b)-known.Cardinality())
+	for _, t := range b {
+		if !known.Contains(t.Oid) {
+			unknown = append(unknown, t)
+			continue
+		}
+
+		tracerx.Printf("tq: skipping %s, known to exist on the server", t.Oid)
+		q.Skip(t.Size)
+		q.wait.Done()
+	}
+	return unknown
+}
+
+// recordExisting tells the existence cache, if any, that the given objects are
+// on the server.
+func (q *TransferQueue) recordExisting(oids ...string) {
+	cache := q.manifest.existenceCache
+	if cache == nil || q.dryRun || len(oids) == 0 {
+		return
+	}
+
+	if err := cache.Record(oids); err != nil {
+		tracerx.Printf("tq: unable to record objects in existence cache: %s", err)
+	}
+}
+
 // makeBatch returns a new, empty batch, with a capacity equal to the maximum
 // batch size designated by the `*TransferQueue`.
 func (q *TransferQueue) makeBatch() batch { return make(batch, 0, q.batchSize) }
@@ -534,7 +653,7 @@ func (q *TransferQueue) addToAdapter(e lfsapi.Endpoint, pending []*Transfer) <-c
 
 		q.errorc <- err
 		for _, t := range pending {
-			q.Skip(t.Size)
+			q.meter.Fail(t.Size)
 			q.wait.Done()
 		}
 
@@ -623,6 +742,11 @@ func (q *TransferQueue) handleTransferResult(
 ) {
 	oid := res.Transfer.Oid
 
+	if isDeadlineExceededError(res.Error) {
+		q.skipTimedOut(res.Transfer.Size)
+		return
+	}
+
 	if res.Error != nil {
 		// If there was an error encountered when processing the
 		// transfer (res.Transfer), handle the error as is appropriate:
@@ -647,20 +771,21 @@ func (q *TransferQueue) handleTransferResult(
 			// exceeded its retry budget, it will be NOT be sent to
 			// the retry channel, and the error will be reported
 			// immediately.
+			q.abort(res.Transfer)
 			q.errorc <- res.Error
+			q.meter.Fail(res.Transfer.Size)
 			q.wait.Done()
 		}
 	} else {
 		q.trMutex.Lock()
-		objects := q.transfers[oid]
-		objects.completed = true
+		objs := q.transfers[oid]
 
 		// Otherwise, if the transfer was successful, notify all of the
 		// watchers, and mark it as finished.
 		for _, c := range q.watchers {
 			// Send one update for each transfer with the
 			// same OID.
-			for _, t := range objects.All() {
+			for _, t := range objs.All() {
 				c <- &Transfer{
 					Name: t.Name,
 					Path: t.Path,
@@ -670,13 +795,43 @@ func (q *TransferQueue) handleTransferResult(
 			}
 		}
 
+		// Only remember that the object was completed, and drop its
+		// transfers, so that each object transferred costs no more
+		// than its OID and this marker. The markers stay in memory,
+		// rather than being spilled to disk, as every Add() has to
+		// check whether its object was already completed.
+		q.transfers[oid] = &objects{completed: true}
 		q.trMutex.Unlock()
 
+		if !q.dryRun {
+			q.manifest.auditLog.Record(q.direction, q.remote, res.Transfer)
+			if q.direction == Upload {
+				q.recordExisting(oid)
+			}
+		}
+
 		q.meter.FinishTransfer(res.Transfer.Name)
 		q.wait.Done()
 	}
 }
 
+// abort asks the current adapter, if it supports it, to discard any partial
+// upload of the given transfer on the server.
+func (q *TransferQueue) abort(t *Transfer) {
+	if q.direction != Upload {
+		return
+	}
+
+	q.adapterInitMutex.Lock()
+	adapter := q.adapter
+	q.adapterInitMutex.Unlock()
+
+	if aa, ok := adapter.(AbortableAdapter); ok {
+		tracerx.Printf("tq: aborting upload of object %s", t.Oid)
+		aa.Abort(t)
+	}
+}
+
 func (q *TransferQueue) useAdapter(name string) {
 	q.adapterInitMutex.Lock()
 	defer q.adapterInitMutex.Unlock()
@@ -713,6 +868,22 @@ func (q *TransferQueue) Skip(size int64) {
 	q.meter.Skip(size)
 }
 
+// TimedOut returns the number of objects that were not transferred because
+// the deadline given by WithDeadline had passed.
+func (q *TransferQueue) TimedOut() int {
+	return int(atomic.LoadInt32(&q.timedOut))
+}
+
+func (q *TransferQueue) pastDeadline() bool {
+	return !q.deadline.IsZero() && time.Now().After(q.deadline)
+}
+
+func (q *TransferQueue) skipTimedOut(size int64) {
+	atomic.AddInt32(&q.timedOut, 1)
+	q.Skip(size)
+	q.wait.Done()
+}
+
 func (q *TransferQueue) ensureAdapterBegun(e lfsapi.Endpoint) error {
 	q.adapterInitMutex.Lock()
 	defer q.adapterInitMutex.Unlock()
@@ -744,6 +915,28 @@ func (q *TransferQueue) ensureAdapterBegun(e lfsapi.Endpoint) error {
 	return nil
 }
 
+// batchTransfers returns the transfers to send in a batch API request for the
+// given batch, including the metadata of each object when uploading to a
+// server that accepts it.
+func (q *TransferQueue) batchTransfers(b batch) []*Transfer {
+	transfers := b.ToTransfers()
+	if q.direction != Upload || !q.manifest.sendMetadata || q.manifest.fs == nil {
+		return transfers
+	}
+
+	for _, t := range transfers {
+		md, err := q.manifest.fs.ReadObjectMetadata(t.Oid)
+		if err != nil {
+			tracerx.Printf("tq: unable to read metadata for %q: %s", t.Oid, err)
+			continue
+		}
+		if len(md) > 0 {
+			t.Metadata = md
+		}
+	}
+	return transfers
+}
+
 func (q *TransferQueue) toAdapterCfg(e lfsapi.Endpoint) AdapterConfig {
 	apiClient := q.manifest.APIClient()
 	concurrency := q.manifest.ConcurrentTransfers()
@@ -755,6 +948,7 @@ func (q *TransferQueue) toAdapterCfg(e lfsapi.Endpoint) AdapterConfig {
 		concurrentTransfers: concurrency,
 		apiClient:           apiClient,
 		remote:              q.remote,
+		deadline:            q.deadline,
 	}
 }
 
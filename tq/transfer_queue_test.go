@@ -1,9 +1,15 @@
 package tq
 
 import (
+	"io/ioutil"
+	"os"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/git-lfs/git-lfs/fs"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestManifestDefaultsToFixedRetries(t *testing.T) {
@@ -37,3 +43,84 @@ func TestBatchSizeReturnsBatchSize(t *testing.T) {
 
 	assert.Equal(t, 3, q.BatchSize())
 }
+
+func TestTransferQueueSkipsTransfersPastDeadline(t *testing.T) {
+	q := NewTransferQueue(
+		Download, NewManifest(nil, nil, "", ""), "origin",
+		WithDeadline(time.Now().Add(-time.Second)))
+
+	q.Add("a.dat", "a.dat", "a", 1)
+	q.Add("b.dat", "b.dat", "b", 2)
+	q.Wait()
+
+	assert.Equal(t, 2, q.TimedOut())
+	assert.Empty(t, q.Errors())
+}
+
+func TestCollectPendingUntilStopsAtMax(t *testing.T) {
+	q := &TransferQueue{incoming: make(chan *objectTuple, 10)}
+	for i := 0; i < 10; i++ {
+		q.incoming <- &objectTuple{Oid: "oid"}
+	}
+
+	done := make(chan struct{})
+	collected := make(chan batch)
+	go func() {
+		pending, _ := q.collectPendingUntil(done, 2)
+		collected <- pending
+	}()
+
+	for len(q.incoming) > 8 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(done)
+
+	assert.Len(t, <-collected, 2)
+	assert.Len(t, q.incoming, 8)
+}
+
+func TestRememberCompletedObject(t *testing.T) {
+	q := &TransferQueue{
+		transfers: make(map[string]*objects),
+		trMutex:   &sync.Mutex{},
+	}
+
+	_, isNew := q.remember(&objectTuple{Oid: "oid", Name: "a.dat"})
+	assert.True(t, isNew)
+
+	objs, isNew := q.remember(&objectTuple{Oid: "oid", Name: "b.dat"})
+	assert.False(t, isNew)
+	assert.Len(t, objs.All(), 2)
+
+	q.transfers["oid"] = &objects{completed: true}
+
+	objs, isNew = q.remember(&objectTuple{Oid: "oid", Name: "c.dat"})
+	assert.False(t, isNew)
+	assert.True(t, objs.completed)
+	assert.Empty(t, objs.All())
+}
+
+func TestBatchTransfersIncludeMetadataWhenSending(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tq-metadata")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	f := &fs.Filesystem{LFSStorageDir: dir}
+	oid := "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+	require.Nil(t, f.WriteObjectMetadata(oid, fs.ObjectMetadata{"path": "a.dat"}))
+
+	m := NewManifest(f, nil, "", "")
+	b := batch{{Oid: oid, Size: 1}, {Oid: "other", Size: 2}}
+
+	q := NewTransferQueue(Upload, m, "origin")
+	assert.Nil(t, q.batchTransfers(b)[0].Metadata)
+
+	m.sendMetadata = true
+	transfers := q.batchTransfers(b)
+	assert.Equal(t, fs.ObjectMetadata{"path": "a.dat"}, transfers[0].Metadata)
+	assert.Nil(t, transfers[1].Metadata)
+
+	q = NewTransferQueue(Download, m, "origin")
+	assert.Nil(t, q.batchTransfers(b)[0].Metadata)
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/git-lfs/git-lfs/errors"
 	"github.com/git-lfs/git-lfs/lfsapi"
@@ -21,6 +22,12 @@ const (
 // Adapter for tus.io protocol resumaable uploads
 type tusUploadAdapter struct {
 	*adapterBase
+
+	// partial holds the upload action of each object whose PATCH request
+	// was started but did not complete, so that it can be terminated by
+	// Abort.
+	partial   map[string]*Action
+	partialMu sync.Mutex
 }
 
 func (a *tusUploadAdapter) ClearTempStorage() error {
@@ -134,6 +141,8 @@ func (a *tusUploadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb ProgressC
 
 	req.Body = reader
 
+	a.setPartial(t.Oid, rel)
+
 	req = a.apiClient.LogRequest(req, "lfs.data.upload")
 	res, err = a.doHTTP(t, req)
 	if err != nil {
@@ -158,16 +167,64 @@ func (a *tusUploadAdapter) DoTransfer(ctx interface{}, t *Transfer, cb ProgressC
 	io.Copy(ioutil.Discard, res.Body)
 	res.Body.Close()
 
+	a.setPartial(t.Oid, nil)
+
 	return verifyUpload(a.apiClient, a.remote, t)
 }
 
+// Abort implements AbortableAdapter by sending a tus.io DELETE request
+// (from the Termination extension) for an object whose upload was started but
+// never completed. Servers that do not support termination will simply
+// reject the request, which is ignored.
+func (a *tusUploadAdapter) Abort(t *Transfer) {
+	a.partialMu.Lock()
+	rel := a.partial[t.Oid]
+	delete(a.partial, t.Oid)
+	a.partialMu.Unlock()
+
+	if rel == nil {
+		return
+	}
+
+	a.Trace("xfer: sending tus.io DELETE request for %q", t.Oid)
+	req, err := a.newHTTPRequest("DELETE", rel)
+	if err != nil {
+		a.Trace("xfer: tus.io abort of %q failed: %s", t.Oid, err)
+		return
+	}
+	req.Header.Set("Tus-Resumable", TusVersion)
+
+	res, err := a.doHTTP(t, req)
+	if err != nil {
+		a.Trace("xfer: tus.io abort of %q failed: %s", t.Oid, err)
+		return
+	}
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+}
+
+func (a *tusUploadAdapter) setPartial(oid string, rel *Action) {
+	a.partialMu.Lock()
+	defer a.partialMu.Unlock()
+
+	if rel == nil {
+		delete(a.partial, oid)
+	} else {
+		a.partial[oid] = rel
+	}
+}
+
 func configureTusAdapter(m *Manifest) {
 	m.RegisterNewAdapterFunc(TusAdapterName, Upload, func(name string, dir Direction) Adapter {
 		switch dir {
 		case Upload:
-			bu := &tusUploadAdapter{newAdapterBase(m.fs, name, dir, nil)}
+			bu := &tusUploadAdapter{
+				adapterBase: newAdapterBase(m.fs, name, dir, nil),
+				partial:     make(map[string]*Action),
+			}
 			// self implements impl
 			bu.transferImpl = bu
+			bu.poolByHost = true
 			return bu
 		case Download:
 			panic("Should never ask tus.io to download")
@@ -0,0 +1,71 @@
+package tq
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/git-lfs/git-lfs/lfsapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTusAbortDeletesPartialUpload(t *testing.T) {
+	var deletes uint32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method)
+		assert.Equal(t, "/upload/oid", r.URL.Path)
+		assert.Equal(t, TusVersion, r.Header.Get("Tus-Resumable"))
+		assert.Equal(t, "token", r.Header.Get("Authorization"))
+
+		atomic.AddUint32(&deletes, 1)
+		w.WriteHeader(204)
+	}))
+	defer srv.Close()
+
+	a := newTestTusAdapter(t)
+	a.setPartial("oid", &Action{
+		Href:   srv.URL + "/upload/oid",
+		Header: map[string]string{"Authorization": "token"},
+	})
+
+	tr := &Transfer{Oid: "oid", Authenticated: true}
+	a.Abort(tr)
+	// A second Abort has nothing left to terminate.
+	a.Abort(tr)
+
+	assert.EqualValues(t, 1, atomic.LoadUint32(&deletes))
+}
+
+func TestTusAbortIgnoresCompletedUpload(t *testing.T) {
+	var requests uint32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&requests, 1)
+	}))
+	defer srv.Close()
+
+	a := newTestTusAdapter(t)
+	a.setPartial("oid", &Action{Href: srv.URL + "/upload/oid"})
+	a.setPartial("oid", nil)
+
+	a.Abort(&Transfer{Oid: "oid", Authenticated: true})
+
+	assert.EqualValues(t, 0, atomic.LoadUint32(&requests))
+}
+
+func newTestTusAdapter(t *testing.T) *tusUploadAdapter {
+	c, err := lfsapi.NewClient(nil)
+	require.Nil(t, err)
+
+	m := NewManifest(nil, c, "", "")
+	configureTusAdapter(m)
+
+	a, ok := m.NewUploadAdapter(TusAdapterName).(*tusUploadAdapter)
+	require.True(t, ok)
+
+	a.apiClient = c
+	return a
+}